@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// allureResult is a test result file of the Allure results format
+// (https://allurereport.org/docs/how-it-works-test-result-file/)
+type allureResult struct {
+	UUID          string              `json:"uuid"`
+	HistoryID     string              `json:"historyId"`
+	TestCaseID    string              `json:"testCaseId"`
+	FullName      string              `json:"fullName"`
+	Name          string              `json:"name"`
+	Status        string              `json:"status"` // passed, failed, broken or skipped
+	StatusDetails allureStatusDetails `json:"statusDetails"`
+	Stage         string              `json:"stage"`
+	Start         int64               `json:"start"` // Unix milliseconds
+	Stop          int64               `json:"stop"`
+	Labels        []allureLabel       `json:"labels"`
+	Attachments   []allureAttachment  `json:"attachments"`
+}
+
+type allureStatusDetails struct {
+	Message string `json:"message,omitempty"`
+	Trace   string `json:"trace,omitempty"`
+	Flaky   bool   `json:"flaky,omitempty"`
+}
+
+type allureLabel struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type allureAttachment struct {
+	Name   string `json:"name"`
+	Source string `json:"source"` // file name in the results directory
+	Type   string `json:"type"`
+}
+
+// allureContainer groups the results of one package
+type allureContainer struct {
+	UUID     string   `json:"uuid"`
+	Name     string   `json:"name"`
+	Children []string `json:"children"`
+	Start    int64    `json:"start"`
+	Stop     int64    `json:"stop"`
+}
+
+// writeAllureResults writes data to dir as Allure results: a result file per
+// test execution, a container per package and the output of failed
+// executions as attachments. The run is taken to have ended at now; as go
+// test doesn't record when tests started, packages start together and their
+// tests follow one another, with subtests starting with their parent.
+// Earlier attempts of re-run tests share the history id of the final one,
+// which Allure shows as retries. Existing files in dir are kept, so several
+// runs can be collected into one report.
+func writeAllureResults(dir string, data *ReportData, now time.Time) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	longest := data.TotalDuration
+	packageDurations := make(map[string]float64)
+	for _, p := range report.PackageDurations(data) {
+		packageDurations[p.Package] = p.Duration
+		longest = max(longest, p.Duration)
+	}
+	runStart := now.Add(-time.Duration(longest * float64(time.Second)))
+
+	files := 0
+	packages, byPackage := resultsByPackage(data)
+	for _, pkg := range packages {
+		container := allureContainer{
+			UUID:  allureUUID(),
+			Name:  pkg,
+			Start: runStart.UnixMilli(),
+			Stop:  runStart.Add(time.Duration(packageDurations[pkg] * float64(time.Second))).UnixMilli(),
+		}
+		starts := make(map[string]time.Time)
+		offset := runStart
+		for _, result := range byPackage[pkg] {
+			top, _, _ := strings.Cut(result.Name, "/")
+			start, ok := starts[top]
+			if !ok {
+				start = offset
+				starts[top] = offset
+				if parent := data.Results[top]; parent != nil {
+					offset = offset.Add(time.Duration(parent.Duration * float64(time.Second)))
+				}
+			}
+
+			executions := make([]allureResult, 0, len(result.Attempts)+1)
+			for _, attempt := range result.Attempts {
+				execution := allureResultOf(pkg, result.Name, "failed", attempt.Duration, start)
+				execution.StatusDetails = allureFailure(attempt.Output)
+				executions = append(executions, execution)
+			}
+			execution := allureResultOf(pkg, result.Name, "passed", result.Duration, start)
+			switch result.Status {
+			case "PASS":
+			case "FLAKY":
+				execution.StatusDetails.Flaky = true
+			case "SKIP":
+				execution.Status = "skipped"
+				execution.StatusDetails.Message = result.SkipReason
+			case "FAIL":
+				execution.Status = "failed"
+				execution.StatusDetails = allureFailure(result.Output)
+			default:
+				execution.Status = "broken"
+				execution.StatusDetails = allureStatusDetails{Message: "test did not report a result", Trace: joinOutput(result.Output)}
+			}
+			executions = append(executions, execution)
+
+			for i := range executions {
+				execution := &executions[i]
+				if execution.StatusDetails.Trace != "" {
+					source := execution.UUID + "-attachment.txt"
+					if err := os.WriteFile(filepath.Join(dir, source), []byte(execution.StatusDetails.Trace), 0o644); err != nil {
+						return err
+					}
+					execution.Attachments = append(execution.Attachments, allureAttachment{Name: "output", Source: source, Type: "text/plain"})
+				}
+				if err := writeAllureFile(dir, execution.UUID+"-result.json", execution); err != nil {
+					return err
+				}
+				container.Children = append(container.Children, execution.UUID)
+				files++
+			}
+		}
+		if err := writeAllureFile(dir, container.UUID+"-container.json", container); err != nil {
+			return err
+		}
+	}
+	logger.Info("wrote Allure results", "dir", dir, "results", files)
+	return nil
+}
+
+// allureResultOf returns the result of one execution of a test. Its
+// history id, which Allure follows across runs, is derived from the
+// package and test name.
+func allureResultOf(pkg, name, status string, seconds float64, start time.Time) allureResult {
+	id := fmt.Sprintf("%x", sha256.Sum256([]byte(pkg+"\x00"+name)))[:32]
+	labels := []allureLabel{
+		{Name: "language", Value: "go"},
+		{Name: "framework", Value: "go test"},
+		{Name: "package", Value: pkg},
+		{Name: "suite", Value: pkg},
+		{Name: "testMethod", Value: name},
+	}
+	if top, _, ok := strings.Cut(name, "/"); ok {
+		labels = append(labels, allureLabel{Name: "subSuite", Value: top})
+	}
+	if host, err := os.Hostname(); err == nil {
+		labels = append(labels, allureLabel{Name: "host", Value: host})
+	}
+	return allureResult{
+		UUID:        allureUUID(),
+		HistoryID:   id,
+		TestCaseID:  id,
+		FullName:    pkg + "." + name,
+		Name:        name,
+		Status:      status,
+		Stage:       "finished",
+		Start:       start.UnixMilli(),
+		Stop:        start.Add(time.Duration(seconds * float64(time.Second))).UnixMilli(),
+		Labels:      labels,
+		Attachments: []allureAttachment{},
+	}
+}
+
+// allureFailure describes a failed execution by its assertion, with its
+// output as the trace
+func allureFailure(output []string) allureStatusDetails {
+	return allureStatusDetails{Message: failureMessage(output), Trace: joinOutput(output)}
+}
+
+func writeAllureFile(dir, name string, v any) error {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name), encoded, 0o644)
+}
+
+// allureUUID returns a random (version 4) UUID
+func allureUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestWriteAllureResults(t *testing.T) {
+	data := &ReportData{
+		TotalDuration: 3,
+		Results: map[string]*TestResult{
+			"TestOK":      {Name: "TestOK", Package: "example.com/a", Status: "PASS", Duration: 1},
+			"TestBad":     {Name: "TestBad", Package: "example.com/a", Status: "FAIL", Duration: 2, SubTests: []string{"TestBad/sub"}, Output: []string{"--- FAIL: TestBad"}},
+			"TestBad/sub": {Name: "TestBad/sub", Package: "example.com/a", Status: "FAIL", Duration: 2, IsSubTest: true, Output: []string{"    a_test.go:9: got 1, want 2"}},
+			"TestSkip":    {Name: "TestSkip", Package: "example.com/b", Status: "SKIP", SkipReason: "needs docker"},
+			"TestRetried": {Name: "TestRetried", Package: "example.com/b", Status: "FLAKY", Duration: 1,
+				Attempts: []report.Attempt{{Status: "FAIL", Duration: 1, Output: []string{"    b_test.go:3: timeout"}}}},
+			"TestHung": {Name: "TestHung", Package: "example.com/b", Status: "RUNNING"},
+		},
+	}
+	dir := filepath.Join(t.TempDir(), "allure-results")
+	now := time.UnixMilli(1700000000000)
+	if err := writeAllureResults(dir, data, now); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := make(map[string]allureResult)
+	var containers []allureContainer
+	var got []string
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case strings.HasSuffix(entry.Name(), "-result.json"):
+			var result allureResult
+			if err := json.Unmarshal(content, &result); err != nil {
+				t.Fatalf("%s: %v", entry.Name(), err)
+			}
+			results[result.UUID] = result
+			line := result.FullName + " " + result.Status + " " + result.StatusDetails.Message
+			if result.StatusDetails.Flaky {
+				line += " (flaky)"
+			}
+			for _, attachment := range result.Attachments {
+				output, err := os.ReadFile(filepath.Join(dir, attachment.Source))
+				if err != nil {
+					t.Errorf("%s: attachment: %v", result.FullName, err)
+				}
+				line += " | " + strings.TrimSpace(string(output))
+			}
+			got = append(got, line)
+		case strings.HasSuffix(entry.Name(), "-container.json"):
+			var container allureContainer
+			if err := json.Unmarshal(content, &container); err != nil {
+				t.Fatalf("%s: %v", entry.Name(), err)
+			}
+			containers = append(containers, container)
+		}
+	}
+	sort.Strings(got)
+	want := []string{
+		"example.com/a.TestBad failed test failed | --- FAIL: TestBad",
+		"example.com/a.TestBad/sub failed got 1, want 2 | a_test.go:9: got 1, want 2",
+		"example.com/a.TestOK passed ",
+		"example.com/b.TestHung broken test did not report a result",
+		"example.com/b.TestRetried failed timeout | b_test.go:3: timeout",
+		"example.com/b.TestRetried passed  (flaky)",
+		"example.com/b.TestSkip skipped needs docker",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("results: got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Retries share the history id; every result is in its package's container
+	retried := map[string]bool{}
+	for _, result := range results {
+		if result.Name == "TestRetried" {
+			retried[result.HistoryID] = true
+		}
+	}
+	if len(retried) != 1 {
+		t.Errorf("TestRetried: got history ids %v, want one", retried)
+	}
+	children := 0
+	for _, container := range containers {
+		for _, child := range container.Children {
+			if results[child].Labels[3].Value != container.Name {
+				t.Errorf("%s: got child %s", container.Name, results[child].FullName)
+			}
+			children++
+		}
+	}
+	if len(containers) != 2 || children != len(results) {
+		t.Errorf("got %d containers with %d children, want 2 with %d", len(containers), children, len(results))
+	}
+
+	// TestOK follows TestBad, which started with the run
+	for _, result := range results {
+		if result.Name == "TestOK" && (result.Start != now.Add(-time.Second).UnixMilli() || result.Stop != now.UnixMilli()) {
+			t.Errorf("TestOK: got %d-%d, want %d-%d", result.Start, result.Stop, now.Add(-time.Second).UnixMilli(), now.UnixMilli())
+		}
+	}
+}
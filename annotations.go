@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// githubMaxWarnings is how many warning annotations GitHub shows per step
+const githubMaxWarnings = 10
+
+// writeSlowAnnotations prints a GitHub Actions warning annotation for each
+// top-level test slower than cfg.slowThreshold, the slowest first, placed at
+// the test's declaration when it is found in tree. Outside GitHub Actions, or
+// without a threshold, it prints nothing.
+func writeSlowAnnotations(w io.Writer, data *ReportData, cfg *config, tree *sourceTree) {
+	if os.Getenv("GITHUB_ACTIONS") != "true" || cfg.slowThreshold <= 0 {
+		return
+	}
+	var slow []*TestResult
+	for _, result := range data.Results {
+		if !result.IsSubTest && slowMarker(cfg, result.Duration) != "" {
+			slow = append(slow, result)
+		}
+	}
+	sort.Slice(slow, func(i, j int) bool {
+		if slow[i].Duration != slow[j].Duration {
+			return slow[i].Duration > slow[j].Duration
+		}
+		return slow[i].Name < slow[j].Name
+	})
+	if len(slow) > githubMaxWarnings {
+		logger.Warn("left out slow test annotations over GitHub's limit per step", "slow", len(slow), "limit", githubMaxWarnings)
+		slow = slow[:githubMaxWarnings]
+	}
+
+	for _, result := range slow {
+		properties := "title=" + escapeProperty("Slow test: "+result.Name)
+		if location, ok := tree.declaration(result.Package, result.Name); ok {
+			properties = fmt.Sprintf("file=%s,line=%d,%s", escapeProperty(filepath.ToSlash(location.rel)), location.line, properties)
+		}
+		message := fmt.Sprintf("%s took %.2fs, over the %gs slow threshold", result.Name, result.Duration, cfg.slowThreshold)
+		if result.Package != "" {
+			message += " (" + cfg.packageName(result.Package) + ")"
+		}
+		fmt.Fprintf(w, "::warning %s::%s\n", properties, escapeData(message))
+	}
+}
+
+// escapeData escapes the message of a workflow command
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// declaration finds the func declaring the top-level test name in the test
+// files of pkg
+func (t *sourceTree) declaration(pkg, name string) (sourceLocation, bool) {
+	dir := t.packageDir(pkg)
+	if dir == "" {
+		return sourceLocation{}, false
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	prefix := "func " + name + "("
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		for line := 1; scanner.Scan(); line++ {
+			if strings.HasPrefix(scanner.Text(), prefix) {
+				file.Close()
+				rel, err := filepath.Rel(t.repoRoot, path)
+				if err != nil {
+					return sourceLocation{}, false
+				}
+				return sourceLocation{path: path, rel: rel, line: line}, true
+			}
+		}
+		file.Close()
+	}
+	return sourceLocation{}, false
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteSlowAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0o644)
+	os.MkdirAll(filepath.Join(dir, "users"), 0o755)
+	os.WriteFile(filepath.Join(dir, "users", "users_test.go"), []byte("package users\n\nimport \"testing\"\n\nfunc TestImport(t *testing.T) {\n}\n"), 0o644)
+
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestImport":     {Name: "TestImport", Package: "example.com/app/users", Status: "PASS", Duration: 3.5},
+		"TestImport/big": {Name: "TestImport/big", Package: "example.com/app/users", Status: "PASS", Duration: 3, IsSubTest: true},
+		"TestElsewhere":  {Name: "TestElsewhere", Package: "example.com/other", Status: "FAIL", Duration: 2.5},
+		"TestQuick":      {Name: "TestQuick", Package: "example.com/app/users", Status: "PASS", Duration: 0.1},
+	}}
+	cfg := defaultConfig()
+	cfg.slowThreshold = 2
+	tree := findSourceTree(dir)
+
+	var sb strings.Builder
+	t.Setenv("GITHUB_ACTIONS", "")
+	writeSlowAnnotations(&sb, data, cfg, tree)
+	if sb.Len() != 0 {
+		t.Errorf("outside GitHub Actions: got %q", sb.String())
+	}
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	writeSlowAnnotations(&sb, data, cfg, tree)
+	want := "::warning file=users/users_test.go,line=5,title=Slow test%3A TestImport::TestImport took 3.50s, over the 2s slow threshold (example.com/app/users)\n" +
+		"::warning title=Slow test%3A TestElsewhere::TestElsewhere took 2.50s, over the 2s slow threshold (example.com/other)\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	sb.Reset()
+	cfg.slowThreshold = 0
+	writeSlowAnnotations(&sb, data, cfg, tree)
+	if sb.Len() != 0 {
+		t.Errorf("without a threshold: got %q", sb.String())
+	}
+}
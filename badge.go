@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// shieldsBadge is the shields.io endpoint schema
+// (https://shields.io/badges/endpoint-badge)
+type shieldsBadge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// renderBadge renders a shields.io endpoint badge of the counts, e.g.
+// "312 passed, 2 failed". It is red when tests failed or the quality gate
+// did, yellow when tests were flaky and green otherwise.
+func renderBadge(data *ReportData) (string, error) {
+	badge := shieldsBadge{SchemaVersion: 1, Label: "tests", Color: "brightgreen"}
+	if data.TotalTests == 0 {
+		badge.Message, badge.Color = "no tests", "lightgrey"
+	} else {
+		parts := []string{fmt.Sprintf("%d passed", data.PassedTests)}
+		if data.FailedTests > 0 {
+			parts = append(parts, fmt.Sprintf("%d failed", data.FailedTests))
+		}
+		if data.SkippedTests > 0 {
+			parts = append(parts, fmt.Sprintf("%d skipped", data.SkippedTests))
+		}
+		if data.FlakyTests > 0 {
+			parts = append(parts, fmt.Sprintf("%d flaky", data.FlakyTests))
+		}
+		badge.Message = strings.Join(parts, ", ")
+	}
+	switch {
+	case data.FailedTests > 0 || data.QualityGate != nil && !data.QualityGate.Passed:
+		badge.Color = "red"
+	case data.FlakyTests > 0:
+		badge.Color = "yellow"
+	}
+
+	encoded, err := json.MarshalIndent(badge, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(encoded) + "\n", nil
+}
+
+// writeBadge writes the badge of data to file
+func writeBadge(file string, data *ReportData) error {
+	content, err := renderBadge(data)
+	if err != nil {
+		return err
+	}
+	return writeReport(file, content)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestRenderBadge(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  *ReportData
+		want  string
+		color string
+	}{
+		{"passing", &ReportData{TotalTests: 312, PassedTests: 312}, "312 passed", "brightgreen"},
+		{"failing", &ReportData{TotalTests: 315, PassedTests: 312, FailedTests: 2, SkippedTests: 1}, "312 passed, 2 failed, 1 skipped", "red"},
+		{"flaky", &ReportData{TotalTests: 3, PassedTests: 2, FlakyTests: 1}, "2 passed, 1 flaky", "yellow"},
+		{"gate", &ReportData{TotalTests: 3, PassedTests: 2, SkippedTests: 1, QualityGate: &report.GateResult{Passed: false}}, "2 passed, 1 skipped", "red"},
+		{"empty", &ReportData{}, "no tests", "lightgrey"},
+	}
+	for _, tt := range tests {
+		content, err := renderBadge(tt.data)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var badge shieldsBadge
+		if err := json.Unmarshal([]byte(content), &badge); err != nil {
+			t.Fatalf("%s: %v\n%s", tt.name, err, content)
+		}
+		if badge.SchemaVersion != 1 || badge.Label != "tests" || badge.Message != tt.want || badge.Color != tt.color {
+			t.Errorf("%s: got %+v, want message %q and color %s", tt.name, badge, tt.want, tt.color)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// benchdiffCommand implements `gotest-report benchdiff [flags] old.json new.json`,
+// which compares the benchmark results found in two go test -json logs and
+// writes a benchstat-style Markdown table per unit, including units reported
+// with b.ReportMetric
+func benchdiffCommand(args []string) int {
+	fs := flag.NewFlagSet("benchdiff", flag.ExitOnError)
+	outputFile := fs.String("output", "-", "Output markdown file (use - for stdout)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: gotest-report benchdiff [flags] old.json new.json\n\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	// Benchmark results are collected from every output line, so no test
+	// output needs to be kept
+	opts := report.ParseOptions{SpoolThreshold: report.DefaultSpoolThreshold}
+	old, err := loadInput(inputSpec{path: fs.Arg(0)}, opts)
+	if err != nil {
+		logger.Error("processing input", "error", err)
+		return 1
+	}
+	new, err := loadInput(inputSpec{path: fs.Arg(1)}, opts)
+	if err != nil {
+		logger.Error("processing input", "error", err)
+		return 1
+	}
+
+	deltas := report.CompareBenchmarks(old.Benchmarks, new.Benchmarks)
+	if len(deltas) == 0 {
+		logger.Error(fmt.Sprintf("no benchmark results in %s or %s (run go test with -bench)", fs.Arg(0), fs.Arg(1)))
+		return 1
+	}
+
+	content := renderBenchmarkDiff(deltas, fs.Arg(0), fs.Arg(1))
+	if err := writeReport(*outputFile, content); err != nil {
+		logger.Error("writing report", "error", err)
+		return 1
+	}
+	if *outputFile != "-" {
+		fmt.Printf("Benchmark comparison generated successfully: %s\n", *outputFile)
+	}
+	return 0
+}
+
+// renderBenchmarkDiff renders one table per unit comparing the mean of each
+// benchmark, with the spread of its samples and a geomean row
+func renderBenchmarkDiff(deltas []report.BenchmarkDelta, oldName, newName string) string {
+	packages := make(map[string]bool)
+	for _, d := range deltas {
+		packages[d.Package] = true
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# 📈 Benchmark Comparison\n\n")
+	sb.WriteString(fmt.Sprintf("**Old:** `%s` · **New:** `%s`\n\n", oldName, newName))
+	sb.WriteString("> Means of all runs (use `-count` for several) with the largest deviation from the mean. " +
+		"Changes marked ~ fall within the noise of the runs.\n\n")
+
+	for start := 0; start < len(deltas); {
+		unit := deltas[start].Unit
+		end := start
+		for end < len(deltas) && deltas[end].Unit == unit {
+			end++
+		}
+
+		sb.WriteString(fmt.Sprintf("## %s\n\n", unit))
+		sb.WriteString("| Benchmark | Old | New | Change |\n")
+		sb.WriteString("| --------- | --- | --- | ------ |\n")
+		for _, d := range deltas[start:end] {
+			name := "`" + markdownCell(d.Name) + "`"
+			if len(packages) > 1 {
+				name += " (" + markdownCell(d.Package) + ")"
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+				name, formatBenchmarkSamples(d.Old), formatBenchmarkSamples(d.New), formatBenchmarkChange(d)))
+		}
+		if change, ok := report.GeomeanChange(deltas[start:end]); ok {
+			sb.WriteString(fmt.Sprintf("| **geomean** | | | %+.2f%% |\n", change))
+		}
+		sb.WriteString("\n")
+		start = end
+	}
+	return sb.String()
+}
+
+// formatBenchmarkSamples shows the mean and spread of a benchmark's samples,
+// or a dash when it didn't run
+func formatBenchmarkSamples(s report.BenchmarkSamples) string {
+	if s.N == 0 {
+		return "—"
+	}
+	if s.N == 1 {
+		return formatBenchmarkValue(s.Mean)
+	}
+	return fmt.Sprintf("%s ±%.0f%%", formatBenchmarkValue(s.Mean), s.Spread())
+}
+
+// formatBenchmarkValue keeps four significant digits without switching to
+// exponents for large values
+func formatBenchmarkValue(v float64) string {
+	if math.Abs(v) >= 1e4 {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.4g", v)
+}
+
+// formatBenchmarkChange shows the change of the means, marked as a gain or a
+// loss when the samples don't overlap. Units go test reports itself are better
+// lower; custom metrics only show which way they moved.
+func formatBenchmarkChange(d report.BenchmarkDelta) string {
+	switch {
+	case d.Old.N == 0:
+		return "new"
+	case d.New.N == 0:
+		return "removed"
+	case d.Old.Mean == d.New.Mean:
+		return "0.00%"
+	case !d.Significant():
+		return fmt.Sprintf("~ (%+.2f%%)", d.Change())
+	case !report.IsStandardBenchmarkUnit(d.Unit) && d.New.Mean > d.Old.Mean:
+		return fmt.Sprintf("⬆️ %+.2f%%", d.Change())
+	case !report.IsStandardBenchmarkUnit(d.Unit):
+		return fmt.Sprintf("⬇️ %+.2f%%", d.Change())
+	case d.New.Mean < d.Old.Mean:
+		return fmt.Sprintf("🟢 %+.2f%%", d.Change())
+	default:
+		return fmt.Sprintf("🔴 %+.2f%%", d.Change())
+	}
+}
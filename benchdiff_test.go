@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestRenderBenchmarkDiff(t *testing.T) {
+	deltas := []report.BenchmarkDelta{
+		{Name: "BenchmarkA", Package: "example.com/a", Unit: "ns/op",
+			Old: report.BenchmarkSamples{Mean: 100, Min: 95, Max: 105, N: 2},
+			New: report.BenchmarkSamples{Mean: 150, Min: 150, Max: 150, N: 2}},
+		{Name: "BenchmarkB", Package: "example.com/b", Unit: "ns/op",
+			Old: report.BenchmarkSamples{Mean: 12345.6, Min: 12345.6, Max: 12345.6, N: 1}},
+		{Name: "BenchmarkA", Package: "example.com/a", Unit: "allocs/op",
+			Old: report.BenchmarkSamples{Mean: 4, Min: 3, Max: 5, N: 2},
+			New: report.BenchmarkSamples{Mean: 3.5, Min: 3, Max: 4, N: 2}},
+		{Name: "BenchmarkA", Package: "example.com/a", Unit: "req/s",
+			Old: report.BenchmarkSamples{Mean: 100, Min: 100, Max: 100, N: 1},
+			New: report.BenchmarkSamples{Mean: 120, Min: 120, Max: 120, N: 1}},
+	}
+
+	markdown := renderBenchmarkDiff(deltas, "old.json", "new.json")
+	for _, want := range []string{
+		"**Old:** `old.json` · **New:** `new.json`",
+		"## ns/op\n\n| Benchmark | Old | New | Change |\n| --------- | --- | --- | ------ |\n" +
+			"| `BenchmarkA` (example.com/a) | 100 ±5% | 150 ±0% | 🔴 +50.00% |\n" +
+			"| `BenchmarkB` (example.com/b) | 12346 | — | removed |\n" +
+			"| **geomean** | | | +50.00% |\n",
+		"## allocs/op\n\n| Benchmark | Old | New | Change |\n| --------- | --- | --- | ------ |\n" +
+			"| `BenchmarkA` (example.com/a) | 4 ±25% | 3.5 ±14% | ~ (-12.50%) |\n",
+		"## req/s\n\n| Benchmark | Old | New | Change |\n| --------- | --- | --- | ------ |\n" +
+			"| `BenchmarkA` (example.com/a) | 100 | 120 | ⬆️ +20.00% |\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("comparison missing %q:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestBenchdiffCommand(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, ns string) string {
+		path := filepath.Join(dir, name)
+		content := `{"Action":"output","Package":"bench","Output":"BenchmarkJoin-8 \t 1000\t ` + ns + ` ns/op\n"}` + "\n"
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	oldPath, newPath := write("old.json", "200"), write("new.json", "100")
+	output := filepath.Join(dir, "benchdiff.md")
+
+	if code := benchdiffCommand([]string{"-output", output, oldPath, newPath}); code != 0 {
+		t.Fatalf("exit code: got %d, want 0", code)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "| `BenchmarkJoin` | 200 | 100 | 🟢 -50.00% |") {
+		t.Errorf("unexpected comparison:\n%s", content)
+	}
+
+	empty := filepath.Join(dir, "empty.json")
+	os.WriteFile(empty, []byte(`{"Action":"pass","Package":"bench"}`+"\n"), 0o644)
+	if code := benchdiffCommand([]string{"-output", output, empty, empty}); code != 1 {
+		t.Errorf("exit code without benchmarks: got %d, want 1", code)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// Bitbucket Code Insights: a report on the commit with the counts, and an
+// annotation per failed test that Bitbucket shows on the diff of pull
+// requests. Inside Pipelines, requests through the local proxy are
+// authenticated as the pipeline, so no token is needed; the proxy only
+// takes plain HTTP.
+const (
+	bitbucketAPI       = "https://api.bitbucket.org/2.0"
+	bitbucketProxyAPI  = "http://api.bitbucket.org/2.0"
+	bitbucketProxy     = "http://localhost:29418"
+	bitbucketReportID  = "gotest-report"
+	bitbucketBatchSize = 100  // annotations per request
+	bitbucketMaxNotes  = 1000 // annotations per report
+	bitbucketSummary   = 450  // characters of an annotation summary
+	bitbucketDetails   = 2000 // characters of an annotation's output
+)
+
+// bitbucketClient calls the Bitbucket Cloud API for one commit
+type bitbucketClient struct {
+	api    string
+	repo   string // workspace/repo-slug
+	commit string
+	token  string // empty when requests go through the Pipelines proxy
+	http   *http.Client
+}
+
+// bitbucketFromEnv configures the client from the Bitbucket Pipelines
+// environment. It returns nil outside of Pipelines. With a repository access
+// token in BITBUCKET_ACCESS_TOKEN it calls the API directly, which also
+// allows setting the commit's build status; otherwise it uses the proxy.
+func bitbucketFromEnv() *bitbucketClient {
+	workspace, slug, commit := os.Getenv("BITBUCKET_WORKSPACE"), os.Getenv("BITBUCKET_REPO_SLUG"), os.Getenv("BITBUCKET_COMMIT")
+	if os.Getenv("BITBUCKET_BUILD_NUMBER") == "" || workspace == "" || slug == "" || commit == "" {
+		return nil
+	}
+	client := &bitbucketClient{
+		repo:   workspace + "/" + slug,
+		commit: commit,
+		token:  os.Getenv("BITBUCKET_ACCESS_TOKEN"),
+	}
+	if client.token != "" {
+		client.api = bitbucketAPI
+		client.http = &http.Client{Timeout: 30 * time.Second}
+	} else {
+		proxy, _ := url.Parse(bitbucketProxy)
+		client.api = bitbucketProxyAPI
+		client.http = &http.Client{Timeout: 30 * time.Second, Transport: &http.Transport{Proxy: http.ProxyURL(proxy)}}
+	}
+	return client
+}
+
+// bitbucketPipelineURL links to the running pipeline
+func bitbucketPipelineURL() string {
+	origin, build := os.Getenv("BITBUCKET_GIT_HTTP_ORIGIN"), os.Getenv("BITBUCKET_BUILD_NUMBER")
+	if origin == "" || build == "" {
+		return ""
+	}
+	return strings.TrimSuffix(origin, "/") + "/pipelines/results/" + build
+}
+
+type bitbucketReport struct {
+	Title      string           `json:"title"`
+	Details    string           `json:"details"`
+	ReportType string           `json:"report_type"`
+	Reporter   string           `json:"reporter"`
+	Result     string           `json:"result"` // PASSED or FAILED
+	Link       string           `json:"link,omitempty"`
+	Data       []bitbucketDatum `json:"data"`
+}
+
+type bitbucketDatum struct {
+	Title string `json:"title"`
+	Type  string `json:"type"` // NUMBER, PERCENTAGE or DURATION (milliseconds)
+	Value any    `json:"value"`
+}
+
+type bitbucketAnnotation struct {
+	ExternalID     string `json:"external_id"`
+	AnnotationType string `json:"annotation_type"`
+	Summary        string `json:"summary"`
+	Details        string `json:"details,omitempty"`
+	Result         string `json:"result"`
+	Severity       string `json:"severity"`
+	Path           string `json:"path,omitempty"`
+	Line           int    `json:"line,omitempty"`
+}
+
+type bitbucketBuildStatus struct {
+	Key         string `json:"key"`
+	State       string `json:"state"` // SUCCESSFUL or FAILED
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+}
+
+// publish replaces the commit's gotest-report Code Insights report and its
+// annotations and, with a token, sets the build status
+func (c *bitbucketClient) publish(data *ReportData, cfg *config, link string) error {
+	result, state := "PASSED", "SUCCESSFUL"
+	if data.FailedTests > 0 || data.QualityGate != nil && !data.QualityGate.Passed {
+		result, state = "FAILED", "FAILED"
+	}
+	counts := fmt.Sprintf("%d tests: %d passed, %d failed, %d skipped, %d flaky", data.TotalTests, data.PassedTests, data.FailedTests, data.SkippedTests, data.FlakyTests)
+	passRate := 0.0
+	if data.TotalTests > 0 {
+		passRate = float64(data.PassedTests) / float64(data.TotalTests) * 100
+	}
+
+	reportURL := fmt.Sprintf("%s/repositories/%s/commit/%s/reports/%s", c.api, c.repo, c.commit, bitbucketReportID)
+	// Replacing a report keeps its annotations, so start over
+	if err := c.do(http.MethodDelete, reportURL, nil); err != nil {
+		return fmt.Errorf("deleting the previous report: %w", err)
+	}
+	if err := c.do(http.MethodPut, reportURL, bitbucketReport{
+		Title:      "Go tests",
+		Details:    counts,
+		ReportType: "TEST",
+		Reporter:   "gotest-report",
+		Result:     result,
+		Link:       link,
+		Data: []bitbucketDatum{
+			{Title: "Passed", Type: "NUMBER", Value: data.PassedTests},
+			{Title: "Failed", Type: "NUMBER", Value: data.FailedTests},
+			{Title: "Skipped", Type: "NUMBER", Value: data.SkippedTests},
+			{Title: "Flaky", Type: "NUMBER", Value: data.FlakyTests},
+			{Title: "Pass rate", Type: "PERCENTAGE", Value: passRate},
+			{Title: "Duration", Type: "DURATION", Value: int64(data.TotalDuration * 1000)},
+		},
+	}); err != nil {
+		return fmt.Errorf("creating the report: %w", err)
+	}
+
+	annotations := bitbucketAnnotations(data, cfg)
+	for start := 0; start < len(annotations); start += bitbucketBatchSize {
+		batch := annotations[start:min(start+bitbucketBatchSize, len(annotations))]
+		if err := c.do(http.MethodPost, reportURL+"/annotations", batch); err != nil {
+			return fmt.Errorf("adding annotations: %w", err)
+		}
+	}
+
+	if c.token == "" {
+		logger.Info("published Bitbucket Code Insights report", "annotations", len(annotations))
+		return nil
+	}
+	if link == "" {
+		link = fmt.Sprintf("https://bitbucket.org/%s/commits/%s", c.repo, c.commit)
+	}
+	if err := c.do(http.MethodPost, fmt.Sprintf("%s/repositories/%s/commit/%s/statuses/build", c.api, c.repo, c.commit), bitbucketBuildStatus{
+		Key:         bitbucketReportID,
+		State:       state,
+		Name:        "Go tests",
+		Description: counts,
+		URL:         link,
+	}); err != nil {
+		return fmt.Errorf("setting the build status: %w", err)
+	}
+	logger.Info("published Bitbucket Code Insights report and build status", "annotations", len(annotations))
+	return nil
+}
+
+// bitbucketAnnotations returns an annotation per failed test, placed at the
+// first file:line of its output that is in the checkout
+func bitbucketAnnotations(data *ReportData, cfg *config) []bitbucketAnnotation {
+	var tree *sourceTree
+	if wd, err := os.Getwd(); err == nil {
+		tree = findSourceTree(wd)
+	}
+
+	failures := report.Failures(data)
+	annotations := make([]bitbucketAnnotation, 0, min(len(failures), bitbucketMaxNotes))
+	for i, failure := range failures {
+		if i == bitbucketMaxNotes {
+			logger.Warn("left out Bitbucket annotations over the limit per report", "failures", len(failures), "limit", bitbucketMaxNotes)
+			break
+		}
+		summary := failure.Name + " failed"
+		if failure.Excerpt.Assertion != "" {
+			summary = failure.Name + ": " + failure.Excerpt.Assertion
+		}
+		annotation := bitbucketAnnotation{
+			ExternalID:     fmt.Sprintf("%s-%d", bitbucketReportID, i+1),
+			AnnotationType: "BUG",
+			Summary:        truncateRunes(summary, bitbucketSummary),
+			Details:        bitbucketOutput(cfg.packageName(failure.Package), failure.Output),
+			Result:         "FAILED",
+			Severity:       "HIGH",
+		}
+		if locations := tree.locate(failure.Package, failure.Output, 1); len(locations) > 0 {
+			annotation.Path = filepath.ToSlash(locations[0].rel)
+			annotation.Line = locations[0].line
+		}
+		annotations = append(annotations, annotation)
+	}
+	return annotations
+}
+
+// bitbucketOutput keeps the end of a test's output, where Go prints the
+// failure, within the size Bitbucket shows
+func bitbucketOutput(pkg string, output []string) string {
+	var sb strings.Builder
+	for _, line := range output {
+		sb.WriteString(strings.TrimRight(line, "\n") + "\n")
+	}
+	text := "Package " + pkg + "\n\n" + sb.String()
+	if runes := []rune(text); len(runes) > bitbucketDetails {
+		text = "…" + string(runes[len(runes)-bitbucketDetails+1:])
+	}
+	return text
+}
+
+// truncateRunes cuts text to at most limit characters, marking the cut
+func truncateRunes(text string, limit int) string {
+	if runes := []rune(text); len(runes) > limit {
+		return string(runes[:limit-1]) + "…"
+	}
+	return text
+}
+
+// do sends a request with a JSON body. A missing resource is fine for
+// DELETE, which is only used to clear what may not exist.
+func (c *bitbucketClient) do(method, endpoint string, in any) error {
+	_, err := doJSON(c.http, method, endpoint, in, nil, func(req *http.Request) {
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+	})
+	var status *httpStatusError
+	if method == http.MethodDelete && errors.As(err, &status) && status.code == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// publishBitbucket publishes to Code Insights when running in Bitbucket
+// Pipelines. Publishing is a convenience, so failures are only warned about.
+func publishBitbucket(data *ReportData, cfg *config) {
+	client := bitbucketFromEnv()
+	if client == nil {
+		return
+	}
+	if err := client.publish(data, cfg, bitbucketPipelineURL()); err != nil {
+		logger.Warn("publishing to Bitbucket Code Insights", "error", err)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBitbucketPublish(t *testing.T) {
+	var requests []string
+	var annotations []bitbucketAnnotation
+	var status bitbucketBuildStatus
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodDelete:
+			http.NotFound(w, r)
+		case strings.HasSuffix(r.URL.Path, "/annotations"):
+			var batch []bitbucketAnnotation
+			json.Unmarshal(body, &batch)
+			annotations = append(annotations, batch...)
+		case strings.HasSuffix(r.URL.Path, "/statuses/build"):
+			json.Unmarshal(body, &status)
+		}
+	}))
+	defer server.Close()
+
+	// A module whose test file the failures point at
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, ".git"), 0o755)
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0o644)
+	os.MkdirAll(filepath.Join(dir, "users"), 0o755)
+	os.WriteFile(filepath.Join(dir, "users", "users_test.go"), []byte("package users\n"), 0o644)
+	chdir(t, dir)
+
+	data := &ReportData{TotalTests: 102, PassedTests: 1, FailedTests: 101, Results: map[string]*TestResult{
+		"TestOK": {Name: "TestOK", Package: "example.com/app/users", Status: "PASS"},
+	}}
+	for i := 0; i < 101; i++ {
+		name := fmt.Sprintf("TestFail%03d", i)
+		data.Results[name] = &TestResult{Name: name, Package: "example.com/app/users", Status: "FAIL",
+			Output: []string{"=== RUN   " + name, "    users_test.go:42: got 1, want 2", "--- FAIL: " + name}}
+	}
+
+	client := &bitbucketClient{api: server.URL, repo: "ws/app", commit: "abc123", token: "tok", http: server.Client()}
+	if err := client.publish(data, defaultConfig(), "https://bitbucket.org/ws/app/pipelines/results/7"); err != nil {
+		t.Fatal(err)
+	}
+
+	report := "/repositories/ws/app/commit/abc123/reports/gotest-report"
+	want := []string{
+		"DELETE " + report,
+		"PUT " + report,
+		"POST " + report + "/annotations",
+		"POST " + report + "/annotations",
+		"POST /repositories/ws/app/commit/abc123/statuses/build",
+	}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests: got\n%s\nwant\n%s", strings.Join(requests, "\n"), strings.Join(want, "\n"))
+	}
+	if len(annotations) != 101 {
+		t.Fatalf("got %d annotations, want 101", len(annotations))
+	}
+	first := annotations[0]
+	if first.Summary != "TestFail000: got 1, want 2" || first.Path != "users/users_test.go" || first.Line != 42 ||
+		first.Result != "FAILED" || first.ExternalID != "gotest-report-1" || !strings.Contains(first.Details, "users_test.go:42") {
+		t.Errorf("annotation: got %+v", first)
+	}
+	if status.State != "FAILED" || status.Key != "gotest-report" || status.URL != "https://bitbucket.org/ws/app/pipelines/results/7" {
+		t.Errorf("build status: got %+v", status)
+	}
+}
+
+func TestBitbucketFromEnv(t *testing.T) {
+	t.Setenv("BITBUCKET_BUILD_NUMBER", "")
+	if client := bitbucketFromEnv(); client != nil {
+		t.Errorf("outside of Pipelines: got %+v", client)
+	}
+
+	t.Setenv("BITBUCKET_BUILD_NUMBER", "7")
+	t.Setenv("BITBUCKET_WORKSPACE", "ws")
+	t.Setenv("BITBUCKET_REPO_SLUG", "app")
+	t.Setenv("BITBUCKET_COMMIT", "abc123")
+	t.Setenv("BITBUCKET_ACCESS_TOKEN", "")
+	if client := bitbucketFromEnv(); client == nil || client.api != bitbucketProxyAPI || client.repo != "ws/app" {
+		t.Errorf("without a token: got %+v", client)
+	}
+	t.Setenv("BITBUCKET_ACCESS_TOKEN", "tok")
+	if client := bitbucketFromEnv(); client == nil || client.api != bitbucketAPI || client.token != "tok" {
+		t.Errorf("with a token: got %+v", client)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	if got := truncateRunes("äöü", 3); got != "äöü" {
+		t.Errorf("short text: got %q", got)
+	}
+	if got := truncateRunes("äöüß", 3); got != "äö…" {
+		t.Errorf("long text: got %q", got)
+	}
+}
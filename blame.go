@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// blamer attributes failed tests to the latest commit that touched their
+// function, per git blame, to help route triage
+type blamer struct {
+	tree  *sourceTree
+	cache map[string]*blameCommit // by package and test
+}
+
+// blameCommit is the latest commit among the blamed lines
+type blameCommit struct {
+	sha     string
+	author  string
+	time    time.Time
+	summary string
+}
+
+// newBlamer returns nil outside of a Go module or without git
+func newBlamer(dir string) *blamer {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil
+	}
+	tree := findSourceTree(dir)
+	if tree == nil {
+		return nil
+	}
+	return &blamer{tree: tree, cache: make(map[string]*blameCommit)}
+}
+
+// blamerFromFlags returns nil unless -blame was given
+func blamerFromFlags(blame bool) *blamer {
+	if !blame {
+		return nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	b := newBlamer(wd)
+	if b == nil {
+		logger.Warn("not attributing failures: -blame needs git and a Go module")
+	}
+	return b
+}
+
+// latest returns the latest commit that touched the function of the top-level
+// test in pkg, or nil when the function or its history can't be found
+func (b *blamer) latest(pkg, test string) *blameCommit {
+	if b == nil {
+		return nil
+	}
+	test, _, _ = strings.Cut(test, "/")
+	key := pkg + "." + test
+	if commit, cached := b.cache[key]; cached {
+		return commit
+	}
+
+	var commit *blameCommit
+	if file, first, last := findTestFunc(b.tree.packageDir(pkg), test); file != "" {
+		commit = b.blame(file, first, last)
+	}
+	b.cache[key] = commit
+	return commit
+}
+
+// findTestFunc returns the test file in dir declaring the function name, and
+// the lines it spans
+func findTestFunc(dir, name string) (string, int, int) {
+	if dir == "" {
+		return "", 0, 0
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	for _, file := range files {
+		fset := token.NewFileSet()
+		parsed, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range parsed.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if ok && fn.Recv == nil && fn.Name.Name == name {
+				return file, fset.Position(fn.Pos()).Line, fset.Position(fn.End()).Line
+			}
+		}
+	}
+	return "", 0, 0
+}
+
+// blame runs git blame on the lines of file and returns the commit with the
+// latest author time. Uncommitted lines are ignored.
+func (b *blamer) blame(file string, first, last int) *blameCommit {
+	cmd := exec.Command("git", "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", first, last), "--", file)
+	cmd.Dir = b.tree.repoRoot
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	// Porcelain output describes each commit once, after the header of the
+	// first line it touched: "<sha> <orig line> <line> [<lines>]"
+	commits := make(map[string]*blameCommit)
+	var current *blameCommit
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\t") {
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		if len(key) == 40 && strings.Trim(key, "0123456789abcdef") == "" {
+			current = commits[key]
+			if current == nil {
+				current = &blameCommit{sha: key}
+				commits[key] = current
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		switch key {
+		case "author":
+			current.author = value
+		case "author-time":
+			if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				current.time = time.Unix(seconds, 0).UTC()
+			}
+		case "summary":
+			current.summary = value
+		}
+	}
+
+	var latest *blameCommit
+	for sha, commit := range commits {
+		if strings.Trim(sha, "0") == "" {
+			continue
+		}
+		if latest == nil || commit.time.After(latest.time) {
+			latest = commit
+		}
+	}
+	return latest
+}
+
+// writeBlame names the latest commit that touched a failed test's function
+func writeBlame(sb *strings.Builder, cfg *config, pkg, test string) {
+	commit := cfg.blame.latest(pkg, test)
+	if commit == nil {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("👤 **Last changed by** %s in `%s` on %s: %s\n\n",
+		commit.author, shortCommit(commit.sha), commit.time.Format("2006-01-02"), commit.summary))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBlame(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	git := func(env []string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(author, date, message string) {
+		git(nil, "add", "-A")
+		git([]string{
+			"GIT_AUTHOR_NAME=" + author, "GIT_AUTHOR_EMAIL=dev@example.com", "GIT_AUTHOR_DATE=" + date,
+			"GIT_COMMITTER_NAME=" + author, "GIT_COMMITTER_EMAIL=dev@example.com", "GIT_COMMITTER_DATE=" + date,
+		}, "commit", "-q", "-m", message)
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git(nil, "init", "-q")
+	write("go.mod", "module example.com/calc\n")
+	write("calc_test.go", "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tt.Log(1)\n}\n\nfunc TestSub(t *testing.T) {\n\tt.Log(1)\n}\n")
+	commit("Ada", "2024-05-01T12:00:00Z", "Add calc tests")
+	write("calc_test.go", "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tt.Log(1)\n}\n\nfunc TestSub(t *testing.T) {\n\tt.Log(2)\n}\n")
+	commit("Grace", "2024-05-03T12:00:00Z", "Tighten TestSub")
+
+	cfg := defaultConfig()
+	cfg.blame = newBlamer(repo)
+	if cfg.blame == nil {
+		t.Fatal("expected a blamer inside a module")
+	}
+
+	var sb strings.Builder
+	writeBlame(&sb, cfg, "example.com/calc", "TestSub/negative")
+	if got := sb.String(); !strings.HasPrefix(got, "👤 **Last changed by** Grace in `") || !strings.HasSuffix(got, "` on 2024-05-03: Tighten TestSub\n\n") {
+		t.Errorf("TestSub: got %q", got)
+	}
+	sb.Reset()
+	writeBlame(&sb, cfg, "example.com/calc", "TestAdd")
+	if got := sb.String(); !strings.Contains(got, "Ada") || !strings.Contains(got, "Add calc tests") {
+		t.Errorf("TestAdd: got %q", got)
+	}
+
+	sb.Reset()
+	writeBlame(&sb, cfg, "example.com/calc", "TestMissing")
+	writeBlame(&sb, defaultConfig(), "example.com/calc", "TestAdd")
+	if sb.Len() != 0 {
+		t.Errorf("unknown tests and the default config should not be attributed: %q", sb.String())
+	}
+}
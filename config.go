@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// config holds report settings. Fields with JSON tags can be set in the file
+// passed with -config; the rest are filled in from command-line flags or the
+// environment.
+type config struct {
+	// Packages maps import paths, or import path prefixes, to the short names
+	// shown in the report, e.g. "github.com/acme/platform/internal/billing" to
+	// "Billing". Several paths may share a name to form a group.
+	Packages []packageMapping `json:"packages,omitempty"`
+
+	// DurationBudgets limit how long the tests of matching packages may
+	// take, e.g. "github.com/acme/platform/api" to "30s", matched like
+	// Packages
+	DurationBudgets []durationBudget `json:"durationBudgets,omitempty"`
+	budgets         []report.Budget
+
+	// Categories group packages, e.g. into unit, integration and e2e tests,
+	// each with its own counts and optional pass rate gate
+	Categories []categoryRule `json:"categories,omitempty"`
+	categories []report.Category
+
+	// Redact holds regular expressions whose matches are masked in captured
+	// output, on top of the built-in credential patterns
+	Redact []string `json:"redact,omitempty"`
+
+	// OutputFilter holds rules dropping log noise from captured output:
+	// drop=REGEX drops matching lines and level=LEVEL log lines below LEVEL.
+	// Rules of -output-filter flags are added to these.
+	OutputFilter []string `json:"outputFilter,omitempty"`
+
+	// Emoji is how the Markdown report writes emoji: "unicode" (the
+	// default) or "shortcode" for GitHub :shortcode:s, for hosts that strip
+	// raw Unicode emoji
+	Emoji string `json:"emoji,omitempty"`
+	// StatusIcons override the icon of each test status (PASS, FAIL, SKIP,
+	// FLAKY, UNKNOWN) in the results table, as text or the URL of an image
+	StatusIcons map[string]string `json:"statusIcons,omitempty"`
+	// StatusBadges override the badge images of the Test Status section for
+	// runs that passed (PASS), failed (FAIL) or were all skipped (SKIP)
+	StatusBadges map[string]string `json:"statusBadges,omitempty"`
+
+	// Jira files tickets for failures that persisted for several runs of
+	// the -history file
+	Jira *jiraConfig `json:"jira,omitempty"`
+
+	// Links failure locations to the source on GitHub; nil disables links
+	sourceLinks *sourceLinker
+	// Embeds the source around failure locations; nil disables snippets
+	sourceSnippets *snippetEmbedder
+	// Names the latest commit to the failed tests' functions; nil disables
+	// attribution
+	blame *blamer
+	// Append a task list of the failed tests for triage
+	triageChecklist bool
+	// Who owns each test, named in the triage checklist; nil names no one
+	owners *codeOwners
+	// Where the reported tests are defined; nil leaves definitions out
+	sourceIndex *sourceIndex
+	// Render a section per go test invocation found in the input
+	separateInvocations bool
+	// Attach the output of passing tests
+	includePassOutput bool
+	// Render when tests ran and how many ran at once
+	timeline bool
+	// Add Mermaid charts for hosts that render them
+	mermaid bool
+	// Where the report will be posted; Markdown is trimmed to its limit
+	target reportTarget
+	// Detail left out to fit the target's limit
+	trim trimming
+	// Rows in the slowest packages table; 0 leaves the table out
+	slowestPackages int
+	// Rows in the test durations table; 0 leaves the table out
+	topSlow int
+	// Seconds above which a test is marked slow; 0 marks none
+	slowThreshold float64
+	// Show parents with the total duration of their subtests when larger
+	aggregateParentDurations bool
+	// Show the underscores in subtest names as the spaces they stand for
+	subTestSpaces bool
+	// Previous runs, including this one; nil without -history
+	history *report.History
+	// Latest runs of history shown next to each test in the results table;
+	// 0 leaves them out
+	historyRuns int
+	// Latest runs of history charted in the trend; 0 leaves it out
+	trendRuns int
+	// Longest input line parsed in one piece; longer ones are streamed
+	maxLineBytes int
+	// Skip input lines that aren't JSON events instead of failing
+	lenient bool
+	// Format of the inputs: report.InputGo, report.InputCTRF or
+	// report.InputJUnit
+	inputFormat string
+}
+
+type durationBudget struct {
+	Match  string `json:"match"`
+	Budget string `json:"budget"`
+}
+
+type categoryRule struct {
+	Name        string   `json:"name"`
+	Match       []string `json:"match"`
+	MinPassRate *float64 `json:"minPassRate,omitempty"`
+}
+
+type packageMapping struct {
+	Match string `json:"match"`
+	Name  string `json:"name"`
+}
+
+// outputFilterList collects repeated -output-filter flags
+type outputFilterList []string
+
+func (l *outputFilterList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *outputFilterList) Set(rule string) error {
+	if _, err := report.ParseOutputFilter([]string{rule}); err != nil {
+		return err
+	}
+	*l = append(*l, rule)
+	return nil
+}
+
+// defaultConfig returns the settings used when no config file is given
+func defaultConfig() *config {
+	return &config{slowestPackages: 10, topSlow: 15, maxLineBytes: report.DefaultMaxLineBytes}
+}
+
+// loadConfig reads a JSON config file. Unknown keys are rejected so typos
+// don't silently disable a setting.
+func loadConfig(path string) (*config, error) {
+	cfg := defaultConfig()
+	if path == "" {
+		return cfg, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening config file: %v", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+
+	for _, mapping := range cfg.Packages {
+		if mapping.Match == "" || mapping.Name == "" {
+			return nil, fmt.Errorf("error parsing config file %s: package mappings need both match and name", path)
+		}
+	}
+	for _, budget := range cfg.DurationBudgets {
+		limit, err := time.ParseDuration(budget.Budget)
+		if budget.Match == "" || err != nil || limit <= 0 {
+			return nil, fmt.Errorf("error parsing config file %s: duration budgets need a match and a positive budget such as \"30s\"", path)
+		}
+		cfg.budgets = append(cfg.budgets, report.Budget{Match: budget.Match, Seconds: limit.Seconds()})
+	}
+	for _, rule := range cfg.Categories {
+		if rule.Name == "" || len(rule.Match) == 0 {
+			return nil, fmt.Errorf("error parsing config file %s: categories need a name and at least one match", path)
+		}
+		category := report.Category{Name: rule.Name, Match: rule.Match, MinPassRate: -1}
+		if rule.MinPassRate != nil {
+			category.MinPassRate = *rule.MinPassRate
+		}
+		cfg.categories = append(cfg.categories, category)
+	}
+	if _, err := report.NewRedactor(false, cfg.Redact); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+	if _, err := report.ParseOutputFilter(cfg.OutputFilter); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+	switch cfg.Emoji {
+	case "", emojiUnicode, emojiShortcode:
+	default:
+		return nil, fmt.Errorf("error parsing config file %s: unknown emoji style %q (want unicode or shortcode)", path, cfg.Emoji)
+	}
+	for status, icon := range cfg.StatusIcons {
+		if _, ok := defaultStatusIcons[status]; !ok || icon == "" {
+			return nil, fmt.Errorf("error parsing config file %s: status icons need one of PASS, FAIL, SKIP, FLAKY or UNKNOWN and an icon, got %q", path, status)
+		}
+	}
+	for outcome, badge := range cfg.StatusBadges {
+		if _, ok := defaultStatusBadges[outcome]; !ok || !isImageURL(badge) {
+			return nil, fmt.Errorf("error parsing config file %s: status badges need one of PASS, FAIL or SKIP and an image URL, got %q", path, outcome)
+		}
+	}
+	if cfg.Jira != nil {
+		if err := cfg.Jira.validate(); err != nil {
+			return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+		}
+	}
+	return cfg, nil
+}
+
+// packageName returns the display name for an import path. The longest
+// matching mapping wins; unmapped packages keep their import path.
+func (c *config) packageName(pkg string) string {
+	if name, ok := matchPackage(c.Packages, pkg); ok {
+		return name
+	}
+	return pkg
+}
+
+// matchPackage returns the name of the longest of mappings matching pkg
+func matchPackage(mappings []packageMapping, pkg string) (string, bool) {
+	best := -1
+	name := ""
+	for _, mapping := range mappings {
+		match := strings.TrimSuffix(mapping.Match, "/...")
+		if (pkg == match || strings.HasPrefix(pkg, match+"/")) && len(match) > best {
+			best = len(match)
+			name = mapping.Name
+		}
+	}
+	return name, best >= 0
+}
+
+// subTestName returns the decoded last element of a subtest's name for
+// display; -run patterns need the raw name
+func (c *config) subTestName(name string) string {
+	return report.SubTestName(name, c.subTestSpaces)
+}
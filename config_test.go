@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "gotest-report.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{"valid", `{"packages":[{"match":"github.com/acme/billing","name":"Billing"}]}`, false},
+		{"empty object", `{}`, false},
+		{"unknown key", `{"pakages":[]}`, true},
+		{"missing name", `{"packages":[{"match":"github.com/acme"}]}`, true},
+		{"duration budget", `{"durationBudgets":[{"match":"github.com/acme/api","budget":"30s"}]}`, false},
+		{"bad duration budget", `{"durationBudgets":[{"match":"github.com/acme/api","budget":"30"}]}`, true},
+		{"category", `{"categories":[{"name":"e2e","match":[".../e2e/..."],"minPassRate":90}]}`, false},
+		{"category without match", `{"categories":[{"name":"e2e"}]}`, true},
+		{"redact", `{"redact":["password=\\S+"]}`, false},
+		{"bad redact pattern", `{"redact":["("]}`, true},
+		{"output filter", `{"outputFilter":["drop=^time=","level=warn"]}`, false},
+		{"bad output filter", `{"outputFilter":["level=loud"]}`, true},
+		{"shortcode emoji", `{"emoji":"shortcode","statusIcons":{"PASS":":heavy_check_mark:"},"statusBadges":{"FAIL":"https://ci.example.com/failed.svg"}}`, false},
+		{"unknown emoji style", `{"emoji":"ascii"}`, true},
+		{"unknown status icon", `{"statusIcons":{"PASSED":"ok"}}`, true},
+		{"badge without url", `{"statusBadges":{"PASS":"passed"}}`, true},
+		{"invalid json", `{`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig(writeConfig(t, tt.content))
+			if tt.expectError && err == nil {
+				t.Fatal("Expected an error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	if cfg, err := loadConfig(""); err != nil || cfg == nil {
+		t.Errorf("empty path should yield the default config, got %v, %v", cfg, err)
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	cfg := &config{Packages: []packageMapping{
+		{Match: "github.com/acme/platform", Name: "Platform"},
+		{Match: "github.com/acme/platform/internal/billing", Name: "Billing"},
+		{Match: "github.com/acme/tools/...", Name: "Tools"},
+	}}
+
+	tests := []struct {
+		pkg  string
+		want string
+	}{
+		{"github.com/acme/platform/internal/billing", "Billing"},
+		{"github.com/acme/platform/internal/billing/invoices", "Billing"},
+		{"github.com/acme/platform/internal/auth", "Platform"},
+		{"github.com/acme/platformx", "github.com/acme/platformx"},
+		{"github.com/acme/tools/lint", "Tools"},
+		{"example.com/other", "example.com/other"},
+	}
+	for _, tt := range tests {
+		if got := cfg.packageName(tt.pkg); got != tt.want {
+			t.Errorf("packageName(%q): got %q, want %q", tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestPackageNamesInFailureDetails(t *testing.T) {
+	cfg := &config{Packages: []packageMapping{{Match: "github.com/acme/platform/internal/billing", Name: "Billing"}}}
+	markdown := renderMarkdownReport(&ReportData{
+		TotalTests:      1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestInvoice"},
+		Results: map[string]*TestResult{
+			"TestInvoice": {Name: "TestInvoice", Package: "github.com/acme/platform/internal/billing", Status: "FAIL"},
+		},
+	}, cfg)
+
+	if !strings.Contains(markdown, "📦 **Package:** Billing") {
+		t.Error("mapped package name not shown in failure details")
+	}
+	// Only the commands re-running the test need the import path
+	if strings.Count(markdown, "internal/billing") != strings.Count(markdown, "go test github.com/acme/platform/internal/billing ") {
+		t.Error("import path should be replaced by its display name")
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// Datadog CI Visibility takes test results without an agent through its
+// test cycle intake. Each package is a test module holding one suite, as in
+// Datadog's own Go instrumentation.
+const (
+	datadogSite      = "datadoghq.com"
+	datadogBatchSize = 1000 // events per request
+)
+
+// datadogPayload is a request to the test cycle intake. Tags in the "*"
+// metadata apply to every event.
+type datadogPayload struct {
+	Version  int                          `json:"version"`
+	Metadata map[string]map[string]string `json:"metadata"`
+	Events   []datadogEvent               `json:"events"`
+}
+
+type datadogEvent struct {
+	Type    string      `json:"type"` // test, test_suite_end, test_module_end or test_session_end
+	Version int         `json:"version"`
+	Content datadogSpan `json:"content"`
+}
+
+type datadogSpan struct {
+	TraceID       uint64             `json:"trace_id,omitempty"`
+	SpanID        uint64             `json:"span_id,omitempty"`
+	ParentID      uint64             `json:"parent_id"`
+	TestSessionID uint64             `json:"test_session_id"`
+	TestModuleID  uint64             `json:"test_module_id,omitempty"`
+	TestSuiteID   uint64             `json:"test_suite_id,omitempty"`
+	Name          string             `json:"name"`
+	Resource      string             `json:"resource"`
+	Service       string             `json:"service"`
+	Type          string             `json:"type"`
+	Start         int64              `json:"start"`    // Unix nanoseconds
+	Duration      int64              `json:"duration"` // nanoseconds
+	Error         int                `json:"error"`
+	Meta          map[string]string  `json:"meta"`
+	Metrics       map[string]float64 `json:"metrics,omitempty"`
+}
+
+// datadogEvents returns the events of a test session ending at now. Tests
+// are laid out by their durations: packages start together, as they run in
+// parallel, and their tests follow one another; subtests start with their
+// top-level test. Earlier attempts of re-run tests are sent with their own
+// status, later ones marked as retries.
+func datadogEvents(data *ReportData, service string, now time.Time) []datadogEvent {
+	packageDurations := make(map[string]float64)
+	longest := data.TotalDuration
+	for _, p := range report.PackageDurations(data) {
+		packageDurations[p.Package] = p.Duration
+		longest = max(longest, p.Duration)
+	}
+	sessionStart := now.Add(-datadogSeconds(longest))
+	sessionID := rand.Uint64()
+	sessionStatus := "pass"
+	if data.FailedTests > 0 {
+		sessionStatus = "fail"
+	}
+
+	var events []datadogEvent
+	packages, byPackage := resultsByPackage(data)
+	for _, pkg := range packages {
+		moduleID, suiteID := rand.Uint64(), rand.Uint64()
+		moduleStatus := "pass"
+		starts := make(map[string]time.Time)
+		offset := sessionStart
+		for _, result := range byPackage[pkg] {
+			top, _, _ := strings.Cut(result.Name, "/")
+			start, ok := starts[top]
+			if !ok {
+				start = offset
+				starts[top] = offset
+				if parent := data.Results[top]; parent != nil {
+					offset = offset.Add(datadogSeconds(parent.Duration))
+				}
+			}
+
+			for i, attempt := range result.Attempts {
+				test := datadogTest(pkg, result.Name, "pass", attempt.Duration, start, service)
+				switch attempt.Status {
+				case "PASS":
+				case "SKIP":
+					test.Meta["test.status"] = "skip"
+				default:
+					test.Meta["test.status"] = "fail"
+					test.Error = 1
+					test.Meta["error.message"] = failureMessage(attempt.Output)
+					test.Meta["error.stack"] = joinOutput(attempt.Output)
+				}
+				if i > 0 {
+					test.Meta["test.is_retry"] = "true"
+				}
+				events = append(events, datadogTestEvent(test, sessionID, moduleID, suiteID))
+			}
+
+			test := datadogTest(pkg, result.Name, "pass", result.Duration, start, service)
+			if len(result.Attempts) > 0 {
+				test.Meta["test.is_retry"] = "true"
+			}
+			switch result.Status {
+			case "PASS", "FLAKY":
+			case "SKIP":
+				test.Meta["test.status"] = "skip"
+				if result.SkipReason != "" {
+					test.Meta["test.skip_reason"] = result.SkipReason
+				}
+			default:
+				test.Meta["test.status"] = "fail"
+				test.Error = 1
+				test.Meta["error.message"] = failureMessage(result.Output)
+				if result.Status != "FAIL" {
+					test.Meta["error.message"] = "test did not report a result"
+				}
+				test.Meta["error.stack"] = joinOutput(result.Output)
+				moduleStatus = "fail"
+			}
+			events = append(events, datadogTestEvent(test, sessionID, moduleID, suiteID))
+		}
+
+		duration := datadogSeconds(packageDurations[pkg])
+		suite := datadogEnd("test_suite_end", "go.test_suite", pkg, service, moduleStatus, sessionStart, duration)
+		suite.TestSessionID, suite.TestModuleID, suite.TestSuiteID = sessionID, moduleID, suiteID
+		suite.Meta["test.module"], suite.Meta["test.suite"] = pkg, pkg
+		module := datadogEnd("test_module_end", "go.test_module", pkg, service, moduleStatus, sessionStart, duration)
+		module.TestSessionID, module.TestModuleID = sessionID, moduleID
+		module.Meta["test.module"] = pkg
+		events = append(events, datadogEvent{Type: suite.Type, Version: 1, Content: suite}, datadogEvent{Type: module.Type, Version: 1, Content: module})
+	}
+
+	session := datadogEnd("test_session_end", "go.test_session", "go test", service, sessionStatus, sessionStart, datadogSeconds(longest))
+	session.TestSessionID = sessionID
+	session.Meta["test.command"] = "go test"
+	return append(events, datadogEvent{Type: session.Type, Version: 1, Content: session})
+}
+
+// datadogEnd returns the span closing a test suite, module or session
+func datadogEnd(kind, name, resource, service, status string, start time.Time, duration time.Duration) datadogSpan {
+	span := datadogSpan{
+		Name:     name,
+		Resource: resource,
+		Service:  service,
+		Type:     kind,
+		Start:    start.UnixNano(),
+		Duration: duration.Nanoseconds(),
+		Meta:     map[string]string{"test.status": status},
+	}
+	if status == "fail" {
+		span.Error = 1
+	}
+	return span
+}
+
+// datadogTest returns the span of one execution of a test
+func datadogTest(pkg, name, status string, seconds float64, start time.Time, service string) datadogSpan {
+	return datadogSpan{
+		Name:     "go.test",
+		Resource: pkg + "." + name,
+		Service:  service,
+		Type:     "test",
+		Start:    start.UnixNano(),
+		Duration: datadogSeconds(seconds).Nanoseconds(),
+		Meta: map[string]string{
+			"test.name":   name,
+			"test.suite":  pkg,
+			"test.module": pkg,
+			"test.status": status,
+			"test.type":   "test",
+		},
+		Metrics: map[string]float64{"_dd.top_level": 1},
+	}
+}
+
+func datadogTestEvent(span datadogSpan, sessionID, moduleID, suiteID uint64) datadogEvent {
+	span.TraceID, span.SpanID = rand.Uint64(), rand.Uint64()
+	span.TestSessionID, span.TestModuleID, span.TestSuiteID = sessionID, moduleID, suiteID
+	return datadogEvent{Type: "test", Version: 2, Content: span}
+}
+
+func datadogSeconds(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// datadogTags are the tags of every event: the git commit and the CI run,
+// from the DD_GIT_* variables Datadog documents or the CI's own variables
+func datadogTags() map[string]string {
+	tags := map[string]string{
+		"language":           "go",
+		"runtime.name":       "go",
+		"runtime.version":    runtime.Version(),
+		"os.platform":        runtime.GOOS,
+		"os.architecture":    runtime.GOARCH,
+		"test.framework":     "testing",
+		"span.kind":          "test",
+		"env":                firstEnv("DD_ENV"),
+		"library_version":    version,
+		"git.repository_url": firstEnv("DD_GIT_REPOSITORY_URL", "CI_PROJECT_URL", "BITBUCKET_GIT_HTTP_ORIGIN"),
+		"git.commit.sha":     firstEnv("DD_GIT_COMMIT_SHA", "GITHUB_SHA", "CI_COMMIT_SHA", "BITBUCKET_COMMIT"),
+		"git.branch":         firstEnv("DD_GIT_BRANCH", "GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME", "BITBUCKET_BRANCH"),
+		"git.tag":            firstEnv("DD_GIT_TAG", "CI_COMMIT_TAG", "BITBUCKET_TAG"),
+	}
+	if tags["env"] == "" {
+		tags["env"] = "ci"
+	}
+	switch server, repo, run := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"); {
+	case run != "":
+		if tags["git.repository_url"] == "" && server != "" && repo != "" {
+			tags["git.repository_url"] = strings.TrimSuffix(server, "/") + "/" + repo + ".git"
+		}
+		tags["ci.provider.name"] = "github"
+		tags["ci.pipeline.id"] = run
+		tags["ci.pipeline.name"] = os.Getenv("GITHUB_WORKFLOW")
+		tags["ci.pipeline.number"] = os.Getenv("GITHUB_RUN_NUMBER")
+		tags["ci.pipeline.url"] = fmt.Sprintf("%s/%s/actions/runs/%s", strings.TrimSuffix(server, "/"), repo, run)
+		tags["ci.job.name"] = os.Getenv("GITHUB_JOB")
+	case os.Getenv("GITLAB_CI") != "":
+		tags["ci.provider.name"] = "gitlab"
+		tags["ci.pipeline.id"] = os.Getenv("CI_PIPELINE_ID")
+		tags["ci.pipeline.name"] = os.Getenv("CI_PROJECT_PATH")
+		tags["ci.pipeline.url"] = os.Getenv("CI_PIPELINE_URL")
+		tags["ci.job.name"] = os.Getenv("CI_JOB_NAME")
+		tags["ci.job.url"] = os.Getenv("CI_JOB_URL")
+	case os.Getenv("BITBUCKET_BUILD_NUMBER") != "":
+		tags["ci.provider.name"] = "bitbucket"
+		tags["ci.pipeline.id"] = strings.Trim(os.Getenv("BITBUCKET_PIPELINE_UUID"), "{}")
+		tags["ci.pipeline.name"] = os.Getenv("BITBUCKET_REPO_FULL_NAME")
+		tags["ci.pipeline.number"] = os.Getenv("BITBUCKET_BUILD_NUMBER")
+		tags["ci.pipeline.url"] = bitbucketPipelineURL()
+	}
+	for name, value := range tags {
+		if value == "" {
+			delete(tags, name)
+		}
+	}
+	return tags
+}
+
+// datadogService names the service tests are reported under: DD_SERVICE,
+// or else the repository
+func datadogService() string {
+	if service := firstEnv("DD_SERVICE", "CI_PROJECT_NAME", "BITBUCKET_REPO_SLUG"); service != "" {
+		return service
+	}
+	if repo := os.Getenv("GITHUB_REPOSITORY"); repo != "" {
+		return repo[strings.LastIndex(repo, "/")+1:]
+	}
+	return "go-tests"
+}
+
+// firstEnv returns the first of the named environment variables that is set
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// sendDatadog posts the results to the CI Visibility intake of DD_SITE, or
+// of DD_CIVISIBILITY_AGENTLESS_URL when set
+func sendDatadog(data *ReportData, apiKey string, now time.Time) error {
+	endpoint := os.Getenv("DD_CIVISIBILITY_AGENTLESS_URL")
+	if endpoint == "" {
+		site := os.Getenv("DD_SITE")
+		if site == "" {
+			site = datadogSite
+		}
+		endpoint = "https://citestcycle-intake." + site
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/") + "/api/v2/citestcycle"
+
+	events := datadogEvents(data, datadogService(), now)
+	metadata := map[string]map[string]string{"*": datadogTags()}
+	client := &http.Client{Timeout: 30 * time.Second}
+	for start := 0; start < len(events); start += datadogBatchSize {
+		batch := events[start:min(start+datadogBatchSize, len(events))]
+		encoded, err := json.Marshal(datadogPayload{Version: 1, Metadata: metadata, Events: batch})
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(encoded))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("DD-API-KEY", apiKey)
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("POST %s: %s: %s", endpoint, resp.Status, strings.TrimSpace(string(message)))
+		}
+	}
+	logger.Info("sent test results to Datadog CI Visibility", "events", len(events))
+	return nil
+}
+
+// publishDatadog sends the results to Datadog when DD_API_KEY is set.
+// Publishing is a convenience, so failures are only warned about.
+func publishDatadog(data *ReportData) {
+	apiKey := os.Getenv("DD_API_KEY")
+	if apiKey == "" {
+		return
+	}
+	if err := sendDatadog(data, apiKey, time.Now()); err != nil {
+		logger.Warn("sending to Datadog CI Visibility", "error", err)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestDatadogEvents(t *testing.T) {
+	data := &ReportData{
+		TotalTests:    5,
+		PassedTests:   1,
+		FailedTests:   2,
+		SkippedTests:  1,
+		FlakyTests:    1,
+		TotalDuration: 3,
+		Results: map[string]*TestResult{
+			"TestOK":   {Name: "TestOK", Package: "example.com/a", Status: "PASS", Duration: 1},
+			"TestBad":  {Name: "TestBad", Package: "example.com/a", Status: "FAIL", Duration: 2, Output: []string{"    a_test.go:9: got 1, want 2"}},
+			"TestSkip": {Name: "TestSkip", Package: "example.com/b", Status: "SKIP", SkipReason: "needs docker"},
+			"TestRetried": {Name: "TestRetried", Package: "example.com/b", Status: "FLAKY", Duration: 1,
+				Attempts: []report.Attempt{{Status: "FAIL", Duration: 1, Output: []string{"    b_test.go:3: timeout"}}}},
+			"TestWorse": {Name: "TestWorse", Package: "example.com/b", Status: "FAIL", Output: []string{"    b_test.go:7: broke"},
+				Attempts: []report.Attempt{{Status: "PASS"}}},
+		},
+	}
+	now := time.Unix(1700000000, 0)
+	events := datadogEvents(data, "app", now)
+
+	var got []string
+	var session datadogSpan
+	for _, event := range events {
+		content := event.Content
+		switch event.Type {
+		case "test":
+			got = append(got, strings.Join([]string{content.Meta["test.suite"], content.Meta["test.name"], content.Meta["test.status"], content.Meta["test.is_retry"], content.Meta["error.message"]}, " "))
+			if content.TraceID == 0 || content.SpanID == 0 || content.TestSuiteID == 0 || content.Service != "app" {
+				t.Errorf("%s: got ids %d/%d/%d and service %q", content.Resource, content.TraceID, content.SpanID, content.TestSuiteID, content.Service)
+			}
+		case "test_session_end":
+			session = content
+		default:
+			got = append(got, event.Type+" "+content.Resource+" "+content.Meta["test.status"])
+		}
+	}
+	want := []string{
+		"example.com/a TestBad fail  got 1, want 2",
+		"example.com/a TestOK pass  ",
+		"test_suite_end example.com/a fail",
+		"test_module_end example.com/a fail",
+		"example.com/b TestRetried fail  timeout",
+		"example.com/b TestRetried pass true ",
+		"example.com/b TestSkip skip  ",
+		"example.com/b TestWorse pass  ",
+		"example.com/b TestWorse fail true broke",
+		"test_suite_end example.com/b fail",
+		"test_module_end example.com/b fail",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events: got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if session.Meta["test.status"] != "fail" || session.Error != 1 || session.Start != now.Add(-3*time.Second).UnixNano() {
+		t.Errorf("session: got %+v", session)
+	}
+	for _, event := range events {
+		if event.Content.TestSessionID != session.TestSessionID {
+			t.Errorf("%s %s: got session %d, want %d", event.Type, event.Content.Resource, event.Content.TestSessionID, session.TestSessionID)
+		}
+	}
+
+	// TestOK starts when TestBad ends, as they ran one after the other
+	for _, event := range events {
+		if event.Content.Resource == "example.com/a.TestOK" && event.Content.Start != now.Add(-time.Second).UnixNano() {
+			t.Errorf("TestOK: got start %d, want %d", event.Content.Start, now.Add(-time.Second).UnixNano())
+		}
+	}
+}
+
+func TestDatadogTags(t *testing.T) {
+	for _, name := range []string{"DD_ENV", "DD_GIT_REPOSITORY_URL", "DD_GIT_COMMIT_SHA", "DD_GIT_BRANCH", "DD_GIT_TAG", "CI_PROJECT_URL", "BITBUCKET_GIT_HTTP_ORIGIN",
+		"CI_COMMIT_SHA", "BITBUCKET_COMMIT", "GITHUB_HEAD_REF", "CI_COMMIT_REF_NAME", "BITBUCKET_BRANCH", "CI_COMMIT_TAG", "BITBUCKET_TAG"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_REPOSITORY", "acme/app")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_SHA", "abc123")
+	t.Setenv("GITHUB_REF_NAME", "main")
+	t.Setenv("DD_GIT_BRANCH", "feature")
+
+	tags := datadogTags()
+	want := map[string]string{
+		"git.repository_url": "https://github.com/acme/app.git",
+		"git.commit.sha":     "abc123",
+		"git.branch":         "feature",
+		"ci.provider.name":   "github",
+		"ci.pipeline.url":    "https://github.com/acme/app/actions/runs/42",
+		"env":                "ci",
+		"language":           "go",
+	}
+	for name, value := range want {
+		if tags[name] != value {
+			t.Errorf("%s: got %q, want %q", name, tags[name], value)
+		}
+	}
+	if _, ok := tags["git.tag"]; ok {
+		t.Errorf("git.tag: got %q, want it left out", tags["git.tag"])
+	}
+}
+
+func TestSendDatadog(t *testing.T) {
+	var payloads []datadogPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/citestcycle" || r.Header.Get("DD-API-KEY") != "key" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var payload datadogPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("decoding payload: %v", err)
+		}
+		payloads = append(payloads, payload)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	t.Setenv("DD_CIVISIBILITY_AGENTLESS_URL", server.URL)
+	t.Setenv("DD_SERVICE", "svc")
+
+	data := &ReportData{Results: map[string]*TestResult{}}
+	for i := 0; i < datadogBatchSize; i++ {
+		name := fmt.Sprintf("Test%04d", i)
+		data.Results[name] = &TestResult{Name: name, Package: "example.com/a", Status: "PASS"}
+	}
+	if err := sendDatadog(data, "key", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	events := 0
+	for _, payload := range payloads {
+		events += len(payload.Events)
+		if payload.Metadata["*"]["language"] != "go" {
+			t.Errorf("metadata: got %v, want the common tags", payload.Metadata)
+		}
+	}
+	if len(payloads) != 2 || events != len(data.Results)+3 {
+		t.Errorf("got %d events in %d requests, want %d in 2", events, len(payloads), len(data.Results)+3)
+	}
+
+	if err := sendDatadog(data, "wrong", time.Now()); err == nil || !strings.Contains(err.Error(), "forbidden") {
+		t.Errorf("got error %v, want the response body in it", err)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// dbSchema is the schema of the -db results database, documented in the
+// README. user_version is bumped when it changes incompatibly.
+const dbSchema = `PRAGMA user_version = 1;
+PRAGMA foreign_keys = ON;
+CREATE TABLE IF NOT EXISTS runs (
+  id         TEXT PRIMARY KEY, -- -db-run, or the time of the run
+  timestamp  TEXT NOT NULL,    -- RFC 3339, UTC
+  commit_sha TEXT,
+  sanitizer  TEXT,
+  total      INTEGER NOT NULL,
+  passed     INTEGER NOT NULL,
+  failed     INTEGER NOT NULL,
+  skipped    INTEGER NOT NULL,
+  flaky      INTEGER NOT NULL,
+  duration   REAL NOT NULL     -- seconds
+);
+CREATE TABLE IF NOT EXISTS packages (
+  run_id   TEXT NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+  package  TEXT NOT NULL,
+  tests    INTEGER NOT NULL, -- top-level tests
+  failed   INTEGER NOT NULL,
+  duration REAL NOT NULL,
+  PRIMARY KEY (run_id, package)
+);
+CREATE TABLE IF NOT EXISTS tests (
+  run_id      TEXT NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+  package     TEXT NOT NULL,
+  name        TEXT NOT NULL,
+  status      TEXT NOT NULL,    -- PASS, FAIL, SKIP or FLAKY
+  duration    REAL NOT NULL,
+  attempts    INTEGER NOT NULL, -- 1, or more when the test was re-run
+  quarantined INTEGER NOT NULL,
+  skip_reason TEXT,
+  output      TEXT,             -- failed tests only
+  PRIMARY KEY (run_id, package, name)
+);
+CREATE INDEX IF NOT EXISTS tests_by_name ON tests (package, name);
+`
+
+// dbSQL returns the statements recording data as the run id in a single
+// transaction. A run recorded again replaces its packages and tests, so
+// results a re-run no longer has don't linger.
+func dbSQL(data *ReportData, id, commit string, now time.Time) string {
+	var sb strings.Builder
+	sb.WriteString(dbSchema)
+	sb.WriteString("BEGIN;\n")
+	fmt.Fprintf(&sb, `INSERT INTO runs (id, timestamp, commit_sha, sanitizer, total, passed, failed, skipped, flaky, duration)
+VALUES (%s, %s, %s, %s, %d, %d, %d, %d, %d, %g)
+ON CONFLICT (id) DO UPDATE SET timestamp = excluded.timestamp, commit_sha = excluded.commit_sha,
+  sanitizer = excluded.sanitizer, total = excluded.total, passed = excluded.passed, failed = excluded.failed,
+  skipped = excluded.skipped, flaky = excluded.flaky, duration = excluded.duration;
+`, sqlString(id), sqlString(now.UTC().Format(time.RFC3339)), sqlNullString(commit), sqlNullString(data.Sanitizer),
+		data.TotalTests, data.PassedTests, data.FailedTests, data.SkippedTests, data.FlakyTests, data.TotalDuration)
+	fmt.Fprintf(&sb, "DELETE FROM packages WHERE run_id = %s;\n", sqlString(id))
+	fmt.Fprintf(&sb, "DELETE FROM tests WHERE run_id = %s;\n", sqlString(id))
+
+	packages := report.PackageDurations(data)
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Package < packages[j].Package })
+	for _, p := range packages {
+		fmt.Fprintf(&sb, "INSERT INTO packages VALUES (%s, %s, %d, %d, %g);\n", sqlString(id), sqlString(p.Package), p.Tests, p.Failed, p.Duration)
+	}
+
+	names := make([]string, 0, len(data.Results))
+	for name := range data.Results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result := data.Results[name]
+		output := ""
+		if result.Status == "FAIL" {
+			output = joinOutput(result.Output)
+		}
+		quarantined := 0
+		if result.Quarantined {
+			quarantined = 1
+		}
+		fmt.Fprintf(&sb, "INSERT INTO tests VALUES (%s, %s, %s, %s, %g, %d, %d, %s, %s);\n",
+			sqlString(id), sqlString(result.Package), sqlString(result.Name), sqlString(result.Status), result.Duration,
+			len(result.Attempts)+1, quarantined, sqlNullString(result.SkipReason), sqlNullString(output))
+	}
+	sb.WriteString("COMMIT;\n")
+	return sb.String()
+}
+
+// sqlString quotes s as an SQL string literal. SQLite strings can't hold
+// NUL, which test output has no business containing anyway.
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, "\x00", ""), "'", "''") + "'"
+}
+
+// sqlNullString is sqlString with the empty string stored as NULL
+func sqlNullString(s string) string {
+	if s == "" {
+		return "NULL"
+	}
+	return sqlString(s)
+}
+
+// saveResultsDB records data in the SQLite database at path, creating it
+// when missing, by piping the statements of dbSQL to the sqlite3 shell
+func saveResultsDB(path string, data *ReportData, id, commit string, now time.Time) error {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return fmt.Errorf("-db needs the sqlite3 tool on PATH")
+	}
+	if id == "" {
+		id = now.UTC().Format(time.RFC3339Nano)
+	}
+	cmd := exec.Command("sqlite3", "-bail", path)
+	cmd.Stdin = strings.NewReader(dbSQL(data, id, commit, now))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error writing %s with sqlite3: %v\n%s", path, err, out)
+	}
+	logger.Info("recorded run in results database", "file", path, "run", id)
+	return nil
+}
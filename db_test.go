@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSQLString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "'plain'"},
+		{"it's", "'it''s'"},
+		{"nul\x00byte", "'nulbyte'"},
+		{"", "''"},
+	}
+	for _, tt := range tests {
+		if got := sqlString(tt.in); got != tt.want {
+			t.Errorf("sqlString(%q): got %s, want %s", tt.in, got, tt.want)
+		}
+	}
+	if got := sqlNullString(""); got != "NULL" {
+		t.Errorf("sqlNullString(\"\"): got %s, want NULL", got)
+	}
+}
+
+func dbTestData(status string) *ReportData {
+	data := &ReportData{
+		TotalTests:    2,
+		PassedTests:   1,
+		TotalDuration: 1.5,
+		Results: map[string]*TestResult{
+			"TestOK":    {Name: "TestOK", Package: "example.com/a", Status: "PASS", Duration: 0.5},
+			"TestMaybe": {Name: "TestMaybe", Package: "example.com/b", Status: status, Duration: 1, Output: []string{"    b_test.go:9: it's broken\n"}},
+		},
+	}
+	if status == "FAIL" {
+		data.FailedTests = 1
+	} else {
+		data.PassedTests = 2
+	}
+	return data
+}
+
+func TestSaveResultsDB(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	path := filepath.Join(t.TempDir(), "results.sqlite")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	query := func(sql string) string {
+		out, err := exec.Command("sqlite3", path, sql).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", sql, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	if err := saveResultsDB(path, dbTestData("FAIL"), "run-1", "abc123", now); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := query("SELECT id, timestamp, commit_sha, total, passed, failed FROM runs"), "run-1|2024-05-01T12:00:00Z|abc123|2|1|1"; got != want {
+		t.Errorf("runs: got %q, want %q", got, want)
+	}
+	if got, want := query("SELECT package, tests, failed FROM packages ORDER BY package"), "example.com/a|1|0\nexample.com/b|1|1"; got != want {
+		t.Errorf("packages: got %q, want %q", got, want)
+	}
+	if got, want := query("SELECT name, status, output FROM tests WHERE status = 'FAIL'"), "TestMaybe|FAIL|    b_test.go:9: it's broken"; got != want {
+		t.Errorf("failed tests: got %q, want %q", got, want)
+	}
+
+	// Recording the run again replaces it; another run is added
+	if err := saveResultsDB(path, dbTestData("PASS"), "run-1", "abc123", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveResultsDB(path, dbTestData("PASS"), "run-2", "def456", now); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := query("SELECT id, failed FROM runs ORDER BY id"), "run-1|0\nrun-2|0"; got != want {
+		t.Errorf("runs after upsert: got %q, want %q", got, want)
+	}
+	if got, want := query("SELECT count(*) FROM tests WHERE status = 'FAIL' OR output IS NOT NULL"), "0"; got != want {
+		t.Errorf("failed tests after upsert: got %s, want %s", got, want)
+	}
+	if got, want := query("SELECT count(*) FROM tests"), "4"; got != want {
+		t.Errorf("tests: got %s, want %s", got, want)
+	}
+}
+
+func TestSaveResultsDBMissingTool(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	err := saveResultsDB(filepath.Join(t.TempDir(), "results.sqlite"), dbTestData("PASS"), "", "", time.Now())
+	if err == nil || !strings.Contains(err.Error(), "sqlite3") {
+		t.Errorf("got error %v, want one naming sqlite3", err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// diffCommand implements `gotest-report diff [flags] old.json new.json`,
+// which compares the tests of two runs without rendering either report
+func diffCommand(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	outputFile := fs.String("output", "-", "Output file (use - for stdout)")
+	format := fs.String("format", "markdown", "Comparison format: markdown or json")
+	failOnRegression := fs.Bool("fail-on-regression", false, "Exit with 1 when the new run has failures the old one didn't")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: gotest-report diff [flags] old.json new.json\n\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "markdown" && *format != "json" {
+		logger.Error(fmt.Sprintf("unknown diff format %q (want markdown or json)", *format))
+		return 1
+	}
+
+	opts := report.ParseOptions{SpoolThreshold: report.DefaultSpoolThreshold}
+	old, err := loadInput(inputSpec{path: fs.Arg(0)}, opts)
+	if err != nil {
+		logger.Error("processing input", "error", err)
+		return 1
+	}
+	new, err := loadInput(inputSpec{path: fs.Arg(1)}, opts)
+	if err != nil {
+		logger.Error("processing input", "error", err)
+		return 1
+	}
+
+	diff := report.Diff(old, new)
+	content := diff.Markdown()
+	if *format == "json" {
+		encoded, err := diff.JSON()
+		if err != nil {
+			logger.Error("rendering comparison", "error", err)
+			return 1
+		}
+		content = string(encoded) + "\n"
+	}
+	if err := writeReport(*outputFile, content); err != nil {
+		logger.Error("writing report", "error", err)
+		return 1
+	}
+	if *outputFile != "-" {
+		fmt.Printf("Comparison generated successfully: %s\n", *outputFile)
+	}
+
+	if *failOnRegression && diff.HasRegressions() {
+		logger.Error("new failures since the old run", "failures", len(diff.NewFailures), "old", fs.Arg(0))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffCommand(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, status string) string {
+		path := filepath.Join(dir, name)
+		content := `{"Action":"run","Package":"example.com/a","Test":"TestA"}` + "\n" +
+			`{"Action":"` + status + `","Package":"example.com/a","Test":"TestA"}` + "\n"
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	oldPath, newPath := write("old.json", "pass"), write("new.json", "fail")
+	output := filepath.Join(dir, "diff.md")
+
+	if code := diffCommand([]string{"-output", output, oldPath, newPath}); code != 0 {
+		t.Fatalf("exit code: got %d, want 0", code)
+	}
+	markdown, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(markdown), "### 🆕 New failures (1)\n\n- `TestA` (example.com/a)\n") {
+		t.Errorf("comparison missing the new failure:\n%s", markdown)
+	}
+
+	if code := diffCommand([]string{"-output", output, "-fail-on-regression", oldPath, newPath}); code != 1 {
+		t.Errorf("exit code with -fail-on-regression: got %d, want 1", code)
+	}
+	if code := diffCommand([]string{"-output", output, "-fail-on-regression", newPath, oldPath}); code != 0 {
+		t.Errorf("exit code without regressions: got %d, want 0", code)
+	}
+	if code := diffCommand([]string{oldPath}); code != 2 {
+		t.Errorf("exit code with one file: got %d, want 2", code)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// githubClient calls the GitHub REST API of one repository
+type githubClient struct {
+	api   string // e.g. https://api.github.com
+	repo  string // owner/name
+	token string
+	http  *http.Client
+}
+
+// githubFromEnv configures the client from GitHub Actions' environment and
+// GITHUB_TOKEN, for the command-line option that needs it
+func githubFromEnv(option string) (*githubClient, error) {
+	client := &githubClient{
+		api:   strings.TrimSuffix(os.Getenv("GITHUB_API_URL"), "/"),
+		repo:  os.Getenv("GITHUB_REPOSITORY"),
+		token: os.Getenv("GITHUB_TOKEN"),
+		http:  &http.Client{Timeout: 30 * time.Second},
+	}
+	if client.api == "" {
+		client.api = "https://api.github.com"
+	}
+	if client.repo == "" || client.token == "" {
+		return nil, fmt.Errorf("%s needs GITHUB_REPOSITORY and GITHUB_TOKEN", option)
+	}
+	return client, nil
+}
+
+// do sends a request with a JSON body and decodes the JSON response into out
+func (c *githubClient) do(method, path string, in, out any) error {
+	_, err := doJSON(c.http, method, c.api+path, in, out, func(req *http.Request) {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	})
+	return err
+}
+
+// githubHeadSHA returns the commit to report on: on pull_request events the
+// head of the pull request, as GITHUB_SHA is then a merge commit branch
+// protection doesn't look at, and GITHUB_SHA otherwise
+func githubHeadSHA() string {
+	if _, sha := githubPullRequest(); sha != "" {
+		return sha
+	}
+	return os.Getenv("GITHUB_SHA")
+}
+
+// githubPullRequest returns the number and head commit of the pull request
+// the workflow runs for, read from the event in GITHUB_EVENT_PATH; 0 and ""
+// for other events
+func githubPullRequest() (int, string) {
+	path := os.Getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return 0, ""
+	}
+	var event struct {
+		PullRequest struct {
+			Number int `json:"number"`
+			Head   struct {
+				SHA string `json:"sha"`
+			} `json:"head"`
+		} `json:"pull_request"`
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(content, &event) != nil {
+		return 0, ""
+	}
+	return event.PullRequest.Number, event.PullRequest.Head.SHA
+}
+
+// setCommitStatus sets the commit status named context on sha: failure
+// when tests or the quality gate failed and success otherwise, with the
+// pass rate as its description and target as its link
+func (c *githubClient) setCommitStatus(sha, context string, data *ReportData, target string) error {
+	if sha == "" {
+		return fmt.Errorf("no commit to set the status of: GITHUB_SHA is not set")
+	}
+	state := "success"
+	if data.FailedTests > 0 || data.QualityGate != nil && !data.QualityGate.Passed {
+		state = "failure"
+	}
+	description := "No tests ran"
+	if data.TotalTests > 0 {
+		description = fmt.Sprintf("%d of %d tests passed (%.1f%%)", data.PassedTests, data.TotalTests, float64(data.PassedTests)/float64(data.TotalTests)*100)
+	}
+	if data.FailedTests > 0 {
+		description += fmt.Sprintf(", %d failed", data.FailedTests)
+	}
+	if data.QualityGate != nil && !data.QualityGate.Passed {
+		description += ", quality gate failed"
+	}
+	status := map[string]string{"state": state, "context": context, "description": truncateRunes(description, 140)}
+	if target != "" {
+		status["target_url"] = target
+	}
+	if err := c.do(http.MethodPost, "/repos/"+c.repo+"/statuses/"+sha, status, nil); err != nil {
+		return err
+	}
+	logger.Info("set commit status", "context", context, "state", state, "commit", sha)
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestGithubHeadSHA(t *testing.T) {
+	t.Setenv("GITHUB_SHA", "merge")
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	if got := githubHeadSHA(); got != "merge" {
+		t.Errorf("without an event: got %q, want GITHUB_SHA", got)
+	}
+
+	event := filepath.Join(t.TempDir(), "event.json")
+	os.WriteFile(event, []byte(`{"pull_request": {"head": {"sha": "head"}}}`), 0o644)
+	t.Setenv("GITHUB_EVENT_PATH", event)
+	if got := githubHeadSHA(); got != "head" {
+		t.Errorf("on a pull request: got %q, want the head commit", got)
+	}
+
+	os.WriteFile(event, []byte(`{"ref": "refs/heads/main"}`), 0o644)
+	if got := githubHeadSHA(); got != "merge" {
+		t.Errorf("on a push: got %q, want GITHUB_SHA", got)
+	}
+}
+
+func TestSetCommitStatus(t *testing.T) {
+	var path string
+	var status map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		path = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		status = nil
+		json.Unmarshal(body, &status)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	client := &githubClient{api: server.URL, repo: "acme/app", token: "tok", http: server.Client()}
+
+	data := &ReportData{TotalTests: 315, PassedTests: 312, FailedTests: 3}
+	if err := client.setCommitStatus("abc", "tests", data, "https://example.com/report.html"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"state":       "failure",
+		"context":     "tests",
+		"description": "312 of 315 tests passed (99.0%), 3 failed",
+		"target_url":  "https://example.com/report.html",
+	}
+	if path != "/repos/acme/app/statuses/abc" {
+		t.Errorf("path: got %q", path)
+	}
+	for key, value := range want {
+		if status[key] != value {
+			t.Errorf("%s: got %q, want %q", key, status[key], value)
+		}
+	}
+
+	data = &ReportData{TotalTests: 2, PassedTests: 2, QualityGate: &report.GateResult{Passed: true}}
+	if err := client.setCommitStatus("abc", "tests", data, ""); err != nil {
+		t.Fatal(err)
+	}
+	if status["state"] != "success" || status["description"] != "2 of 2 tests passed (100.0%)" {
+		t.Errorf("passing run: got %v", status)
+	}
+	if _, ok := status["target_url"]; ok {
+		t.Errorf("without a link: got target_url %q", status["target_url"])
+	}
+
+	data.QualityGate.Passed = false
+	if err := client.setCommitStatus("abc", "tests", data, ""); err != nil {
+		t.Fatal(err)
+	}
+	if status["state"] != "failure" || status["description"] != "2 of 2 tests passed (100.0%), quality gate failed" {
+		t.Errorf("failed quality gate: got %v", status)
+	}
+
+	if err := client.setCommitStatus("", "tests", data, ""); err == nil {
+		t.Error("without a commit: got no error")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// gitlabJUnitFile is where -gitlab writes JUnit XML, the path the README's
+// artifacts:reports:junit example collects
+const gitlabJUnitFile = "junit.xml"
+
+// gitlabNoteMarker starts the merge request note, so the next pipeline
+// updates it instead of adding another
+const gitlabNoteMarker = "<!-- gotest-report -->"
+
+// publishGitLab does what -gitlab adds on GitLab CI: it writes the JUnit
+// artifact GitLab shows in merge requests and pipelines and, in merge
+// request pipelines, posts the Markdown report as a note on the merge
+// request. Posting needs a token with the api scope in GITLAB_TOKEN, as
+// CI_JOB_TOKEN can't write notes.
+func publishGitLab(data *ReportData, cfg *config) error {
+	junit, err := renderJUnit(data)
+	if err != nil {
+		return err
+	}
+	if err := writeReport(gitlabJUnitFile, junit); err != nil {
+		return fmt.Errorf("writing %s: %w", gitlabJUnitFile, err)
+	}
+	logger.Info("wrote JUnit report for GitLab", "file", gitlabJUnitFile)
+
+	iid := os.Getenv("CI_MERGE_REQUEST_IID")
+	if iid == "" {
+		return nil
+	}
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		logger.Warn("not posting a merge request note: GITLAB_TOKEN is not set")
+		return nil
+	}
+
+	noteCfg := *cfg
+	noteCfg.target = reportTargets["gitlab-note"]
+	body, err := renderReport(data, &noteCfg, "markdown")
+	if err != nil {
+		return err
+	}
+	client := &gitlabClient{
+		api:     strings.TrimSuffix(os.Getenv("CI_API_V4_URL"), "/"),
+		project: os.Getenv("CI_PROJECT_ID"),
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+	if client.api == "" || client.project == "" {
+		return fmt.Errorf("posting a merge request note needs CI_API_V4_URL and CI_PROJECT_ID")
+	}
+	return client.upsertNote(iid, gitlabNoteMarker+"\n"+body)
+}
+
+// gitlabClient calls the GitLab REST API of one project
+type gitlabClient struct {
+	api     string // e.g. https://gitlab.com/api/v4
+	project string // numeric id or URL-encoded path
+	token   string
+	http    *http.Client
+}
+
+type gitlabNote struct {
+	ID   int    `json:"id"`
+	Body string `json:"body"`
+}
+
+// upsertNote updates the merge request note starting with gitlabNoteMarker,
+// or adds one when there is none yet
+func (c *gitlabClient) upsertNote(iid, body string) error {
+	notes := fmt.Sprintf("%s/projects/%s/merge_requests/%s/notes", c.api, url.PathEscape(c.project), url.PathEscape(iid))
+	for page := 1; ; page++ {
+		var existing []gitlabNote
+		next, err := c.do(http.MethodGet, fmt.Sprintf("%s?per_page=100&page=%d", notes, page), nil, &existing)
+		if err != nil {
+			return fmt.Errorf("listing merge request notes: %w", err)
+		}
+		for _, note := range existing {
+			if strings.HasPrefix(note.Body, gitlabNoteMarker) {
+				if _, err := c.do(http.MethodPut, fmt.Sprintf("%s/%d", notes, note.ID), map[string]string{"body": body}, nil); err != nil {
+					return fmt.Errorf("updating merge request note: %w", err)
+				}
+				logger.Info("updated merge request note", "mergeRequest", iid, "note", note.ID)
+				return nil
+			}
+		}
+		if next == "" {
+			break
+		}
+	}
+
+	var created gitlabNote
+	if _, err := c.do(http.MethodPost, notes, map[string]string{"body": body}, &created); err != nil {
+		return fmt.Errorf("adding merge request note: %w", err)
+	}
+	logger.Info("added merge request note", "mergeRequest", iid, "note", created.ID)
+	return nil
+}
+
+// do sends a request with a JSON body and decodes the JSON response into
+// out, returning the X-Next-Page header GitLab paginates lists with
+func (c *gitlabClient) do(method, endpoint string, in, out any) (string, error) {
+	header, err := doJSON(c.http, method, endpoint, in, out, func(req *http.Request) {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	})
+	if err != nil {
+		return "", err
+	}
+	return header.Get("X-Next-Page"), nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPublishGitLab(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []gitlabNote
+		want     string // method and path of the write
+	}{
+		{name: "first pipeline", existing: []gitlabNote{{ID: 1, Body: "LGTM"}}, want: "POST /api/v4/projects/group%2Fapp/merge_requests/7/notes"},
+		{name: "later pipeline", existing: []gitlabNote{{ID: 1, Body: "LGTM"}, {ID: 42, Body: gitlabNoteMarker + "\nold"}}, want: "PUT /api/v4/projects/group%2Fapp/merge_requests/7/notes/42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var writes []string
+			var body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+					http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+					return
+				}
+				if r.Method == http.MethodGet {
+					json.NewEncoder(w).Encode(tt.existing)
+					return
+				}
+				writes = append(writes, r.Method+" "+r.URL.EscapedPath())
+				var note gitlabNote
+				json.NewDecoder(r.Body).Decode(&note)
+				body = note.Body
+				json.NewEncoder(w).Encode(gitlabNote{ID: 43})
+			}))
+			defer server.Close()
+
+			chdir(t, t.TempDir())
+			t.Setenv("CI_MERGE_REQUEST_IID", "7")
+			t.Setenv("CI_API_V4_URL", server.URL+"/api/v4")
+			t.Setenv("CI_PROJECT_ID", "group/app")
+			t.Setenv("GITLAB_TOKEN", "secret")
+
+			data := &ReportData{TotalTests: 1, FailedTests: 1, Results: map[string]*TestResult{
+				"TestA": {Name: "TestA", Package: "example.com/a", Status: "FAIL"},
+			}}
+			if err := publishGitLab(data, defaultConfig()); err != nil {
+				t.Fatal(err)
+			}
+			if len(writes) != 1 || writes[0] != tt.want {
+				t.Errorf("writes: got %v, want [%s]", writes, tt.want)
+			}
+			if !strings.HasPrefix(body, gitlabNoteMarker+"\n") || !strings.Contains(body, "TestA") {
+				t.Errorf("note body:\n%s", body)
+			}
+			junit, err := os.ReadFile(filepath.Join(".", gitlabJUnitFile))
+			if err != nil || !strings.Contains(string(junit), `<testcase name="TestA"`) {
+				t.Errorf("%s: got %v\n%s", gitlabJUnitFile, err, junit)
+			}
+		})
+	}
+}
+
+func TestPublishGitLabWithoutMergeRequest(t *testing.T) {
+	chdir(t, t.TempDir())
+	t.Setenv("CI_MERGE_REQUEST_IID", "")
+	t.Setenv("GITLAB_TOKEN", "secret")
+	// Nothing listens here; posting would fail
+	t.Setenv("CI_API_V4_URL", "http://127.0.0.1:1/api/v4")
+	t.Setenv("CI_PROJECT_ID", "1")
+
+	if err := publishGitLab(&ReportData{Results: map[string]*TestResult{}}, defaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(gitlabJUnitFile); err != nil {
+		t.Errorf("JUnit file not written: %v", err)
+	}
+}
+
+// chdir changes the working directory for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
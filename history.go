@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// historyCommand implements `gotest-report history [flags]`, which summarizes
+// the runs recorded in a -history file
+func historyCommand(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	historyFile := fs.String("history", "", "History file written by report -history")
+	outputFile := fs.String("output", "-", "Output file (use - for stdout)")
+	format := fs.String("format", "markdown", "Summary format: markdown or json")
+	last := fs.Int("last", 20, "Number of most recent runs to summarize (0 for all)")
+	if err := parseFlags(fs, args); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	if *historyFile == "" {
+		logger.Error("history needs a -history file")
+		return 1
+	}
+	if *format != "markdown" && *format != "json" {
+		logger.Error(fmt.Sprintf("unknown history format %q (want markdown or json)", *format))
+		return 1
+	}
+	history, err := report.LoadHistory(*historyFile)
+	if err != nil {
+		logger.Error("loading history", "error", err)
+		return 1
+	}
+	if *last > 0 && len(history.Runs) > *last {
+		history.Runs = history.Runs[len(history.Runs)-*last:]
+	}
+
+	content := renderHistory(history)
+	if *format == "json" {
+		encoded, err := json.MarshalIndent(history, "", "  ")
+		if err != nil {
+			logger.Error("rendering history", "error", err)
+			return 1
+		}
+		content = string(encoded) + "\n"
+	}
+	if err := writeReport(*outputFile, content); err != nil {
+		logger.Error("writing report", "error", err)
+		return 1
+	}
+	if *outputFile != "-" {
+		fmt.Printf("History summary generated successfully: %s\n", *outputFile)
+	}
+	return 0
+}
+
+// recordHistory records the inputs of a report in history as one run per
+// sanitizer, so inputs split with -suite-name, -label or -package count as
+// the single run they are, while sanitizer runs stay comparable to their own
+func recordHistory(history *report.History, runs []*ReportData, timestamp time.Time, commit string, maxRuns int) {
+	var sanitizers []string
+	bySanitizer := make(map[string][]*ReportData)
+	for _, run := range runs {
+		if _, ok := bySanitizer[run.Sanitizer]; !ok {
+			sanitizers = append(sanitizers, run.Sanitizer)
+		}
+		bySanitizer[run.Sanitizer] = append(bySanitizer[run.Sanitizer], run)
+	}
+	for _, sanitizer := range sanitizers {
+		group := bySanitizer[sanitizer]
+		data := group[0]
+		if len(group) > 1 {
+			data = report.Merge(group, nil)
+		}
+		history.Record(data, timestamp, commit, maxRuns)
+	}
+}
+
+// historyFailures is how often a test failed in the recorded runs
+type historyFailures struct {
+	name     string
+	failures int
+	runs     int
+}
+
+// renderHistory renders the recorded runs, newest first, and the tests that
+// failed most often in them
+func renderHistory(history *report.History) string {
+	var sb strings.Builder
+	sb.WriteString("# 📜 Test History\n\n")
+	if len(history.Runs) == 0 {
+		sb.WriteString("> No runs recorded yet.\n")
+		return sb.String()
+	}
+
+	sb.WriteString("| Run | Commit | Total | Passed | Failed | Skipped | Flaky | Duration |\n")
+	sb.WriteString("| --- | ------ | ----- | ------ | ------ | ------- | ----- | -------- |\n")
+	for i := len(history.Runs) - 1; i >= 0; i-- {
+		run := history.Runs[i]
+		commit := shortCommit(run.Commit)
+		label := run.Timestamp.Format("2006-01-02 15:04")
+		if run.Sanitizer != "" {
+			label += fmt.Sprintf(" (%s)", run.Sanitizer)
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %d | %d | %d | %d | %d | %.2fs |\n",
+			label, commit, run.Total, run.Passed, run.Failed, run.Skipped, run.Flaky, run.Duration))
+	}
+	sb.WriteString("\n")
+
+	counts := make(map[string]*historyFailures)
+	for _, run := range history.Runs {
+		for name, status := range run.Tests {
+			c := counts[name]
+			if c == nil {
+				c = &historyFailures{name: name}
+				counts[name] = c
+			}
+			c.runs++
+			if status == "FAIL" {
+				c.failures++
+			}
+		}
+	}
+	var failing []*historyFailures
+	for _, c := range counts {
+		if c.failures > 0 {
+			failing = append(failing, c)
+		}
+	}
+	sort.Slice(failing, func(i, j int) bool {
+		if failing[i].failures != failing[j].failures {
+			return failing[i].failures > failing[j].failures
+		}
+		return failing[i].name < failing[j].name
+	})
+
+	sb.WriteString("## ❌ Most Frequent Failures\n\n")
+	if len(failing) == 0 {
+		sb.WriteString("> No test failed in these runs.\n")
+		return sb.String()
+	}
+	sb.WriteString("| Test | Failed | Of Runs |\n")
+	sb.WriteString("| ---- | ------ | ------- |\n")
+	for i, c := range failing {
+		if i == 10 {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("| `%s` | %d | %d |\n", c.name, c.failures, c.runs))
+	}
+	return sb.String()
+}
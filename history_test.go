@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestRenderHistory(t *testing.T) {
+	history := &report.History{Runs: []report.HistoryRun{
+		{Timestamp: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Commit: "0123456789abcdef", Total: 2, Passed: 1, Failed: 1, Duration: 1.5,
+			Tests: map[string]string{"TestA": "FAIL", "TestB": "PASS"}},
+		{Timestamp: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), Sanitizer: "race", Total: 2, Failed: 2, Duration: 3,
+			Tests: map[string]string{"TestA": "FAIL", "TestB": "FAIL"}},
+	}}
+
+	markdown := renderHistory(history)
+	for _, want := range []string{
+		"| 2024-01-02 10:00 (race) |  | 2 | 0 | 2 | 0 | 0 | 3.00s |\n" +
+			"| 2024-01-01 10:00 | 0123456 | 2 | 1 | 1 | 0 | 0 | 1.50s |\n",
+		"| `TestA` | 2 | 2 |\n| `TestB` | 1 | 2 |\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("history missing %q:\n%s", want, markdown)
+		}
+	}
+
+	if empty := renderHistory(&report.History{}); !strings.Contains(empty, "No runs recorded yet") {
+		t.Errorf("empty history:\n%s", empty)
+	}
+}
+
+func TestRecordHistory(t *testing.T) {
+	run := func(sanitizer string, results ...*TestResult) *ReportData {
+		data := &ReportData{Sanitizer: sanitizer, Results: map[string]*TestResult{}}
+		for _, result := range results {
+			data.Results[result.Name] = result
+			data.TotalTests++
+		}
+		return data
+	}
+	runs := []*ReportData{
+		run("", &TestResult{Name: "TestA", Package: "example.com/a", Status: "PASS"}),
+		run("", &TestResult{Name: "TestB", Package: "example.com/b", Status: "FAIL"}),
+		run("race", &TestResult{Name: "TestA", Package: "example.com/a", Status: "FAIL"}),
+	}
+	history := &report.History{}
+	recordHistory(history, runs, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "abc", 10)
+
+	if len(history.Runs) != 2 {
+		t.Fatalf("got %d runs, want one per sanitizer: %+v", len(history.Runs), history.Runs)
+	}
+	if plain := history.Runs[0]; plain.Sanitizer != "" || plain.Total != 2 || plain.Tests["TestA"] != "PASS" || plain.Tests["TestB"] != "FAIL" {
+		t.Errorf("merged inputs: got %+v", plain)
+	}
+	if race := history.Runs[1]; race.Sanitizer != "race" || race.Total != 1 || race.Tests["TestA"] != "FAIL" {
+		t.Errorf("race run: got %+v", race)
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// searchIndex lets the HTML report search the output of every test in the
+// browser. Tokens map to the tests whose output contains them, so a query only
+// scans the output of candidate tests for the exact phrase.
+type searchIndex struct {
+	Tests  []indexedTest    `json:"tests"`
+	Tokens map[string][]int `json:"tokens"`
+}
+
+type indexedTest struct {
+	Name    string   `json:"n"`
+	Package string   `json:"p"`
+	Status  string   `json:"s"`
+	Output  []string `json:"o"`
+}
+
+// buildSearchIndex indexes the output of all tests, including subtests
+func buildSearchIndex(data *ReportData, cfg *config) searchIndex {
+	names := make([]string, 0, len(data.Results))
+	for name := range data.Results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	index := searchIndex{Tests: []indexedTest{}, Tokens: make(map[string][]int)}
+	for _, name := range names {
+		result := data.Results[name]
+		if len(result.Output) == 0 {
+			continue
+		}
+
+		id := len(index.Tests)
+		index.Tests = append(index.Tests, indexedTest{
+			Name:    name,
+			Package: cfg.packageName(result.Package),
+			Status:  result.Status,
+			Output:  result.Output,
+		})
+
+		seen := make(map[string]bool)
+		for _, line := range result.Output {
+			for _, token := range searchTokens(line) {
+				if !seen[token] {
+					seen[token] = true
+					index.Tokens[token] = append(index.Tokens[token], id)
+				}
+			}
+		}
+	}
+	return index
+}
+
+// searchTokens splits text into lowercase words of 2 to 40 characters. The
+// browser tokenizes queries the same way.
+func searchTokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	tokens := words[:0]
+	for _, word := range words {
+		if n := utf8.RuneCountInString(word); n >= 2 && n <= 40 {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// htmlTest is one row of the HTML results table
+type htmlTest struct {
+	Name        string
+	Package     string
+	Status      string
+	Duration    float64
+	IsSubTest   bool
+	Output      []string
+	Description string
+	SameFailure []string // subtests of the same test that failed the same way
+}
+
+// renderHTMLReport renders a self-contained HTML page with the results, the
+// failure output and a search over all captured output
+func renderHTMLReport(data *ReportData, cfg *config) (string, error) {
+	var tests, failures []htmlTest
+	// Subtests failing the same way share one failure, shown with the
+	// output of whichever of them comes first
+	sameFailure := make(map[string]string)
+	for _, name := range data.SortedTestNames {
+		for _, bucket := range report.SubTestFailures(data, name) {
+			if len(bucket) > 1 {
+				for _, subTest := range bucket {
+					sameFailure[subTest] = bucket[0]
+				}
+			}
+		}
+	}
+	failureOf := make(map[string]int)
+
+	var add func(name string)
+	add = func(name string) {
+		result := data.Results[name]
+		test := htmlTest{
+			Name:        name,
+			Package:     cfg.packageName(result.Package),
+			Status:      result.Status,
+			Duration:    result.Duration,
+			IsSubTest:   result.IsSubTest,
+			Output:      result.Output,
+			Description: cfg.sourceIndex.description(result.Package, name),
+		}
+		tests = append(tests, test)
+		if i, ok := failureOf[sameFailure[name]]; ok {
+			failures[i].SameFailure = append(failures[i].SameFailure, name)
+		} else if result.Status == "FAIL" && len(result.Output) > 0 {
+			if key, ok := sameFailure[name]; ok {
+				failureOf[key] = len(failures)
+			}
+			failures = append(failures, test)
+		}
+
+		subTests := append([]string(nil), result.SubTests...)
+		sort.Strings(subTests)
+		for _, subTest := range subTests {
+			add(subTest)
+		}
+	}
+	for _, name := range data.SortedTestNames {
+		add(name)
+	}
+
+	var sb strings.Builder
+	err := htmlReportTemplate.Execute(&sb, map[string]any{
+		"Data":      data,
+		"Tests":     tests,
+		"Failures":  failures,
+		"Index":     buildSearchIndex(data, cfg),
+		"Generated": time.Now().Format("2006-01-02 15:04:05 MST"),
+	})
+	if err != nil {
+		return "", err
+	}
+	// html/template drops comments, so the trailer goes in afterwards
+	page := sb.String()
+	if i := strings.LastIndex(page, "</body>"); i >= 0 {
+		page = page[:i] + integrityComment(data.Integrity) + page[i:]
+	}
+	return page, nil
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Test Summary Report</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 1100px; color: #1f2328; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .35rem .6rem; border-bottom: 1px solid #d0d7de; }
+pre { background: #f6f8fa; padding: .75rem; overflow-x: auto; font-size: .85rem; }
+.summary span { display: inline-block; margin-right: 1.5rem; }
+.pass { color: #1a7f37; } .fail { color: #cf222e; } .skip { color: #9a6700; } .flaky { color: #8250df; }
+.sub td:first-child { padding-left: 2rem; }
+#search { width: 100%; padding: .5rem; font-size: 1rem; box-sizing: border-box; }
+#results li { margin: .4rem 0; }
+#results code { display: block; white-space: pre-wrap; color: #57606a; }
+mark { background: #fff8c5; }
+.filters { display: flex; gap: .5rem; margin-bottom: .5rem; }
+.filters input { flex: 1; padding: .4rem; font-size: .95rem; }
+th[data-sort] { cursor: pointer; user-select: none; }
+@media print { .no-print { display: none; } }
+th[aria-sort="ascending"]::after { content: " ▲"; } th[aria-sort="descending"]::after { content: " ▼"; }
+.gate { border: 2px solid #cf222e; background: #ffebe9; padding: .5rem 1rem; margin-bottom: 1rem; }
+</style>
+</head>
+<body>
+<h1>🧪 Test Summary Report</h1>
+{{- with .Data.QualityGate}}{{if not .Passed}}
+<div class="gate">
+<strong>🚫 Quality gate failed</strong>
+<ul>
+{{- range .Violations}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+</div>
+{{- end}}{{end}}
+<p class="summary">
+<span>🧪 Total: {{.Data.TotalTests}}</span>
+<span class="pass">✅ Passed: {{.Data.PassedTests}}</span>
+<span class="fail">❌ Failed: {{.Data.FailedTests}}</span>
+<span class="skip">⏭️ Skipped: {{.Data.SkippedTests}}</span>
+{{- if .Data.FlakyTests}}
+<span class="flaky">🔁 Flaky: {{.Data.FlakyTests}}</span>
+{{- end}}
+<span>⏱️ {{printf "%.2f" .Data.TotalDuration}}s</span>
+</p>
+{{- with .Data.Environment}}
+
+<h2>🖥️ Environment</h2>
+<table>
+<tr><td>Go</td><td>{{.GoVersion}}</td></tr>
+<tr><td>Platform</td><td>{{.GOOS}}/{{.GOARCH}}</td></tr>
+<tr><td>CPUs</td><td>{{.NumCPU}}</td></tr>
+{{- range $name, $value := .CI}}
+<tr><td><code>{{$name}}</code></td><td>{{$value}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+<div class="no-print">
+<h2>🔎 Search Output</h2>
+<input id="search" type="search" placeholder="Find tests whose output contains…" autocomplete="off">
+<p id="search-status"></p>
+<ul id="results"></ul>
+</div>
+
+<h2>📝 Test Results</h2>
+<div class="filters no-print">
+<input id="filter" type="search" placeholder="Filter tests by name or package…" autocomplete="off">
+<select id="status-filter">
+<option value="">All statuses</option>
+<option value="FAIL">Failed</option>
+<option value="FLAKY">Flaky</option>
+<option value="SKIP">Skipped</option>
+<option value="PASS">Passed</option>
+</select>
+</div>
+<p id="filter-status" class="no-print"></p>
+<table id="tests">
+<thead><tr><th data-sort="name">Test</th><th data-sort="package">Package</th><th data-sort="status">Status</th><th data-sort="duration">Duration</th></tr></thead>
+<tbody>
+{{- range .Tests}}
+<tr{{if .IsSubTest}} class="sub"{{end}} data-status="{{.Status}}" data-duration="{{.Duration}}"><td{{with .Description}} title="{{.}}"{{end}}>{{.Name}}</td><td>{{.Package}}</td><td class="{{lower .Status}}">{{.Status}}</td><td>{{printf "%.3f" .Duration}}s</td></tr>
+{{- end}}
+</tbody>
+</table>
+
+{{- if .Failures}}
+<h2>🔴 Failed Tests Details</h2>
+{{- range .Failures}}
+<details open>
+<summary><strong>{{.Name}}</strong> {{.Package}}</summary>
+{{- with .SameFailure}}
+<p>Same failure in {{len .}} more subtests: {{join . ", "}}</p>
+{{- end}}
+<pre>{{join .Output "\n"}}</pre>
+</details>
+{{- end}}
+{{- end}}
+
+<p>📅 Report generated at: {{.Generated}}</p>
+
+<script>
+const index = {{.Index}};
+
+function tokenize(text) {
+  return text.toLowerCase().split(/[^\p{L}\p{N}_]+/u).filter(t => t.length >= 2 && t.length <= 40);
+}
+
+// Tests whose output has every query token; the last token may be a prefix
+// of a word so results update while typing
+function candidates(tokens) {
+  let ids = null;
+  tokens.forEach((token, i) => {
+    const matches = new Set();
+    if (i === tokens.length - 1) {
+      for (const key in index.tokens) {
+        if (key.startsWith(token)) index.tokens[key].forEach(id => matches.add(id));
+      }
+    } else {
+      (index.tokens[token] || []).forEach(id => matches.add(id));
+    }
+    ids = ids === null ? matches : new Set([...ids].filter(id => matches.has(id)));
+  });
+  return ids || new Set();
+}
+
+function search(query) {
+  const results = document.getElementById("results");
+  const status = document.getElementById("search-status");
+  results.replaceChildren();
+  const needle = query.trim().toLowerCase();
+  const tokens = tokenize(needle);
+  if (tokens.length === 0) {
+    status.textContent = "";
+    return;
+  }
+
+  let found = 0;
+  for (const id of [...candidates(tokens)].sort((a, b) => a - b)) {
+    const test = index.tests[id];
+    const line = test.o.find(l => l.toLowerCase().includes(needle));
+    if (line === undefined) continue;
+    found++;
+    if (found > 200) continue;
+
+    const item = document.createElement("li");
+    const name = document.createElement("strong");
+    name.textContent = test.n;
+    item.append(name, " " + test.s + " " + test.p);
+    const code = document.createElement("code");
+    const at = line.toLowerCase().indexOf(needle);
+    const mark = document.createElement("mark");
+    mark.textContent = line.slice(at, at + needle.length);
+    code.append(line.slice(0, at), mark, line.slice(at + needle.length));
+    item.append(code);
+    results.append(item);
+  }
+  status.textContent = found === 0 ? "No output matches." :
+    found > 200 ? found + " tests match, showing the first 200." : found + " tests match.";
+}
+
+document.getElementById("search").addEventListener("input", e => search(e.target.value));
+
+// Filtering and sorting of the results table. Rows keep their position in
+// the page order (subtests under their parent) until a column is sorted.
+const tbody = document.querySelector("#tests tbody");
+const rows = [...tbody.rows];
+rows.forEach((row, i) => row.dataset.order = i);
+
+function filterRows() {
+  const text = document.getElementById("filter").value.trim().toLowerCase();
+  const status = document.getElementById("status-filter").value;
+  let shown = 0;
+  for (const row of rows) {
+    const visible = (status === "" || row.dataset.status === status) &&
+      (text === "" || row.cells[0].textContent.toLowerCase().includes(text) ||
+        row.cells[1].textContent.toLowerCase().includes(text));
+    row.hidden = !visible;
+    if (visible) shown++;
+  }
+  document.getElementById("filter-status").textContent =
+    shown === rows.length ? "" : "Showing " + shown + " of " + rows.length + " tests.";
+}
+
+const statusRank = {FAIL: 0, FLAKY: 1, SKIP: 2, PASS: 3};
+const sortKeys = {
+  name: row => row.cells[0].textContent,
+  package: row => row.cells[1].textContent,
+  status: row => statusRank[row.dataset.status] ?? 4,
+  duration: row => parseFloat(row.dataset.duration),
+};
+
+// Clicking a column sorts ascending, then descending, then back to page order
+function sortRows(th) {
+  const next = {none: "ascending", ascending: "descending", descending: "none"}[th.getAttribute("aria-sort") || "none"];
+  document.querySelectorAll("#tests th").forEach(h => h.removeAttribute("aria-sort"));
+  let key = row => parseInt(row.dataset.order);
+  let dir = 1;
+  if (next !== "none") {
+    th.setAttribute("aria-sort", next);
+    key = sortKeys[th.dataset.sort];
+    dir = next === "ascending" ? 1 : -1;
+  }
+  const sorted = [...rows].sort((a, b) => {
+    const x = key(a), y = key(b);
+    const c = typeof x === "string" ? x.localeCompare(y) : x - y;
+    return dir * c || a.dataset.order - b.dataset.order;
+  });
+  tbody.append(...sorted);
+}
+
+document.getElementById("filter").addEventListener("input", filterRows);
+document.getElementById("status-filter").addEventListener("change", filterRows);
+document.querySelectorAll("#tests th[data-sort]").forEach(th => th.addEventListener("click", () => sortRows(th)));
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearchTokens(t *testing.T) {
+	got := strings.Join(searchTokens(`    db_test.go:42: dial tcp 10.0.0.1:5432: connect: Connection REFUSED (x)`), ",")
+	want := "db_test,go,42,dial,tcp,10,5432,connect,connection,refused"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestHTMLReport(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      2,
+		PassedTests:     1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestA", "TestB"},
+		Results: map[string]*TestResult{
+			"TestA":      {Name: "TestA", Package: "pkg/a", Status: "FAIL", SubTests: []string{"TestA/case"}, Output: []string{"--- FAIL: TestA"}},
+			"TestA/case": {Name: "TestA/case", Package: "pkg/a", Status: "FAIL", IsSubTest: true, Output: []string{"    a_test.go:3: connection refused <script>"}},
+			"TestB":      {Name: "TestB", Package: "pkg/b", Status: "PASS", Output: []string{"    b_test.go:9: connection established"}},
+		},
+	}
+
+	index := buildSearchIndex(data, defaultConfig())
+	if len(index.Tests) != 3 {
+		t.Fatalf("expected every test with output to be indexed, got %d", len(index.Tests))
+	}
+	if got := index.Tokens["connection"]; len(got) != 2 {
+		t.Errorf("connection: got postings %v, want 2 tests", got)
+	}
+	if got := index.Tokens["refused"]; len(got) != 1 || index.Tests[got[0]].Name != "TestA/case" {
+		t.Errorf("refused: got postings %v", got)
+	}
+
+	page, err := renderReport(data, defaultConfig(), "html")
+	if err != nil {
+		t.Fatalf("renderReport: %v", err)
+	}
+	for _, want := range []string{
+		"<h2>🔎 Search Output</h2>",
+		`<tr class="sub" data-status="FAIL" data-duration="0"><td>TestA/case</td>`,
+		`<th data-sort="duration">Duration</th>`,
+		`<select id="status-filter">`,
+		"connection refused &lt;script&gt;",
+		`"refused":[`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML report missing %q", want)
+		}
+	}
+	if strings.Contains(page, "refused <script>") {
+		t.Error("output must be escaped")
+	}
+}
+
+func TestHTMLReportSameFailure(t *testing.T) {
+	timeout := []string{"    api_test.go:20: dial tcp 127.0.0.1:8080: i/o timeout"}
+	data := &ReportData{
+		TotalTests:      1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestAPI"},
+		Results: map[string]*TestResult{
+			"TestAPI":      {Name: "TestAPI", Status: "FAIL", SubTests: []string{"TestAPI/list", "TestAPI/get", "TestAPI/put"}, Output: []string{"--- FAIL: TestAPI"}},
+			"TestAPI/list": {Name: "TestAPI/list", Status: "FAIL", IsSubTest: true, Output: timeout},
+			"TestAPI/get":  {Name: "TestAPI/get", Status: "FAIL", IsSubTest: true, Output: timeout},
+			"TestAPI/put":  {Name: "TestAPI/put", Status: "FAIL", IsSubTest: true, Output: timeout},
+		},
+	}
+
+	page, err := renderReport(data, defaultConfig(), "html")
+	if err != nil {
+		t.Fatalf("renderReport: %v", err)
+	}
+	if !strings.Contains(page, "<summary><strong>TestAPI/get</strong> </summary>\n<p>Same failure in 2 more subtests: TestAPI/list, TestAPI/put</p>") {
+		t.Error("identical subtest failures should be shown once with the other subtests")
+	}
+	if got := strings.Count(page, "i/o timeout\n") + strings.Count(page, "i/o timeout</pre>"); got != 1 {
+		t.Errorf("shared failure output should be shown once, found %d times", got)
+	}
+}
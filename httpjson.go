@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// httpStatusError is an API response with an error status, carrying the
+// start of its body, which usually says what was wrong
+type httpStatusError struct {
+	method, endpoint string
+	status           string
+	code             int
+	message          string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("%s %s: %s: %s", e.method, e.endpoint, e.status, e.message)
+}
+
+// doJSON sends a request with in, unless it is nil, as its JSON body and
+// decodes the JSON response into out, unless it is nil. auth sets the
+// credentials and any other headers the API expects. It returns the response
+// headers, which some APIs paginate with; an error status is returned as an
+// *httpStatusError.
+func doJSON(client *http.Client, method, endpoint string, in, out any, auth func(*http.Request)) (http.Header, error) {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth != nil {
+		auth(req)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &httpStatusError{method: method, endpoint: endpoint, status: resp.Status, code: resp.StatusCode, message: strings.TrimSpace(string(message))}
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("%s %s: decoding response: %w", method, endpoint, err)
+		}
+	}
+	return resp.Header, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDoJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "bad credentials", http.StatusUnauthorized)
+			return
+		}
+		var in map[string]string
+		json.NewDecoder(r.Body).Decode(&in)
+		w.Header().Set("X-Next-Page", "2")
+		json.NewEncoder(w).Encode(map[string]string{"echo": in["name"], "type": r.Header.Get("Content-Type")})
+	}))
+	defer server.Close()
+
+	var out map[string]string
+	header, err := doJSON(server.Client(), http.MethodPost, server.URL, map[string]string{"name": "TestA"}, &out, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer secret")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["echo"] != "TestA" || out["type"] != "application/json" || header.Get("X-Next-Page") != "2" {
+		t.Errorf("response: got %v and next page %q", out, header.Get("X-Next-Page"))
+	}
+
+	_, err = doJSON(server.Client(), http.MethodGet, server.URL, nil, nil, nil)
+	var status *httpStatusError
+	if !errors.As(err, &status) || status.code != http.StatusUnauthorized {
+		t.Fatalf("without credentials: got %v", err)
+	}
+	if want := "GET " + server.URL + ": 401 Unauthorized: bad credentials"; err.Error() != want {
+		t.Errorf("error: got %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// renderInflux renders data as InfluxDB line protocol: a gotest_run point
+// with the counts, a gotest_integrity point with the integrity summary, a
+// gotest_package point per package and a gotest_test point per test, all at
+// now with nanosecond precision. Statuses are tags, so each series follows
+// one test and dashboards can group by status.
+func renderInflux(data *ReportData, now time.Time) string {
+	timestamp := now.UnixNano()
+	var sb strings.Builder
+
+	runTags := ""
+	if data.Sanitizer != "" {
+		runTags = ",sanitizer=" + influxTag(data.Sanitizer)
+	}
+	passRate := 0.0
+	if data.TotalTests > 0 {
+		passRate = float64(data.PassedTests) / float64(data.TotalTests) * 100
+	}
+	fmt.Fprintf(&sb, "gotest_run%s total=%di,passed=%di,failed=%di,skipped=%di,flaky=%di,pass_rate=%g,duration=%g %d\n",
+		runTags, data.TotalTests, data.PassedTests, data.FailedTests, data.SkippedTests, data.FlakyTests, passRate, data.TotalDuration, timestamp)
+	integrity := data.Integrity
+	fmt.Fprintf(&sb, "gotest_integrity%s events_parsed=%di,lines_skipped=%di,incomplete_tests=%di,truncations=%di,complete=%t %d\n",
+		runTags, integrity.EventsParsed, integrity.LinesSkipped, len(integrity.IncompleteTests), len(integrity.Truncations), integrity.Complete(), timestamp)
+
+	packages := report.PackageDurations(data)
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Package < packages[j].Package })
+	for _, p := range packages {
+		fmt.Fprintf(&sb, "gotest_package,package=%s%s tests=%di,failed=%di,duration=%g %d\n",
+			influxTag(p.Package), runTags, p.Tests, p.Failed, p.Duration, timestamp)
+	}
+
+	names := make([]string, 0, len(data.Results))
+	for name := range data.Results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result := data.Results[name]
+		failed := 0
+		if result.Status == "FAIL" {
+			failed = 1
+		}
+		fmt.Fprintf(&sb, "gotest_test,package=%s,test=%s,status=%s%s duration=%g,attempts=%di,failed=%di %d\n",
+			influxTag(result.Package), influxTag(result.Name), influxTag(result.Status), runTags,
+			result.Duration, len(result.Attempts)+1, failed, timestamp)
+	}
+	return sb.String()
+}
+
+// influxTag escapes a tag value. Line protocol has no empty tag values, so
+// an empty one (e.g. a test outside any package) becomes "-".
+func influxTag(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`).Replace(value)
+}
+
+// pushInflux writes data to an InfluxDB write endpoint: /api/v2/write with
+// org and bucket parameters on InfluxDB 2 and later, or /write?db=... on
+// InfluxDB 1. A token in INFLUX_TOKEN is sent for authentication.
+func pushInflux(url string, data *ReportData, now time.Time) error {
+	if !strings.Contains(url, "precision=") {
+		separator := "?"
+		if strings.Contains(url, "?") {
+			separator = "&"
+		}
+		url += separator + "precision=ns"
+	}
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(renderInflux(data, now)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if token := os.Getenv("INFLUX_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("POST %s: %s: %s", url, resp.Status, strings.TrimSpace(string(message)))
+	}
+	logger.Info("pushed metrics to InfluxDB", "points", len(data.Results)+len(report.PackageDurations(data))+1)
+	return nil
+}
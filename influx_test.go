@@ -0,0 +1,94 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestInfluxTag(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"example.com/pkg", "example.com/pkg"},
+		{"TestX/a b,c=d", `TestX/a\ b\,c\=d`},
+		{`back\slash`, `back\\slash`},
+		{"", "-"},
+	}
+	for _, tt := range tests {
+		if got := influxTag(tt.in); got != tt.want {
+			t.Errorf("influxTag(%q): got %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderInflux(t *testing.T) {
+	data := &ReportData{
+		TotalTests:    3,
+		PassedTests:   1,
+		FailedTests:   1,
+		FlakyTests:    1,
+		TotalDuration: 1.5,
+		Sanitizer:     "race",
+		Integrity:     report.Integrity{EventsParsed: 9, Truncations: []string{"report cut off"}},
+		Results: map[string]*TestResult{
+			"TestOK":      {Name: "TestOK", Package: "example.com/a", Status: "PASS", Duration: 0.5},
+			"TestBad/a b": {Name: "TestBad/a b", Package: "example.com/a", Status: "FAIL", Duration: 1, IsSubTest: true},
+			"TestBad":     {Name: "TestBad", Package: "example.com/a", Status: "FAIL", Duration: 1},
+			"TestRetried": {Name: "TestRetried", Package: "example.com/b", Status: "FLAKY", Attempts: []report.Attempt{{Status: "FAIL"}}},
+		},
+	}
+	got := renderInflux(data, time.Unix(1700000000, 5))
+	want := `gotest_run,sanitizer=race total=3i,passed=1i,failed=1i,skipped=0i,flaky=1i,pass_rate=33.33333333333333,duration=1.5 1700000000000000005
+gotest_integrity,sanitizer=race events_parsed=9i,lines_skipped=0i,incomplete_tests=0i,truncations=1i,complete=false 1700000000000000005
+gotest_package,package=example.com/a,sanitizer=race tests=2i,failed=1i,duration=1.5 1700000000000000005
+gotest_package,package=example.com/b,sanitizer=race tests=1i,failed=0i,duration=0 1700000000000000005
+gotest_test,package=example.com/a,test=TestBad,status=FAIL,sanitizer=race duration=1,attempts=1i,failed=1i 1700000000000000005
+gotest_test,package=example.com/a,test=TestBad/a\ b,status=FAIL,sanitizer=race duration=1,attempts=1i,failed=1i 1700000000000000005
+gotest_test,package=example.com/a,test=TestOK,status=PASS,sanitizer=race duration=0.5,attempts=1i,failed=0i 1700000000000000005
+gotest_test,package=example.com/b,test=TestRetried,status=FLAKY,sanitizer=race duration=0,attempts=2i,failed=0i 1700000000000000005
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPushInflux(t *testing.T) {
+	var gotQuery, gotAuth, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery, gotAuth = r.URL.RawQuery, r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		if r.URL.Query().Get("bucket") == "missing" {
+			http.Error(w, `{"message":"bucket not found"}`, http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	t.Setenv("INFLUX_TOKEN", "secret")
+
+	data := &ReportData{TotalTests: 1, PassedTests: 1}
+	if err := pushInflux(server.URL+"/api/v2/write?org=ci&bucket=tests", data, time.Unix(1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "org=ci&bucket=tests&precision=ns"; gotQuery != want {
+		t.Errorf("query: got %q, want %q", gotQuery, want)
+	}
+	if gotAuth != "Token secret" {
+		t.Errorf("Authorization: got %q, want %q", gotAuth, "Token secret")
+	}
+	if !strings.HasPrefix(gotBody, "gotest_run total=1i,passed=1i") {
+		t.Errorf("body: got %q, want the run point first", gotBody)
+	}
+
+	err := pushInflux(server.URL+"/api/v2/write?org=ci&bucket=missing", data, time.Unix(1, 0))
+	if err == nil || !strings.Contains(err.Error(), "bucket not found") {
+		t.Errorf("got error %v, want the response body in it", err)
+	}
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// inputSpec is one -input file together with the per-input flags given after it
+type inputSpec struct {
+	path      string
+	sanitizer string
+	// Import path of the test binary whose raw output the input holds
+	pkg string
+	// Suite the input belongs to, e.g. backend or web
+	suite string
+	// Comma-separated "key=value" pairs describing where the input ran,
+	// e.g. os=linux,go=1.22
+	labels string
+}
+
+// inputList collects repeated -input flags. Per-input flags such as
+// -sanitizer apply to the most recent -input, or to stdin when no input file
+// has been given yet.
+type inputList struct {
+	specs []inputSpec
+	stdin inputSpec
+}
+
+func (l *inputList) String() string {
+	paths := make([]string, len(l.specs))
+	for i, spec := range l.specs {
+		paths[i] = spec.path
+	}
+	return strings.Join(paths, ",")
+}
+
+func (l *inputList) Set(path string) error {
+	l.specs = append(l.specs, inputSpec{path: path})
+	return nil
+}
+
+// current returns the input that per-input flags should apply to
+func (l *inputList) current() *inputSpec {
+	if len(l.specs) == 0 {
+		return &l.stdin
+	}
+	return &l.specs[len(l.specs)-1]
+}
+
+// resolved returns the inputs to read, falling back to stdin
+func (l *inputList) resolved() []inputSpec {
+	if len(l.specs) == 0 {
+		return []inputSpec{l.stdin}
+	}
+	return l.specs
+}
+
+// sanitizerFlag sets the sanitizer of the current input
+type sanitizerFlag struct {
+	inputs *inputList
+}
+
+func (f sanitizerFlag) String() string {
+	if f.inputs == nil {
+		return ""
+	}
+	return f.inputs.current().sanitizer
+}
+
+func (f sanitizerFlag) Set(value string) error {
+	switch value {
+	case "race", "asan", "msan":
+		f.inputs.current().sanitizer = value
+		return nil
+	}
+	return fmt.Errorf("unknown sanitizer %q (want race, asan or msan)", value)
+}
+
+// packageFlag sets the package of the current input, for raw test binary
+// output that doesn't name it
+type packageFlag struct {
+	inputs *inputList
+}
+
+func (f packageFlag) String() string {
+	if f.inputs == nil {
+		return ""
+	}
+	return f.inputs.current().pkg
+}
+
+func (f packageFlag) Set(value string) error {
+	f.inputs.current().pkg = value
+	return nil
+}
+
+// suiteFlag sets the suite of the current input
+type suiteFlag struct {
+	inputs *inputList
+}
+
+func (f suiteFlag) String() string {
+	if f.inputs == nil {
+		return ""
+	}
+	return f.inputs.current().suite
+}
+
+func (f suiteFlag) Set(value string) error {
+	f.inputs.current().suite = value
+	return nil
+}
+
+// labelFlag sets the labels of the current input from "os=linux,go=1.22"
+type labelFlag struct {
+	inputs *inputList
+}
+
+func (f labelFlag) String() string {
+	if f.inputs == nil {
+		return ""
+	}
+	return f.inputs.current().labels
+}
+
+func (f labelFlag) Set(value string) error {
+	var labels []string
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		if !ok || key == "" || val == "" || strings.ContainsAny(key+val, " =") {
+			return fmt.Errorf("invalid label %q (want key=value pairs such as os=linux,go=1.22)", pair)
+		}
+		labels = append(labels, key+"="+val)
+	}
+	f.inputs.current().labels = strings.Join(labels, ",")
+	return nil
+}
+
+// inputFlags are the flags of the commands that read go test output
+type inputFlags struct {
+	inputs       inputList
+	maxOpenFiles *int
+	lenient      *bool
+	format       *string
+	maxLineBytes *int
+	progress     *bool
+}
+
+func addInputFlags(fs *flag.FlagSet) *inputFlags {
+	f := &inputFlags{}
+	fs.Var(&f.inputs, "input", "go test -json (or plain go test -v) output file, optionally gzip/zstd compressed (default is stdin); repeat to merge several files")
+	fs.Var(sanitizerFlag{&f.inputs}, "sanitizer", "Sanitizer (race, asan, msan) the preceding -input was run under")
+	fs.Var(suiteFlag{&f.inputs}, "suite-name", "Suite the preceding -input belongs to (e.g. backend or web); inputs of a suite are merged, and each suite gets a section")
+	fs.Var(labelFlag{&f.inputs}, "label", "Where the preceding -input ran, as key=value pairs (e.g. os=linux,go=1.22); inputs with different labels are compared in a matrix")
+	fs.Var(packageFlag{&f.inputs}, "package", "Import path of the test binary whose raw output (./pkg.test -test.v) the preceding -input holds")
+	f.maxOpenFiles = fs.Int("max-open-files", report.DefaultMaxOpenFiles, "Maximum number of -input files parsed at once while merging shards")
+	f.lenient = fs.Bool("lenient", false, "Skip input lines that aren't go test -json events (e.g. make output) instead of failing")
+	f.format = fs.String("input-format", report.InputGo, "Format of every -input: go (go test -json or -v output, JUnit XML or CTRF JSON, told apart automatically), junit or ctrf")
+	f.maxLineBytes = fs.Int("max-line-bytes", report.DefaultMaxLineBytes, "Longest input line read into memory at once; longer lines are decoded as a stream")
+	f.progress = fs.Bool("progress", true, "Show parse progress on stderr when reading the input takes more than a few seconds")
+	return f
+}
+
+// configure copies the parsing flags to cfg
+func (f *inputFlags) configure(cfg *config) {
+	cfg.maxLineBytes = *f.maxLineBytes
+	cfg.lenient = *f.lenient
+	cfg.inputFormat = *f.format
+}
+
+// load reads the inputs as loadRuns does, for a report in format
+func (f *inputFlags) load(cfg *config, format string) ([]*ReportData, error) {
+	switch cfg.inputFormat {
+	case report.InputGo, report.InputCTRF, report.InputJUnit:
+	default:
+		return nil, fmt.Errorf("unknown -input-format %q: expected go, ctrf or junit", cfg.inputFormat)
+	}
+	specs := f.inputs.resolved()
+	opts := parseOptions(cfg, format)
+	if *f.progress {
+		progress := newProgressReporter(specs)
+		defer progress.done()
+		opts.Progress = progress.add
+	}
+	return loadRuns(specs, *f.maxOpenFiles, opts)
+}
+
+// loadRuns reads the inputs. Several input files without sanitizer tags are
+// shards of one run, parsed in parallel by report.ParseShards with at most
+// maxOpen files open at once; once any input is tagged, every input stays a
+// separate run so they can be compared. Inputs with a -package, a -suite-name
+// or a -label are parsed one by one and merged afterwards.
+func loadRuns(specs []inputSpec, maxOpen int, opts report.ParseOptions) ([]*ReportData, error) {
+	if len(specs) < 2 {
+		return loadInputs(specs, opts)
+	}
+
+	paths := make([]string, len(specs))
+	for i, spec := range specs {
+		if spec.sanitizer != "" || spec.pkg != "" || spec.suite != "" || spec.labels != "" {
+			return loadInputs(specs, opts)
+		}
+		paths[i] = spec.path
+	}
+
+	data, err := report.ParseShards(paths, maxOpen, opts)
+	if err != nil {
+		return nil, err
+	}
+	logParseStats(strings.Join(paths, ","), data)
+	return []*ReportData{data}, nil
+}
+
+// loadInputs parses every input into its own ReportData
+func loadInputs(specs []inputSpec, opts report.ParseOptions) ([]*ReportData, error) {
+	runs := make([]*ReportData, 0, len(specs))
+	for _, spec := range specs {
+		data, err := loadInput(spec, opts)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, data)
+	}
+	return runs, nil
+}
+
+func loadInput(spec inputSpec, opts report.ParseOptions) (*ReportData, error) {
+	var reader io.Reader = os.Stdin
+	if spec.path != "" {
+		file, err := os.Open(spec.path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening input file: %v", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	opts.Package = spec.pkg
+	data, err := processTestEvents(reader, opts)
+	if err != nil {
+		if spec.path != "" {
+			return nil, fmt.Errorf("%s: %v", spec.path, err)
+		}
+		return nil, err
+	}
+	data.Sanitizer = spec.sanitizer
+	data.Suite = spec.suite
+	if spec.labels != "" {
+		data.Labels = strings.Split(spec.labels, ",")
+	}
+	source := spec.path
+	if source == "" {
+		source = "stdin"
+	}
+	logParseStats(source, data)
+	return data, nil
+}
+
+// parseOptions keeps the output of passing tests only for reports that show
+// it: with -include-pass-output, in the HTML search index, in NDJSON records
+// and in the system-out of the XML formats
+func parseOptions(cfg *config, format string) report.ParseOptions {
+	return report.ParseOptions{
+		KeepPassOutput: cfg.includePassOutput || keepsPassOutput[format],
+		SpoolThreshold: report.DefaultSpoolThreshold,
+		MaxLineBytes:   cfg.maxLineBytes,
+		Lenient:        cfg.lenient,
+		Format:         cfg.inputFormat,
+		Timeline:       cfg.timeline,
+	}
+}
+
+// keepsPassOutput are the formats that show the output of passing tests
+var keepsPassOutput = map[string]bool{
+	"html":   true,
+	"ndjson": true,
+	"junit":  true,
+	"xunit":  true,
+	"nunit3": true,
+	"trx":    true,
+}
+
+// warnSkippedLines tells the user how much of the input -lenient left out
+func warnSkippedLines(data *ReportData) {
+	if n := data.Integrity.LinesSkipped; n > 0 {
+		logger.Warn("skipped input lines that were not go test -json events", "lines", n)
+	}
+}
+
+// combineRuns merges several runs into one report. Runs are labelled by
+// sanitizer when any of them used one, so they can be compared side by side.
+// When the runs belong to several suites, the runs of each suite are merged
+// first and kept in Suites; runs without one form a suite named "other".
+func combineRuns(runs []*ReportData) *ReportData {
+	var names []string
+	bySuite := make(map[string][]*ReportData)
+	for _, run := range runs {
+		name := run.Suite
+		if name == "" {
+			name = "other"
+		}
+		if _, ok := bySuite[name]; !ok {
+			names = append(names, name)
+		}
+		bySuite[name] = append(bySuite[name], run)
+	}
+	if len(names) == 1 {
+		merged := mergeRuns(runs)
+		merged.Suite = runs[0].Suite
+		return merged
+	}
+
+	suites := make([]*ReportData, len(names))
+	for i, name := range names {
+		suites[i] = mergeRuns(bySuite[name])
+		suites[i].Suite = name
+	}
+	merged := mergeRuns(suites)
+	merged.Suites = suites
+	return merged
+}
+
+// mergeRuns merges the runs of one suite
+func mergeRuns(runs []*ReportData) *ReportData {
+	if len(runs) == 1 {
+		return runs[0]
+	}
+	for _, run := range runs {
+		if len(run.Labels) > 0 {
+			return mergeMatrix(runs)
+		}
+	}
+
+	var labels []string
+	for _, run := range runs {
+		if run.Sanitizer != "" {
+			labels = make([]string, len(runs))
+			for i, r := range runs {
+				labels[i] = sanitizerLabel(r.Sanitizer)
+			}
+			break
+		}
+	}
+	return report.Merge(runs, labels)
+}
+
+// mergeMatrix merges labelled runs: the runs sharing labels (e.g. the
+// shards of one platform) are merged first, then compared side by side with
+// their labels, and a sanitizer, as the variant
+func mergeMatrix(runs []*ReportData) *ReportData {
+	var dimensions, variants []string
+	seen := make(map[string]bool)
+	byVariant := make(map[string][]*ReportData)
+	for _, run := range runs {
+		labels := run.Labels
+		if run.Sanitizer != "" {
+			labels = append(labels[:len(labels):len(labels)], "sanitizer="+run.Sanitizer)
+		}
+		for _, label := range labels {
+			key, _, _ := strings.Cut(label, "=")
+			if !seen[key] {
+				seen[key] = true
+				dimensions = append(dimensions, key)
+			}
+		}
+		variant := strings.Join(labels, " ")
+		if variant == "" {
+			variant = "unlabeled"
+		}
+		if _, ok := byVariant[variant]; !ok {
+			variants = append(variants, variant)
+		}
+		byVariant[variant] = append(byVariant[variant], run)
+	}
+
+	merged := make([]*ReportData, len(variants))
+	for i, variant := range variants {
+		merged[i] = report.Merge(byVariant[variant], nil)
+	}
+	data := report.Merge(merged, variants)
+	data.Dimensions = dimensions
+	return data
+}
+
+func sanitizerLabel(sanitizer string) string {
+	if sanitizer == "" {
+		return "none"
+	}
+	return sanitizer
+}
+
+// detectSanitizer returns the sanitizer enabled by go test flags, if any
+func detectSanitizer(testFlags []string) string {
+	sanitizer := ""
+	for _, arg := range testFlags {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch name {
+		case "race", "asan", "msan":
+			if !hasValue || value == "true" {
+				sanitizer = name
+			} else if sanitizer == name {
+				sanitizer = ""
+			}
+		}
+	}
+	return sanitizer
+}
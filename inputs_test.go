@@ -0,0 +1,351 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestInputListFlags(t *testing.T) {
+	var inputs inputList
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&inputs, "input", "")
+	fs.Var(sanitizerFlag{&inputs}, "sanitizer", "")
+	fs.Var(packageFlag{&inputs}, "package", "")
+
+	err := fs.Parse([]string{"-input", "plain.json", "-input", "race.json", "-sanitizer", "race", "-input", "asan.json", "-sanitizer=asan",
+		"-input", "pkg.txt", "-package", "example.com/pkg"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	specs := inputs.resolved()
+	if len(specs) != 4 {
+		t.Fatalf("expected 4 inputs, got %d", len(specs))
+	}
+	want := []inputSpec{
+		{path: "plain.json"},
+		{path: "race.json", sanitizer: "race"},
+		{path: "asan.json", sanitizer: "asan"},
+		{path: "pkg.txt", pkg: "example.com/pkg"},
+	}
+	for i, spec := range specs {
+		if spec != want[i] {
+			t.Errorf("input %d: got %+v, want %+v", i, spec, want[i])
+		}
+	}
+
+	if err := fs.Parse([]string{"-sanitizer", "tsan"}); err == nil {
+		t.Error("expected an error for an unknown sanitizer")
+	}
+}
+
+func TestInputListDefaultsToStdin(t *testing.T) {
+	var inputs inputList
+	if err := (sanitizerFlag{&inputs}).Set("msan"); err != nil {
+		t.Fatal(err)
+	}
+	specs := inputs.resolved()
+	if len(specs) != 1 || specs[0].path != "" || specs[0].sanitizer != "msan" {
+		t.Errorf("expected stdin tagged with msan, got %+v", specs)
+	}
+}
+
+func TestDetectSanitizer(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{[]string{"-count=1"}, ""},
+		{[]string{"-race", "-count=1"}, "race"},
+		{[]string{"--asan"}, "asan"},
+		{[]string{"-msan=true"}, "msan"},
+		{[]string{"-race", "-race=false"}, ""},
+		{[]string{"-run", "race"}, ""},
+	}
+	for _, tt := range tests {
+		if got := detectSanitizer(tt.flags); got != tt.want {
+			t.Errorf("detectSanitizer(%v): got %q, want %q", tt.flags, got, tt.want)
+		}
+	}
+}
+
+func TestCombineRunsLabelsBySanitizer(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, status string) string {
+		path := filepath.Join(dir, name)
+		content := `{"Action":"run","Test":"TestShared","Package":"pkg"}
+{"Action":"` + status + `","Test":"TestShared","Package":"pkg","Elapsed":0.1}
+`
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	runs, err := loadInputs([]inputSpec{
+		{path: write("plain.json", "pass")},
+		{path: write("race.json", "fail"), sanitizer: "race"},
+	}, report.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	merged := combineRuns(runs)
+	if got := strings.Join(merged.Variants, ","); got != "none,race" {
+		t.Errorf("variants: got %q, want none,race", got)
+	}
+	shared := merged.Results["TestShared"]
+	if shared.Status != "FAIL" || shared.Variants["none"] != "PASS" || shared.Variants["race"] != "FAIL" {
+		t.Errorf("unexpected merged result: %+v", shared)
+	}
+
+	markdown := generateMarkdownReport(merged)
+	for _, want := range []string{"## 🧬 Sanitizer Matrix", "| Run | none | race |", "| TestShared | ✅ | ❌ |"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+}
+
+func TestLoadRunsMergesShards(t *testing.T) {
+	dir := t.TempDir()
+	var specs []inputSpec
+	for i, test := range []string{"TestA", "TestB", "TestC", "TestD"} {
+		path := filepath.Join(dir, test+".json")
+		content := fmt.Sprintf(`{"Time":"2024-01-01T10:00:0%dZ","Action":"run","Test":"%s","Package":"pkg"}
+{"Time":"2024-01-01T10:00:0%dZ","Action":"pass","Test":"%s","Package":"pkg","Elapsed":0.1}
+`, i, test, i+1, test)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		specs = append(specs, inputSpec{path: path})
+	}
+
+	runs, err := loadRuns(specs, 3, report.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(runs) != 1 || runs[0].PassedTests != 4 || len(runs[0].Variants) != 0 {
+		t.Errorf("expected shards to form one run with 4 passed tests, got %d runs: %+v", len(runs), runs[0])
+	}
+
+	specs[1].sanitizer = "race"
+	runs, err = loadRuns(specs, 3, report.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(runs) != 4 {
+		t.Errorf("tagged inputs should stay separate runs, got %d", len(runs))
+	}
+}
+
+func TestLoadRunsTestBinaryOutput(t *testing.T) {
+	dir := t.TempDir()
+	var specs []inputSpec
+	for _, pkg := range []string{"a", "b"} {
+		path := filepath.Join(dir, pkg+".txt")
+		content := fmt.Sprintf("=== RUN   Test%[1]s\n--- PASS: Test%[1]s (0.10s)\nPASS\n", pkg)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		specs = append(specs, inputSpec{path: path, pkg: "example.com/" + pkg})
+	}
+
+	runs, err := loadRuns(specs, 3, report.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	merged := combineRuns(runs)
+	if merged.PassedTests != 2 || len(merged.Variants) != 0 {
+		t.Fatalf("expected 2 passed tests in one run, got %+v", merged)
+	}
+	if got := merged.Results["Testa"].Package; got != "example.com/a" {
+		t.Errorf("Testa: got package %q", got)
+	}
+	if got := merged.Results["Testb"].Package; got != "example.com/b" {
+		t.Errorf("Testb: got package %q", got)
+	}
+}
+
+func TestLoadRunsJUnit(t *testing.T) {
+	original, err := report.Parse(strings.NewReader(`{"Action":"run","Test":"TestA","Package":"example.com/a"}
+{"Action":"run","Test":"TestA/sub","Package":"example.com/a"}
+{"Action":"output","Test":"TestA/sub","Package":"example.com/a","Output":"    a_test.go:9: got 1, want 2\n"}
+{"Action":"fail","Test":"TestA/sub","Package":"example.com/a","Elapsed":0.5}
+{"Action":"fail","Test":"TestA","Package":"example.com/a","Elapsed":0.75}
+{"Action":"run","Test":"TestB","Package":"example.com/b"}
+{"Action":"pass","Test":"TestB","Package":"example.com/b","Elapsed":0.25}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	xml, err := renderJUnit(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	if err := os.WriteFile(path, []byte(xml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := defaultConfig()
+	cfg.inputFormat = report.InputJUnit
+	runs, err := loadRuns([]inputSpec{{path: path}, {path: path}}, 3, parseOptions(cfg, "markdown"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := combineRuns(runs)
+	if data.TotalTests != 2 || data.FailedTests != 1 || data.PassedTests != 1 {
+		t.Errorf("got %d tests, %d failed, %d passed; want 2, 1 and 1", data.TotalTests, data.FailedTests, data.PassedTests)
+	}
+	sub := data.Results["TestA/sub"]
+	if sub == nil || sub.Package != "example.com/a" || sub.Duration != 0.5 || report.ExtractExcerpt(sub.Output).Assertion != "got 1, want 2" {
+		t.Errorf("TestA/sub: got %+v", sub)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	inputs := addInputFlags(fs)
+	if err := fs.Parse([]string{"-input", path, "-input-format", "xml"}); err != nil {
+		t.Fatal(err)
+	}
+	inputs.configure(cfg)
+	if _, err := inputs.load(cfg, "markdown"); err == nil || !strings.Contains(err.Error(), "-input-format") {
+		t.Errorf("unknown format: got error %v", err)
+	}
+}
+
+func TestCombineRunsBySuite(t *testing.T) {
+	run := func(suite string, results ...*TestResult) *ReportData {
+		data := &ReportData{Results: make(map[string]*TestResult)}
+		for _, result := range results {
+			data.Results[result.Name] = result
+		}
+		data = report.Merge([]*ReportData{data}, nil)
+		data.Suite = suite
+		return data
+	}
+	runs := []*ReportData{
+		run("backend", &TestResult{Name: "TestA", Package: "example.com/a", Status: "PASS", Duration: 1}),
+		run("web", &TestResult{Name: "renders", Package: "cart.test.js", Status: "FAIL", Duration: 2}),
+		run("backend", &TestResult{Name: "TestB", Package: "example.com/b", Status: "FAIL", Duration: 3}),
+		run("", &TestResult{Name: "test_api", Package: "tests", Status: "SKIP"}),
+	}
+
+	data := combineRuns(runs)
+	if data.TotalTests != 4 || data.FailedTests != 2 {
+		t.Errorf("merged: got %d tests, %d failed; want 4 and 2", data.TotalTests, data.FailedTests)
+	}
+	var got []string
+	for _, suite := range data.Suites {
+		got = append(got, fmt.Sprintf("%s:%d/%d", suite.Suite, suite.FailedTests, suite.TotalTests))
+	}
+	if want := "backend:1/2 web:1/1 other:0/1"; strings.Join(got, " ") != want {
+		t.Errorf("suites: got %q, want %q", strings.Join(got, " "), want)
+	}
+
+	markdown := generateMarkdownReport(data)
+	for _, want := range []string{
+		"- 🧩 **Suites:** backend, web, other\n",
+		"### ❌ backend\n\n- 📦 **Packages:** example.com/a, example.com/b\n- 🧪 **Tests:** 2 (✅ 1, ❌ 1, ⏭️ 0)\n- ⏱️ **Duration:** 4.00s\n\n- ❌ `TestB`\n",
+		"### ✅ other\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown: missing %q", want)
+		}
+	}
+
+	single := combineRuns(runs[:1])
+	if single.Suite != "backend" || single.Suites != nil {
+		t.Errorf("one suite: got suite %q with %d suites, want no sections", single.Suite, len(single.Suites))
+	}
+}
+
+func TestLabelFlag(t *testing.T) {
+	var inputs inputList
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&inputs, "input", "")
+	fs.Var(labelFlag{&inputs}, "label", "")
+	if err := fs.Parse([]string{"-input", "linux.json", "-label", "os=linux, go=1.22"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := inputs.resolved()[0].labels; got != "os=linux,go=1.22" {
+		t.Errorf("labels: got %q", got)
+	}
+	for _, bad := range []string{"linux", "os=", "=linux", "os=linux,", "os=a=b"} {
+		if err := (labelFlag{&inputs}).Set(bad); err == nil {
+			t.Errorf("%q: got no error", bad)
+		}
+	}
+}
+
+func TestCombineRunsMatrix(t *testing.T) {
+	run := func(labels, status string) *ReportData {
+		data := report.Merge([]*ReportData{{Results: map[string]*TestResult{
+			"TestPath": {Name: "TestPath", Package: "example.com/a", Status: status},
+			"TestOK":   {Name: "TestOK", Package: "example.com/a", Status: "PASS"},
+		}}}, nil)
+		data.Labels = strings.Split(labels, ",")
+		return data
+	}
+	runs := []*ReportData{
+		run("os=linux,go=1.22", "PASS"),
+		run("os=windows,go=1.22", "FAIL"),
+		run("os=linux,go=1.23", "PASS"),
+		run("os=windows,go=1.23", "PASS"),
+		// A second shard of the same platform merges into its column
+		run("os=windows,go=1.23", "FAIL"),
+	}
+
+	data := combineRuns(runs)
+	if want := []string{"os=linux go=1.22", "os=windows go=1.22", "os=linux go=1.23", "os=windows go=1.23"}; !slices.Equal(data.Variants, want) {
+		t.Errorf("variants: got %q, want %q", data.Variants, want)
+	}
+	if !slices.Equal(data.Dimensions, []string{"os", "go"}) {
+		t.Errorf("dimensions: got %q", data.Dimensions)
+	}
+	if got := failureScope(data, data.Results["TestPath"]); got != "only os=windows" {
+		t.Errorf("scope: got %q, want only os=windows", got)
+	}
+
+	markdown := generateMarkdownReport(data)
+	for _, want := range []string{
+		"- 🧮 **Matrix:** 4 runs across os, go\n",
+		"## 🧮 Test Matrix",
+		"| TestPath | ✅ | ❌ | ✅ | ❌ | ⚠️ **only os=windows** |",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown: missing %q", want)
+		}
+	}
+
+	runs[3].Results["TestPath"].Status = "FAIL"
+	runs[0].Results["TestPath"].Status = "FAIL"
+	data = combineRuns(runs)
+	if got := failureScope(data, data.Results["TestPath"]); got != "3 of 4 runs" {
+		t.Errorf("scope: got %q, want 3 of 4 runs", got)
+	}
+	runs[1].Results["TestPath"].Status = "PASS"
+	runs[3].Results["TestPath"].Status = "PASS"
+	runs[4].Results["TestPath"].Status = "PASS"
+	data = combineRuns(runs)
+	if got := failureScope(data, data.Results["TestPath"]); got != "only os=linux go=1.22" {
+		t.Errorf("scope: got %q, want only os=linux go=1.22", got)
+	}
+	runs[1].Results["TestPath"].Status = "FAIL"
+	runs[2].Results["TestPath"].Status = "FAIL"
+	runs[3].Results["TestPath"].Status = "FAIL"
+	data = combineRuns(runs)
+	if got := failureScope(data, data.Results["TestPath"]); got != "everywhere" {
+		t.Errorf("scope: got %q, want everywhere", got)
+	}
+}
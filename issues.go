@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// issueMarker starts the body of issues filed by -file-issues and holds the
+// failure fingerprint, so a later run updates the issue instead of filing
+// another
+const issueMarker = "<!-- gotest-report:fingerprint %s -->"
+
+// issueOutputLines is how much of the failure output an issue shows
+const issueOutputLines = 200
+
+// newFailureIssue is one issue to file: the new failures sharing a
+// fingerprint
+type newFailureIssue struct {
+	fingerprint string
+	failures    []report.Failure
+	reportURL   string // the uploaded report, if any
+}
+
+// newFailureIssues groups the failures that are new since the baseline by
+// fingerprint, in the order of their first failure
+func newFailureIssues(data *ReportData) []newFailureIssue {
+	if data.Comparison == nil {
+		return nil
+	}
+	isNew := make(map[string]bool)
+	for _, change := range data.Comparison.NewFailures {
+		isNew[change.Name] = true
+	}
+	var issues []newFailureIssue
+	index := make(map[string]int)
+	for _, failure := range report.Failures(data) {
+		if !isNew[failure.Name] || failure.Quarantined {
+			continue
+		}
+		i, ok := index[failure.Fingerprint]
+		if !ok {
+			i = len(issues)
+			index[failure.Fingerprint] = i
+			issues = append(issues, newFailureIssue{fingerprint: failure.Fingerprint, reportURL: data.ReportURL})
+		}
+		issues[i].failures = append(issues[i].failures, failure)
+	}
+	return issues
+}
+
+// title names the first failed test and how many more failed the same way
+func (i newFailureIssue) title() string {
+	title := "Test failure: " + i.failures[0].Name
+	if more := len(i.failures) - 1; more == 1 {
+		title += " and 1 more test"
+	} else if more > 1 {
+		title += fmt.Sprintf(" and %d more tests", more)
+	}
+	return title
+}
+
+// body renders the issue: the tests, the assertion and the output of the
+// first failure, and the run it was seen in
+func (i newFailureIssue) body(cfg *config) string {
+	first := i.failures[0]
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(issueMarker, i.fingerprint) + "\n")
+	sb.WriteString(fmt.Sprintf("New test failure, not failing on the baseline, last seen in the %s", workflowRunLink()))
+	if i.reportURL != "" {
+		sb.WriteString(fmt.Sprintf(" ([full report](%s))", i.reportURL))
+	}
+	sb.WriteString(".\n\n")
+	sb.WriteString("| Test | Package |\n| ---- | ------- |\n")
+	for _, failure := range i.failures {
+		sb.WriteString(fmt.Sprintf("| `%s` | %s |\n", markdownCell(failure.Name), markdownCell(cfg.packageName(failure.Package))))
+	}
+	if first.Excerpt.Assertion != "" {
+		sb.WriteString("\n> `" + strings.ReplaceAll(first.Excerpt.Assertion, "`", "'") + "`")
+		if first.Excerpt.File != "" {
+			sb.WriteString(fmt.Sprintf(" (`%s:%d`)", first.Excerpt.File, first.Excerpt.Line))
+		}
+		sb.WriteString("\n")
+	}
+	output := joinOutput(trimOutput(first.Output, issueOutputLines))
+	// GitHub rejects bodies over 65,536 characters
+	sb.WriteString("\n<details>\n<summary>Output of " + markdownCell(first.Name) + "</summary>\n\n```text\n" + truncateRunes(output, 60000) + "```\n\n</details>\n")
+	sb.WriteString(fmt.Sprintf("\n<sub>Fingerprint `%s`. Filed by gotest-report, which updates this issue while the failure persists.</sub>\n", i.fingerprint))
+	return sb.String()
+}
+
+type githubIssue struct {
+	Number      int       `json:"number"`
+	Title       string    `json:"title"`
+	Body        string    `json:"body"`
+	HTMLURL     string    `json:"html_url"`
+	PullRequest *struct{} `json:"pull_request"` // set when the issue is a pull request
+}
+
+// fileIssues opens an issue for each group of new failures, or updates the
+// open issue carrying its fingerprint. Issues are looked up among the open
+// ones with the first label, or all open ones without labels.
+func (c *githubClient) fileIssues(issues []newFailureIssue, labels []string, cfg *config) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	existing, err := c.openIssues(labels)
+	if err != nil {
+		return fmt.Errorf("listing open issues: %w", err)
+	}
+	for _, issue := range issues {
+		marker := fmt.Sprintf(issueMarker, issue.fingerprint)
+		body := issue.body(cfg)
+		var found *githubIssue
+		for i := range existing {
+			if strings.HasPrefix(existing[i].Body, marker) {
+				found = &existing[i]
+				break
+			}
+		}
+		if found != nil {
+			if err := c.do(http.MethodPatch, fmt.Sprintf("/repos/%s/issues/%d", c.repo, found.Number), map[string]any{"body": body}, nil); err != nil {
+				return fmt.Errorf("updating issue #%d: %w", found.Number, err)
+			}
+			logger.Info("updated issue for new test failure", "issue", found.HTMLURL, "fingerprint", issue.fingerprint)
+			continue
+		}
+		request := map[string]any{"title": issue.title(), "body": body}
+		if len(labels) > 0 {
+			request["labels"] = labels
+		}
+		var created githubIssue
+		if err := c.do(http.MethodPost, "/repos/"+c.repo+"/issues", request, &created); err != nil {
+			return fmt.Errorf("opening an issue: %w", err)
+		}
+		logger.Info("opened issue for new test failure", "issue", created.HTMLURL, "fingerprint", issue.fingerprint)
+	}
+	return nil
+}
+
+// openIssues lists the open issues with the first of labels, leaving out
+// pull requests, which the issues API also returns
+func (c *githubClient) openIssues(labels []string) ([]githubIssue, error) {
+	query := url.Values{"state": {"open"}, "per_page": {"100"}}
+	if len(labels) > 0 {
+		query.Set("labels", labels[0])
+	}
+	var issues []githubIssue
+	for page := 1; ; page++ {
+		query.Set("page", fmt.Sprint(page))
+		var batch []githubIssue
+		if err := c.do(http.MethodGet, "/repos/"+c.repo+"/issues?"+query.Encode(), nil, &batch); err != nil {
+			return nil, err
+		}
+		for _, issue := range batch {
+			if issue.PullRequest == nil {
+				issues = append(issues, issue)
+			}
+		}
+		if len(batch) < 100 {
+			return issues, nil
+		}
+	}
+}
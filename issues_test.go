@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func issuesTestData() *ReportData {
+	refused := []string{"=== RUN   TestDB", "    db_test.go:12: dial tcp 127.0.0.1:5432: connection refused", "--- FAIL: TestDB"}
+	return &ReportData{
+		Results: map[string]*TestResult{
+			"TestDB":      {Name: "TestDB", Package: "example.com/store", Status: "FAIL", Output: refused},
+			"TestCache":   {Name: "TestCache", Package: "example.com/store", Status: "FAIL", Output: refused},
+			"TestParse":   {Name: "TestParse", Package: "example.com/parse", Status: "FAIL", Output: []string{"    parse_test.go:7: got 1, want 2"}},
+			"TestOld":     {Name: "TestOld", Package: "example.com/parse", Status: "FAIL", Output: []string{"    parse_test.go:9: still broken"}},
+			"TestIgnored": {Name: "TestIgnored", Package: "example.com/parse", Status: "FAIL", Quarantined: true},
+		},
+		Comparison: &report.DiffData{
+			NewFailures:  []report.TestChange{{Name: "TestCache"}, {Name: "TestDB"}, {Name: "TestParse"}, {Name: "TestIgnored"}},
+			StillFailing: []report.TestChange{{Name: "TestOld"}},
+		},
+	}
+}
+
+func TestNewFailureIssues(t *testing.T) {
+	issues := newFailureIssues(issuesTestData())
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.title())
+	}
+	want := []string{"Test failure: TestCache and 1 more test", "Test failure: TestParse"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues: got %q, want %q", got, want)
+	}
+
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_REPOSITORY", "acme/app")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("CI_JOB_URL", "")
+	body := issues[0].body(defaultConfig())
+	for _, want := range []string{
+		fmt.Sprintf(issueMarker, issues[0].fingerprint) + "\n",
+		"[workflow run](https://github.com/acme/app/actions/runs/42)",
+		"| `TestCache` | example.com/store |\n| `TestDB` | example.com/store |",
+		"> `dial tcp <addr>: connection refused` (`db_test.go:12`)",
+		"```text\n=== RUN   TestDB\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body: missing %q in\n%s", want, body)
+		}
+	}
+
+	uploaded := issuesTestData()
+	uploaded.ReportURL = "https://reports.example.com/run.html"
+	if body := newFailureIssues(uploaded)[0].body(defaultConfig()); !strings.Contains(body, "([full report](https://reports.example.com/run.html)).") {
+		t.Errorf("body: missing the uploaded report in\n%s", body)
+	}
+
+	if issues := newFailureIssues(&ReportData{Results: issuesTestData().Results}); issues != nil {
+		t.Errorf("without a baseline: got %d issues, want none", len(issues))
+	}
+}
+
+func TestFileIssues(t *testing.T) {
+	issues := newFailureIssues(issuesTestData())
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		calls = append(calls, r.Method+" "+r.URL.RequestURI())
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode([]githubIssue{
+				{Number: 3, Body: fmt.Sprintf(issueMarker, issues[1].fingerprint), PullRequest: &struct{}{}},
+				{Number: 7, Body: fmt.Sprintf(issueMarker, issues[1].fingerprint) + "\nold output"},
+			})
+		case http.MethodPost:
+			var request struct {
+				Title  string
+				Labels []string
+			}
+			json.Unmarshal(body, &request)
+			if request.Title != issues[0].title() || strings.Join(request.Labels, ",") != "test-failure,ci" {
+				t.Errorf("new issue: got %s", body)
+			}
+			w.Write([]byte(`{"number": 8}`))
+		case http.MethodPatch:
+			w.Write([]byte(`{"number": 7}`))
+		}
+	}))
+	defer server.Close()
+
+	client := &githubClient{api: server.URL, repo: "acme/app", token: "tok", http: server.Client()}
+	if err := client.fileIssues(issues, []string{"test-failure", "ci"}, defaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"GET /repos/acme/app/issues?labels=test-failure&page=1&per_page=100&state=open",
+		"POST /repos/acme/app/issues",
+		"PATCH /repos/acme/app/issues/7",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls: got\n%s\nwant\n%s", strings.Join(calls, "\n"), strings.Join(want, "\n"))
+	}
+
+	client.token = "wrong"
+	if err := client.fileIssues(issues, nil, defaultConfig()); err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("got error %v, want the response body in it", err)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// jiraOutputLines is how much of a failed test's output goes into its code
+// block; the end, where Go prints the failure, is kept. Jira limits comments
+// and descriptions to 32,767 characters.
+const jiraOutputLines = 50
+
+// renderJiraReport renders a failure summary in Jira wiki markup, to paste
+// into an issue or post through the Jira API
+func renderJiraReport(data *ReportData, cfg *config) string {
+	var sb strings.Builder
+	sb.WriteString("h1. Test Summary Report\n\n")
+
+	if gate := data.QualityGate; gate != nil && !gate.Passed {
+		sb.WriteString("{panel:title=Quality gate failed|borderColor=#cf222e|titleBGColor=#ffebe9}\n")
+		for _, violation := range gate.Violations {
+			sb.WriteString(fmt.Sprintf("* %s\n", jiraEscape(violation)))
+		}
+		sb.WriteString("{panel}\n\n")
+	}
+
+	passRate := "N/A"
+	if data.TotalTests > 0 {
+		passRate = fmt.Sprintf("%.1f%%", float64(data.PassedTests)/float64(data.TotalTests)*100)
+	}
+	sb.WriteString("||Total||Passed||Failed||Skipped||Flaky||Pass Rate||Duration||\n")
+	sb.WriteString(fmt.Sprintf("|%d|%d|%d|%d|%d|%s|%.2fs|\n\n",
+		data.TotalTests, data.PassedTests, data.FailedTests, data.SkippedTests, data.FlakyTests, passRate, data.TotalDuration))
+
+	if data.FailedTests == 0 {
+		sb.WriteString("(/) All tests passed.\n\n")
+		writeJiraIntegrity(&sb, data.Integrity)
+		return sb.String()
+	}
+
+	// A parent fails along with its subtests; the subtests tell what broke
+	var failed []*TestResult
+	for _, result := range data.Results {
+		if result.Status == "FAIL" && !hasFailedSubTest(data, result) {
+			failed = append(failed, result)
+		}
+	}
+	sort.Slice(failed, func(i, j int) bool {
+		if failed[i].Package != failed[j].Package {
+			return failed[i].Package < failed[j].Package
+		}
+		return failed[i].Name < failed[j].Name
+	})
+
+	sb.WriteString("h2. (x) Failed Tests\n\n")
+	for _, result := range failed {
+		title := result.Name
+		if result.Package != "" {
+			title += " (" + cfg.packageName(result.Package) + ")"
+		}
+		sb.WriteString(fmt.Sprintf("{panel:title=%s|borderColor=#cf222e}\n", jiraPanelTitle(title)))
+		excerpt := report.ExtractExcerpt(result.Output)
+		if excerpt.Assertion != "" {
+			location := ""
+			if excerpt.File != "" {
+				location = fmt.Sprintf(" at {{%s:%d}}", jiraEscape(excerpt.File), excerpt.Line)
+			}
+			sb.WriteString(fmt.Sprintf("*%s*%s\n", jiraEscape(excerpt.Assertion), location))
+		}
+		writeJiraOutput(&sb, result.Output)
+		sb.WriteString("{panel}\n\n")
+	}
+	writeJiraIntegrity(&sb, data.Integrity)
+	return sb.String()
+}
+
+// writeJiraIntegrity writes the integrity summary in a noformat block, as
+// wiki markup has no comments to hide it in
+func writeJiraIntegrity(sb *strings.Builder, integrity report.Integrity) {
+	encoded, err := json.Marshal(integrity.Summary())
+	if err != nil {
+		return
+	}
+	// A test name holding "{noformat}" would end the block early
+	line := strings.ReplaceAll(string(encoded), "{noformat}", `\u007bnoformat}`)
+	sb.WriteString("{noformat}\ngotest-report:integrity " + line + "\n{noformat}\n")
+}
+
+func hasFailedSubTest(data *ReportData, result *TestResult) bool {
+	for _, name := range result.SubTests {
+		if sub := data.Results[name]; sub != nil && sub.Status == "FAIL" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJiraOutput writes the end of a test's output as a literal block
+func writeJiraOutput(sb *strings.Builder, output []string) {
+	if len(output) == 0 {
+		return
+	}
+	lines := output
+	if len(lines) > jiraOutputLines {
+		sb.WriteString(fmt.Sprintf("_%d earlier output lines omitted_\n", len(lines)-jiraOutputLines))
+		lines = lines[len(lines)-jiraOutputLines:]
+	}
+	sb.WriteString("{noformat}\n")
+	for _, line := range lines {
+		// A literal {noformat} would end the block early
+		sb.WriteString(strings.ReplaceAll(strings.TrimRight(line, "\n"), "{noformat}", "{ noformat}") + "\n")
+	}
+	sb.WriteString("{noformat}\n")
+}
+
+// jiraEscaper escapes the characters Jira wiki markup would read as
+// formatting, links or macros
+var jiraEscaper = strings.NewReplacer(
+	`\`, `\\`, "{", `\{`, "}", `\}`, "[", `\[`, "]", `\]`, "|", `\|`,
+	"*", `\*`, "_", `\_`, "+", `\+`, "^", `\^`, "~", `\~`, "-", `\-`,
+)
+
+// jiraEscape makes text appear as is in Jira wiki markup
+func jiraEscape(text string) string {
+	return jiraEscaper.Replace(text)
+}
+
+// jiraPanelTitle makes text safe as a panel title, where | and = separate
+// parameters and escapes aren't applied
+func jiraPanelTitle(text string) string {
+	return strings.NewReplacer("|", "/", "=", "-", "}", ")", "{", "(").Replace(text)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRenderJiraReport(t *testing.T) {
+	output := []string{"=== RUN   TestA/case"}
+	for i := 0; i < 60; i++ {
+		output = append(output, fmt.Sprintf("    log line %d", i))
+	}
+	output = append(output, "    a_test.go:12: got [1], want {2}", "    {noformat}", "--- FAIL: TestA/case (0.10s)")
+	data := &ReportData{
+		TotalTests: 3, PassedTests: 1, FailedTests: 1, SkippedTests: 1, TotalDuration: 1.5,
+		Results: map[string]*TestResult{
+			"TestA":      {Name: "TestA", Package: "example.com/a", Status: "FAIL", SubTests: []string{"TestA/case"}},
+			"TestA/case": {Name: "TestA/case", Package: "example.com/a", Status: "FAIL", IsSubTest: true, Output: output},
+			"TestB":      {Name: "TestB", Package: "example.com/b", Status: "PASS"},
+		},
+	}
+
+	jira := renderJiraReport(data, defaultConfig())
+	for _, want := range []string{
+		"h1. Test Summary Report\n\n",
+		"||Total||Passed||Failed||Skipped||Flaky||Pass Rate||Duration||\n|3|1|1|1|0|33.3%|1.50s|\n",
+		"{panel:title=TestA/case (example.com/a)|borderColor=#cf222e}\n*got \\[1\\], want \\{2\\}* at {{a\\_test.go:12}}\n",
+		"_14 earlier output lines omitted_\n{noformat}\n    log line 13\n",
+		"    { noformat}\n--- FAIL: TestA/case (0.10s)\n{noformat}\n{panel}\n",
+	} {
+		if !strings.Contains(jira, want) {
+			t.Errorf("Jira report missing %q:\n%s", want, jira)
+		}
+	}
+	if strings.Contains(jira, "title=TestA (") {
+		t.Errorf("the parent of a failed subtest should not get a panel:\n%s", jira)
+	}
+
+	if !strings.HasSuffix(jira, "{noformat}\ngotest-report:integrity "+
+		`{"eventsParsed":0,"linesSkipped":0,"incompleteTests":[],"truncations":[],"complete":true}`+"\n{noformat}\n") {
+		t.Errorf("Jira report should end with the integrity summary:\n%s", jira)
+	}
+
+	passed := renderJiraReport(&ReportData{TotalTests: 1, PassedTests: 1}, defaultConfig())
+	if !strings.Contains(passed, "(/) All tests passed.\n\n{noformat}\ngotest-report:integrity ") {
+		t.Errorf("passing run:\n%s", passed)
+	}
+}
+
+func TestJiraEscape(t *testing.T) {
+	if got, want := jiraEscape(`a*b_c{d}[e]|f-g\h`), `a\*b\_c\{d\}\[e\]\|f\-g\\h`; got != want {
+		t.Errorf("jiraEscape: got %s, want %s", got, want)
+	}
+	if got, want := jiraPanelTitle("TestX/a=b|c"), "TestX/a-b/c"; got != want {
+		t.Errorf("jiraPanelTitle: got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// jiraTicketLabel is on every ticket filed for a persistent failure
+const jiraTicketLabel = "gotest-report"
+
+// jiraConfig is the "jira" section of the config file: where tickets for
+// persistent failures are filed
+type jiraConfig struct {
+	URL       string   `json:"url"`       // e.g. https://acme.atlassian.net
+	Project   string   `json:"project"`   // project key, e.g. PLAT
+	IssueType string   `json:"issueType"` // default Bug
+	After     int      `json:"after"`     // consecutive failing runs before filing; default 3
+	Labels    []string `json:"labels"`    // added to gotest-report and the test's own label
+	// Components maps import paths, or import path prefixes, to the Jira
+	// component owning them, like package display names
+	Components []packageMapping `json:"components"`
+}
+
+// validate fills in defaults and checks the section is usable
+func (j *jiraConfig) validate() error {
+	if j.URL == "" || j.Project == "" {
+		return fmt.Errorf("jira needs url and project")
+	}
+	if j.IssueType == "" {
+		j.IssueType = "Bug"
+	}
+	if j.After == 0 {
+		j.After = 3
+	}
+	if j.After < 1 {
+		return fmt.Errorf("jira after must be at least 1")
+	}
+	for _, mapping := range j.Components {
+		if mapping.Match == "" || mapping.Name == "" {
+			return fmt.Errorf("jira component mappings need both match and name")
+		}
+	}
+	return nil
+}
+
+// component returns the component owning pkg, the longest matching
+// mapping, or "" when none does
+func (j *jiraConfig) component(pkg string) string {
+	component, _ := matchPackage(j.Components, pkg)
+	return component
+}
+
+// persistentFailures returns the failures of data that also failed in each
+// of the runs before it, per history, which already holds this run
+func persistentFailures(data *ReportData, history *report.History, runs int) []report.Failure {
+	var persistent []report.Failure
+	for _, failure := range report.Failures(data) {
+		if failure.Quarantined {
+			continue
+		}
+		statuses := history.Statuses(failure.Name, runs)
+		if len(statuses) < runs {
+			continue
+		}
+		failing := true
+		for _, status := range statuses {
+			failing = failing && status == "FAIL"
+		}
+		if failing {
+			persistent = append(persistent, failure)
+		}
+	}
+	return persistent
+}
+
+// jiraTestLabel identifies the ticket of one test, as JQL can match labels
+// exactly but not summaries
+func jiraTestLabel(failure report.Failure) string {
+	return fmt.Sprintf("gotest-%x", sha256.Sum256([]byte(failure.Package+"\x00"+failure.Name)))[:19]
+}
+
+// jiraClient calls the Jira REST API (version 2, whose text fields take wiki
+// markup) as a user with an API token on Jira Cloud, or with a personal
+// access token on Jira Data Center
+type jiraClient struct {
+	cfg   *jiraConfig
+	user  string
+	token string
+	http  *http.Client
+}
+
+// jiraFromEnv returns a client for cfg with the credentials in JIRA_USER and
+// JIRA_API_TOKEN, or nil when no token is set
+func jiraFromEnv(cfg *jiraConfig) *jiraClient {
+	token := os.Getenv("JIRA_API_TOKEN")
+	if token == "" {
+		return nil
+	}
+	return &jiraClient{cfg: cfg, user: os.Getenv("JIRA_USER"), token: token, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// fileTickets creates a ticket for each persistent failure, or comments on
+// the open one when the failure already has a ticket
+func (c *jiraClient) fileTickets(failures []report.Failure, cfg *config) error {
+	for _, failure := range failures {
+		label := jiraTestLabel(failure)
+		key, err := c.openTicket(label)
+		if err != nil {
+			return fmt.Errorf("searching for the ticket of %s: %w", failure.Name, err)
+		}
+		description := jiraTicketDescription(failure, c.cfg.After, cfg)
+		if key != "" {
+			if err := c.do(http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(key)+"/comment", map[string]string{"body": description}, nil); err != nil {
+				return fmt.Errorf("commenting on %s: %w", key, err)
+			}
+			logger.Info("updated Jira ticket for persistent failure", "ticket", key, "test", failure.Name)
+			continue
+		}
+
+		fields := map[string]any{
+			"project":     map[string]string{"key": c.cfg.Project},
+			"issuetype":   map[string]string{"name": c.cfg.IssueType},
+			"summary":     truncateRunes(fmt.Sprintf("%s keeps failing in %s", failure.Name, cfg.packageName(failure.Package)), 255),
+			"description": description,
+			"labels":      append([]string{jiraTicketLabel, label}, c.cfg.Labels...),
+		}
+		if component := c.cfg.component(failure.Package); component != "" {
+			fields["components"] = []map[string]string{{"name": component}}
+		}
+		var created struct {
+			Key string `json:"key"`
+		}
+		if err := c.do(http.MethodPost, "/rest/api/2/issue", map[string]any{"fields": fields}, &created); err != nil {
+			return fmt.Errorf("creating a ticket for %s: %w", failure.Name, err)
+		}
+		logger.Info("created Jira ticket for persistent failure", "ticket", created.Key, "test", failure.Name)
+	}
+	return nil
+}
+
+// openTicket returns the key of the unresolved ticket with label, or ""
+func (c *jiraClient) openTicket(label string) (string, error) {
+	// Jira Cloud replaced /search with /search/jql; Data Center only has /search
+	endpoint := "/rest/api/2/search"
+	if host, err := url.Parse(c.cfg.URL); err == nil && strings.HasSuffix(host.Hostname(), ".atlassian.net") {
+		endpoint += "/jql"
+	}
+	query := url.Values{
+		"jql":        {fmt.Sprintf(`project = "%s" AND labels = "%s" AND resolution = Unresolved ORDER BY created DESC`, c.cfg.Project, label)},
+		"fields":     {"key"},
+		"maxResults": {"1"},
+	}
+	var found struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	if err := c.do(http.MethodGet, endpoint+"?"+query.Encode(), nil, &found); err != nil {
+		return "", err
+	}
+	if len(found.Issues) == 0 {
+		return "", nil
+	}
+	return found.Issues[0].Key, nil
+}
+
+// jiraTicketDescription describes a persistent failure in wiki markup
+func jiraTicketDescription(failure report.Failure, runs int, cfg *config) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("{{%s}} in {{%s}} failed in the last %d recorded runs", jiraEscape(failure.Name), jiraEscape(cfg.packageName(failure.Package)), runs))
+	if text, link := workflowRun(); link != "" {
+		sb.WriteString(fmt.Sprintf(", most recently in the [%s|%s]", text, link))
+	}
+	sb.WriteString(".\n\n")
+	if failure.Excerpt.Assertion != "" {
+		location := ""
+		if failure.Excerpt.File != "" {
+			location = fmt.Sprintf(" at {{%s:%d}}", jiraEscape(failure.Excerpt.File), failure.Excerpt.Line)
+		}
+		sb.WriteString(fmt.Sprintf("*%s*%s\n", jiraEscape(failure.Excerpt.Assertion), location))
+	}
+	writeJiraOutput(&sb, failure.Output)
+	return sb.String()
+}
+
+// do sends a request with a JSON body and decodes the JSON response into out
+func (c *jiraClient) do(method, path string, in, out any) error {
+	_, err := doJSON(c.http, method, strings.TrimSuffix(c.cfg.URL, "/")+path, in, out, func(req *http.Request) {
+		req.Header.Set("Accept", "application/json")
+		if c.user != "" {
+			req.SetBasicAuth(c.user, c.token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+	})
+	return err
+}
+
+// fileJiraTickets files tickets for the persistent failures of data when
+// the config has a jira section. Telling persistent failures needs the
+// history, already holding this run, of the runs under the same sanitizer.
+func fileJiraTickets(data *ReportData, history *report.History, cfg *config) error {
+	if cfg.Jira == nil {
+		return nil
+	}
+	if history == nil {
+		logger.Warn("not filing Jira tickets: the jira config needs -history to tell persistent failures")
+		return nil
+	}
+	client := jiraFromEnv(cfg.Jira)
+	if client == nil {
+		logger.Warn("not filing Jira tickets: JIRA_API_TOKEN is not set")
+		return nil
+	}
+	return client.fileTickets(persistentFailures(data, history, cfg.Jira.After), cfg)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestPersistentFailures(t *testing.T) {
+	history := &report.History{}
+	record := func(statuses map[string]string) {
+		data := &ReportData{Results: map[string]*TestResult{}}
+		for name, status := range statuses {
+			data.Results[name] = &TestResult{Name: name, Status: status}
+		}
+		history.Record(data, time.Now(), "", 0)
+	}
+	record(map[string]string{"TestA": "FAIL", "TestB": "PASS", "TestC": "FAIL"})
+	record(map[string]string{"TestA": "FAIL", "TestB": "FAIL", "TestC": "FAIL"})
+	record(map[string]string{"TestA": "FAIL", "TestB": "FAIL", "TestC": "FAIL", "TestD": "FAIL"})
+
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestA": {Name: "TestA", Status: "FAIL"},
+		"TestB": {Name: "TestB", Status: "FAIL"},
+		"TestC": {Name: "TestC", Status: "FAIL", Quarantined: true},
+		"TestD": {Name: "TestD", Status: "FAIL"},
+	}}
+	var got []string
+	for _, failure := range persistentFailures(data, history, 3) {
+		got = append(got, failure.Name)
+	}
+	if strings.Join(got, ",") != "TestA" {
+		t.Errorf("got %v, want [TestA]", got)
+	}
+}
+
+func TestLoadConfigJira(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"defaults", `{"jira": {"url": "https://acme.atlassian.net", "project": "PLAT"}}`, ""},
+		{"no project", `{"jira": {"url": "https://acme.atlassian.net"}}`, "jira needs url and project"},
+		{"negative after", `{"jira": {"url": "https://acme.atlassian.net", "project": "PLAT", "after": -1}}`, "at least 1"},
+		{"component", `{"jira": {"url": "https://acme.atlassian.net", "project": "PLAT", "components": [{"match": "x"}]}}`, "match and name"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name+".json")
+		os.WriteFile(path, []byte(tt.content), 0o644)
+		cfg, err := loadConfig(path)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: got error %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if cfg.Jira.IssueType != "Bug" || cfg.Jira.After != 3 {
+			t.Errorf("%s: got issue type %q and after %d, want Bug and 3", tt.name, cfg.Jira.IssueType, cfg.Jira.After)
+		}
+	}
+}
+
+func TestJiraFileTickets(t *testing.T) {
+	failures := []report.Failure{
+		{Name: "TestCharge", Package: "example.com/billing/stripe", Excerpt: report.Excerpt{Assertion: "got 402", File: "stripe_test.go", Line: 12}, Output: []string{"    stripe_test.go:12: got 402"}},
+		{Name: "TestLogin", Package: "example.com/auth"},
+	}
+	existing := jiraTestLabel(failures[1])
+
+	var calls []string
+	var created map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, _ := r.BasicAuth(); user != "bot@acme.com" || token != "tok" {
+			http.Error(w, `{"errorMessages":["unauthorized"]}`, http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/rest/api/2/search":
+			if strings.Contains(r.URL.Query().Get("jql"), existing) {
+				w.Write([]byte(`{"issues": [{"key": "PLAT-7"}]}`))
+			} else {
+				w.Write([]byte(`{"issues": []}`))
+			}
+		case r.URL.Path == "/rest/api/2/issue":
+			json.Unmarshal(body, &created)
+			w.Write([]byte(`{"key": "PLAT-8"}`))
+		case strings.HasSuffix(r.URL.Path, "/comment"):
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("GITHUB_RUN_ID", "")
+	t.Setenv("CI_JOB_URL", "")
+	t.Setenv("JIRA_USER", "bot@acme.com")
+	t.Setenv("JIRA_API_TOKEN", "tok")
+	client := jiraFromEnv(&jiraConfig{URL: server.URL, Project: "PLAT", IssueType: "Bug", After: 3, Labels: []string{"flaky"},
+		Components: []packageMapping{{Match: "example.com/billing", Name: "Billing"}}})
+	if err := client.fileTickets(failures, defaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"GET /rest/api/2/search",
+		"POST /rest/api/2/issue",
+		"GET /rest/api/2/search",
+		"POST /rest/api/2/issue/PLAT-7/comment",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls: got\n%s\nwant\n%s", strings.Join(calls, "\n"), strings.Join(want, "\n"))
+	}
+	fields, _ := created["fields"].(map[string]any)
+	encoded, _ := json.Marshal(fields)
+	for _, want := range []string{
+		`"components":[{"name":"Billing"}]`,
+		`"labels":["gotest-report","` + jiraTestLabel(failures[0]) + `","flaky"]`,
+		`"summary":"TestCharge keeps failing in example.com/billing/stripe"`,
+		`failed in the last 3 recorded runs.\n\n*got 402* at {{stripe\\_test.go:12}}\n{noformat}`,
+	} {
+		if !strings.Contains(string(encoded), want) {
+			t.Errorf("created ticket: missing %s in %s", want, encoded)
+		}
+	}
+
+	client.token = "wrong"
+	if err := client.fileTickets(failures, defaultConfig()); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("got error %v, want the response body in it", err)
+	}
+}
+
+func TestJiraFromEnv(t *testing.T) {
+	t.Setenv("JIRA_API_TOKEN", "")
+	if client := jiraFromEnv(&jiraConfig{}); client != nil {
+		t.Error("got a client without JIRA_API_TOKEN")
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// JUnit XML as understood by CI servers (Jenkins, GitLab, Azure DevOps, ...):
+// one testsuite per package and one testcase per test, subtests included
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// renderJUnit renders data as JUnit XML. Flaky tests count as passed, with
+// their earlier attempts noted in the output; tests that never reported a
+// result are errors.
+func renderJUnit(data *ReportData) (string, error) {
+	packages, byPackage := resultsByPackage(data)
+	suites := junitTestSuites{}
+	var total float64
+	for _, pkg := range packages {
+		results := byPackage[pkg]
+		suite := junitTestSuite{Name: pkg}
+		var duration float64
+		for _, result := range results {
+			suite.Cases = append(suite.Cases, junitCase(result))
+			suite.Tests++
+			switch result.Status {
+			case "FAIL":
+				suite.Failures++
+			case "SKIP":
+				suite.Skipped++
+			case "PASS", "FLAKY":
+			default:
+				suite.Errors++
+			}
+			if !result.IsSubTest {
+				duration += result.Duration
+			}
+		}
+		suite.Time = junitTime(duration)
+		total += duration
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+	suites.Time = junitTime(total)
+
+	encoded, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(encoded) + "\n" + integrityComment(data.Integrity), nil
+}
+
+// resultsByPackage groups the tests by package, returning the packages and
+// each package's tests in name order
+func resultsByPackage(data *ReportData) ([]string, map[string][]*TestResult) {
+	byPackage := make(map[string][]*TestResult)
+	for _, result := range data.Results {
+		byPackage[result.Package] = append(byPackage[result.Package], result)
+	}
+	packages := make([]string, 0, len(byPackage))
+	for pkg, results := range byPackage {
+		packages = append(packages, pkg)
+		sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	}
+	sort.Strings(packages)
+	return packages, byPackage
+}
+
+func junitCase(result *TestResult) junitTestCase {
+	testCase := junitTestCase{
+		Name:      result.Name,
+		Classname: result.Package,
+		Time:      junitTime(result.Duration),
+	}
+	output := strings.Join(result.Output, "\n")
+
+	switch result.Status {
+	case "FAIL":
+		message := report.ExtractExcerpt(result.Output).Assertion
+		if message == "" {
+			message = "test failed"
+		}
+		testCase.Failure = &junitMessage{Message: message, Type: "failure", Body: output}
+	case "SKIP":
+		testCase.Skipped = &junitMessage{Message: result.SkipReason}
+	case "FLAKY":
+		testCase.SystemOut = fmt.Sprintf("Passed on attempt %d after failing %d times\n", len(result.Attempts)+1, len(result.Attempts))
+		if output != "" {
+			testCase.SystemOut += output
+		}
+	case "PASS":
+		testCase.SystemOut = output
+	default:
+		testCase.Error = &junitMessage{Message: "test did not report a result", Type: "incomplete", Body: output}
+	}
+	return testCase
+}
+
+// junitTime formats seconds the way JUnit consumers expect
+func junitTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
+
+// junitCommand implements `gotest-report junit [flags]`, a shorthand for
+// report -format junit for pipelines that only need the XML
+func junitCommand(args []string) int {
+	fs := flag.NewFlagSet("junit", flag.ExitOnError)
+	inputs := addInputFlags(fs)
+	outputFile := fs.String("output", "junit.xml", "Output XML file (use - for stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	cfg := defaultConfig()
+	inputs.configure(cfg)
+	runs, err := inputs.load(cfg, "junit")
+	if err != nil {
+		logger.Error("processing test events", "error", err)
+		return 1
+	}
+	data := combineRuns(runs)
+	warnSkippedLines(data)
+
+	content, err := renderJUnit(data)
+	if err != nil {
+		logger.Error("rendering report", "error", err)
+		return 1
+	}
+	if err := writeReport(*outputFile, content); err != nil {
+		logger.Error("writing report", "error", err)
+		return 1
+	}
+	if *outputFile != "-" {
+		fmt.Printf("JUnit report generated successfully: %s\n", *outputFile)
+	}
+	return 0
+}
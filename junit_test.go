@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderJUnit(t *testing.T) {
+	data, err := processTestEvents(strings.NewReader(`
+{"Action":"run","Package":"example.com/a","Test":"TestPass"}
+{"Action":"output","Package":"example.com/a","Test":"TestPass","Output":"    a_test.go:5: connected\n"}
+{"Action":"pass","Package":"example.com/a","Test":"TestPass","Elapsed":0.25}
+{"Action":"run","Package":"example.com/a","Test":"TestFail"}
+{"Action":"output","Package":"example.com/a","Test":"TestFail","Output":"    a_test.go:12: got 1, want 2 <\u001b>\n"}
+{"Action":"fail","Package":"example.com/a","Test":"TestFail","Elapsed":0.5}
+{"Action":"run","Package":"example.com/b","Test":"TestSkip"}
+{"Action":"output","Package":"example.com/b","Test":"TestSkip","Output":"    b_test.go:3: needs docker\n"}
+{"Action":"skip","Package":"example.com/b","Test":"TestSkip"}
+{"Action":"run","Package":"example.com/b","Test":"TestHang"}
+`), parseOptions(defaultConfig(), "junit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := renderJUnit(data)
+	if err != nil {
+		t.Fatalf("renderJUnit: %v", err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal([]byte(content), &suites); err != nil {
+		t.Fatalf("decoding JUnit XML: %v\n%s", err, content)
+	}
+	if suites.Tests != 4 || suites.Failures != 1 || suites.Skipped != 1 || suites.Errors != 1 || suites.Time != "0.750" {
+		t.Errorf("totals: got %+v", suites)
+	}
+	if len(suites.Suites) != 2 || suites.Suites[0].Name != "example.com/a" || suites.Suites[1].Name != "example.com/b" {
+		t.Fatalf("suites: got %+v", suites.Suites)
+	}
+
+	fail := suites.Suites[0].Cases[0]
+	if fail.Name != "TestFail" || fail.Classname != "example.com/a" || fail.Time != "0.500" || fail.Failure == nil {
+		t.Fatalf("failing test case: got %+v", fail)
+	}
+	if fail.Failure.Message != "got 1, want 2 <�>" || !strings.Contains(fail.Failure.Body, "a_test.go:12") {
+		t.Errorf("failure: got %+v", fail.Failure)
+	}
+	if pass := suites.Suites[0].Cases[1]; !strings.Contains(pass.SystemOut, "a_test.go:5: connected") {
+		t.Errorf("passing test case: got system-out %q, want its output", pass.SystemOut)
+	}
+	if hang := suites.Suites[1].Cases[0]; hang.Name != "TestHang" || hang.Error == nil {
+		t.Errorf("unfinished test case: got %+v", hang)
+	}
+	if skip := suites.Suites[1].Cases[1]; skip.Skipped == nil || skip.Skipped.Message != "needs docker" {
+		t.Errorf("skipped test case: got %+v", skip)
+	}
+}
+
+func TestJUnitCommand(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "test.json")
+	content := `{"Action":"run","Package":"example.com/a","Test":"TestA"}` + "\n" +
+		`{"Action":"pass","Package":"example.com/a","Test":"TestA","Elapsed":0.1}` + "\n"
+	if err := os.WriteFile(input, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "junit.xml")
+
+	if code := junitCommand([]string{"-input", input, "-output", output}); code != 0 {
+		t.Fatalf("exit code: got %d, want 0", code)
+	}
+	written, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(written), `<testcase name="TestA" classname="example.com/a" time="0.100"></testcase>`) {
+		t.Errorf("unexpected JUnit output:\n%s", written)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// logger reports errors and warnings on stderr and, with -log-level debug,
+// what was parsed from where. Commands set it up from their -log-level and
+// -log-format flags.
+var logger = slog.New(newTextLogHandler(os.Stderr, slog.LevelInfo))
+
+// parseFlags parses the flags of a command, adding the logging flags every
+// command has, and sets up logger from them
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	level := fs.String("log-level", "info", "Least severe messages logged to stderr: debug, info, warn or error")
+	format := fs.String("log-format", "text", "Format of messages on stderr: text or json (one object per line)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	handler, err := newLogHandler(os.Stderr, *level, *format)
+	if err != nil {
+		return err
+	}
+	logger = slog.New(handler)
+	return nil
+}
+
+// newLogHandler returns the handler for the -log-level and -log-format flags
+func newLogHandler(w io.Writer, level, format string) (slog.Handler, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown -log-level %q (want debug, info, warn or error)", level)
+	}
+	switch format {
+	case "text":
+		return newTextLogHandler(w, l), nil
+	case "json":
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: l}), nil
+	}
+	return nil, fmt.Errorf("unknown -log-format %q (want text or json)", format)
+}
+
+// textLogHandler writes one line per message meant to be read by people:
+// "Error: loading config: open x: no such file", with the "error" attribute
+// after a colon and any other attributes as key=value pairs. Groups are
+// flattened into dotted keys.
+type textLogHandler struct {
+	w     io.Writer
+	mu    *sync.Mutex
+	level slog.Level
+	attrs []slog.Attr
+	group string
+}
+
+func newTextLogHandler(w io.Writer, level slog.Level) *textLogHandler {
+	return &textLogHandler{w: w, mu: &sync.Mutex{}, level: level}
+}
+
+func (h *textLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textLogHandler) Handle(_ context.Context, r slog.Record) error {
+	var sb strings.Builder
+	switch {
+	case r.Level >= slog.LevelError:
+		sb.WriteString("Error: ")
+	case r.Level >= slog.LevelWarn:
+		sb.WriteString("Warning: ")
+	case r.Level < slog.LevelInfo:
+		sb.WriteString("Debug: ")
+	}
+	sb.WriteString(r.Message)
+
+	var pairs []string
+	add := func(a slog.Attr) bool {
+		value := a.Value.Resolve().String()
+		if a.Key == "error" {
+			sb.WriteString(": " + value)
+			return true
+		}
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		pairs = append(pairs, a.Key+"="+value)
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		if h.group != "" {
+			a.Key = h.group + a.Key
+		}
+		return add(a)
+	})
+	for _, pair := range pairs {
+		sb.WriteString(" " + pair)
+	}
+	sb.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, sb.String())
+	return err
+}
+
+func (h *textLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		a.Key = h.group + a.Key
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+func (h *textLogHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.group = h.group + name + "."
+	return &clone
+}
+
+// logParseStats logs what was parsed from source at debug level, to see
+// whether a CI integration feeds the tool what it should
+func logParseStats(source string, data *ReportData) {
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	packages := make(map[string]bool)
+	for _, result := range data.Results {
+		packages[result.Package] = true
+	}
+	attrs := []any{
+		"source", source,
+		"events", data.Integrity.EventsParsed,
+		"linesSkipped", data.Integrity.LinesSkipped,
+		"tests", data.TotalTests,
+		"packages", len(packages),
+		"incompleteTests", len(data.Integrity.IncompleteTests),
+	}
+	if data.Metrics != nil {
+		attrs = append(attrs, "inputBytes", data.Metrics.InputBytes, "parseSeconds", data.Metrics.ParseSeconds)
+	}
+	logger.Debug("parsed input", attrs...)
+}
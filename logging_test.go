@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestTextLogHandler(t *testing.T) {
+	var out strings.Builder
+	handler, err := newLogHandler(&out, "warn", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := logger
+	defer func() { logger = saved }()
+	logger = slog.New(handler)
+
+	logger.Info("not shown")
+	logger.Error("loading config", "error", errors.New("open x.json: no such file"), "path", "x.json")
+	logger.Warn("skipped input lines", "lines", 3, "source", "make output")
+	logger.With("run", 2).WithGroup("gate").Error("quality gate failed", "violations", "")
+
+	want := "Error: loading config: open x.json: no such file path=x.json\n" +
+		"Warning: skipped input lines lines=3 source=\"make output\"\n" +
+		"Error: quality gate failed run=2 gate.violations=\"\"\n"
+	if got := out.String(); got != want {
+		t.Errorf("log output:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestNewLogHandler(t *testing.T) {
+	var out strings.Builder
+	handler, err := newLogHandler(&out, "debug", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := logger
+	defer func() { logger = saved }()
+	logger = slog.New(handler)
+
+	logParseStats("test.json", &ReportData{TotalTests: 2, Results: map[string]*TestResult{
+		"TestA": {Name: "TestA", Package: "example.com/a"},
+		"TestB": {Name: "TestB", Package: "example.com/b"},
+	}})
+	for _, want := range []string{`"level":"DEBUG"`, `"msg":"parsed input"`, `"source":"test.json"`, `"tests":2`, `"packages":2`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("log output missing %s:\n%s", want, out.String())
+		}
+	}
+
+	for _, tc := range []struct{ level, format string }{{"loud", "text"}, {"info", "xml"}} {
+		if _, err := newLogHandler(&out, tc.level, tc.format); err == nil {
+			t.Errorf("newLogHandler(%q, %q): expected an error", tc.level, tc.format)
+		}
+	}
+}
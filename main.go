@@ -1,231 +1,327 @@
 package main
 
 import (
-	"bufio"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"html"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// Aliases for the report model so the CLI can keep referring to it unqualified.
+type (
+	TestEvent  = report.TestEvent
+	TestResult = report.TestResult
+	ReportData = report.ReportData
 )
 
-var version = "dev"
-
-// TestEvent represents a single event from go test -json output
-type TestEvent struct {
-	Time    time.Time // Time when the event occurred
-	Action  string    // Action: "run", "pause", "cont", "pass", "bench", "fail", "skip", "output"
-	Test    string    // Test name
-	Package string    // Package being tested
-	Output  string    // Output text (for "output" action)
-	Elapsed float64   // Elapsed time in seconds for "pass" or "fail" events
-}
-
-// TestResult holds the aggregated result for a single test
-type TestResult struct {
-	Name       string
-	Package    string
-	Status     string // "PASS", "FAIL", "SKIP"
-	Duration   float64
-	Output     []string
-	ParentTest string // For subtests
-	SubTests   []string
-	IsSubTest  bool
-}
-
-// ReportData contains all data needed for the report
-type ReportData struct {
-	TotalTests      int
-	PassedTests     int
-	FailedTests     int
-	SkippedTests    int
-	TotalDuration   float64
-	Results         map[string]*TestResult
-	SortedTestNames []string
+// command is a subcommand of the CLI
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+// commands lists the subcommands in the order usage shows them. Arguments
+// that don't start with a known command are those of report, so invocations
+// from before the subcommands keep working. It is set in init, as -version
+// lists the commands and report would otherwise depend on its own table.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"report", "Render a report from go test -json output (the default)", reportCommand},
+		{"run", "Run go test and report on it", runCommand},
+		{"merge", "Merge go test -json files into one event stream", mergeCommand},
+		{"diff", "Compare the tests of two runs", diffCommand},
+		{"benchdiff", "Compare the benchmarks of two runs", benchdiffCommand},
+		{"junit", "Convert go test -json output to JUnit XML", junitCommand},
+		{"serve", "Serve the HTML report over HTTP, re-rendered on every request", serveCommand},
+		{"history", "Summarize the runs recorded in a history file", historyCommand},
+		{"tui", "Browse the tests of a go test -json file in the terminal", tuiCommand},
+	}
 }
 
 func main() {
-	inputFile := flag.String("input", "", "go test -json output file (default is stdin)")
-	outputFile := flag.String("output", "test-report.md", "Output markdown file")
-	showVersion := flag.Bool("version", false, "Show version information")
-	flag.Parse()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "help", "-h", "-help", "--help":
+			printUsage(os.Stdout)
+			os.Exit(0)
+		}
+		for _, cmd := range commands {
+			if os.Args[1] == cmd.name {
+				os.Exit(cmd.run(os.Args[2:]))
+			}
+		}
+	}
+	os.Exit(reportCommand(os.Args[1:]))
+}
 
-	if *showVersion {
-		fmt.Printf("gotest-report version %s\n", version)
-		os.Exit(0)
+// printUsage lists the subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: gotest-report [command] [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun gotest-report <command> -h for the flags of a command.\n")
+}
+
+// reportCommand implements `gotest-report [report] [flags]`, which renders a
+// report from go test output
+func reportCommand(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	inputs := addInputFlags(fs)
+	flags := addReportFlags(fs)
+	showVersion := fs.Bool("version", false, "Show version information")
+	versionJSONFlag := fs.Bool("version-json", false, "Print version, build information and supported formats/features as JSON")
+	jsonVersion := fs.Bool("json", false, "With -version, the same as -version-json")
+	invocations := fs.String("invocations", "merged", "How to report inputs holding several go test invocations: merged or separate")
+	goEnvFile := fs.String("go-env", "", "File holding the output of go env -json on the test machine, for the Environment section (implies -environment)")
+	if err := parseFlags(fs, args); err != nil {
+		logger.Error(err.Error())
+		return 1
 	}
 
-	var reader io.Reader = os.Stdin
-	if *inputFile != "" {
-		file, err := os.Open(*inputFile)
+	if *versionJSONFlag || *showVersion && *jsonVersion {
+		encoded, err := versionJSON()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error opening input file: %v\n", err)
-			os.Exit(1)
+			logger.Error("encoding version", "error", err)
+			return 1
 		}
-		defer file.Close()
-		reader = file
+		fmt.Print(encoded)
+		return 0
+	}
+	if *showVersion {
+		fmt.Print(versionText())
+		return 0
 	}
 
-	reportData, err := processTestEvents(reader)
+	reporter, err := flags.newReporter(os.Stdout)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error processing test events: %v\n", err)
-		os.Exit(1)
+		logger.Error(err.Error())
+		return 1
+	}
+	cfg := reporter.cfg
+	inputs.configure(cfg)
+	switch *invocations {
+	case "merged":
+	case "separate":
+		cfg.separateInvocations = true
+	default:
+		logger.Error(fmt.Sprintf("unknown -invocations value %q (want merged or separate)", *invocations))
+		return 1
 	}
 
-	markdown := generateMarkdownReport(reportData)
-
-	if err := os.WriteFile(*outputFile, []byte(markdown), 0o644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
-		os.Exit(1)
+	runs, err := inputs.load(cfg, *flags.format)
+	if err != nil {
+		logger.Error("processing test events", "error", err)
+		return 1
+	}
+	for _, run := range runs {
+		reporter.clean(run)
+	}
+	reportData := combineRuns(runs)
+	warnSkippedLines(reportData)
+	if *goEnvFile != "" {
+		reportData.Environment, err = report.LoadGoEnv(*goEnvFile)
+		if err != nil {
+			logger.Error("loading go env", "error", err)
+			return 1
+		}
+	} else if *flags.environment {
+		reportData.Environment = report.HostEnvironment()
+	}
+	if err := reporter.process(reportData, runs); err != nil {
+		logger.Error(err.Error())
+		return 1
 	}
 
-	fmt.Printf("Report generated successfully: %s\n", *outputFile)
+	// Keep stdout clean when the report itself is being written there
+	console := io.Writer(os.Stdout)
+	if *flags.output == "-" {
+		console = nil
+	}
+	if err := reporter.publish(reportData, console, inputs.inputs.resolved()); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	if gateFailed(reportData) {
+		return 1
+	}
+	return 0
 }
 
-func processTestEvents(reader io.Reader) (*ReportData, error) {
-	// Use a Scanner with an increased buffer to safely handle long JSON lines from `go test -json`.
-	scanner := bufio.NewScanner(reader)
-	// Set the initial and maximum token size to allow large outputs (up to ~10MB per line).
-	buf := make([]byte, 0, 1024*1024)
-	scanner.Buffer(buf, 10*1024*1024)
-	results := make(map[string]*TestResult)
-	testOutputMap := make(map[string][]string)
-
-	testStartTime := make(map[string]time.Time)
+// checkFormat validates the value of -format
+func checkFormat(format string) error {
+	for _, known := range reportFormats {
+		if format == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown report format %q (want %s)", format, strings.Join(reportFormats, " or "))
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.TrimSpace(line) == "" {
-			// Skip blank lines that can occur in piped or concatenated outputs
-			continue
+// renderReport renders data in the given format. When data carries Metrics,
+// they are completed and shown in a footnote.
+func renderReport(data *ReportData, cfg *config, format string) (string, error) {
+	start := time.Now()
+	var content string
+	switch format {
+	case "pdf":
+		page, err := renderReport(data, cfg, "html")
+		if err != nil {
+			return "", err
 		}
-		var event TestEvent
-		if err := json.Unmarshal([]byte(line), &event); err != nil {
-			return nil, fmt.Errorf("error unmarshalling JSON: %v", err)
+		return htmlToPDF(page)
+	case "json":
+		encoded, err := data.JSON()
+		if err != nil {
+			return "", err
 		}
-
-		testFullName := event.Test
-		if testFullName == "" {
-			// Skip package-level events
-			continue
+		return string(encoded) + "\n", nil
+	case "ndjson":
+		var sb strings.Builder
+		if err := data.WriteNDJSON(&sb); err != nil {
+			return "", err
 		}
-
-		if _, exists := results[testFullName]; !exists && (event.Action == "run" || event.Action == "pass" || event.Action == "fail" || event.Action == "skip") {
-			results[testFullName] = &TestResult{
-				Name:      testFullName,
-				Package:   event.Package,
-				Status:    "UNKNOWN",
-				Duration:  0,
-				Output:    []string{},
-				IsSubTest: strings.Contains(testFullName, "/"),
-			}
-
-			if results[testFullName].IsSubTest {
-				parentName := testFullName[:strings.LastIndex(testFullName, "/")]
-				results[testFullName].ParentTest = parentName
-
-				if _, exists := results[parentName]; !exists {
-					results[parentName] = &TestResult{
-						Name:      parentName,
-						Package:   event.Package,
-						Status:    "UNKNOWN",
-						Duration:  0,
-						Output:    []string{},
-						SubTests:  []string{},
-						IsSubTest: strings.Contains(parentName, "/"),
-					}
-				}
-
-				results[parentName].SubTests = append(results[parentName].SubTests, testFullName)
-			}
+		return sb.String(), nil
+	case "junit":
+		return renderJUnit(data)
+	case "xunit":
+		return renderXUnit(data, time.Now())
+	case "nunit3":
+		return renderNUnit(data, time.Now())
+	case "trx":
+		return renderTRX(data, time.Now())
+	case "xlsx":
+		return renderXLSX(data, cfg)
+	case "influx":
+		return renderInflux(data, time.Now()), nil
+	case "jira":
+		content = renderJiraReport(data, cfg)
+	case "html":
+		page, err := renderHTMLReport(data, cfg)
+		if err != nil {
+			return "", err
 		}
-
-		switch event.Action {
-		case "run":
-			testStartTime[testFullName] = event.Time
-
-		case "pass":
-			results[testFullName].Status = "PASS"
-			if event.Elapsed > 0 {
-				results[testFullName].Duration = event.Elapsed
-			} else if !testStartTime[testFullName].IsZero() {
-				results[testFullName].Duration = event.Time.Sub(testStartTime[testFullName]).Seconds()
-			}
-
-		case "fail":
-			results[testFullName].Status = "FAIL"
-			if event.Elapsed > 0 {
-				results[testFullName].Duration = event.Elapsed
-			} else if !testStartTime[testFullName].IsZero() {
-				results[testFullName].Duration = event.Time.Sub(testStartTime[testFullName]).Seconds()
-			}
-
-		case "skip":
-			results[testFullName].Status = "SKIP"
-
-		case "output":
-			// Collect test output lines
-			if _, exists := testOutputMap[testFullName]; !exists {
-				testOutputMap[testFullName] = []string{}
-			}
-			// Clean output (remove trailing newlines)
-			output := strings.TrimSuffix(event.Output, "\n")
-			if output != "" {
-				testOutputMap[testFullName] = append(testOutputMap[testFullName], output)
-			}
+		content = page
+	default:
+		if cfg.target.limit > 0 {
+			content = fitReport(data, cfg)
+		} else {
+			content = renderMarkdownReport(data, cfg)
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading input: %v", err)
+	if data.Metrics == nil {
+		return content, nil
 	}
-
-	// Add collected output to each test
-	for testName, output := range testOutputMap {
-		if result, exists := results[testName]; exists {
-			result.Output = output
-		}
+	data.Metrics.RenderSeconds = time.Since(start).Seconds()
+	data.Metrics.PeakMemoryBytes = report.PeakMemory()
+	footnote := metricsFootnote(data)
+	switch format {
+	case "html":
+		footnote = "<p><small>" + html.EscapeString(footnote) + "</small></p>\n"
+	case "jira":
+		footnote = "{color:#57606a}" + jiraEscape(footnote) + "{color}\n\n"
+	default:
+		footnote = "<sub>" + footnote + "</sub>\n\n"
+	}
+	// Before the integrity trailer, which stays last
+	marker := "<!-- gotest-report:integrity"
+	if format == "jira" {
+		marker = "{noformat}\ngotest-report:integrity"
+	}
+	if i := strings.LastIndex(content, marker); i >= 0 {
+		return content[:i] + footnote + content[i:], nil
 	}
+	return content + "\n" + footnote, nil
+}
+
+// metricsFootnote describes what producing the report cost
+func metricsFootnote(data *ReportData) string {
+	m := data.Metrics
+	return fmt.Sprintf("⚙️ gotest-report %s: parsed %s (%d events) in %.2fs, rendered in %.2fs, peak memory %s",
+		version, formatBytes(m.InputBytes), data.Integrity.EventsParsed, m.ParseSeconds, m.RenderSeconds, formatBytes(int64(m.PeakMemoryBytes)))
+}
 
-	reportData := &ReportData{
-		Results: results,
+// formatBytes formats a size with a binary unit, e.g. "12.3 MiB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
 	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
 
-	var sortedNames []string
-	for name, result := range results {
-		// Only count root tests in summary (not subtests)
-		if !result.IsSubTest {
-			sortedNames = append(sortedNames, name)
-			reportData.TotalTests++
-			reportData.TotalDuration += result.Duration
+// writeReport writes the rendered report to path, or to stdout when path is "-"
+func writeReport(path, content string) error {
+	if path == "-" {
+		_, err := io.WriteString(os.Stdout, content)
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
 
-			switch result.Status {
-			case "PASS":
-				reportData.PassedTests++
-			case "FAIL":
-				reportData.FailedTests++
-			case "SKIP":
-				reportData.SkippedTests++
-			}
-		}
+// joinOutput joins lines of test output, each ending in a newline
+func joinOutput(output []string) string {
+	var sb strings.Builder
+	for _, line := range output {
+		sb.WriteString(strings.TrimRight(line, "\n") + "\n")
 	}
+	return sb.String()
+}
 
-	sort.Strings(sortedNames)
-	reportData.SortedTestNames = sortedNames
+// failureMessage is the assertion a failed test reported, if any
+func failureMessage(output []string) string {
+	if assertion := report.ExtractExcerpt(output).Assertion; assertion != "" {
+		return assertion
+	}
+	return "test failed"
+}
 
-	return reportData, nil
+// processTestEvents parses go test -json events. Unless opts say otherwise,
+// the output of passing tests is dropped as soon as they pass and large
+// outputs are spooled to disk, so giant logs don't have to fit in memory.
+func processTestEvents(reader io.Reader, opts report.ParseOptions) (*ReportData, error) {
+	return report.ParseWithOptions(reader, opts)
 }
 
 func generateMarkdownReport(data *ReportData) string {
+	return renderMarkdownReport(data, defaultConfig())
+}
+
+func renderMarkdownReport(data *ReportData, cfg *config) string {
 	var sb strings.Builder
+	durations := tableDurations(data, cfg)
 
 	// Generate header with emoji
 	sb.WriteString("# 🧪 Test Summary Report\n\n")
 
+	if gate := data.QualityGate; gate != nil && !gate.Passed {
+		sb.WriteString("🚫 ![Quality Gate](https://img.shields.io/badge/Quality_Gate-FAILED-red) 🚫\n\n")
+		sb.WriteString("> **Quality gate failed**\n")
+		for _, violation := range gate.Violations {
+			sb.WriteString(fmt.Sprintf("> - %s\n", violation))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Generate summary with emojis
 	passPercentage := 0.0
 	passPercentageDisplay := "N/A"
@@ -239,13 +335,73 @@ func generateMarkdownReport(data *ReportData) string {
 	sb.WriteString(fmt.Sprintf("- ✅ **Passed:** %d (%s)\n", data.PassedTests, passPercentageDisplay))
 	sb.WriteString(fmt.Sprintf("- ❌ **Failed:** %d\n", data.FailedTests))
 	sb.WriteString(fmt.Sprintf("- ⏭️ **Skipped:** %d\n", data.SkippedTests))
-	sb.WriteString(fmt.Sprintf("- ⏱️ **Total Duration:** %.2fs\n\n", data.TotalDuration))
+	if data.FlakyTests > 0 {
+		sb.WriteString(fmt.Sprintf("- 🔁 **Flaky:** %d (passed on re-run)\n", data.FlakyTests))
+	}
+	if data.Sanitizer != "" {
+		sb.WriteString(fmt.Sprintf("- 🧬 **Sanitizer:** %s\n", data.Sanitizer))
+	}
+	if len(data.Dimensions) > 0 && len(data.Variants) > 1 {
+		sb.WriteString(fmt.Sprintf("- 🧮 **Matrix:** %d runs across %s\n", len(data.Variants), strings.Join(data.Dimensions, ", ")))
+	}
+	if leaks := report.LeakFailures(data); len(leaks) > 0 {
+		sb.WriteString(fmt.Sprintf("- 🚰 **Goroutine Leaks (LEAK):** %d failed tests leaked goroutines\n", len(leaks)))
+	}
+	if examples := report.Examples(data); len(examples) > 0 {
+		failed := 0
+		for _, name := range examples {
+			if data.Results[name].Status == "FAIL" {
+				failed++
+			}
+		}
+		sb.WriteString(fmt.Sprintf("- 📘 **Examples:** %d (%d failed)\n", len(examples), failed))
+	}
+	if gate := data.QualityGate; gate != nil {
+		if gate.Passed {
+			sb.WriteString("- 🚦 **Quality Gate:** passed\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("- 🚦 **Quality Gate:** failed (%d thresholds breached)\n", len(gate.Violations)))
+		}
+	}
+	if seed, ok := singleShuffleSeed(data); ok {
+		sb.WriteString(fmt.Sprintf("- 🔀 **Shuffle Seed:** `%s`\n", seed))
+	} else if len(data.ShuffleSeeds) > 1 {
+		sb.WriteString(fmt.Sprintf("- 🔀 **Shuffled:** %d packages ran in random order (seeds below)\n", len(data.ShuffleSeeds)))
+	}
+	if len(data.Invocations) > 1 {
+		sb.WriteString(fmt.Sprintf("- 🧾 **Invocations:** %d go test runs in the input\n", len(data.Invocations)))
+	}
+	if len(data.Suites) > 0 {
+		names := make([]string, len(data.Suites))
+		for i, suite := range data.Suites {
+			names[i] = suite.Suite
+		}
+		sb.WriteString(fmt.Sprintf("- 🧩 **Suites:** %s\n", strings.Join(names, ", ")))
+	}
+	if data.ReportURL != "" {
+		sb.WriteString(fmt.Sprintf("- 📄 **Full Report:** [uploaded report](%s)\n", data.ReportURL))
+	}
+	if slow := countSlow(cfg, data, durations); slow > 0 {
+		sb.WriteString(fmt.Sprintf("- 🐢 **Slow:** %d tests took longer than %s\n", slow, time.Duration(cfg.slowThreshold*float64(time.Second))))
+	}
+	if wall := data.WallClock(); wall > 0 {
+		sb.WriteString(fmt.Sprintf("- ⏱️ **Total Duration:** %.2fs of test time in %.2fs wall clock (%.1f× parallelism)\n\n",
+			data.TotalDuration, wall, data.Parallelism()))
+	} else {
+		sb.WriteString(fmt.Sprintf("- ⏱️ **Total Duration:** %.2fs\n\n", data.TotalDuration))
+	}
 
 	// Add visual progress bar for pass rate
 	if data.TotalTests > 0 {
 		sb.WriteString("### Pass Rate Progress\n\n")
 		progressBar := generateProgressBar(passPercentage)
 		sb.WriteString(fmt.Sprintf("%s **%.1f%%**\n\n", progressBar, passPercentage))
+		if cfg.mermaid {
+			writeStatusPie(&sb, data)
+		}
+	}
+	if cfg.history != nil && cfg.trendRuns > 0 {
+		writeTrend(&sb, cfg, cfg.history)
 	}
 
 	// Visual pass/fail indicator with emojis
@@ -253,24 +409,66 @@ func generateMarkdownReport(data *ReportData) string {
 
 	// Create status badges with celebration or warning emojis
 	if data.FailedTests > 0 {
-		sb.WriteString("⚠️ ![Status](https://img.shields.io/badge/Status-FAILED-red) ⚠️\n\n")
+		sb.WriteString(fmt.Sprintf("⚠️ ![Status](%s) ⚠️\n\n", cfg.statusBadge("FAIL")))
 		sb.WriteString("> 💔 Some tests failed. Please review the failed tests below.\n\n")
 	} else if data.SkippedTests == data.TotalTests {
-		sb.WriteString("⏸️ ![Status](https://img.shields.io/badge/Status-SKIPPED-yellow) ⏸️\n\n")
+		sb.WriteString(fmt.Sprintf("⏸️ ![Status](%s) ⏸️\n\n", cfg.statusBadge("SKIP")))
 		sb.WriteString("> ⚡ All tests were skipped.\n\n")
+	} else if data.FlakyTests > 0 {
+		sb.WriteString(fmt.Sprintf("🎉 ![Status](%s) 🎉\n\n", cfg.statusBadge("PASS")))
+		sb.WriteString(fmt.Sprintf("> 🔁 All tests passed, but %d only passed after being re-run.\n\n", data.FlakyTests))
 	} else {
-		sb.WriteString("🎉 ![Status](https://img.shields.io/badge/Status-PASSED-brightgreen) 🎉\n\n")
+		sb.WriteString(fmt.Sprintf("🎉 ![Status](%s) 🎉\n\n", cfg.statusBadge("PASS")))
 		sb.WriteString("> ✨ Excellent! All tests passed successfully!\n\n")
 	}
 
 	sb.WriteString("---\n\n")
 
+	// What this change broke, apart from breakage the baseline already had
+	if data.Comparison != nil {
+		sb.WriteString(data.Comparison.Markdown())
+	}
+
+	if data.Environment != nil {
+		writeEnvironment(&sb, data.Environment)
+	}
+
+	if cfg.separateInvocations && len(data.Invocations) > 1 {
+		writeInvocations(&sb, data)
+	}
+
+	if len(data.Suites) > 0 {
+		writeSuites(&sb, data)
+	}
+
+	if len(data.Modules) > 0 {
+		writeModules(&sb, data.Modules)
+	}
+
+	if len(data.Categories) > 0 {
+		writeCategories(&sb, data.Categories)
+	}
+
+	if labels := report.LabelSummaries(data); len(labels) > 0 {
+		writeLabels(&sb, labels)
+	}
+
+	if len(data.ShuffleSeeds) > 0 {
+		writeShuffleRerun(&sb, data)
+	}
+
 	// Create a table of test results
 	sb.WriteString("## 📝 Test Results\n\n")
-	sb.WriteString("| Test | Status | Duration | Details |\n")
-	sb.WriteString("| ---- | ------ | -------- | ------- |\n")
+	if cfg.history != nil && cfg.historyRuns > 0 {
+		sb.WriteString("| Test | Status | Duration | Recent Runs | Details |\n")
+		sb.WriteString("| ---- | ------ | -------- | ----------- | ------- |\n")
+	} else {
+		sb.WriteString("| Test | Status | Duration | Details |\n")
+		sb.WriteString("| ---- | ------ | -------- | ------- |\n")
+	}
 
 	// Sort tests by package and name for a more organized report
+	hiddenPassing := 0
 	for _, testName := range data.SortedTestNames {
 		result := data.Results[testName]
 
@@ -278,24 +476,33 @@ func generateMarkdownReport(data *ReportData) string {
 		if result.IsSubTest {
 			continue
 		}
-
-		// Determine status emoji
-		statusEmoji := "⏺️"
-		switch result.Status {
-		case "PASS":
-			statusEmoji = "✅"
-		case "FAIL":
-			statusEmoji = "❌"
-		case "SKIP":
-			statusEmoji = "⏭️"
+		if cfg.trim.passingRows && result.Status == "PASS" {
+			hiddenPassing++
+			continue
 		}
 
+		statusEmoji := cfg.statusIcon(result.Status)
+
 		// Format test name to be more readable (remove package prefix if present)
 		displayName := result.Name
 		if strings.Contains(displayName, "/") && !result.IsSubTest {
 			displayName = filepath.Base(displayName)
 		}
 
+		quarantineMarker := ""
+		if result.Quarantined {
+			quarantineMarker = " 🔒"
+		}
+		if report.IsExample(result.Name) {
+			quarantineMarker += " 📘"
+		}
+		for _, label := range result.Labels {
+			quarantineMarker += " `" + label + "`"
+		}
+		if description := cfg.sourceIndex.description(result.Package, testName); description != "" {
+			quarantineMarker += "<br><sub>" + markdownCell(description) + "</sub>"
+		}
+
 		// Prepare details column content
 		detailsColumn := ""
 		if len(result.SubTests) > 0 {
@@ -307,33 +514,56 @@ func generateMarkdownReport(data *ReportData) string {
 			sort.Strings(result.SubTests)
 			for _, subTestName := range result.SubTests {
 				subTest := data.Results[subTestName]
-				subTestDisplayName := subTestName[strings.LastIndex(subTestName, "/")+1:]
-
-				statusEmoji := "⏺️"
-				switch subTest.Status {
-				case "PASS":
-					statusEmoji = "✅"
-				case "FAIL":
-					statusEmoji = "❌"
-				case "SKIP":
-					statusEmoji = "⏭️"
-				}
+				subTestDisplayName := cfg.subTestName(subTestName)
 
-				detailsColumn += fmt.Sprintf("<tr><td>%s</td><td>%s %s</td><td>%.3fs</td></tr>",
-					subTestDisplayName, statusEmoji, subTest.Status, subTest.Duration)
+				statusEmoji := cfg.statusIcon(subTest.Status)
+
+				detailsColumn += fmt.Sprintf("<tr><td>%s</td><td>%s %s</td><td>%.3fs%s</td></tr>",
+					subTestDisplayName, statusEmoji, subTest.Status, durations[subTestName], slowMarker(cfg, durations[subTestName]))
 			}
 
 			detailsColumn += "</table></details>"
+		} else if result.Status == "SKIP" && result.SkipReason != "" {
+			detailsColumn = "⏭️ " + markdownCell(result.SkipReason)
 		} else {
 			detailsColumn = "-"
 		}
 
-		sb.WriteString(fmt.Sprintf("| **%s** | %s %s | %.3fs | %s |\n",
-			displayName, statusEmoji, result.Status, result.Duration, detailsColumn))
+		historyColumn := ""
+		if cfg.history != nil && cfg.historyRuns > 0 {
+			historyColumn = " " + historySparkline(cfg.history.Statuses(testName, cfg.historyRuns)) + " |"
+		}
+		sb.WriteString(fmt.Sprintf("| **%s**%s | %s %s | %.3fs%s |%s %s |\n",
+			displayName, quarantineMarker, statusEmoji, result.Status, durations[testName], slowMarker(cfg, durations[testName]), historyColumn, detailsColumn))
 	}
 	sb.WriteString("\n")
+	if hiddenPassing > 0 {
+		sb.WriteString(fmt.Sprintf("_✂️ %d passing tests not listed to keep the report within the size limit._\n\n", hiddenPassing))
+	}
+
+	if skipGroups := report.SkipReasons(data); len(skipGroups) > 0 {
+		writeSkipReasons(&sb, skipGroups)
+	}
 
+	// Failures shared by several tests are printed once, in their group
+	groupOf := make(map[string]report.FailureGroup)
 	if data.FailedTests > 0 {
+		groups := report.GroupFailures(data)
+		for _, group := range groups {
+			if len(group.Tests) > 1 {
+				for _, name := range group.Tests {
+					groupOf[name] = group
+				}
+			}
+		}
+		writeFailureGroups(&sb, data, cfg, groups)
+	}
+
+	if data.FailedTests > 0 {
+		writeRerunFailed(&sb, data)
+	}
+
+	if data.FailedTests > 0 && !cfg.trim.failureDetails {
 		sb.WriteString("## 🔴 Failed Tests Details\n\n")
 		sb.WriteString("<details>\n")
 		sb.WriteString("<summary>💥 Click to expand failed test details</summary>\n\n")
@@ -360,25 +590,44 @@ func generateMarkdownReport(data *ReportData) string {
 				}
 
 				sb.WriteString(fmt.Sprintf("### ❌ %s\n\n", displayName))
+				if result.Package != "" {
+					sb.WriteString(fmt.Sprintf("📦 **Package:** %s\n\n", cfg.packageName(result.Package)))
+				}
+				writeDefinition(&sb, cfg, result.Package, testName)
+				writeBlame(&sb, cfg, result.Package, testName)
+				if result.Status == "FAIL" {
+					writeFailingStreak(&sb, cfg, testName)
+					writeRerunCommand(&sb, result.Package, testPattern(testName))
+				}
 
 				// Output for the main test
-				if result.Status == "FAIL" && len(result.Output) > 0 {
-					formattedOutput := formatFailureOutput(result.Output)
-					sb.WriteString(formattedOutput)
+				if group, grouped := groupOf[testName]; grouped && result.Status == "FAIL" {
+					sb.WriteString(groupReference(group))
+				} else if result.Status == "FAIL" && len(result.Output) > 0 {
+					writeFailureOutput(&sb, cfg, result)
+				}
+				if result.Status == "FAIL" {
+					writeAttempts(&sb, cfg, result)
 				}
 
-				// Output for failed subtests
-				for _, subTestName := range result.SubTests {
+				// Output for failed subtests, once for those failing the same way
+				for _, bucket := range report.SubTestFailures(data, testName) {
+					if len(bucket) > 1 {
+						writeDuplicateSubTests(&sb, cfg, data, bucket, groupOf[bucket[0]])
+						continue
+					}
+					subTestName := bucket[0]
 					subTest := data.Results[subTestName]
-					if subTest.Status == "FAIL" {
-						subTestDisplayName := subTestName[strings.LastIndex(subTestName, "/")+1:]
-						sb.WriteString(fmt.Sprintf("#### ❌ %s\n\n", subTestDisplayName))
-
-						if len(subTest.Output) > 0 {
-							formattedOutput := formatFailureOutput(subTest.Output)
-							sb.WriteString(formattedOutput)
-						}
+					sb.WriteString(fmt.Sprintf("#### ❌ %s\n\n", cfg.subTestName(subTestName)))
+					writeFailingStreak(&sb, cfg, subTestName)
+					writeRerunCommand(&sb, subTest.Package, testPattern(subTestName))
+
+					if group, grouped := groupOf[subTestName]; grouped {
+						sb.WriteString(groupReference(group))
+					} else if len(subTest.Output) > 0 {
+						writeFailureOutput(&sb, cfg, subTest)
 					}
+					writeAttempts(&sb, cfg, subTest)
 				}
 				sb.WriteString("---\n\n")
 			}
@@ -388,8 +637,317 @@ func generateMarkdownReport(data *ReportData) string {
 		sb.WriteString("</details>\n\n")
 	}
 
-	// Add duration metrics
+	if data.FlakyTests > 0 {
+		writeFlakyTests(&sb, cfg, data)
+	}
+
+	if repeated := report.RepeatedTests(data); len(repeated) > 0 {
+		writeRepeatedTests(&sb, repeated)
+	}
+
+	if cfg.includePassOutput {
+		writePassingOutput(&sb, data)
+	}
+
+	if len(data.Variants) > 1 {
+		writeVariantMatrix(&sb, data)
+	}
+
+	if len(data.UnquarantineCandidates) > 0 {
+		sb.WriteString("## 🔓 Candidates to Un-quarantine\n\n")
+		sb.WriteString("> These quarantined tests have passed consistently in recent runs and can likely be taken out of quarantine.\n\n")
+		for _, name := range data.UnquarantineCandidates {
+			sb.WriteString(fmt.Sprintf("- `%s`\n", name))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(data.Benchmarks) > 0 {
+		writeBenchmarks(&sb, data)
+	}
+
+	if len(data.DurationRegressions) > 0 {
+		writeDurationRegressions(&sb, data.DurationRegressions)
+	}
+
+	if len(data.BudgetOverruns) > 0 {
+		writeBudgetOverruns(&sb, cfg, data.BudgetOverruns)
+	}
+
+	if data.Integrity.LinesSkipped > 0 {
+		writeDiagnostics(&sb, data)
+	}
+
+	if !cfg.trim.durations {
+		if cfg.slowestPackages > 0 {
+			writeSlowestPackages(&sb, data, cfg)
+		}
+		writeDurations(&sb, data, cfg, durations)
+		if cfg.timeline {
+			writeTimeline(&sb, data)
+		}
+	}
+	if cfg.triageChecklist && data.FailedTests > 0 {
+		writeTriageChecklist(&sb, cfg, data)
+	}
+	sb.WriteString("---\n\n")
+	sb.WriteString(fmt.Sprintf("📅 **Report generated at:** %s\n\n", time.Now().Format("2006-01-02 15:04:05 MST")))
+	// Last, so tools reading the end of the document find it
+	sb.WriteString(integrityComment(data.Integrity))
+
+	return cfg.writeEmoji(sb.String())
+}
+
+// writeTrend charts the pass rate and total duration of the latest
+// cfg.trendRuns recorded runs, oldest first, as sparklines, and with
+// cfg.mermaid as Mermaid line charts too. A single run has no trend.
+func writeTrend(sb *strings.Builder, cfg *config, history *report.History) {
+	runs := history.Runs
+	if len(runs) > cfg.trendRuns {
+		runs = runs[len(runs)-cfg.trendRuns:]
+	}
+	if len(runs) < 2 {
+		return
+	}
+	passRates := make([]float64, len(runs))
+	durations := make([]float64, len(runs))
+	for i, run := range runs {
+		if run.Total > 0 {
+			passRates[i] = float64(run.Passed) / float64(run.Total) * 100
+		}
+		durations[i] = run.Duration
+	}
+	first, last := 0, len(runs)-1
+
+	sb.WriteString(fmt.Sprintf("### 📈 Trend (last %d runs)\n\n", len(runs)))
+	sb.WriteString("```text\n")
+	sb.WriteString(fmt.Sprintf("Pass rate  %s  %.1f%% → %.1f%%\n", trendSparkline(passRates), passRates[first], passRates[last]))
+	sb.WriteString(fmt.Sprintf("Duration   %s  %.2fs → %.2fs\n", trendSparkline(durations), durations[first], durations[last]))
+	sb.WriteString("```\n\n")
+
+	if cfg.mermaid {
+		writeTrendChart(sb, "Pass rate (%)", passRates, "%.1f")
+		writeTrendChart(sb, "Duration (s)", durations, "%.2f")
+	}
+}
+
+// trendSparkline draws values scaled from their minimum to their maximum
+func trendSparkline(values []float64) string {
+	lowest, highest := slices.Min(values), slices.Max(values)
+	var sb strings.Builder
+	for _, value := range values {
+		level := len(sparkBlocks) / 2
+		if highest > lowest {
+			level = int(math.Round((value - lowest) / (highest - lowest) * float64(len(sparkBlocks)-1)))
+		}
+		sb.WriteRune(sparkBlocks[level])
+	}
+	return sb.String()
+}
+
+// writeTrendChart renders values as a Mermaid line chart
+func writeTrendChart(sb *strings.Builder, title string, values []float64, format string) {
+	points := make([]string, len(values))
+	runs := make([]string, len(values))
+	for i, value := range values {
+		points[i] = fmt.Sprintf(format, value)
+		runs[i] = fmt.Sprint(i + 1)
+	}
+	sb.WriteString("```mermaid\nxychart-beta\n")
+	sb.WriteString(fmt.Sprintf("    title \"%s\"\n", title))
+	sb.WriteString(fmt.Sprintf("    x-axis \"Run\" [%s]\n", strings.Join(runs, ", ")))
+	sb.WriteString(fmt.Sprintf("    line [%s]\n", strings.Join(points, ", ")))
+	sb.WriteString("```\n\n")
+}
+
+// writeStatusPie renders the test statuses as a Mermaid pie chart, leaving
+// out statuses no test has
+func writeStatusPie(sb *strings.Builder, data *ReportData) {
+	sb.WriteString("```mermaid\npie showData title Test Results\n")
+	for _, slice := range []struct {
+		label string
+		count int
+	}{
+		{"Passed", data.PassedTests},
+		{"Failed", data.FailedTests},
+		{"Skipped", data.SkippedTests},
+		{"Flaky", data.FlakyTests},
+	} {
+		if slice.count > 0 {
+			sb.WriteString(fmt.Sprintf("    \"%s\" : %d\n", slice.label, slice.count))
+		}
+	}
+	sb.WriteString("```\n\n")
+}
+
+// timelineTests is the number of tests drawn in the timeline's Gantt chart
+const timelineTests = 30
+
+// sparkBlocks draw the concurrency profile, from one test running to the peak
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// writeTimeline renders how many tests ran at once over the run as a
+// sparkline, and when the longest-running tests ran as a Mermaid Gantt
+// chart
+func writeTimeline(sb *strings.Builder, data *ReportData) {
+	timeline := report.BuildTimeline(data, 60)
+	if timeline == nil {
+		return
+	}
+	total := timeline.End.Sub(timeline.Start).Seconds()
+
+	sb.WriteString("## 🕒 Concurrency Timeline\n\n")
+	sb.WriteString(fmt.Sprintf("- **Peak:** %d tests at once\n", timeline.Peak))
+	sb.WriteString(fmt.Sprintf("- **Average:** %.1f tests while any were running\n", timeline.Average))
+	if timeline.BusySeconds > 0 {
+		sb.WriteString(fmt.Sprintf("- **Serial:** %.2fs (%.0f%%) with a single test running\n",
+			timeline.SerialSeconds, timeline.SerialSeconds/timeline.BusySeconds*100))
+	}
+	sb.WriteString("\n```text\n")
+	var line strings.Builder
+	for _, running := range timeline.Profile {
+		if running <= 0 {
+			line.WriteRune(' ')
+			continue
+		}
+		level := int(math.Round(running/float64(timeline.Peak)*float64(len(sparkBlocks)))) - 1
+		line.WriteRune(sparkBlocks[min(max(level, 0), len(sparkBlocks)-1)])
+	}
+	sb.WriteString(fmt.Sprintf("%3d ┤%s\n", timeline.Peak, line.String()))
+	sb.WriteString(fmt.Sprintf("    └%s %.2fs\n", strings.Repeat("─", len(timeline.Profile)), total))
+	sb.WriteString("```\n\n")
+
+	// The longest-running tests, drawn in the order they started
+	tests := append([]report.TimelineTest(nil), timeline.Tests...)
+	if len(tests) > timelineTests {
+		sort.SliceStable(tests, func(i, j int) bool { return tests[i].Seconds() > tests[j].Seconds() })
+		tests = tests[:timelineTests]
+		sort.SliceStable(tests, func(i, j int) bool { return tests[i].Spans[0].Start.Before(tests[j].Spans[0].Start) })
+	}
+	sb.WriteString("<details>\n")
+	sb.WriteString(fmt.Sprintf("<summary>📈 When each of %d tests ran</summary>\n\n", len(tests)))
+	sb.WriteString("```mermaid\ngantt\n    dateFormat x\n    axisFormat %M:%S\n")
+	section := ""
+	for _, test := range tests {
+		if test.Package != section {
+			section = test.Package
+			sb.WriteString(fmt.Sprintf("    section %s\n", mermaidText(section)))
+		}
+		for _, span := range test.Spans {
+			start := span.Start.Sub(timeline.Start).Milliseconds()
+			end := max(span.End.Sub(timeline.Start).Milliseconds(), start+1)
+			sb.WriteString(fmt.Sprintf("    %s : %d, %d\n", mermaidText(test.Name), start, end))
+		}
+	}
+	sb.WriteString("```\n\n</details>\n\n")
+}
+
+// mermaidText makes a name safe to use as a Mermaid Gantt task or section,
+// where colons, semicolons and hashes have a meaning
+func mermaidText(name string) string {
+	if name == "" {
+		return "tests"
+	}
+	return strings.NewReplacer(":", " ", ";", " ", "#", " ").Replace(name)
+}
+
+// writeBenchmarks renders the mean of every metric each benchmark reported,
+// with a column per unit so metrics added with b.ReportMetric show up too
+func writeBenchmarks(sb *strings.Builder, data *ReportData) {
+	units := report.BenchmarkMetricUnits(data.Benchmarks)
+	summaries := report.SummarizeBenchmarks(data.Benchmarks)
+	packages := make(map[string]bool)
+	for _, summary := range summaries {
+		packages[summary.Package] = true
+	}
+
+	sb.WriteString("## 🏎️ Benchmarks\n\n")
+	sb.WriteString("| Benchmark | Runs |")
+	for _, unit := range units {
+		sb.WriteString(fmt.Sprintf(" %s |", markdownCell(unit)))
+	}
+	sb.WriteString("\n| --------- | ---- |")
+	sb.WriteString(strings.Repeat(" ---: |", len(units)))
+	sb.WriteString("\n")
+
+	for _, summary := range summaries {
+		name := "`" + markdownCell(summary.Name) + "`"
+		if len(packages) > 1 {
+			name += " (" + markdownCell(summary.Package) + ")"
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %d |", name, summary.Runs))
+		for _, unit := range units {
+			sb.WriteString(fmt.Sprintf(" %s |", formatBenchmarkSamples(summary.Metrics[unit])))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+}
+
+// writeDurationRegressions lists the total and tests that got slower than
+// the baseline allows, biggest increase first
+func writeDurationRegressions(sb *strings.Builder, regressions []report.DurationRegression) {
+	sb.WriteString("## 🐢 Duration Regressions\n\n")
+	sb.WriteString("> ⚠️ Slower than the baseline allows.\n\n")
+	sb.WriteString("| Test | Baseline | Current | Change |\n")
+	sb.WriteString("| ---- | -------- | ------- | ------ |\n")
+	for i, r := range regressions {
+		if i == 20 {
+			sb.WriteString(fmt.Sprintf("| …and %d more | | | |\n", len(regressions)-20))
+			break
+		}
+		name := "**Total**"
+		if r.Name != "" {
+			name = "`" + markdownCell(r.Name) + "`"
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %.2fs | %.2fs | +%.0f%% |\n", name, r.Baseline, r.Current, r.Increase()))
+	}
+	sb.WriteString("\n")
+}
+
+// writeBudgetOverruns lists the packages whose tests took longer than their
+// duration budget
+func writeBudgetOverruns(sb *strings.Builder, cfg *config, overruns []report.BudgetOverrun) {
+	sb.WriteString("## ⌛ Duration Budgets\n\n")
+	sb.WriteString(fmt.Sprintf("> ⚠️ %d packages took longer than their budget.\n\n", len(overruns)))
+	sb.WriteString("| Package | Budget | Duration | Over |\n")
+	sb.WriteString("| ------- | ------ | -------- | ---- |\n")
+	for _, o := range overruns {
+		sb.WriteString(fmt.Sprintf("| %s | %gs | %.2fs | +%.0f%% |\n",
+			markdownCell(cfg.packageName(o.Package)), o.Budget, o.Duration, (o.Duration-o.Budget)/o.Budget*100))
+	}
+	sb.WriteString("\n")
+}
+
+// writeSlowestPackages renders the packages whose tests took longest, which
+// bound CI wall-clock time when packages run in parallel. Reports covering a
+// single package leave it out.
+func writeSlowestPackages(sb *strings.Builder, data *ReportData, cfg *config) {
+	packages := report.PackageDurations(data)
+	if len(packages) < 2 {
+		return
+	}
+	if len(packages) > cfg.slowestPackages {
+		packages = packages[:cfg.slowestPackages]
+	}
+
+	sb.WriteString("## 📦 Slowest Packages\n\n")
+	sb.WriteString("| Package | Tests | ❌ | Duration |\n")
+	sb.WriteString("| ------- | ----- | -- | -------- |\n")
+	for _, p := range packages {
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %.2fs |\n", markdownCell(cfg.packageName(p.Package)), p.Tests, p.Failed, p.Duration))
+	}
+	sb.WriteString("\n")
+}
+
+// writeDurations renders the cfg.topSlow longest-running tests by their
+// tableDurations as a bar chart
+func writeDurations(sb *strings.Builder, data *ReportData, cfg *config, tableDurations map[string]float64) {
 	sb.WriteString("## ⏱️ Test Durations\n\n")
+	if cfg.topSlow <= 0 {
+		writeDurationHistogram(sb, data)
+		return
+	}
 	sb.WriteString("<details>\n")
 	sb.WriteString("<summary>⚡ Click to expand test durations</summary>\n\n")
 	sb.WriteString("| Test | Duration |\n")
@@ -406,7 +964,7 @@ func generateMarkdownReport(data *ReportData) string {
 	for testName, result := range data.Results {
 		durations = append(durations, testDuration{
 			name:     testName,
-			duration: result.Duration,
+			duration: tableDurations[testName],
 			isRoot:   !result.IsSubTest,
 		})
 	}
@@ -429,10 +987,9 @@ func generateMarkdownReport(data *ReportData) string {
 		}
 	}
 
-	// Take top 15 longest tests (increased from 10)
 	count := 0
 	for _, d := range durations {
-		if count >= 15 {
+		if count >= cfg.topSlow {
 			break
 		}
 
@@ -444,7 +1001,7 @@ func generateMarkdownReport(data *ReportData) string {
 			}
 		} else {
 			// For subtests, show parent/child relationship
-			displayName = "↳ " + d.name[strings.LastIndex(d.name, "/")+1:]
+			displayName = "↳ " + cfg.subTestName(d.name)
 		}
 
 		// Add bar chart using unicode block characters
@@ -469,12 +1026,781 @@ func generateMarkdownReport(data *ReportData) string {
 
 	// Close the details tag
 	sb.WriteString("\n</details>\n\n")
-	sb.WriteString("---\n\n")
-	sb.WriteString(fmt.Sprintf("📅 **Report generated at:** %s\n", time.Now().Format("2006-01-02 15:04:05 MST")))
 
+	writeDurationHistogram(sb, data)
+}
+
+// historySparkline draws a test's statuses in recorded runs, oldest first,
+// with ▫️ for runs it wasn't part of
+func historySparkline(statuses []string) string {
+	var sb strings.Builder
+	for _, status := range statuses {
+		switch status {
+		case "PASS":
+			sb.WriteString("✅")
+		case "FAIL":
+			sb.WriteString("❌")
+		case "SKIP":
+			sb.WriteString("⏭️")
+		case "FLAKY":
+			sb.WriteString("🔁")
+		case "":
+			sb.WriteString("▫️")
+		default:
+			sb.WriteString("⏺️")
+		}
+	}
 	return sb.String()
 }
 
+// tableDurations returns the duration the results and durations tables show
+// for each test: its own, or with cfg.aggregateParentDurations the larger
+// of its own and the total of its subtests', rolled up the tree. Parents of
+// parallel subtests often report next to nothing while their subtests carry
+// the time.
+func tableDurations(data *ReportData, cfg *config) map[string]float64 {
+	durations := make(map[string]float64, len(data.Results))
+	var rollUp func(name string) float64
+	rollUp = func(name string) float64 {
+		if duration, done := durations[name]; done {
+			return duration
+		}
+		result := data.Results[name]
+		if result == nil {
+			return 0
+		}
+		var subTests float64
+		for _, subTest := range result.SubTests {
+			subTests += rollUp(subTest)
+		}
+		durations[name] = max(result.Duration, subTests)
+		return durations[name]
+	}
+	for name, result := range data.Results {
+		if cfg.aggregateParentDurations {
+			rollUp(name)
+		} else {
+			durations[name] = result.Duration
+		}
+	}
+	return durations
+}
+
+// slowMarker marks a duration above cfg.slowThreshold
+func slowMarker(cfg *config, seconds float64) string {
+	if cfg.slowThreshold > 0 && seconds > cfg.slowThreshold {
+		return " 🐢"
+	}
+	return ""
+}
+
+// countSlow returns the number of top-level tests whose table durations are
+// above cfg.slowThreshold
+func countSlow(cfg *config, data *ReportData, durations map[string]float64) int {
+	slow := 0
+	for name, result := range data.Results {
+		if !result.IsSubTest && slowMarker(cfg, durations[name]) != "" {
+			slow++
+		}
+	}
+	return slow
+}
+
+// durationBuckets are the upper bounds, in seconds, of the duration
+// histogram's buckets; the last bucket holds everything slower
+var durationBuckets = []struct {
+	label string
+	upTo  float64
+}{
+	{"< 10ms", 0.01},
+	{"10ms – 100ms", 0.1},
+	{"100ms – 1s", 1},
+	{"1s – 10s", 10},
+	{"≥ 10s", math.Inf(1)},
+}
+
+// writeDurationHistogram renders how many top-level tests fall in each
+// duration bucket, for a sense of the suite's make-up beyond the slowest
+// tests. Skipped tests and tests without a result are left out.
+func writeDurationHistogram(sb *strings.Builder, data *ReportData) {
+	counts := make([]int, len(durationBuckets))
+	total := 0
+	for _, result := range data.Results {
+		if result.IsSubTest || result.Status == "SKIP" || result.Status == "UNKNOWN" {
+			continue
+		}
+		for i, bucket := range durationBuckets {
+			if result.Duration < bucket.upTo {
+				counts[i]++
+				break
+			}
+		}
+		total++
+	}
+	if total == 0 {
+		return
+	}
+
+	sb.WriteString("### Duration Distribution\n\n")
+	sb.WriteString("| Duration | Tests | Share |\n")
+	sb.WriteString("| -------- | ----- | ----- |\n")
+	for i, bucket := range durationBuckets {
+		share := float64(counts[i]) / float64(total) * 100
+		bar := strings.Repeat("█", int(math.Round(share/5)))
+		sb.WriteString(fmt.Sprintf("| %s | %d | %s %.0f%% |\n", bucket.label, counts[i], bar, share))
+	}
+	sb.WriteString("\n")
+}
+
+// writeFailureGroups renders failures shared by more than one test once, with
+// the list of affected tests, so one broken dependency doesn't repeat the
+// same output for every test it took down
+func writeFailureGroups(sb *strings.Builder, data *ReportData, cfg *config, groups []report.FailureGroup) {
+	shared := 0
+	for _, group := range groups {
+		if len(group.Tests) > 1 {
+			shared++
+		}
+	}
+	if shared == 0 {
+		return
+	}
+
+	sb.WriteString("## 🧩 Failure Groups\n\n")
+	for _, group := range groups {
+		if len(group.Tests) < 2 {
+			continue
+		}
+
+		message := truncateRunes(strings.ReplaceAll(group.Message, "`", "'"), 120)
+		sb.WriteString(fmt.Sprintf("### 🧩 %d tests failed with: `%s`\n\n", len(group.Tests), message))
+		sb.WriteString(fmt.Sprintf("Fingerprint: `%s`\n\n", group.Fingerprint))
+
+		sb.WriteString("<details>\n")
+		sb.WriteString(fmt.Sprintf("<summary>Affected tests (%d)</summary>\n\n", len(group.Tests)))
+		for _, name := range group.Tests {
+			sb.WriteString(fmt.Sprintf("- %s\n", name))
+		}
+		sb.WriteString("\n</details>\n\n")
+
+		if first := data.Results[group.Tests[0]]; len(first.Output) > 0 && !cfg.trim.failureDetails {
+			writeFailureOutput(sb, cfg, first)
+		}
+	}
+}
+
+// writeSkipReasons summarizes why tests were skipped, most common reason
+// first, so skips don't go unnoticed
+func writeSkipReasons(sb *strings.Builder, groups []report.SkipGroup) {
+	sb.WriteString("## ⏭️ Skip Reasons\n\n")
+	for i, group := range groups {
+		if i == 10 {
+			sb.WriteString(fmt.Sprintf("- …and %d more reasons\n", len(groups)-10))
+			break
+		}
+		noun := "tests"
+		if len(group.Tests) == 1 {
+			noun = "test"
+		}
+		sb.WriteString(fmt.Sprintf("- **%d %s skipped:** %s\n", len(group.Tests), noun, strings.ReplaceAll(group.Reason, "\n", " ")))
+	}
+	sb.WriteString("\n")
+}
+
+// markdownCell makes text safe to use in a Markdown table cell
+func markdownCell(text string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(text)
+}
+
+// writeInvocations renders one section per go test invocation found in the
+// input, with its own counts and failures
+func writeInvocations(sb *strings.Builder, data *ReportData) {
+	sb.WriteString("## 🧾 Invocations\n\n")
+	for i, run := range data.Invocations {
+		sb.WriteString(fmt.Sprintf("### %s Invocation %d of %d\n\n", runStatusEmoji(run), i+1, len(data.Invocations)))
+		writeRunOverview(sb, run)
+	}
+}
+
+// writeModules counts the packages and tests of each module of a
+// multi-module repository, so it's clear which module's suite broke
+func writeModules(sb *strings.Builder, modules []report.ModuleSummary) {
+	sb.WriteString("## 🧱 Modules\n\n")
+	sb.WriteString("| Module | Packages | Tests | ✅ | ❌ | ⏭️ | 🔁 | Duration |\n")
+	sb.WriteString("| ------ | -------- | ----- | -- | -- | -- | -- | -------- |\n")
+	for _, m := range modules {
+		status := "✅"
+		if m.Failed > 0 {
+			status = "❌"
+		}
+		sb.WriteString(fmt.Sprintf("| %s `%s` | %d | %d | %d | %d | %d | %d | %.2fs |\n",
+			status, m.Module, m.Packages, m.Total, m.Passed, m.Failed, m.Skipped, m.Flaky, m.Duration))
+	}
+	sb.WriteString("\n")
+}
+
+// writeCategories counts the tests of each category of packages, with the
+// pass rate each must reach
+func writeCategories(sb *strings.Builder, categories []report.CategorySummary) {
+	sb.WriteString("## 🗂️ Categories\n\n")
+	sb.WriteString("| Category | Tests | ✅ | ❌ | ⏭️ | 🔁 | Pass Rate | Duration |\n")
+	sb.WriteString("| -------- | ----- | -- | -- | -- | -- | --------- | -------- |\n")
+	for _, c := range categories {
+		passRate := fmt.Sprintf("%.1f%%", c.PassRate())
+		if c.MinPassRate > 0 {
+			marker := "✅"
+			if c.PassRate() < c.MinPassRate {
+				marker = "❌"
+			}
+			passRate += fmt.Sprintf(" %s (min %g%%)", marker, c.MinPassRate)
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %d | %d | %s | %.2fs |\n",
+			markdownCell(c.Category), c.Total, c.Passed, c.Failed, c.Skipped, c.Flaky, passRate, c.Duration))
+	}
+	sb.WriteString("\n")
+}
+
+// writeLabels counts the tests of each label their output declared. A test
+// with several labels is counted under each.
+func writeLabels(sb *strings.Builder, labels []report.LabelSummary) {
+	sb.WriteString("## 🏷️ Labels\n\n")
+	sb.WriteString("| Label | Tests | ✅ | ❌ | ⏭️ | 🔁 | Pass Rate | Duration |\n")
+	sb.WriteString("| ----- | ----- | -- | -- | -- | -- | --------- | -------- |\n")
+	for _, l := range labels {
+		sb.WriteString(fmt.Sprintf("| `%s` | %d | %d | %d | %d | %d | %.1f%% | %.2fs |\n",
+			l.Label, l.Total, l.Passed, l.Failed, l.Skipped, l.Flaky, float64(l.Passed)/float64(l.Total)*100, l.Duration))
+	}
+	sb.WriteString("\n")
+}
+
+// writeSuites renders one section per suite of a report combining several,
+// e.g. the Go and JavaScript tests of a monorepo, with its own counts and
+// failures
+func writeSuites(sb *strings.Builder, data *ReportData) {
+	sb.WriteString("## 🧩 Suites\n\n")
+	for _, suite := range data.Suites {
+		sb.WriteString(fmt.Sprintf("### %s %s\n\n", runStatusEmoji(suite), suite.Suite))
+		writeRunOverview(sb, suite)
+	}
+}
+
+func runStatusEmoji(run *ReportData) string {
+	if run.FailedTests > 0 {
+		return "❌"
+	}
+	return "✅"
+}
+
+// writeRunOverview writes the packages, counts and failed tests of one of
+// the runs a report combines
+func writeRunOverview(sb *strings.Builder, run *ReportData) {
+	packages := make(map[string]bool)
+	for _, result := range run.Results {
+		packages[result.Package] = true
+	}
+	var names []string
+	for pkg := range packages {
+		names = append(names, pkg)
+	}
+	sort.Strings(names)
+	if len(names) > 5 {
+		names = append(names[:5], fmt.Sprintf("and %d more", len(packages)-5))
+	}
+
+	if len(names) > 0 {
+		sb.WriteString(fmt.Sprintf("- 📦 **Packages:** %s\n", strings.Join(names, ", ")))
+	}
+	sb.WriteString(fmt.Sprintf("- 🧪 **Tests:** %d (✅ %d, ❌ %d, ⏭️ %d)\n", run.TotalTests, run.PassedTests, run.FailedTests, run.SkippedTests))
+	sb.WriteString(fmt.Sprintf("- ⏱️ **Duration:** %.2fs\n\n", run.TotalDuration))
+
+	for _, name := range run.SortedTestNames {
+		if run.Results[name].Status == "FAIL" {
+			sb.WriteString(fmt.Sprintf("- ❌ `%s`\n", name))
+		}
+	}
+	if run.FailedTests > 0 {
+		sb.WriteString("\n")
+	}
+}
+
+// writeFailingStreak says how many recorded runs in a row a failed test has
+// been failing for, telling new breakage from long-ignored failures
+func writeFailingStreak(sb *strings.Builder, cfg *config, name string) {
+	if cfg.history == nil || len(cfg.history.Runs) < 2 {
+		return
+	}
+	runs, since := cfg.history.FailingStreak(name)
+	switch {
+	case runs == 1:
+		sb.WriteString("🆕 **New failure:** passed or didn't run in the previous recorded run\n\n")
+	case runs > 1 && since.IsZero():
+		sb.WriteString(fmt.Sprintf("⏳ **Failing for %d runs**\n\n", runs))
+	case runs > 1:
+		sb.WriteString(fmt.Sprintf("⏳ **Failing for %d runs** / since %s\n\n", runs, since.Format("2006-01-02")))
+	}
+
+	// The commits around the start of the streak are where to bisect from
+	lastPass, firstFail, ok := cfg.history.FirstFailure(name)
+	if !ok || lastPass.Commit == "" || firstFail.Commit == "" || lastPass.Commit == firstFail.Commit {
+		return
+	}
+	good, bad := shortCommit(lastPass.Commit), shortCommit(firstFail.Commit)
+	sb.WriteString(fmt.Sprintf("🔎 **Last passed at** `%s`, **first failed at** `%s`: `git bisect start %s %s`\n\n", good, bad, bad, good))
+}
+
+// shortCommit abbreviates a commit SHA the way git does by default
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// writeFailureOutput writes the source links and snippets, leaked goroutines
+// and formatted output of a failed test
+func writeFailureOutput(sb *strings.Builder, cfg *config, result *TestResult) {
+	writeSourceLinks(sb, cfg, result.Package, result.Output)
+	writeSourceSnippets(sb, cfg, result.Package, result.Output)
+	if leaks := report.ParseLeaks(result.Output); len(leaks) > 0 {
+		writeLeakedGoroutines(sb, leaks)
+	}
+	if got, want, ok := report.ExampleMismatch(result.Output); ok && report.IsExample(result.Name) {
+		writeExampleDiff(sb, got, want)
+	}
+	output := trimOutput(result.Output, cfg.trim.outputLines)
+	sb.WriteString(formatFailureOutput(output))
+	if len(output) < len(result.Output) {
+		sb.WriteString(fmt.Sprintf("_✂️ Showing the first and last %d of %d output lines._\n\n", len(output), len(result.Output)))
+	}
+}
+
+// writeTriageChecklist lists the failed tests, and under them their failed
+// subtests, as a GitHub task list with the owners of each, so the comment
+// holding the report doubles as a triage tracker
+func writeTriageChecklist(sb *strings.Builder, cfg *config, data *ReportData) {
+	sb.WriteString("## 📋 Triage Checklist\n\n")
+	for _, name := range data.SortedTestNames {
+		result := data.Results[name]
+		if result.IsSubTest || result.Status != "FAIL" {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- [ ] `%s`", name))
+		if result.Package != "" {
+			sb.WriteString(" in " + cfg.packageName(result.Package))
+		}
+		if owners := cfg.owners.owners(result.Package, name); len(owners) > 0 {
+			sb.WriteString(fmt.Sprintf(" (owner: %s)", strings.Join(owners, ", ")))
+		}
+		sb.WriteString("\n")
+		for _, subTestName := range result.SubTests {
+			if data.Results[subTestName].Status == "FAIL" {
+				sb.WriteString(fmt.Sprintf("  - [ ] `%s`\n", cfg.subTestName(subTestName)))
+			}
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// writeEnvironment describes the machine and CI job the tests ran on
+func writeEnvironment(sb *strings.Builder, env *report.Environment) {
+	sb.WriteString("## 🖥️ Environment\n\n")
+	sb.WriteString("| Setting | Value |\n")
+	sb.WriteString("| ------- | ----- |\n")
+	sb.WriteString(fmt.Sprintf("| Go | %s |\n", markdownCell(env.GoVersion)))
+	sb.WriteString(fmt.Sprintf("| Platform | %s/%s |\n", markdownCell(env.GOOS), markdownCell(env.GOARCH)))
+	sb.WriteString(fmt.Sprintf("| CPUs | %d |\n", env.NumCPU))
+	for _, name := range report.CIVariables {
+		if value, ok := env.CI[name]; ok {
+			sb.WriteString(fmt.Sprintf("| `%s` | %s |\n", name, markdownCell(value)))
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// singleShuffleSeed returns the seed when every shuffled package used the same
+// one, as when a single package was tested
+func singleShuffleSeed(data *ReportData) (string, bool) {
+	seed := ""
+	for _, s := range data.ShuffleSeeds {
+		if seed != "" && s != seed {
+			return "", false
+		}
+		seed = s
+	}
+	return seed, seed != ""
+}
+
+// writeShuffleRerun prints the commands that rerun each shuffled package in
+// the same order, so order-dependent failures can be reproduced
+func writeShuffleRerun(sb *strings.Builder, data *ReportData) {
+	failed := make(map[string]bool)
+	for _, result := range data.Results {
+		if result.Status == "FAIL" {
+			failed[result.Package] = true
+		}
+	}
+
+	packages := make([]string, 0, len(data.ShuffleSeeds))
+	for pkg := range data.ShuffleSeeds {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+
+	sb.WriteString("## 🔀 Reproduce Test Order\n\n")
+	sb.WriteString("Tests ran in random order (`-shuffle=on`). To rerun them in the same order:\n\n")
+	sb.WriteString("```sh\n")
+	for _, pkg := range packages {
+		sb.WriteString(fmt.Sprintf("go test -shuffle=%s %s", data.ShuffleSeeds[pkg], pkg))
+		if failed[pkg] {
+			sb.WriteString("  # failed")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("```\n\n")
+}
+
+// writeExampleDiff shows how an example's output differs from its
+// // Output: comment, with "-" for expected lines and "+" for actual ones
+func writeExampleDiff(sb *strings.Builder, got, want []string) {
+	sb.WriteString("📘 **Example output mismatch** (`-` want, `+` got)\n\n")
+	sb.WriteString("```diff\n")
+	for _, line := range report.DiffLines(want, got) {
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("```\n\n")
+}
+
+// writeLeakedGoroutines renders goleak's leaked goroutines, highlighting the
+// function that started each one since that's usually where the fix goes
+func writeLeakedGoroutines(sb *strings.Builder, leaks []report.LeakedGoroutine) {
+	sb.WriteString("<details>\n")
+	sb.WriteString(fmt.Sprintf("<summary>🚰 <b>LEAK: %d leaked goroutines</b></summary>\n\n", len(leaks)))
+	for _, leak := range leaks {
+		sb.WriteString(fmt.Sprintf("**Goroutine %d** (%s) in `%s`", leak.ID, leak.State, leak.TopFunction))
+		if leak.CreatedBy != "" {
+			sb.WriteString(fmt.Sprintf(", created by **`%s`**", leak.CreatedBy))
+		}
+		sb.WriteString("\n\n```\n")
+		for _, line := range leak.Stack {
+			if strings.HasPrefix(line, "created by ") {
+				line = "→ " + line
+			}
+			sb.WriteString(line + "\n")
+		}
+		sb.WriteString("```\n\n")
+	}
+	sb.WriteString("</details>\n\n")
+}
+
+// groupReference points a failed test at the failure group holding its output
+func groupReference(group report.FailureGroup) string {
+	return fmt.Sprintf("↪️ Same failure as %d other tests, see failure group `%s` above.\n\n",
+		len(group.Tests)-1, group.Fingerprint)
+}
+
+// writeDuplicateSubTests writes the subtests of a test that failed with the
+// same normalized output as one entry listing them, instead of repeating the
+// output for each. The output itself is in their failure group.
+func writeDuplicateSubTests(sb *strings.Builder, cfg *config, data *ReportData, bucket []string, group report.FailureGroup) {
+	sb.WriteString(fmt.Sprintf("#### ❌ %d subtests failed the same way\n\n", len(bucket)))
+	sb.WriteString("<details>\n")
+	sb.WriteString(fmt.Sprintf("<summary>Affected subtests (%d)</summary>\n\n", len(bucket)))
+	for _, name := range bucket {
+		sb.WriteString(fmt.Sprintf("- %s\n", cfg.subTestName(name)))
+	}
+	sb.WriteString("\n</details>\n\n")
+	writeRerunCommand(sb, data.Results[bucket[0]].Package, runPattern(bucket))
+
+	if others := len(group.Tests) - len(bucket); others > 0 {
+		sb.WriteString(fmt.Sprintf("↪️ Same failure as %d other tests, see failure group `%s` above.\n\n", others, group.Fingerprint))
+	} else {
+		sb.WriteString(fmt.Sprintf("↪️ See failure group `%s` above for the output.\n\n", group.Fingerprint))
+	}
+	for _, name := range bucket {
+		writeAttempts(sb, cfg, data.Results[name])
+	}
+}
+
+// writeFlakyTests lists tests that only passed after being re-run, with the
+// outcome of every attempt
+func writeFlakyTests(sb *strings.Builder, cfg *config, data *ReportData) {
+	var names []string
+	for name, result := range data.Results {
+		if result.Status == "FLAKY" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	sb.WriteString("## 🔁 Flaky Tests\n\n")
+	sb.WriteString("| Test | Attempts | Outcomes |\n")
+	sb.WriteString("| ---- | -------- | -------- |\n")
+	for _, name := range names {
+		result := data.Results[name]
+		var outcomes []string
+		for _, attempt := range result.Attempts {
+			outcomes = append(outcomes, fmt.Sprintf("%s (%.3fs)", attempt.Status, attempt.Duration))
+		}
+		outcomes = append(outcomes, fmt.Sprintf("PASS (%.3fs)", result.Duration))
+		sb.WriteString(fmt.Sprintf("| %s | %d | %s |\n", name, len(outcomes), strings.Join(outcomes, " → ")))
+	}
+	sb.WriteString("\n")
+	for _, name := range names {
+		writeAttempts(sb, cfg, data.Results[name])
+	}
+}
+
+// writeRepeatedTests renders the duration spread and failure frequency of
+// tests run several times with -count, the least stable first
+func writeRepeatedTests(sb *strings.Builder, repeated []report.RepeatedTest) {
+	sb.WriteString("## 🔂 Repeated Runs\n\n")
+	sb.WriteString("| Test | Runs | Failed | Min | Median | P95 | Max |\n")
+	sb.WriteString("| ---- | ---- | ------ | --- | ------ | --- | --- |\n")
+	for i, test := range repeated {
+		if i == 20 {
+			sb.WriteString(fmt.Sprintf("| …and %d more | | | | | | |\n", len(repeated)-20))
+			break
+		}
+		failed := "0"
+		if test.Failures > 0 {
+			failed = fmt.Sprintf("❌ %d (%.0f%%)", test.Failures, test.FailureRate()*100)
+		}
+		sb.WriteString(fmt.Sprintf("| `%s` | %d | %s | %.3fs | %.3fs | %.3fs | %.3fs |\n",
+			markdownCell(test.Name), test.Runs, failed, test.Min, test.Median, test.P95, test.Max))
+	}
+	sb.WriteString("\n")
+}
+
+// writeAttempts lists the earlier attempts of a test that was retried, each
+// with its duration and output in a collapsed block
+func writeAttempts(sb *strings.Builder, cfg *config, result *TestResult) {
+	if len(result.Attempts) == 0 {
+		return
+	}
+	total := len(result.Attempts) + 1
+	for i, attempt := range result.Attempts {
+		sb.WriteString("<details>\n")
+		sb.WriteString(fmt.Sprintf("<summary>🔁 %s: attempt %d of %d, %s (%.3fs)</summary>\n\n", result.Name, i+1, total, attempt.Status, attempt.Duration))
+		if len(attempt.Output) == 0 {
+			sb.WriteString("_No output._\n\n")
+		} else {
+			output := trimOutput(attempt.Output, cfg.trim.outputLines)
+			sb.WriteString("```\n")
+			for _, line := range output {
+				sb.WriteString(line + "\n")
+			}
+			sb.WriteString("```\n\n")
+			if len(output) < len(attempt.Output) {
+				sb.WriteString(fmt.Sprintf("_✂️ Showing the first and last %d of %d output lines._\n\n", len(output), len(attempt.Output)))
+			}
+		}
+		sb.WriteString("</details>\n\n")
+	}
+}
+
+// writePassingOutput attaches the output of passing tests in collapsed
+// blocks, for debugging behavior that didn't make a test fail
+func writePassingOutput(sb *strings.Builder, data *ReportData) {
+	var names []string
+	for name, result := range data.Results {
+		if (result.Status == "PASS" || result.Status == "FLAKY") && len(passOutput(result.Output)) > 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	sb.WriteString("## 📜 Passing Test Output\n\n")
+	for _, name := range names {
+		result := data.Results[name]
+		output := passOutput(result.Output)
+		sb.WriteString("<details>\n")
+		sb.WriteString(fmt.Sprintf("<summary>✅ %s (%d lines)</summary>\n\n", name, len(output)))
+		sb.WriteString("```\n")
+		for _, line := range output {
+			sb.WriteString(line + "\n")
+		}
+		sb.WriteString("```\n")
+		sb.WriteString("</details>\n\n")
+	}
+}
+
+// passOutput drops the test framework's own RUN/PAUSE/CONT/PASS lines
+func passOutput(output []string) []string {
+	var lines []string
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- PASS") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// writeVariantMatrix renders each merged run's counts and, for tests that
+// failed in at least one run, their status per run (e.g. per sanitizer, or
+// per OS and Go version). With labelled runs, it also tells where each test
+// failed, so platform-specific failures stand out.
+func writeVariantMatrix(sb *strings.Builder, data *ReportData) {
+	if len(data.Dimensions) > 0 {
+		sb.WriteString("## 🧮 Test Matrix\n\n")
+	} else {
+		sb.WriteString("## 🧬 Sanitizer Matrix\n\n")
+	}
+
+	header := "| Test |"
+	separator := "| ---- |"
+	for _, variant := range data.Variants {
+		header += " " + variant + " |"
+		separator += " --- |"
+	}
+
+	passed := make(map[string]int)
+	failed := make(map[string]int)
+	var divergent []string
+	for _, name := range data.SortedTestNames {
+		result := data.Results[name]
+		anyFailed := false
+		for variant, status := range result.Variants {
+			switch status {
+			case "PASS", "FLAKY":
+				passed[variant]++
+			case "FAIL":
+				failed[variant]++
+				anyFailed = true
+			}
+		}
+		if anyFailed {
+			divergent = append(divergent, name)
+		}
+	}
+
+	sb.WriteString(strings.Replace(header, "Test", "Run", 1) + "\n")
+	sb.WriteString(separator + "\n")
+	passedRow := "| ✅ Passed |"
+	failedRow := "| ❌ Failed |"
+	for _, variant := range data.Variants {
+		passedRow += fmt.Sprintf(" %d |", passed[variant])
+		failedRow += fmt.Sprintf(" %d |", failed[variant])
+	}
+	sb.WriteString(passedRow + "\n" + failedRow + "\n\n")
+
+	if len(divergent) == 0 {
+		return
+	}
+
+	sb.WriteString("### Failures by Run\n\n")
+	if len(data.Dimensions) > 0 {
+		header += " Where |"
+		separator += " ----- |"
+	}
+	sb.WriteString(header + "\n")
+	sb.WriteString(separator + "\n")
+	for _, name := range divergent {
+		row := fmt.Sprintf("| %s |", name)
+		for _, variant := range data.Variants {
+			status, ran := data.Results[name].Variants[variant]
+			switch {
+			case !ran:
+				row += " - |"
+			case status == "FAIL":
+				row += " ❌ |"
+			case status == "SKIP":
+				row += " ⏭️ |"
+			default:
+				row += " ✅ |"
+			}
+		}
+		if len(data.Dimensions) > 0 {
+			scope := failureScope(data, data.Results[name])
+			if strings.HasPrefix(scope, "only ") {
+				scope = "⚠️ **" + scope + "**"
+			}
+			row += " " + scope + " |"
+		}
+		sb.WriteString(row + "\n")
+	}
+	sb.WriteString("\n")
+}
+
+// failureScope tells where a test failed among the labelled runs it ran in:
+// everywhere, only with one label value (e.g. only os=windows) or one
+// combination of them, or in how many of the runs
+func failureScope(data *ReportData, result *TestResult) string {
+	var ran, failed []string
+	for _, variant := range data.Variants {
+		status, ok := result.Variants[variant]
+		if !ok || status == "SKIP" {
+			continue
+		}
+		ran = append(ran, variant)
+		if status == "FAIL" {
+			failed = append(failed, variant)
+		}
+	}
+	if len(failed) == len(ran) {
+		return "everywhere"
+	}
+	for _, dimension := range data.Dimensions {
+		values := make(map[string][]string)
+		var order []string
+		for _, variant := range ran {
+			value, ok := variantLabels(variant)[dimension]
+			if !ok {
+				continue
+			}
+			if _, seen := values[value]; !seen {
+				order = append(order, value)
+			}
+			values[value] = append(values[value], variant)
+		}
+		for _, value := range order {
+			if slices.Equal(values[value], failed) {
+				return fmt.Sprintf("only %s=%s", dimension, value)
+			}
+		}
+	}
+	if len(failed) == 1 {
+		return "only " + failed[0]
+	}
+	return fmt.Sprintf("%d of %d runs", len(failed), len(ran))
+}
+
+// variantLabels splits a labelled run's variant, e.g. "os=linux go=1.22",
+// into its labels
+func variantLabels(variant string) map[string]string {
+	labels := make(map[string]string)
+	for _, label := range strings.Fields(variant) {
+		if key, value, ok := strings.Cut(label, "="); ok {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// writeDiagnostics notes problems with the input that may make the report
+// incomplete
+func writeDiagnostics(sb *strings.Builder, data *ReportData) {
+	sb.WriteString("## 🩺 Diagnostics\n\n")
+	sb.WriteString(fmt.Sprintf("- ⚠️ **Skipped lines:** %d input lines were not go test -json events and were left out (`-lenient`)\n\n",
+		data.Integrity.LinesSkipped))
+}
+
+// integrityComment renders the integrity summary as an HTML or XML comment,
+// which every report in those formats ends with. In Markdown it survives
+// rendering without cluttering the report.
+func integrityComment(integrity report.Integrity) string {
+	encoded, err := json.Marshal(integrity.Summary())
+	if err != nil {
+		return ""
+	}
+	// "--" would terminate the comment early
+	return fmt.Sprintf("<!-- gotest-report:integrity %s -->\n", strings.ReplaceAll(string(encoded), "--", "-\\u002d"))
+}
+
 // generateProgressBar creates a visual progress bar based on percentage
 func generateProgressBar(percentage float64) string {
 	barLength := 20
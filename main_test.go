@@ -1,8 +1,16 @@
 package main
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dipjyotimetia/gotest-report/report"
 )
 
 func TestProcessTestEvents(t *testing.T) {
@@ -148,7 +156,7 @@ func TestProcessTestEvents(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			reader := strings.NewReader(tt.jsonInput)
-			reportData, err := processTestEvents(reader)
+			reportData, err := processTestEvents(reader, report.ParseOptions{KeepPassOutput: true})
 
 			if tt.expectError && err == nil {
 				t.Fatal("Expected an error but got none")
@@ -235,6 +243,7 @@ func TestGenerateMarkdownReportSections(t *testing.T) {
 				"## 🎯 Test Status",
 				"## 📝 Test Results",
 				"## ⏱️ Test Durations",
+				"### Duration Distribution",
 				"![Status](https://img.shields.io/badge/Status-PASSED-brightgreen)",
 			},
 			notExpectedSections: []string{
@@ -303,10 +312,41 @@ func TestGenerateMarkdownReportSections(t *testing.T) {
 			},
 			notExpectedSections: []string{
 				"## 🔴 Failed Tests Details",
+				"### Duration Distribution",
 				"![Status](https://img.shields.io/badge/Status-PASSED-brightgreen)",
 				"![Status](https://img.shields.io/badge/Status-FAILED-red)",
 			},
 		},
+		{
+			name: "flaky tests should show flaky section and passed status",
+			reportData: &ReportData{
+				TotalTests:      2,
+				PassedTests:     1,
+				FlakyTests:      1,
+				TotalDuration:   0.5,
+				SortedTestNames: []string{"StableTest", "FlakyTest"},
+				Results: map[string]*TestResult{
+					"StableTest": {Name: "StableTest", Status: "PASS", Duration: 0.2},
+					"FlakyTest": {
+						Name:     "FlakyTest",
+						Status:   "FLAKY",
+						Duration: 0.3,
+						Attempts: []report.Attempt{{Status: "FAIL", Duration: 0.4}},
+					},
+				},
+			},
+			expectedSections: []string{
+				"- 🔁 **Flaky:** 1 (passed on re-run)",
+				"![Status](https://img.shields.io/badge/Status-PASSED-brightgreen)",
+				"🔁 FLAKY",
+				"## 🔁 Flaky Tests",
+				"| FlakyTest | 2 | FAIL (0.400s) → PASS (0.300s) |",
+			},
+			notExpectedSections: []string{
+				"## 🔴 Failed Tests Details",
+				"![Status](https://img.shields.io/badge/Status-FAILED-red)",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -451,3 +491,924 @@ func TestFormattingInMarkdownReport(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteReport(t *testing.T) {
+	t.Run("writes to file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "report.md")
+		if err := writeReport(path, "# report\n"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Reading report: %v", err)
+		}
+		if string(content) != "# report\n" {
+			t.Errorf("got %q, want %q", content, "# report\n")
+		}
+	})
+
+	t.Run("dash writes to stdout", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		stdout := os.Stdout
+		os.Stdout = w
+		defer func() { os.Stdout = stdout }()
+
+		if err := writeReport("-", "# report\n"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		w.Close()
+
+		content, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "# report\n" {
+			t.Errorf("got %q, want %q", content, "# report\n")
+		}
+	})
+}
+
+func TestIntegrityTrailerFormats(t *testing.T) {
+	data := &ReportData{
+		TotalTests: 1, FailedTests: 1, SortedTestNames: []string{"TestA"},
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Package: "example.com/a", Status: "FAIL", Duration: 0.1},
+		},
+		Integrity: report.Integrity{EventsParsed: 3, LinesSkipped: 1},
+	}
+	summary := `{"eventsParsed":3,"linesSkipped":1,"incompleteTests":[],"truncations":[],"complete":false}`
+	for format, want := range map[string]string{
+		"markdown": "<!-- gotest-report:integrity " + summary + " -->\n",
+		"html":     "<!-- gotest-report:integrity " + summary + " -->\n</body>",
+		"junit":    "<!-- gotest-report:integrity " + summary + " -->\n",
+		"xunit":    "<!-- gotest-report:integrity " + summary + " -->\n",
+		"nunit3":   "<!-- gotest-report:integrity " + summary + " -->\n",
+		"trx":      "<!-- gotest-report:integrity " + summary + " -->\n",
+		"jira":     "gotest-report:integrity " + summary + "\n",
+		"ndjson":   `{"integrity":` + summary + "}\n",
+		"influx":   "events_parsed=3i,lines_skipped=1i,incomplete_tests=0i,truncations=0i,complete=false",
+	} {
+		data.Metrics = &report.Metrics{}
+		content, err := renderReport(data, defaultConfig(), format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if i := strings.Index(content, "gotest-report "+version+": parsed"); i > strings.Index(content, "gotest-report:integrity") {
+			t.Errorf("%s report: metrics footnote after the integrity trailer:\n%s", format, content)
+		}
+		if format == "markdown" && !strings.HasSuffix(content, " -->\n") {
+			t.Errorf("integrity trailer should end the report:\n%s", content)
+		}
+		if !strings.Contains(content, want) {
+			t.Errorf("%s report missing %q:\n%s", format, want, content)
+		}
+	}
+}
+
+func TestIntegrityTrailer(t *testing.T) {
+	complete := generateMarkdownReport(&ReportData{
+		TotalTests:      1,
+		PassedTests:     1,
+		SortedTestNames: []string{"TestA"},
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Status: "PASS", Duration: 0.1},
+		},
+		Integrity: report.Integrity{EventsParsed: 2},
+	})
+	if !strings.Contains(complete, `<!-- gotest-report:integrity {"eventsParsed":2,"linesSkipped":0,"incompleteTests":[],"truncations":[],"complete":true} -->`) {
+		t.Errorf("complete trailer not found in report:\n%s", complete)
+	}
+	if !strings.HasSuffix(complete, " -->\n") {
+		t.Errorf("integrity trailer should end the report:\n%s", complete)
+	}
+
+	results := map[string]*TestResult{}
+	var names []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("Test%02d", i)
+		results[name] = &TestResult{Name: name, Status: "PASS", Duration: 0.1}
+		names = append(names, name)
+	}
+	capped := generateMarkdownReport(&ReportData{
+		TotalTests:      20,
+		PassedTests:     20,
+		SortedTestNames: names,
+		Results:         results,
+	})
+	// Tables that only show the top entries lose no data
+	if !strings.Contains(capped, `"truncations":[],"complete":true`) {
+		t.Error("capped durations table recorded as a truncation in trailer")
+	}
+}
+
+func TestFailureGroupsInReport(t *testing.T) {
+	refused := []string{"    db_test.go:10: Error: dial tcp 10.0.0.1:5432: connect: connection refused"}
+	markdown := generateMarkdownReport(&ReportData{
+		TotalTests:      3,
+		FailedTests:     3,
+		SortedTestNames: []string{"TestA", "TestB", "TestC"},
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Status: "FAIL", Output: refused},
+			"TestB": {Name: "TestB", Status: "FAIL", Output: refused},
+			"TestC": {Name: "TestC", Status: "FAIL", Output: []string{"    c_test.go:1: expected 1, got 2"}},
+		},
+	})
+
+	if !strings.Contains(markdown, "### 🧩 2 tests failed with: `db_test.go:10: Error: dial tcp <addr>: connect: connection refused`") {
+		t.Error("failure group heading not found")
+	}
+	if got := strings.Count(markdown, "connection refused\n"); got != 1 {
+		t.Errorf("shared failure output should be printed once, found %d times", got)
+	}
+	if got := strings.Count(markdown, "↪️ Same failure as 1 other tests"); got != 2 {
+		t.Errorf("expected 2 group references, found %d", got)
+	}
+	if !strings.Contains(markdown, "expected 1, got 2") {
+		t.Error("ungrouped failure output should still be shown")
+	}
+}
+
+func TestFailureGroupsTruncateRunes(t *testing.T) {
+	long := []string{"    x_test.go:1: " + strings.Repeat("é", 200)}
+	markdown := generateMarkdownReport(&ReportData{
+		TotalTests:      2,
+		FailedTests:     2,
+		SortedTestNames: []string{"TestA", "TestB"},
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Status: "FAIL", Output: long},
+			"TestB": {Name: "TestB", Status: "FAIL", Output: long},
+		},
+	})
+	if !utf8.ValidString(markdown) {
+		t.Error("long failure group message cut inside a rune")
+	}
+	if !strings.Contains(markdown, strings.Repeat("é", 10)+"…`") {
+		t.Errorf("long failure group message not truncated:\n%s", markdown)
+	}
+}
+
+func TestDuplicateSubTestsInReport(t *testing.T) {
+	timeout := []string{"    api_test.go:20: dial tcp 127.0.0.1:8080: i/o timeout"}
+	markdown := generateMarkdownReport(&ReportData{
+		TotalTests:      1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestAPI"},
+		Results: map[string]*TestResult{
+			"TestAPI":      {Name: "TestAPI", Status: "FAIL", SubTests: []string{"TestAPI/get", "TestAPI/list", "TestAPI/put"}, Output: []string{"--- FAIL: TestAPI"}},
+			"TestAPI/get":  {Name: "TestAPI/get", Status: "FAIL", IsSubTest: true, Output: timeout},
+			"TestAPI/list": {Name: "TestAPI/list", Status: "FAIL", IsSubTest: true, Output: timeout},
+			"TestAPI/put":  {Name: "TestAPI/put", Status: "FAIL", IsSubTest: true, Output: []string{"    api_test.go:31: expected 201, got 500"}},
+		},
+	})
+
+	for _, want := range []string{
+		"#### ❌ 2 subtests failed the same way\n\n<details>\n<summary>Affected subtests (2)</summary>\n\n- get\n- list\n",
+		"↪️ See failure group `",
+		"#### ❌ put\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	if strings.Contains(markdown, "#### ❌ get") {
+		t.Error("identical subtest failures should be collapsed")
+	}
+	if got := strings.Count(markdown, "i/o timeout\n"); got != 1 {
+		t.Errorf("shared failure output should be printed once, found %d times", got)
+	}
+}
+
+func TestSubTestDisplayNames(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestParse"},
+		Results: map[string]*TestResult{
+			"TestParse":               {Name: "TestParse", Status: "FAIL", SubTests: []string{"TestParse/empty%2Finput", "TestParse/no_data"}},
+			"TestParse/empty%2Finput": {Name: "TestParse/empty%2Finput", Status: "FAIL", IsSubTest: true, Output: []string{"    parse_test.go:9: unexpected EOF"}},
+			"TestParse/no_data":       {Name: "TestParse/no_data", Status: "FAIL", IsSubTest: true, Output: []string{"    parse_test.go:12: want error"}},
+		},
+	}
+
+	cfg := defaultConfig()
+	markdown := renderMarkdownReport(data, cfg)
+	for _, want := range []string{"<td>empty/input</td>", "#### ❌ empty/input\n", "#### ❌ no_data\n"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+
+	cfg.subTestSpaces = true
+	if markdown := renderMarkdownReport(data, cfg); !strings.Contains(markdown, "#### ❌ no data\n") {
+		t.Error("underscores should be shown as spaces with -subtest-spaces")
+	}
+}
+
+func TestRenderReportFormats(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestA"},
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Status: "FAIL", Output: []string{"    a_test.go:3: expected 1, got 2"}},
+		},
+	}
+
+	markdown, err := renderReport(data, defaultConfig(), "markdown")
+	if err != nil || !strings.Contains(markdown, "# 🧪 Test Summary Report") {
+		t.Errorf("markdown format: err=%v", err)
+	}
+
+	encoded, err := renderReport(data, defaultConfig(), "json")
+	if err != nil {
+		t.Fatalf("json format: %v", err)
+	}
+	if !strings.Contains(encoded, `"category": "assertion"`) || !strings.Contains(encoded, `"line": 3`) {
+		t.Errorf("json report missing excerpt:\n%s", encoded)
+	}
+
+	if err := checkFormat("docx"); err == nil {
+		t.Error("expected unknown format to be rejected")
+	}
+}
+
+func TestInvocationSections(t *testing.T) {
+	input := `{"Action":"run","Test":"TestA","Package":"pkg/a"}
+{"Action":"fail","Test":"TestA","Package":"pkg/a","Elapsed":0.1}
+{"Action":"fail","Package":"pkg/a"}
+{"Action":"run","Test":"TestA","Package":"pkg/a"}
+{"Action":"pass","Test":"TestA","Package":"pkg/a","Elapsed":0.1}
+{"Action":"pass","Package":"pkg/a"}
+`
+	data, err := processTestEvents(strings.NewReader(input), report.ParseOptions{KeepPassOutput: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	merged := generateMarkdownReport(data)
+	if !strings.Contains(merged, "- 🧾 **Invocations:** 2 go test runs in the input") {
+		t.Error("merged report should mention the invocations")
+	}
+	if strings.Contains(merged, "## 🧾 Invocations") {
+		t.Error("merged report should not render per-invocation sections")
+	}
+
+	cfg := defaultConfig()
+	cfg.separateInvocations = true
+	separate := renderMarkdownReport(data, cfg)
+	for _, want := range []string{
+		"## 🧾 Invocations",
+		"### ❌ Invocation 1 of 2\n\n- 📦 **Packages:** pkg/a\n- 🧪 **Tests:** 1 (✅ 0, ❌ 1, ⏭️ 0)",
+		"- ❌ `TestA`",
+		"### ✅ Invocation 2 of 2",
+	} {
+		if !strings.Contains(separate, want) {
+			t.Errorf("separate report missing %q", want)
+		}
+	}
+}
+
+func TestPassingOutput(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      2,
+		PassedTests:     2,
+		SortedTestNames: []string{"TestQuiet", "TestVerbose"},
+		Results: map[string]*TestResult{
+			"TestQuiet":   {Name: "TestQuiet", Status: "PASS", Output: []string{"=== RUN   TestQuiet", "--- PASS: TestQuiet (0.00s)"}},
+			"TestVerbose": {Name: "TestVerbose", Status: "PASS", Output: []string{"=== RUN   TestVerbose", "    v_test.go:4: using endpoint eu-west-1", "--- PASS: TestVerbose (0.00s)"}},
+		},
+	}
+
+	if strings.Contains(generateMarkdownReport(data), "Passing Test Output") {
+		t.Error("passing output should be off by default")
+	}
+
+	cfg := defaultConfig()
+	cfg.includePassOutput = true
+	markdown := renderMarkdownReport(data, cfg)
+	want := "## 📜 Passing Test Output\n\n<details>\n<summary>✅ TestVerbose (1 lines)</summary>\n\n```\n    v_test.go:4: using endpoint eu-west-1\n```\n</details>\n\n"
+	if !strings.Contains(markdown, want) {
+		t.Errorf("report missing passing output block")
+	}
+	if strings.Contains(markdown, "TestQuiet (") {
+		t.Error("tests without output of their own should be left out")
+	}
+}
+
+func TestDurationHistogram(t *testing.T) {
+	var sb strings.Builder
+	writeDurationHistogram(&sb, &ReportData{Results: map[string]*TestResult{
+		"TestFast":     {Name: "TestFast", Status: "PASS", Duration: 0.001},
+		"TestFast/sub": {Name: "TestFast/sub", Status: "PASS", Duration: 0.001, IsSubTest: true},
+		"TestQuick":    {Name: "TestQuick", Status: "PASS", Duration: 0.005},
+		"TestMedium":   {Name: "TestMedium", Status: "FAIL", Duration: 0.5},
+		"TestSlow":     {Name: "TestSlow", Status: "FLAKY", Duration: 10},
+		"TestSkipped":  {Name: "TestSkipped", Status: "SKIP"},
+		"TestCutShort": {Name: "TestCutShort", Status: "UNKNOWN"},
+	}})
+
+	want := "### Duration Distribution\n\n" +
+		"| Duration | Tests | Share |\n" +
+		"| -------- | ----- | ----- |\n" +
+		"| < 10ms | 2 | ██████████ 50% |\n" +
+		"| 10ms – 100ms | 0 |  0% |\n" +
+		"| 100ms – 1s | 1 | █████ 25% |\n" +
+		"| 1s – 10s | 0 |  0% |\n" +
+		"| ≥ 10s | 1 | █████ 25% |\n\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMermaidStatusPie(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      3,
+		PassedTests:     2,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestA", "TestB", "TestC"},
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Status: "PASS"},
+			"TestB": {Name: "TestB", Status: "PASS"},
+			"TestC": {Name: "TestC", Status: "FAIL"},
+		},
+	}
+
+	if strings.Contains(generateMarkdownReport(data), "```mermaid") {
+		t.Error("the pie chart should be off by default")
+	}
+
+	cfg := defaultConfig()
+	cfg.mermaid = true
+	want := "```mermaid\npie showData title Test Results\n    \"Passed\" : 2\n    \"Failed\" : 1\n```\n\n"
+	if markdown := renderMarkdownReport(data, cfg); !strings.Contains(markdown, want) {
+		t.Errorf("report missing pie chart without empty slices:\n%s", markdown)
+	}
+}
+
+func TestGoroutineLeakReport(t *testing.T) {
+	output := []string{
+		"    pool_test.go:12: found unexpected goroutines:",
+		"        [Goroutine 7 in state chan receive, with example.com/app/pool.worker on top of the stack:",
+		"        example.com/app/pool.worker()",
+		"        \t/src/app/pool/pool.go:48 +0x65",
+		"        created by example.com/app/pool.New in goroutine 6",
+		"        \t/src/app/pool/pool.go:30 +0x1d",
+		"        ]",
+	}
+	markdown := generateMarkdownReport(&ReportData{
+		TotalTests:      1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestPool"},
+		Results: map[string]*TestResult{
+			"TestPool": {Name: "TestPool", Status: "FAIL", Output: output},
+		},
+	})
+
+	for _, want := range []string{
+		"- 🚰 **Goroutine Leaks (LEAK):** 1 failed tests leaked goroutines",
+		"<summary>🚰 <b>LEAK: 1 leaked goroutines</b></summary>",
+		"**Goroutine 7** (chan receive) in `example.com/app/pool.worker`, created by **`example.com/app/pool.New`**",
+		"→ created by example.com/app/pool.New in goroutine 6",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+}
+
+func TestExampleReport(t *testing.T) {
+	markdown := generateMarkdownReport(&ReportData{
+		TotalTests:      2,
+		PassedTests:     1,
+		FailedTests:     1,
+		SortedTestNames: []string{"ExampleGreet", "TestGreet"},
+		Results: map[string]*TestResult{
+			"ExampleGreet": {Name: "ExampleGreet", Status: "FAIL", Output: []string{
+				"--- FAIL: ExampleGreet (0.00s)\n", "got:\n", "hi\n", "want:\n", "hello\n",
+			}},
+			"TestGreet": {Name: "TestGreet", Status: "PASS"},
+		},
+	})
+
+	for _, want := range []string{
+		"- 📘 **Examples:** 1 (1 failed)",
+		"| **ExampleGreet** 📘 | ❌ FAIL |",
+		"| **TestGreet** | ✅ PASS |",
+		"📘 **Example output mismatch** (`-` want, `+` got)\n\n```diff\n- hello\n+ hi\n```",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+}
+
+func TestShuffleSeedReport(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      2,
+		PassedTests:     1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestA", "TestB"},
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Package: "example.com/a", Status: "PASS"},
+			"TestB": {Name: "TestB", Package: "example.com/b", Status: "FAIL"},
+		},
+		ShuffleSeeds: map[string]string{"example.com/a": "11", "example.com/b": "22"},
+	}
+	markdown := generateMarkdownReport(data)
+	for _, want := range []string{
+		"- 🔀 **Shuffled:** 2 packages ran in random order (seeds below)",
+		"## 🔀 Reproduce Test Order",
+		"```sh\ngo test -shuffle=11 example.com/a\ngo test -shuffle=22 example.com/b  # failed\n```",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+
+	data.ShuffleSeeds = map[string]string{"example.com/a": "11"}
+	if markdown := generateMarkdownReport(data); !strings.Contains(markdown, "- 🔀 **Shuffle Seed:** `11`") {
+		t.Error("report missing the single shuffle seed")
+	}
+
+	data.ShuffleSeeds = nil
+	if markdown := generateMarkdownReport(data); strings.Contains(markdown, "🔀") {
+		t.Error("unshuffled report mentions shuffling")
+	}
+}
+
+func TestEnvironmentSection(t *testing.T) {
+	data := &ReportData{
+		Results: map[string]*TestResult{},
+		Environment: &report.Environment{
+			GoVersion: "go1.22.4",
+			GOOS:      "linux",
+			GOARCH:    "amd64",
+			NumCPU:    4,
+			CI:        map[string]string{"GITHUB_JOB": "unit-tests", "RUNNER_OS": "Linux"},
+		},
+	}
+
+	markdown := generateMarkdownReport(data)
+	want := "## 🖥️ Environment\n\n| Setting | Value |\n| ------- | ----- |\n" +
+		"| Go | go1.22.4 |\n| Platform | linux/amd64 |\n| CPUs | 4 |\n| `RUNNER_OS` | Linux |\n| `GITHUB_JOB` | unit-tests |\n\n"
+	if !strings.Contains(markdown, want) {
+		t.Errorf("report missing environment section:\n%s", markdown)
+	}
+
+	data.Environment = nil
+	if strings.Contains(generateMarkdownReport(data), "Environment") {
+		t.Error("report has an environment section although none was requested")
+	}
+}
+
+func TestQualityGateReport(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestA"},
+		Results:         map[string]*TestResult{"TestA": {Name: "TestA", Status: "FAIL"}},
+		QualityGate: &report.GateResult{Violations: []string{
+			"pass rate 0.0% is below the minimum of 95%",
+		}},
+	}
+
+	markdown := generateMarkdownReport(data)
+	want := "# 🧪 Test Summary Report\n\n🚫 ![Quality Gate](https://img.shields.io/badge/Quality_Gate-FAILED-red) 🚫\n\n" +
+		"> **Quality gate failed**\n> - pass rate 0.0% is below the minimum of 95%\n\n## 📊 Summary"
+	if !strings.Contains(markdown, want) {
+		t.Errorf("report missing quality gate banner:\n%s", markdown[:300])
+	}
+	if !strings.Contains(markdown, "- 🚦 **Quality Gate:** failed (1 thresholds breached)") {
+		t.Error("summary missing failed quality gate")
+	}
+
+	data.QualityGate = &report.GateResult{Passed: true, Violations: []string{}}
+	markdown = generateMarkdownReport(data)
+	if strings.Contains(markdown, "Quality gate failed") || !strings.Contains(markdown, "- 🚦 **Quality Gate:** passed") {
+		t.Error("passed quality gate rendered wrongly")
+	}
+}
+
+func TestBaselineComparisonSection(t *testing.T) {
+	baseline := &ReportData{Results: map[string]*TestResult{
+		"TestBroken": {Name: "TestBroken", Package: "example.com/a", Status: "PASS"},
+		"TestFixed":  {Name: "TestFixed", Package: "example.com/a", Status: "FAIL"},
+		"TestOld":    {Name: "TestOld", Package: "example.com/a", Status: "FAIL"},
+	}}
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestBroken": {Name: "TestBroken", Package: "example.com/a", Status: "FAIL"},
+		"TestFixed":  {Name: "TestFixed", Package: "example.com/a", Status: "PASS"},
+		"TestOld":    {Name: "TestOld", Package: "example.com/a", Status: "FAIL"},
+	}}
+	data.Comparison = report.Diff(baseline, data)
+
+	markdown := generateMarkdownReport(data)
+	for _, want := range []string{
+		"## 🔀 Comparison with Baseline",
+		"### 🆕 New failures (1)\n\n- `TestBroken` (example.com/a)\n",
+		"### ✅ Fixed since baseline (1)\n\n- `TestFixed` (example.com/a)\n",
+		"### 🔁 Still failing (1)\n\n- `TestOld` (example.com/a)\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q:\n%s", want, markdown)
+		}
+	}
+	if strings.Index(markdown, "Comparison with Baseline") > strings.Index(markdown, "## 📝 Test Results") {
+		t.Error("comparison should come before the results table")
+	}
+	if strings.Contains(generateMarkdownReport(&ReportData{Results: map[string]*TestResult{}}), "Comparison with Baseline") {
+		t.Error("comparison rendered without a baseline")
+	}
+}
+
+func TestBenchmarksSection(t *testing.T) {
+	markdown := generateMarkdownReport(&ReportData{
+		Results: map[string]*TestResult{},
+		Benchmarks: []report.BenchmarkResult{
+			{Name: "BenchmarkServe", Package: "example.com/a", Metrics: map[string]float64{"ns/op": 100, "req/s": 9000, "p99-ms": 1.5}},
+			{Name: "BenchmarkServe", Package: "example.com/a", Metrics: map[string]float64{"ns/op": 120, "req/s": 11000, "p99-ms": 2.5}},
+			{Name: "BenchmarkParse", Package: "example.com/a", Metrics: map[string]float64{"ns/op": 50, "B/op": 16}},
+		},
+	})
+	want := "## 🏎️ Benchmarks\n\n| Benchmark | Runs | ns/op | B/op | p99-ms | req/s |\n" +
+		"| --------- | ---- | ---: | ---: | ---: | ---: |\n" +
+		"| `BenchmarkParse` | 1 | 50 | 16 | — | — |\n" +
+		"| `BenchmarkServe` | 2 | 110 ±9% | — | 2 ±25% | 10000 ±10% |\n"
+	if !strings.Contains(markdown, want) {
+		t.Errorf("report missing benchmarks table:\n%s", markdown)
+	}
+	if strings.Contains(generateMarkdownReport(&ReportData{Results: map[string]*TestResult{}}), "Benchmarks") {
+		t.Error("benchmarks table rendered without benchmarks")
+	}
+}
+
+func TestSlowestPackagesSection(t *testing.T) {
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestA": {Name: "TestA", Package: "example.com/a", Status: "PASS", Duration: 1},
+		"TestB": {Name: "TestB", Package: "example.com/b", Status: "FAIL", Duration: 4},
+		"TestC": {Name: "TestC", Package: "example.com/c", Status: "PASS", Duration: 2},
+	}}
+	cfg := defaultConfig()
+	cfg.slowestPackages = 2
+
+	markdown := renderMarkdownReport(data, cfg)
+	want := "## 📦 Slowest Packages\n\n| Package | Tests | ❌ | Duration |\n| ------- | ----- | -- | -------- |\n" +
+		"| example.com/b | 1 | 1 | 4.00s |\n| example.com/c | 1 | 0 | 2.00s |\n\n"
+	if !strings.Contains(markdown, want) {
+		t.Errorf("report missing slowest packages:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, `"truncations":[],"complete":true`) {
+		t.Error("integrity trailer should not count the limited table as a truncation")
+	}
+
+	cfg.slowestPackages = 0
+	if strings.Contains(renderMarkdownReport(data, cfg), "Slowest Packages") {
+		t.Error("table rendered with -slowest-packages 0")
+	}
+	delete(data.Results, "TestB")
+	delete(data.Results, "TestC")
+	if strings.Contains(generateMarkdownReport(data), "Slowest Packages") {
+		t.Error("table rendered for a single package")
+	}
+}
+
+func TestTopSlowAndSlowThreshold(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      3,
+		PassedTests:     3,
+		SortedTestNames: []string{"TestA", "TestB", "TestC"},
+		Results: map[string]*TestResult{
+			"TestA":     {Name: "TestA", Status: "PASS", Duration: 0.5},
+			"TestB":     {Name: "TestB", Status: "PASS", Duration: 3, SubTests: []string{"TestB/sub"}},
+			"TestB/sub": {Name: "TestB/sub", Status: "PASS", Duration: 2.5, IsSubTest: true, ParentTest: "TestB"},
+			"TestC":     {Name: "TestC", Status: "PASS", Duration: 1},
+		},
+	}
+	cfg := defaultConfig()
+	cfg.topSlow = 2
+	cfg.slowThreshold = 2
+
+	markdown := renderMarkdownReport(data, cfg)
+	for _, want := range []string{
+		"- 🐢 **Slow:** 1 tests took longer than 2s\n",
+		"| **TestA** | ✅ PASS | 0.500s | - |\n",
+		"| **TestB** | ✅ PASS | 3.000s 🐢 |",
+		"<td>2.500s 🐢</td>",
+		`"truncations":[],"complete":true`,
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q:\n%s", want, markdown)
+		}
+	}
+
+	cfg.topSlow = 0
+	cfg.slowThreshold = 0
+	markdown = renderMarkdownReport(data, cfg)
+	if strings.Contains(markdown, "🐢") || strings.Contains(markdown, "Click to expand test durations") {
+		t.Errorf("got slow markers or a durations table with both turned off:\n%s", markdown)
+	}
+}
+
+func TestAggregateParentDurations(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      1,
+		PassedTests:     1,
+		SortedTestNames: []string{"TestTable"},
+		Results: map[string]*TestResult{
+			"TestTable":        {Name: "TestTable", Status: "PASS", Duration: 0.01, SubTests: []string{"TestTable/a", "TestTable/b"}},
+			"TestTable/a":      {Name: "TestTable/a", Status: "PASS", Duration: 1, IsSubTest: true, SubTests: []string{"TestTable/a/deep"}},
+			"TestTable/a/deep": {Name: "TestTable/a/deep", Status: "PASS", Duration: 1.5, IsSubTest: true},
+			"TestTable/b":      {Name: "TestTable/b", Status: "PASS", Duration: 2, IsSubTest: true},
+		},
+	}
+
+	if markdown := generateMarkdownReport(data); !strings.Contains(markdown, "| **TestTable** | ✅ PASS | 0.010s |") {
+		t.Errorf("parent should show its own duration by default:\n%s", markdown)
+	}
+
+	cfg := defaultConfig()
+	cfg.aggregateParentDurations = true
+	markdown := renderMarkdownReport(data, cfg)
+	for _, want := range []string{
+		"| **TestTable** | ✅ PASS | 3.500s |",
+		"<tr><td>a</td><td>✅ PASS</td><td>1.500s</td></tr>",
+		"| TestTable | 3.500s ",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestHistorySparkline(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      2,
+		PassedTests:     1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestNew", "TestOld"},
+		Results: map[string]*TestResult{
+			"TestNew": {Name: "TestNew", Status: "PASS", Duration: 0.1},
+			"TestOld": {Name: "TestOld", Status: "FAIL", Duration: 0.2},
+		},
+	}
+	history := &report.History{}
+	for _, tests := range []map[string]string{
+		{"TestOld": "PASS"},
+		{"TestOld": "FAIL"},
+		{"TestOld": "FLAKY"},
+		{"TestOld": "FAIL", "TestNew": "PASS"},
+	} {
+		history.Runs = append(history.Runs, report.HistoryRun{Tests: tests})
+	}
+
+	if strings.Contains(generateMarkdownReport(data), "Recent Runs") {
+		t.Error("sparklines should be off without a history")
+	}
+
+	cfg := defaultConfig()
+	cfg.history, cfg.historyRuns = history, 3
+	markdown := renderMarkdownReport(data, cfg)
+	for _, want := range []string{
+		"| Test | Status | Duration | Recent Runs | Details |\n",
+		"| **TestNew** | ✅ PASS | 0.100s | ▫️▫️✅ | - |\n",
+		"| **TestOld** | ❌ FAIL | 0.200s | ❌🔁❌ | - |\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestTrend(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      1,
+		PassedTests:     1,
+		SortedTestNames: []string{"TestA"},
+		Results:         map[string]*TestResult{"TestA": {Name: "TestA", Status: "PASS", Duration: 1}},
+	}
+	history := &report.History{Runs: []report.HistoryRun{
+		{Total: 4, Passed: 1, Duration: 9},
+		{Total: 4, Passed: 4, Duration: 2},
+		{Total: 4, Passed: 3, Duration: 3},
+		{Total: 4, Passed: 2, Duration: 4},
+	}}
+
+	cfg := defaultConfig()
+	cfg.history, cfg.trendRuns = history, 3
+	markdown := renderMarkdownReport(data, cfg)
+	for _, want := range []string{
+		"### 📈 Trend (last 3 runs)\n",
+		"Pass rate  █▅▁  100.0% → 50.0%\n",
+		"Duration   ▁▅█  2.00s → 4.00s\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q:\n%s", want, markdown)
+		}
+	}
+	if strings.Contains(markdown, "xychart-beta") {
+		t.Error("trend charts should need -mermaid")
+	}
+
+	cfg.mermaid = true
+	markdown = renderMarkdownReport(data, cfg)
+	for _, want := range []string{
+		"    title \"Pass rate (%)\"\n    x-axis \"Run\" [1, 2, 3]\n    line [100.0, 75.0, 50.0]\n",
+		"    title \"Duration (s)\"\n    x-axis \"Run\" [1, 2, 3]\n    line [2.00, 3.00, 4.00]\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q:\n%s", want, markdown)
+		}
+	}
+
+	cfg.history = &report.History{Runs: history.Runs[:1]}
+	if strings.Contains(renderMarkdownReport(data, cfg), "Trend") {
+		t.Error("a single run should have no trend")
+	}
+}
+
+func TestFailingStreak(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      3,
+		FailedTests:     3,
+		SortedTestNames: []string{"TestNew", "TestRot"},
+		Results: map[string]*TestResult{
+			"TestNew":      {Name: "TestNew", Status: "FAIL", Output: []string{"boom\n"}},
+			"TestRot":      {Name: "TestRot", Status: "FAIL", Output: []string{"boom\n"}, SubTests: []string{"TestRot/case"}},
+			"TestRot/case": {Name: "TestRot/case", Status: "FAIL", Output: []string{"boom\n"}, IsSubTest: true},
+		},
+	}
+	start := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	history := &report.History{}
+	for i, tests := range []map[string]string{
+		{"TestRot": "PASS", "TestRot/case": "FAIL"},
+		{"TestRot": "FAIL", "TestRot/case": "FAIL"},
+		{"TestRot": "FAIL", "TestRot/case": "FAIL", "TestNew": "FAIL"},
+	} {
+		commit := fmt.Sprintf("%d%039d", i+1, 0)
+		history.Runs = append(history.Runs, report.HistoryRun{Timestamp: start.AddDate(0, 0, i), Commit: commit, Tests: tests})
+	}
+
+	cfg := defaultConfig()
+	cfg.history = history
+	markdown := renderMarkdownReport(data, cfg)
+	for _, want := range []string{
+		"### ❌ TestNew\n\n🆕 **New failure:**",
+		"### ❌ TestRot\n\n⏳ **Failing for 2 runs** / since 2024-05-03\n",
+		"#### ❌ case\n\n⏳ **Failing for 3 runs** / since 2024-05-02\n",
+		"⏳ **Failing for 2 runs** / since 2024-05-03\n\n🔎 **Last passed at** `1000000`, **first failed at** `2000000`: `git bisect start 2000000 1000000`\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q:\n%s", want, markdown)
+		}
+	}
+
+	if strings.Count(markdown, "🔎") != 1 {
+		t.Errorf("only TestRot has a recorded passing run to bisect from:\n%s", markdown)
+	}
+
+	cfg.history = &report.History{Runs: history.Runs[2:]}
+	if markdown := renderMarkdownReport(data, cfg); strings.Contains(markdown, "New failure") {
+		t.Errorf("a single recorded run has no streaks:\n%s", markdown)
+	}
+}
+
+func TestLabelsSection(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      2,
+		PassedTests:     1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestDB", "TestUnit"},
+		Results: map[string]*TestResult{
+			"TestDB":   {Name: "TestDB", Status: "FAIL", Duration: 2, Labels: []string{"db", "integration"}},
+			"TestUnit": {Name: "TestUnit", Status: "PASS", Duration: 0.5},
+		},
+	}
+	markdown := generateMarkdownReport(data)
+	for _, want := range []string{
+		"## 🏷️ Labels\n\n",
+		"| `db` | 1 | 0 | 1 | 0 | 0 | 0.0% | 2.00s |\n",
+		"| **TestDB** `db` `integration` | ❌ FAIL |",
+		"| **TestUnit** | ✅ PASS |",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q:\n%s", want, markdown)
+		}
+	}
+
+	data.Results["TestDB"].Labels = nil
+	if strings.Contains(generateMarkdownReport(data), "Labels") {
+		t.Error("the labels section should be left out without labels")
+	}
+}
+
+func TestCategoriesSection(t *testing.T) {
+	data := &ReportData{Categories: []report.CategorySummary{
+		{Category: "unit", Total: 4, Passed: 4, Duration: 1, MinPassRate: 100},
+		{Category: "e2e", Total: 2, Passed: 1, Failed: 1, Duration: 8, MinPassRate: 90},
+		{Category: report.Uncategorized, Total: 1, Skipped: 1},
+	}}
+	markdown := generateMarkdownReport(data)
+	for _, want := range []string{
+		"## 🗂️ Categories\n\n",
+		"| unit | 4 | 4 | 0 | 0 | 0 | 100.0% ✅ (min 100%) | 1.00s |\n",
+		"| e2e | 2 | 1 | 1 | 0 | 0 | 50.0% ❌ (min 90%) | 8.00s |\n",
+		"| uncategorized | 1 | 0 | 0 | 1 | 0 | 0.0% | 0.00s |\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestDiagnosticsSection(t *testing.T) {
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestA": {Name: "TestA", Package: "example.com/a", Status: "PASS", Duration: 1},
+	}}
+	if strings.Contains(generateMarkdownReport(data), "Diagnostics") {
+		t.Error("diagnostics rendered for clean input")
+	}
+
+	data.Integrity.LinesSkipped = 3
+	markdown := generateMarkdownReport(data)
+	if !strings.Contains(markdown, "## 🩺 Diagnostics\n\n- ⚠️ **Skipped lines:** 3 input lines were not go test -json events") {
+		t.Errorf("report missing diagnostics:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, `"linesSkipped":3`) || !strings.Contains(markdown, `"complete":false`) {
+		t.Error("integrity trailer should count the skipped lines")
+	}
+}
+
+func TestDurationRegressionsSection(t *testing.T) {
+	markdown := generateMarkdownReport(&ReportData{
+		Results: map[string]*TestResult{},
+		DurationRegressions: []report.DurationRegression{
+			{Baseline: 10, Current: 15},
+			{Name: "TestSlow|Pipe", Package: "example.com/a", Baseline: 1, Current: 3},
+		},
+	})
+	want := "## 🐢 Duration Regressions\n\n> ⚠️ Slower than the baseline allows.\n\n" +
+		"| Test | Baseline | Current | Change |\n| ---- | -------- | ------- | ------ |\n" +
+		"| **Total** | 10.00s | 15.00s | +50% |\n| `TestSlow\\|Pipe` | 1.00s | 3.00s | +200% |\n"
+	if !strings.Contains(markdown, want) {
+		t.Errorf("report missing duration regressions:\n%s", markdown)
+	}
+}
+
+func TestMetricsFootnote(t *testing.T) {
+	data := &ReportData{
+		Results:   map[string]*TestResult{},
+		Integrity: report.Integrity{EventsParsed: 42},
+		Metrics:   &report.Metrics{InputBytes: 3 * 1024 * 1024, ParseSeconds: 0.5},
+	}
+
+	markdown, err := renderReport(data, defaultConfig(), "markdown")
+	if err != nil {
+		t.Fatalf("renderReport: %v", err)
+	}
+	if !strings.Contains(markdown, "<sub>⚙️ gotest-report dev: parsed 3.0 MiB (42 events) in 0.50s, rendered in ") {
+		t.Errorf("markdown missing metrics footnote:\n%s", markdown[len(markdown)-200:])
+	}
+	if data.Metrics.PeakMemoryBytes == 0 {
+		t.Error("peak memory should be recorded")
+	}
+
+	page, err := renderReport(data, defaultConfig(), "html")
+	if err != nil {
+		t.Fatalf("renderReport: %v", err)
+	}
+	if !strings.Contains(page, "<p><small>⚙️ gotest-report dev: parsed 3.0 MiB") || !strings.HasSuffix(strings.TrimSpace(page), "</html>") {
+		t.Error("html missing metrics footnote before </body>")
+	}
+
+	for n, want := range map[int64]string{512: "512 B", 1536: "1.5 KiB", 5 << 30: "5.0 GiB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d): got %s, want %s", n, got, want)
+		}
+	}
+}
+
+func TestSkipReasonsInReport(t *testing.T) {
+	markdown := generateMarkdownReport(&ReportData{
+		TotalTests:      3,
+		SkippedTests:    3,
+		SortedTestNames: []string{"TestA", "TestB", "TestC"},
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Status: "SKIP", SkipReason: "requires docker"},
+			"TestB": {Name: "TestB", Status: "SKIP", SkipReason: "requires docker"},
+			"TestC": {Name: "TestC", Status: "SKIP", SkipReason: "flag a|b not set"},
+		},
+	})
+
+	for _, want := range []string{
+		"## ⏭️ Skip Reasons\n\n- **2 tests skipped:** requires docker\n- **1 test skipped:** flag a|b not set\n",
+		"| **TestA** | ⏭️ SKIP | 0.000s | ⏭️ requires docker |",
+		`| ⏭️ flag a\|b not set |`,
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+}
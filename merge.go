@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// mergeCommand implements `gotest-report merge [flags] files...`, which
+// interleaves the go test -json output of several shards into one stream by
+// event time, for tools that only take a single file
+func mergeCommand(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	outputFile := fs.String("output", "-", "Output go test -json file (use - for stdout)")
+	maxOpenFiles := fs.Int("max-open-files", report.DefaultMaxOpenFiles, "Maximum number of input files open at once; more are merged in batches through temporary files")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: gotest-report merge [flags] file.json...\n\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var w io.Writer = os.Stdout
+	if *outputFile != "-" {
+		file, err := os.Create(*outputFile)
+		if err != nil {
+			logger.Error("creating output file", "error", err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := mergeFiles(w, fs.Args(), *maxOpenFiles); err != nil {
+		logger.Error("merging inputs", "error", err)
+		return 1
+	}
+	if *outputFile != "-" {
+		fmt.Printf("Merged %d files into %s\n", fs.NArg(), *outputFile)
+	}
+	return 0
+}
+
+// mergeFiles merges the go test -json files at paths into w, keeping at most
+// maxOpen of them open at once. Beyond that, consecutive batches are merged
+// into temporary files first, which keeps events with equal times in the
+// order of the files.
+func mergeFiles(w io.Writer, paths []string, maxOpen int) error {
+	if maxOpen < 2 {
+		maxOpen = report.DefaultMaxOpenFiles
+	}
+	for len(paths) > maxOpen {
+		var merged []string
+		for start := 0; start < len(paths); start += maxOpen {
+			batch := paths[start:min(start+maxOpen, len(paths))]
+			if len(batch) == 1 {
+				merged = append(merged, batch[0])
+				continue
+			}
+			temp, err := os.CreateTemp("", "gotest-report-merge-*.json")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(temp.Name())
+			err = mergeBatch(temp, batch)
+			if closeErr := temp.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+			merged = append(merged, temp.Name())
+		}
+		paths = merged
+	}
+	return mergeBatch(w, paths)
+}
+
+// mergeBatch merges the files at paths into w, opening all of them
+func mergeBatch(w io.Writer, paths []string) error {
+	readers := make([]io.Reader, len(paths))
+	for i, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		readers[i] = file
+	}
+	return report.MergeStreams(w, readers)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeFilesBatches(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", t.TempDir())
+	var paths, want []string
+	for i := 0; i < 5; i++ {
+		// Every file has an event at the same time, which keeps file order
+		lines := []string{
+			fmt.Sprintf(`{"Time":"2024-01-01T00:00:%02dZ","Action":"run","Package":"pkg/%d","Test":"TestA"}`, 5-i, i),
+			fmt.Sprintf(`{"Time":"2024-01-01T00:00:10Z","Action":"pass","Package":"pkg/%d","Test":"TestA"}`, i),
+		}
+		path := filepath.Join(dir, fmt.Sprintf("shard-%d.json", i))
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+		want = append([]string{lines[0]}, want...)
+	}
+	for i := 0; i < 5; i++ {
+		want = append(want, fmt.Sprintf(`{"Time":"2024-01-01T00:00:10Z","Action":"pass","Package":"pkg/%d","Test":"TestA"}`, i))
+	}
+
+	var out strings.Builder
+	if err := mergeFiles(&out, paths, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != strings.Join(want, "\n")+"\n" {
+		t.Errorf("merged stream:\ngot:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+	if left, _ := os.ReadDir(os.Getenv("TMPDIR")); len(left) != 0 {
+		t.Errorf("temporary files left behind: %d", len(left))
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NUnit 3 XML, as read by Azure DevOps' NUnit result format. Unlike JUnit
+// and xUnit, NUnit nests: a package is an Assembly suite, a test with
+// subtests is a ParameterizedMethod suite holding them, and only tests
+// without subtests are test cases.
+type nunitTestRun struct {
+	XMLName xml.Name `xml:"test-run"`
+	nunitCounts
+	ID            string           `xml:"id,attr"`
+	TestCaseCount int              `xml:"testcasecount,attr"`
+	Result        string           `xml:"result,attr"`
+	StartTime     string           `xml:"start-time,attr"`
+	EndTime       string           `xml:"end-time,attr"`
+	Duration      string           `xml:"duration,attr"`
+	Suites        []nunitTestSuite `xml:"test-suite"`
+}
+
+// nunitCounts are the test case counts of a run or suite
+type nunitCounts struct {
+	Total        int `xml:"total,attr"`
+	Passed       int `xml:"passed,attr"`
+	Failed       int `xml:"failed,attr"`
+	Inconclusive int `xml:"inconclusive,attr"`
+	Skipped      int `xml:"skipped,attr"`
+}
+
+func (c *nunitCounts) add(other nunitCounts) {
+	c.Total += other.Total
+	c.Passed += other.Passed
+	c.Failed += other.Failed
+	c.Inconclusive += other.Inconclusive
+	c.Skipped += other.Skipped
+}
+
+type nunitTestSuite struct {
+	Type     string `xml:"type,attr"`
+	ID       string `xml:"id,attr"`
+	Name     string `xml:"name,attr"`
+	FullName string `xml:"fullname,attr"`
+	RunState string `xml:"runstate,attr"`
+	nunitCounts
+	TestCaseCount int              `xml:"testcasecount,attr"`
+	Result        string           `xml:"result,attr"`
+	Label         string           `xml:"label,attr,omitempty"`
+	Site          string           `xml:"site,attr,omitempty"`
+	Duration      string           `xml:"duration,attr"`
+	Failure       *nunitMessage    `xml:"failure,omitempty"`
+	Output        *nunitText       `xml:"output,omitempty"`
+	Suites        []nunitTestSuite `xml:"test-suite"`
+	Cases         []nunitTestCase  `xml:"test-case"`
+}
+
+type nunitTestCase struct {
+	ID         string        `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	FullName   string        `xml:"fullname,attr"`
+	MethodName string        `xml:"methodname,attr"`
+	ClassName  string        `xml:"classname,attr"`
+	RunState   string        `xml:"runstate,attr"`
+	Result     string        `xml:"result,attr"` // Passed, Failed or Skipped
+	Label      string        `xml:"label,attr,omitempty"`
+	Duration   string        `xml:"duration,attr"`
+	Failure    *nunitMessage `xml:"failure,omitempty"`
+	Reason     *nunitMessage `xml:"reason,omitempty"`
+	Output     *nunitText    `xml:"output,omitempty"`
+}
+
+type nunitMessage struct {
+	Message    nunitText  `xml:"message"`
+	StackTrace *nunitText `xml:"stack-trace,omitempty"`
+}
+
+type nunitText struct {
+	Text string `xml:",chardata"`
+}
+
+// nunitWriter numbers the suites and cases, which NUnit identifies by id
+type nunitWriter struct {
+	data   *ReportData
+	nextID int
+}
+
+func (w *nunitWriter) id() string {
+	w.nextID++
+	return fmt.Sprintf("0-%d", w.nextID)
+}
+
+// renderNUnit renders data as NUnit 3 XML. Flaky tests pass and tests that
+// never reported a result fail with the Error label.
+func renderNUnit(data *ReportData, now time.Time) (string, error) {
+	w := &nunitWriter{data: data}
+	run := nunitTestRun{ID: "0"}
+	var total float64
+	packages, byPackage := resultsByPackage(data)
+	for _, pkg := range packages {
+		suite := nunitTestSuite{Type: "Assembly", ID: w.id(), Name: pkg, FullName: pkg, RunState: "Runnable"}
+		var duration float64
+		for _, result := range byPackage[pkg] {
+			if parent, ok := data.Results[result.ParentTest]; result.IsSubTest && ok && parent.Package == pkg {
+				continue // nested under its parent
+			}
+			w.addTest(&suite, result)
+			duration += result.Duration
+		}
+		suite.Duration = junitTime(duration)
+		w.finishSuite(&suite)
+		run.add(suite.nunitCounts)
+		run.Suites = append(run.Suites, suite)
+		total += duration
+	}
+	run.TestCaseCount = run.Total
+	run.Result = "Passed"
+	for _, suite := range run.Suites {
+		if suite.Result == "Failed" {
+			run.Result = "Failed"
+		}
+	}
+	run.StartTime = now.Add(-time.Duration(total * float64(time.Second))).UTC().Format("2006-01-02 15:04:05Z")
+	run.EndTime = now.UTC().Format("2006-01-02 15:04:05Z")
+	run.Duration = junitTime(total)
+
+	encoded, err := xml.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(encoded) + "\n" + integrityComment(data.Integrity), nil
+}
+
+// addTest adds result to suite: as a test case, or as a suite of its
+// subtests when it has any
+func (w *nunitWriter) addTest(suite *nunitTestSuite, result *TestResult) {
+	output := strings.Join(result.Output, "\n")
+	if result.Status == "FLAKY" {
+		output = fmt.Sprintf("Passed on attempt %d after failing %d times\n", len(result.Attempts)+1, len(result.Attempts)) + output
+	}
+	name := result.Name
+	if result.ParentTest != "" {
+		name = strings.TrimPrefix(name, result.ParentTest+"/")
+	}
+
+	var subTests []*TestResult
+	for _, subName := range result.SubTests {
+		if sub, ok := w.data.Results[subName]; ok {
+			subTests = append(subTests, sub)
+		}
+	}
+	if len(subTests) == 0 {
+		testCase := nunitTestCase{
+			ID: w.id(), Name: name, FullName: result.Name, MethodName: result.Name, ClassName: result.Package,
+			RunState: "Runnable", Duration: junitTime(result.Duration),
+		}
+		testCase.Result, testCase.Label = nunitResult(result.Status)
+		switch testCase.Result {
+		case "Failed":
+			testCase.Failure = nunitFailure(result)
+		case "Skipped":
+			testCase.Reason = &nunitMessage{Message: nunitText{result.SkipReason}}
+		}
+		if output != "" {
+			testCase.Output = &nunitText{output}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+		return
+	}
+
+	method := nunitTestSuite{
+		Type: "ParameterizedMethod", ID: w.id(), Name: name, FullName: result.Name, RunState: "Runnable",
+		Duration: junitTime(result.Duration),
+	}
+	for _, sub := range subTests {
+		w.addTest(&method, sub)
+	}
+	w.finishSuite(&method)
+	// The parent's own outcome: it can fail, or never finish, with every
+	// subtest passing
+	if status, label := nunitResult(result.Status); status == "Failed" && method.Result != "Failed" {
+		method.Result, method.Label = status, label
+		method.Failure = nunitFailure(result)
+	}
+	if output != "" {
+		method.Output = &nunitText{output}
+	}
+	suite.Suites = append(suite.Suites, method)
+}
+
+// finishSuite adds up the counts of a suite's children and derives its
+// result from them
+func (w *nunitWriter) finishSuite(suite *nunitTestSuite) {
+	failed := false
+	for _, child := range suite.Suites {
+		suite.add(child.nunitCounts)
+		failed = failed || child.Result == "Failed"
+	}
+	for _, testCase := range suite.Cases {
+		suite.Total++
+		switch testCase.Result {
+		case "Passed":
+			suite.Passed++
+		case "Failed":
+			suite.Failed++
+		case "Skipped":
+			suite.Skipped++
+		}
+	}
+	suite.TestCaseCount = suite.Total
+	switch {
+	case suite.Failed > 0 || failed:
+		suite.Result, suite.Site = "Failed", "Child"
+	case suite.Total > 0 && suite.Skipped == suite.Total:
+		suite.Result = "Skipped"
+	default:
+		suite.Result = "Passed"
+	}
+}
+
+// nunitResult maps a go test status to an NUnit result and label
+func nunitResult(status string) (string, string) {
+	switch status {
+	case "PASS", "FLAKY":
+		return "Passed", ""
+	case "SKIP":
+		return "Skipped", ""
+	case "FAIL":
+		return "Failed", ""
+	}
+	return "Failed", "Error"
+}
+
+// nunitFailure describes a failure as in xUnit output
+func nunitFailure(result *TestResult) *nunitMessage {
+	message := "test failed"
+	if result.Status != "FAIL" {
+		message = "test did not report a result"
+	}
+	failure := xunitFailureOf(result, "", message)
+	nunit := &nunitMessage{Message: nunitText{failure.Message.Text}}
+	if failure.StackTrace.Text != "" {
+		nunit.StackTrace = &nunitText{failure.StackTrace.Text}
+	}
+	return nunit
+}
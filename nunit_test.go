@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderNUnit(t *testing.T) {
+	data, err := processTestEvents(strings.NewReader(formatTestEvents), parseOptions(defaultConfig(), "nunit3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := renderNUnit(data, time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("renderNUnit: %v", err)
+	}
+
+	var run nunitTestRun
+	if err := xml.Unmarshal([]byte(content), &run); err != nil {
+		t.Fatalf("decoding NUnit XML: %v\n%s", err, content)
+	}
+	want := nunitCounts{Total: 5, Passed: 2, Failed: 2, Skipped: 1}
+	if run.nunitCounts != want || run.Result != "Failed" || run.Duration != "0.750" ||
+		run.StartTime != "2024-05-06 07:08:08Z" || run.EndTime != "2024-05-06 07:08:09Z" {
+		t.Errorf("test run: got %+v", run)
+	}
+	if len(run.Suites) != 2 {
+		t.Fatalf("assemblies: got %+v", run.Suites)
+	}
+
+	a := run.Suites[0]
+	if a.Type != "Assembly" || a.Name != "example.com/a" || a.Result != "Failed" || a.Total != 3 || len(a.Cases) != 1 || len(a.Suites) != 1 {
+		t.Fatalf("assembly a: got %+v", a)
+	}
+	table := a.Suites[0]
+	if table.Type != "ParameterizedMethod" || table.Name != "TestTable" || table.Result != "Failed" || table.Site != "Child" || len(table.Cases) != 2 {
+		t.Fatalf("TestTable suite: got %+v", table)
+	}
+	bad := table.Cases[1] // subtests stay in run order
+	if bad.Name != "bad" || bad.FullName != "TestTable/bad" || bad.Result != "Failed" || bad.Failure == nil ||
+		bad.Failure.Message.Text != "got 1, want 2 <�>" || bad.Failure.StackTrace == nil || bad.Failure.StackTrace.Text != "a_test.go:12" {
+		t.Errorf("failing subtest: got %+v", bad)
+	}
+
+	b := run.Suites[1]
+	if hang := b.Cases[0]; hang.Name != "TestHang" || hang.Result != "Failed" || hang.Label != "Error" {
+		t.Errorf("unfinished test: got %+v", hang)
+	}
+	if skip := b.Cases[1]; skip.Result != "Skipped" || skip.Reason == nil || skip.Reason.Message.Text != "needs docker" {
+		t.Errorf("skipped test: got %+v", skip)
+	}
+}
+
+func TestNUnitParentFailure(t *testing.T) {
+	// A parent can fail after all its subtests passed, e.g. in a cleanup
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestA":    {Name: "TestA", Package: "p", Status: "FAIL", SubTests: []string{"TestA/ok"}},
+		"TestA/ok": {Name: "TestA/ok", Package: "p", Status: "PASS", ParentTest: "TestA", IsSubTest: true},
+	}}
+	content, err := renderNUnit(data, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var run nunitTestRun
+	if err := xml.Unmarshal([]byte(content), &run); err != nil {
+		t.Fatal(err)
+	}
+	method := run.Suites[0].Suites[0]
+	if run.Result != "Failed" || run.Suites[0].Result != "Failed" || method.Result != "Failed" || method.Failure == nil {
+		t.Errorf("got run %s, assembly %s, method %+v", run.Result, run.Suites[0].Result, method)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codeOwnersFiles are where GitHub looks for the CODEOWNERS file of a
+// repository, in order
+var codeOwnersFiles = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// codeOwners finds who owns a test per the CODEOWNERS file of the repository
+type codeOwners struct {
+	tree  *sourceTree
+	rules []codeOwnersRule
+}
+
+// codeOwnersRule is one line of a CODEOWNERS file. A rule without owners
+// leaves the paths it matches unowned.
+type codeOwnersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// loadCodeOwners reads the CODEOWNERS file of the repository around dir. It
+// returns nil outside of a Go module or without a CODEOWNERS file.
+func loadCodeOwners(dir string) (*codeOwners, error) {
+	tree := findSourceTree(dir)
+	if tree == nil {
+		return nil, nil
+	}
+	for _, name := range codeOwnersFiles {
+		file, err := os.Open(filepath.Join(tree.repoRoot, filepath.FromSlash(name)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error opening %s: %v", name, err)
+		}
+		defer file.Close()
+		rules, err := parseCodeOwners(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", name, err)
+		}
+		return &codeOwners{tree: tree, rules: rules}, nil
+	}
+	return nil, nil
+}
+
+// codeOwnersFromFlags returns nil unless -triage-checklist was given.
+// Without a CODEOWNERS file the checklist names no owners.
+func codeOwnersFromFlags(enabled bool) *codeOwners {
+	if !enabled {
+		return nil
+	}
+	owners, err := loadCodeOwners(".")
+	if err != nil {
+		logger.Warn("not naming owners in the triage checklist", "error", err)
+		return nil
+	}
+	if owners == nil {
+		logger.Debug("not naming owners in the triage checklist: no CODEOWNERS file found")
+	}
+	return owners
+}
+
+// parseCodeOwners reads the rules of a CODEOWNERS file
+func parseCodeOwners(r io.Reader) ([]codeOwnersRule, error) {
+	var rules []codeOwnersRule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		pattern, err := codeOwnersPattern(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, codeOwnersRule{pattern: pattern, owners: fields[1:]})
+	}
+	return rules, scanner.Err()
+}
+
+// codeOwnersPattern compiles a CODEOWNERS pattern, which follows gitignore
+// rules, to a regular expression matching the paths it covers relative to
+// the repository root, including those below matching directories
+func codeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	// Patterns with a slash other than a trailing one are relative to the
+	// root; others match at any depth
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	// A trailing "/*" covers the files of a directory but not those below
+	shallow := strings.HasSuffix(pattern, "/*")
+	pattern = strings.Trim(pattern, "/")
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if !shallow {
+		expr.WriteString("(?:/.*)?")
+	}
+	expr.WriteString("$")
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	return re, nil
+}
+
+// owners returns the owners of the top-level test in pkg: those of the last
+// rule matching the file declaring it, or else the package's directory
+func (o *codeOwners) owners(pkg, test string) []string {
+	if o == nil {
+		return nil
+	}
+	test, _, _ = strings.Cut(test, "/")
+	var path string
+	if location, ok := o.tree.declaration(pkg, test); ok {
+		path = location.rel
+	} else if dir := o.tree.packageDir(pkg); dir != "" {
+		rel, err := filepath.Rel(o.tree.repoRoot, dir)
+		if err != nil {
+			return nil
+		}
+		path = rel
+	} else {
+		return nil
+	}
+	path = filepath.ToSlash(path)
+
+	var owners []string
+	for _, rule := range o.rules {
+		if rule.pattern.MatchString(path) {
+			owners = rule.owners
+		}
+	}
+	return owners
+}
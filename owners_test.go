@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCodeOwnersPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*", "internal/api/api_test.go", true},
+		{"*_test.go", "internal/api/api_test.go", true},
+		{"api/", "internal/api/api_test.go", true},
+		{"/api/", "internal/api/api_test.go", false},
+		{"/internal/", "internal/api/api_test.go", true},
+		{"internal/*", "internal/api_test.go", true},
+		{"internal/*", "internal/api/api_test.go", false},
+		{"internal/**/db", "internal/store/db/db_test.go", true},
+		{"docs/", "internal/api/api_test.go", false},
+	}
+	for _, tt := range tests {
+		re, err := codeOwnersPattern(tt.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("%q matching %q: got %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCodeOwners(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".git/HEAD":              "ref: refs/heads/main\n",
+		"go.mod":                 "module example.com/app\n",
+		".github/CODEOWNERS":     "# Default owners\n*       @acme/platform\n\n/billing/  @acme/billing @alice  # payments\n/billing/legacy_test.go\n",
+		"billing/charge_test.go": "package billing\n\nfunc TestCharge(t *testing.T) {}\n",
+		"billing/legacy_test.go": "package billing\n\nfunc TestLegacy(t *testing.T) {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	owners, err := loadCodeOwners(root)
+	if err != nil || owners == nil {
+		t.Fatalf("loadCodeOwners: %v, %v", owners, err)
+	}
+	tests := []struct {
+		pkg, test string
+		want      string
+	}{
+		{"example.com/app/billing", "TestCharge/visa", "@acme/billing,@alice"},
+		{"example.com/app/billing", "TestMissing", "@acme/billing,@alice"},
+		{"example.com/app/billing", "TestLegacy", ""},
+		{"example.com/app/cmd", "TestMain", "@acme/platform"},
+		{"example.org/other", "TestOther", ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(owners.owners(tt.pkg, tt.test), ","); got != tt.want {
+			t.Errorf("owners(%s, %s): got %q, want %q", tt.pkg, tt.test, got, tt.want)
+		}
+	}
+
+	if err := os.Remove(filepath.Join(root, ".github", "CODEOWNERS")); err != nil {
+		t.Fatal(err)
+	}
+	if owners, err := loadCodeOwners(root); owners != nil || err != nil {
+		t.Errorf("without CODEOWNERS: got %v, %v", owners, err)
+	}
+}
+
+func TestTriageChecklist(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.triageChecklist = true
+	cfg.Packages = []packageMapping{{Match: "example.com/app/billing", Name: "Billing"}}
+	data := &ReportData{
+		TotalTests:      3,
+		PassedTests:     1,
+		FailedTests:     2,
+		SortedTestNames: []string{"TestCharge", "TestLogin", "TestRefund"},
+		Results: map[string]*TestResult{
+			"TestCharge":      {Name: "TestCharge", Package: "example.com/app/billing", Status: "FAIL", SubTests: []string{"TestCharge/visa", "TestCharge/amex"}},
+			"TestCharge/visa": {Name: "TestCharge/visa", Package: "example.com/app/billing", Status: "FAIL", IsSubTest: true},
+			"TestCharge/amex": {Name: "TestCharge/amex", Package: "example.com/app/billing", Status: "PASS", IsSubTest: true},
+			"TestLogin":       {Name: "TestLogin", Package: "example.com/app/auth", Status: "PASS"},
+			"TestRefund":      {Name: "TestRefund", Package: "example.com/app/billing", Status: "FAIL"},
+		},
+	}
+
+	rules, err := parseCodeOwners(strings.NewReader("/billing/ @acme/billing @alice\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.owners = &codeOwners{tree: &sourceTree{repoRoot: "/repo", moduleRoot: "/repo", modulePath: "example.com/app"}, rules: rules}
+
+	markdown := renderMarkdownReport(data, cfg)
+	want := "## 📋 Triage Checklist\n\n" +
+		"- [ ] `TestCharge` in Billing (owner: @acme/billing, @alice)\n  - [ ] `visa`\n" +
+		"- [ ] `TestRefund` in Billing (owner: @acme/billing, @alice)\n\n"
+	if !strings.Contains(markdown, want) {
+		t.Errorf("report missing %q", want)
+	}
+
+	cfg.triageChecklist = false
+	if strings.Contains(renderMarkdownReport(data, cfg), "Triage Checklist") {
+		t.Error("checklist should only be added with -triage-checklist")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// pdfConverters are the HTML to PDF converters tried in turn, by executable
+// name. Headless Chrome or Chromium renders the page as a browser would;
+// wkhtmltopdf is a lighter fallback often found on CI images.
+var pdfConverters = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "wkhtmltopdf"}
+
+// htmlToPDF converts an HTML report to PDF with the first converter found on
+// PATH, printing the page from a temporary directory
+func htmlToPDF(page string) (string, error) {
+	converter := ""
+	for _, name := range pdfConverters {
+		if path, err := exec.LookPath(name); err == nil {
+			converter = path
+			break
+		}
+	}
+	if converter == "" {
+		return "", fmt.Errorf("-format pdf needs Chrome, Chromium or wkhtmltopdf on PATH to convert the HTML report")
+	}
+
+	dir, err := os.MkdirTemp("", "gotest-report-pdf")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	input := filepath.Join(dir, "report.html")
+	output := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(input, []byte(page), 0o644); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command(converter, pdfConverterArgs(filepath.Base(converter), input, output)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("error converting the report to PDF with %s: %v\n%s", filepath.Base(converter), err, out)
+	}
+	pdf, err := os.ReadFile(output)
+	if err != nil {
+		return "", fmt.Errorf("error converting the report to PDF with %s: %v", filepath.Base(converter), err)
+	}
+	return string(pdf), nil
+}
+
+// pdfConverterArgs returns the arguments that make converter print the HTML
+// file input to the PDF file output
+func pdfConverterArgs(converter, input, output string) []string {
+	if converter == "wkhtmltopdf" {
+		return []string{"--quiet", "--enable-local-file-access", input, output}
+	}
+	args := []string{"--headless", "--disable-gpu", "--no-pdf-header-footer", "--print-to-pdf=" + output}
+	// Chrome refuses to start its sandbox as root, as in most CI containers
+	if os.Geteuid() == 0 {
+		args = append(args, "--no-sandbox")
+	}
+	return append(args, "file://"+input)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHTMLToPDF(t *testing.T) {
+	dir := t.TempDir()
+	// A stand-in for wkhtmltopdf that checks for its input and writes a PDF
+	script := "#!/bin/sh\n[ -s \"$3\" ] || exit 1\nprintf '%%PDF-1.4 fake' > \"$4\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "wkhtmltopdf"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	data := &ReportData{TotalTests: 1, PassedTests: 1, Results: map[string]*TestResult{}}
+	pdf, err := renderReport(data, defaultConfig(), "pdf")
+	if err != nil {
+		t.Fatalf("renderReport: %v", err)
+	}
+	if pdf != "%PDF-1.4 fake" {
+		t.Errorf("PDF: got %q", pdf)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := renderReport(data, defaultConfig(), "pdf"); err == nil || !strings.Contains(err.Error(), "wkhtmltopdf") {
+		t.Errorf("without a converter: got %v", err)
+	}
+}
+
+func TestPDFConverterArgs(t *testing.T) {
+	args := strings.Join(pdfConverterArgs("chromium", "/tmp/r.html", "/tmp/r.pdf"), " ")
+	if !strings.HasPrefix(args, "--headless --disable-gpu --no-pdf-header-footer --print-to-pdf=/tmp/r.pdf") ||
+		!strings.HasSuffix(args, " file:///tmp/r.html") {
+		t.Errorf("chromium args: got %s", args)
+	}
+	if args := strings.Join(pdfConverterArgs("wkhtmltopdf", "in.html", "out.pdf"), " "); args != "--quiet --enable-local-file-access in.html out.pdf" {
+		t.Errorf("wkhtmltopdf args: got %s", args)
+	}
+}
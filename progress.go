@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// progressDelay is how long parsing runs before progress is shown, so small
+// inputs stay quiet
+const progressDelay = 2 * time.Second
+
+// progressReporter shows how much of the input has been parsed, so a long
+// parse of a multi-hundred-MB log doesn't look like a hang. On a terminal
+// it keeps one line up to date; elsewhere, e.g. in CI logs, it logs a line
+// every logInterval.
+type progressReporter struct {
+	terminal io.Writer // nil when stderr isn't a terminal
+	total    int64     // bytes of all inputs, 0 when unknown (stdin)
+
+	mu      sync.Mutex
+	events  int
+	bytes   int64
+	start   time.Time
+	last    time.Time
+	printed bool
+}
+
+// logInterval is how often progress is logged when not on a terminal
+const logInterval = 10 * time.Second
+
+func newProgressReporter(specs []inputSpec) *progressReporter {
+	p := &progressReporter{start: time.Now()}
+	for _, spec := range specs {
+		if spec.path == "" {
+			p.total = 0
+			break
+		}
+		if info, err := os.Stat(spec.path); err == nil {
+			p.total += info.Size()
+		}
+	}
+	if isTerminal(os.Stderr) {
+		p.terminal = os.Stderr
+	}
+	return p
+}
+
+// add is a ParseOptions.Progress callback
+func (p *progressReporter) add(events int, bytes int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events += events
+	p.bytes += bytes
+
+	now := time.Now()
+	if now.Sub(p.start) < progressDelay {
+		return
+	}
+	interval := logInterval
+	if p.terminal != nil {
+		interval = 250 * time.Millisecond
+	}
+	if p.printed && now.Sub(p.last) < interval {
+		return
+	}
+	p.last, p.printed = now, true
+	p.show()
+}
+
+// done shows the final count, if progress was shown at all
+func (p *progressReporter) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.printed {
+		return
+	}
+	p.show()
+	if p.terminal != nil {
+		fmt.Fprintln(p.terminal)
+	}
+}
+
+func (p *progressReporter) show() {
+	if p.terminal == nil {
+		logger.Info("parse progress", "events", p.events, "bytes", p.bytes, "totalBytes", p.total)
+		return
+	}
+	fmt.Fprintf(p.terminal, "\r⏳ %s", p.text())
+}
+
+// text describes the progress, e.g. "Parsed 120000 events / 31.2 MiB of
+// 80.0 MiB (39%)"
+func (p *progressReporter) text() string {
+	text := fmt.Sprintf("Parsed %d events / %s", p.events, formatBytes(p.bytes))
+	if p.total > 0 {
+		text += fmt.Sprintf(" of %s (%d%%)", formatBytes(p.total), min(100, p.bytes*100/p.total))
+	}
+	return text
+}
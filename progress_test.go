@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressReporter(t *testing.T) {
+	var out strings.Builder
+	p := &progressReporter{terminal: &out, total: 4 * 1024 * 1024, start: time.Now()}
+
+	p.add(1000, 1024*1024)
+	p.done()
+	if out.Len() != 0 {
+		t.Errorf("progress shown before %v:\n%q", progressDelay, out.String())
+	}
+
+	p.start = time.Now().Add(-progressDelay)
+	p.add(500, 1024*1024)
+	p.add(500, 1024*1024) // within the refresh interval
+	p.done()
+	want := "\r⏳ Parsed 1500 events / 2.0 MiB of 4.0 MiB (50%)" +
+		"\r⏳ Parsed 2000 events / 3.0 MiB of 4.0 MiB (75%)\n"
+	if got := out.String(); got != want {
+		t.Errorf("progress:\ngot:  %q\nwant: %q", got, want)
+	}
+
+	p = &progressReporter{events: 7, bytes: 2048}
+	if got, want := p.text(), "Parsed 7 events / 2.0 KiB"; got != want {
+		t.Errorf("progress of stdin: got %q, want %q", got, want)
+	}
+}
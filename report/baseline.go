@@ -0,0 +1,78 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Baseline is a compact summary of a run, typically of the main branch,
+// that later runs are compared with
+type Baseline struct {
+	Commit string                  `json:"commit,omitempty"`
+	Tests  map[string]BaselineTest `json:"tests"`
+}
+
+// BaselineTest is the outcome of one test in a Baseline
+type BaselineTest struct {
+	Package  string  `json:"package,omitempty"`
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"`
+}
+
+// NewBaseline summarizes data as a baseline for later runs
+func NewBaseline(data *ReportData, commit string) *Baseline {
+	baseline := &Baseline{Commit: commit, Tests: make(map[string]BaselineTest, len(data.Results))}
+	for name, result := range data.Results {
+		baseline.Tests[name] = BaselineTest{Package: result.Package, Status: result.Status, Duration: result.Duration}
+	}
+	return baseline
+}
+
+// LoadBaseline reads a baseline file written by Baseline.Save
+func LoadBaseline(path string) (*Baseline, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading baseline: %v", err)
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(content, &baseline); err != nil {
+		return nil, fmt.Errorf("error parsing baseline %s: %v", path, err)
+	}
+	return &baseline, nil
+}
+
+// Save writes the baseline to path
+func (b *Baseline) Save(path string) error {
+	content, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// ReportData rebuilds the baseline run so it can be compared with Diff
+func (b *Baseline) ReportData() *ReportData {
+	data := &ReportData{Results: make(map[string]*TestResult, len(b.Tests))}
+	for name, test := range b.Tests {
+		data.Results[name] = &TestResult{
+			Name:      name,
+			Package:   test.Package,
+			Status:    test.Status,
+			Duration:  test.Duration,
+			IsSubTest: strings.Contains(name, "/"),
+		}
+	}
+	for name, result := range data.Results {
+		if result.IsSubTest {
+			result.ParentTest = name[:strings.LastIndex(name, "/")]
+			if parent, ok := data.Results[result.ParentTest]; ok {
+				parent.SubTests = append(parent.SubTests, name)
+			}
+		}
+	}
+	summarize(data)
+	return data
+}
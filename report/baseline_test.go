@@ -0,0 +1,42 @@
+package report
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBaselineRoundTrip(t *testing.T) {
+	data := &ReportData{
+		Results: map[string]*TestResult{
+			"TestA":     {Name: "TestA", Package: "example.com/a", Status: "PASS", Duration: 1.5, SubTests: []string{"TestA/sub"}},
+			"TestA/sub": {Name: "TestA/sub", Package: "example.com/a", Status: "PASS", Duration: 1, IsSubTest: true, ParentTest: "TestA"},
+			"TestB":     {Name: "TestB", Package: "example.com/b", Status: "FAIL", Duration: 0.5},
+		},
+	}
+	summarize(data)
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := NewBaseline(data, "abc123").Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline: %v", err)
+	}
+	if baseline.Commit != "abc123" || len(baseline.Tests) != 3 {
+		t.Errorf("unexpected baseline: %+v", baseline)
+	}
+
+	rebuilt := baseline.ReportData()
+	if rebuilt.TotalTests != 2 || rebuilt.PassedTests != 1 || rebuilt.FailedTests != 1 || rebuilt.TotalDuration != 2 {
+		t.Errorf("unexpected rebuilt summary: %+v", rebuilt)
+	}
+	if !reflect.DeepEqual(rebuilt.Results["TestA"].SubTests, []string{"TestA/sub"}) || rebuilt.Results["TestA/sub"].ParentTest != "TestA" {
+		t.Errorf("subtests not rebuilt: %+v", rebuilt.Results["TestA"])
+	}
+
+	if _, err := LoadBaseline(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing baseline")
+	}
+}
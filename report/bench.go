@@ -0,0 +1,255 @@
+package report
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BenchmarkResult is one line of benchmark output, e.g.
+// "BenchmarkJoin-8  1000  103.3 ns/op  112 B/op  1 allocs/op"
+type BenchmarkResult struct {
+	Name       string `json:"name"` // without the -GOMAXPROCS suffix
+	Package    string `json:"package"`
+	Procs      int    `json:"procs,omitempty"`
+	Iterations int64  `json:"iterations"`
+	// Values by unit, e.g. "ns/op", "B/op", "allocs/op"
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// parseBenchmarkLine parses a benchmark result line, reporting false for the
+// bare "BenchmarkJoin" lines printed when a benchmark starts and anything else
+// that only looks like a result
+func parseBenchmarkLine(line string) (BenchmarkResult, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || len(fields)%2 != 0 || !strings.HasPrefix(fields[0], "Benchmark") {
+		return BenchmarkResult{}, false
+	}
+	iterations, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return BenchmarkResult{}, false
+	}
+
+	result := BenchmarkResult{Name: fields[0], Iterations: iterations, Metrics: make(map[string]float64, (len(fields)-2)/2)}
+	if i := strings.LastIndex(result.Name, "-"); i > 0 {
+		if procs, err := strconv.Atoi(result.Name[i+1:]); err == nil {
+			result.Name, result.Procs = result.Name[:i], procs
+		}
+	}
+	for i := 2; i < len(fields); i += 2 {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return BenchmarkResult{}, false
+		}
+		result.Metrics[fields[i+1]] = value
+	}
+	return result, true
+}
+
+// BenchmarkUnits are the units go test reports itself, in the order they are
+// shown. Units added with b.ReportMetric follow them.
+var BenchmarkUnits = []string{"ns/op", "B/op", "allocs/op"}
+
+// IsStandardBenchmarkUnit reports whether unit is one of BenchmarkUnits, for
+// which lower is better. Custom metrics can go either way (req/s vs p99-ms).
+func IsStandardBenchmarkUnit(unit string) bool {
+	for _, u := range BenchmarkUnits {
+		if u == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// BenchmarkMetricUnits returns the units found in results: those of
+// BenchmarkUnits that occur, then custom units in order of first appearance
+func BenchmarkMetricUnits(results ...[]BenchmarkResult) []string {
+	seen := make(map[string]bool)
+	var custom []string
+	for _, rs := range results {
+		for _, result := range rs {
+			// Metrics is a map, so sort each line's new units for a stable order
+			var fresh []string
+			for unit := range result.Metrics {
+				if !seen[unit] {
+					seen[unit] = true
+					if !IsStandardBenchmarkUnit(unit) {
+						fresh = append(fresh, unit)
+					}
+				}
+			}
+			sort.Strings(fresh)
+			custom = append(custom, fresh...)
+		}
+	}
+
+	var units []string
+	for _, unit := range BenchmarkUnits {
+		if seen[unit] {
+			units = append(units, unit)
+		}
+	}
+	return append(units, custom...)
+}
+
+// BenchmarkSummary is one benchmark's results over its -count runs
+type BenchmarkSummary struct {
+	Name    string
+	Package string
+	Runs    int
+	Metrics map[string]BenchmarkSamples // by unit
+}
+
+// SummarizeBenchmarks groups results by package and name, sorted the same way
+func SummarizeBenchmarks(results []BenchmarkResult) []BenchmarkSummary {
+	type key struct{ pkg, name string }
+	byKey := make(map[key]*BenchmarkSummary)
+	var summaries []*BenchmarkSummary
+	for _, result := range results {
+		k := key{result.Package, result.Name}
+		summary, ok := byKey[k]
+		if !ok {
+			summary = &BenchmarkSummary{Name: result.Name, Package: result.Package, Metrics: make(map[string]BenchmarkSamples)}
+			byKey[k] = summary
+			summaries = append(summaries, summary)
+		}
+		summary.Runs++
+		for unit, value := range result.Metrics {
+			summary.Metrics[unit] = summary.Metrics[unit].add(value)
+		}
+	}
+
+	sorted := make([]BenchmarkSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		sorted = append(sorted, *summary)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Package != sorted[j].Package {
+			return sorted[i].Package < sorted[j].Package
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// BenchmarkSamples summarizes the values one benchmark reported for a unit
+// over its -count runs
+type BenchmarkSamples struct {
+	Mean float64 `json:"mean"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	N    int     `json:"n"`
+}
+
+// add returns s with one more sample
+func (s BenchmarkSamples) add(value float64) BenchmarkSamples {
+	if s.N == 0 {
+		return BenchmarkSamples{Mean: value, Min: value, Max: value, N: 1}
+	}
+	s.Mean = (s.Mean*float64(s.N) + value) / float64(s.N+1)
+	s.Min = math.Min(s.Min, value)
+	s.Max = math.Max(s.Max, value)
+	s.N++
+	return s
+}
+
+// Spread is how far the samples stray from the mean, in percent
+func (s BenchmarkSamples) Spread() float64 {
+	if s.Mean == 0 {
+		return 0
+	}
+	return math.Max(s.Max-s.Mean, s.Mean-s.Min) / s.Mean * 100
+}
+
+// BenchmarkDelta compares one benchmark and unit between two runs. Old or New
+// has no samples when the benchmark only ran on one side.
+type BenchmarkDelta struct {
+	Name    string           `json:"name"`
+	Package string           `json:"package"`
+	Unit    string           `json:"unit"`
+	Old     BenchmarkSamples `json:"old"`
+	New     BenchmarkSamples `json:"new"`
+}
+
+// Change is the difference of the means in percent
+func (d BenchmarkDelta) Change() float64 {
+	if d.Old.Mean == 0 {
+		return 0
+	}
+	return (d.New.Mean - d.Old.Mean) / d.Old.Mean * 100
+}
+
+// Significant reports whether the old and new samples don't overlap, the
+// rough test used instead of benchstat's Mann-Whitney U to mark changes that
+// may just be noise
+func (d BenchmarkDelta) Significant() bool {
+	if d.Old.N == 0 || d.New.N == 0 {
+		return false
+	}
+	return d.New.Min > d.Old.Max || d.New.Max < d.Old.Min
+}
+
+// CompareBenchmarks pairs the benchmarks of two runs by package and name and
+// compares the means of every unit, grouped by unit in the order of
+// BenchmarkMetricUnits and sorted by package and name within each unit
+func CompareBenchmarks(old, new []BenchmarkResult) []BenchmarkDelta {
+	type key struct{ pkg, name, unit string }
+	samples := func(results []BenchmarkResult) map[key]BenchmarkSamples {
+		byKey := make(map[key]BenchmarkSamples)
+		for _, result := range results {
+			for unit, value := range result.Metrics {
+				k := key{result.Package, result.Name, unit}
+				byKey[k] = byKey[k].add(value)
+			}
+		}
+		return byKey
+	}
+	oldSamples, newSamples := samples(old), samples(new)
+
+	keys := make(map[key]bool, len(oldSamples)+len(newSamples))
+	for k := range oldSamples {
+		keys[k] = true
+	}
+	for k := range newSamples {
+		keys[k] = true
+	}
+
+	unitRank := make(map[string]int)
+	for i, unit := range BenchmarkMetricUnits(old, new) {
+		unitRank[unit] = i
+	}
+	deltas := make([]BenchmarkDelta, 0, len(keys))
+	for k := range keys {
+		deltas = append(deltas, BenchmarkDelta{Name: k.name, Package: k.pkg, Unit: k.unit, Old: oldSamples[k], New: newSamples[k]})
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		a, b := deltas[i], deltas[j]
+		if a.Unit != b.Unit {
+			return unitRank[a.Unit] < unitRank[b.Unit]
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Name < b.Name
+	})
+	return deltas
+}
+
+// GeomeanChange is the geometric mean of the changes of the benchmarks that
+// ran on both sides, in percent, as benchstat's geomean row. It reports false
+// when there is nothing to compare.
+func GeomeanChange(deltas []BenchmarkDelta) (float64, bool) {
+	sum, n := 0.0, 0
+	for _, d := range deltas {
+		if d.Old.N == 0 || d.New.N == 0 || d.Old.Mean <= 0 || d.New.Mean <= 0 {
+			continue
+		}
+		sum += math.Log(d.New.Mean / d.Old.Mean)
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return (math.Exp(sum/float64(n)) - 1) * 100, true
+}
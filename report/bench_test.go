@@ -0,0 +1,151 @@
+package report
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBenchmarkLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want BenchmarkResult
+		ok   bool
+	}{
+		{
+			line: "BenchmarkJoin-8 \t    1000\t       103.3 ns/op\t     112 B/op\t       1 allocs/op",
+			want: BenchmarkResult{Name: "BenchmarkJoin", Procs: 8, Iterations: 1000, Metrics: map[string]float64{"ns/op": 103.3, "B/op": 112, "allocs/op": 1}},
+			ok:   true,
+		},
+		{
+			line: "BenchmarkSub/size-10         \t    1000\t        41.78 ns/op",
+			want: BenchmarkResult{Name: "BenchmarkSub/size", Procs: 10, Iterations: 1000, Metrics: map[string]float64{"ns/op": 41.78}},
+			ok:   true,
+		},
+		{
+			line: "BenchmarkSub/a-b \t 5\t 2 ns/op",
+			want: BenchmarkResult{Name: "BenchmarkSub/a-b", Iterations: 5, Metrics: map[string]float64{"ns/op": 2}},
+			ok:   true,
+		},
+		{line: "BenchmarkJoin"},
+		{line: "BenchmarkJoin \t"},
+		{line: "BenchmarkJoin is slow: 10 ns/op"},
+		{line: "BenchmarkJoin \t 1000\t fast ns/op"},
+		{line: "ok  \tbench\t0.005s"},
+	}
+	for _, tt := range tests {
+		got, ok := parseBenchmarkLine(tt.line)
+		if ok != tt.ok || ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseBenchmarkLine(%q): got %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseBenchmarks(t *testing.T) {
+	input := `{"Action":"run","Package":"bench","Test":"BenchmarkJoin"}
+{"Action":"output","Package":"bench","Test":"BenchmarkJoin","Output":"BenchmarkJoin\n"}
+{"Action":"output","Package":"bench","Test":"BenchmarkJoin","Output":"BenchmarkJoin-8 \t    1000\t       103.3 ns/op\n"}
+{"Action":"output","Package":"bench","Output":"BenchmarkJoin-8 \t"}
+{"Action":"output","Package":"bench","Output":"    1000\t       106.3 ns/op\n"}
+{"Action":"output","Package":"bench","Output":"PASS\n"}
+{"Action":"pass","Package":"bench","Elapsed":0.005}
+`
+	data, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var values []float64
+	for _, result := range data.Benchmarks {
+		if result.Name != "BenchmarkJoin" || result.Package != "bench" {
+			t.Errorf("unexpected benchmark: %+v", result)
+		}
+		values = append(values, result.Metrics["ns/op"])
+	}
+	if want := []float64{103.3, 106.3}; !reflect.DeepEqual(values, want) {
+		t.Errorf("ns/op: got %v, want %v", values, want)
+	}
+}
+
+func TestCompareBenchmarks(t *testing.T) {
+	result := func(name string, ns, allocs float64) BenchmarkResult {
+		return BenchmarkResult{Name: name, Package: "bench", Iterations: 1000,
+			Metrics: map[string]float64{"ns/op": ns, "allocs/op": allocs, "MB/s": 1}}
+	}
+	old := []BenchmarkResult{result("BenchmarkA", 100, 2), result("BenchmarkA", 110, 2), result("BenchmarkGone", 5, 0)}
+	new := []BenchmarkResult{result("BenchmarkA", 50, 1), result("BenchmarkA", 60, 1), result("BenchmarkNew", 7, 0)}
+
+	deltas := CompareBenchmarks(old, new)
+	var keys []string
+	for _, d := range deltas {
+		keys = append(keys, d.Unit+" "+d.Name)
+	}
+	want := []string{
+		"ns/op BenchmarkA", "ns/op BenchmarkGone", "ns/op BenchmarkNew",
+		"allocs/op BenchmarkA", "allocs/op BenchmarkGone", "allocs/op BenchmarkNew",
+		"MB/s BenchmarkA", "MB/s BenchmarkGone", "MB/s BenchmarkNew",
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("deltas: got %q, want %q", keys, want)
+	}
+
+	a := deltas[0]
+	if a.Old != (BenchmarkSamples{Mean: 105, Min: 100, Max: 110, N: 2}) || a.New.Mean != 55 {
+		t.Errorf("unexpected samples: %+v", a)
+	}
+	if math.Abs(a.Change()-(-47.619)) > 0.001 || !a.Significant() {
+		t.Errorf("BenchmarkA: change %v, significant %v", a.Change(), a.Significant())
+	}
+	if spread := a.Old.Spread(); math.Abs(spread-4.762) > 0.001 {
+		t.Errorf("spread: got %v", spread)
+	}
+	if deltas[1].Significant() || deltas[2].Significant() {
+		t.Error("one-sided benchmarks can't be significant")
+	}
+
+	overlapping := BenchmarkDelta{Old: BenchmarkSamples{Mean: 10, Min: 8, Max: 12, N: 2}, New: BenchmarkSamples{Mean: 11, Min: 9, Max: 13, N: 2}}
+	if overlapping.Significant() {
+		t.Error("overlapping samples should not be significant")
+	}
+}
+
+func TestGeomeanChange(t *testing.T) {
+	deltas := []BenchmarkDelta{
+		{Old: BenchmarkSamples{Mean: 100, N: 1}, New: BenchmarkSamples{Mean: 200, N: 1}},
+		{Old: BenchmarkSamples{Mean: 100, N: 1}, New: BenchmarkSamples{Mean: 50, N: 1}},
+		{Old: BenchmarkSamples{Mean: 0, N: 1}, New: BenchmarkSamples{Mean: 0, N: 1}},
+		{New: BenchmarkSamples{Mean: 10, N: 1}},
+	}
+	if change, ok := GeomeanChange(deltas); !ok || math.Abs(change) > 1e-9 {
+		t.Errorf("GeomeanChange: got %v, %v, want 0, true", change, ok)
+	}
+	if _, ok := GeomeanChange(deltas[2:]); ok {
+		t.Error("expected nothing to compare")
+	}
+}
+
+func TestSummarizeBenchmarks(t *testing.T) {
+	results := []BenchmarkResult{
+		{Name: "BenchmarkB", Package: "bench", Metrics: map[string]float64{"ns/op": 10, "p99-ms": 4, "req/s": 100}},
+		{Name: "BenchmarkA", Package: "bench", Metrics: map[string]float64{"ns/op": 20, "B/op": 8}},
+		{Name: "BenchmarkB", Package: "bench", Metrics: map[string]float64{"ns/op": 30, "p99-ms": 6, "req/s": 300}},
+		{Name: "BenchmarkA", Package: "bench", Metrics: map[string]float64{"ns/op": 20, "B/op": 8, "MB/s": 5}},
+	}
+
+	if units, want := BenchmarkMetricUnits(results), []string{"ns/op", "B/op", "p99-ms", "req/s", "MB/s"}; !reflect.DeepEqual(units, want) {
+		t.Errorf("BenchmarkMetricUnits: got %q, want %q", units, want)
+	}
+
+	summaries := SummarizeBenchmarks(results)
+	if len(summaries) != 2 || summaries[0].Name != "BenchmarkA" || summaries[1].Name != "BenchmarkB" {
+		t.Fatalf("unexpected summaries: %+v", summaries)
+	}
+	b := summaries[1]
+	if b.Runs != 2 || b.Metrics["req/s"] != (BenchmarkSamples{Mean: 200, Min: 100, Max: 300, N: 2}) || b.Metrics["p99-ms"].Mean != 5 {
+		t.Errorf("unexpected BenchmarkB summary: %+v", b)
+	}
+	if a := summaries[0]; a.Metrics["MB/s"].N != 1 {
+		t.Errorf("MB/s should only count the run that reported it: %+v", a.Metrics["MB/s"])
+	}
+}
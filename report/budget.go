@@ -0,0 +1,62 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Budget limits how long the tests of each package matching Match may take
+// together. Match is an import path, which covers the packages below it
+// too, and may end in "/...".
+type Budget struct {
+	Match   string
+	Seconds float64
+}
+
+// BudgetOverrun is a package whose tests took longer than its budget
+type BudgetOverrun struct {
+	Package  string  `json:"package"`
+	Budget   float64 `json:"budget"`
+	Duration float64 `json:"duration"`
+}
+
+// CheckBudgets returns the packages whose top-level tests took longer than
+// the longest budget matching them, the furthest over budget first.
+// Packages no budget matches are unlimited.
+func CheckBudgets(data *ReportData, budgets []Budget) []BudgetOverrun {
+	var overruns []BudgetOverrun
+	for _, p := range PackageDurations(data) {
+		budget, ok := matchBudget(budgets, p.Package)
+		if ok && p.Duration > budget {
+			overruns = append(overruns, BudgetOverrun{Package: p.Package, Budget: budget, Duration: p.Duration})
+		}
+	}
+	sort.SliceStable(overruns, func(i, j int) bool {
+		return overruns[i].Duration-overruns[i].Budget > overruns[j].Duration-overruns[j].Budget
+	})
+	return overruns
+}
+
+// matchBudget returns the seconds of the longest of budgets matching pkg
+func matchBudget(budgets []Budget, pkg string) (float64, bool) {
+	best := -1
+	seconds := 0.0
+	for _, budget := range budgets {
+		match := strings.TrimSuffix(budget.Match, "/...")
+		if (pkg == match || strings.HasPrefix(pkg, match+"/")) && len(match) > best {
+			best = len(match)
+			seconds = budget.Seconds
+		}
+	}
+	return seconds, best >= 0
+}
+
+// BudgetViolations describes overruns as quality gate violations
+func BudgetViolations(overruns []BudgetOverrun) []string {
+	var violations []string
+	for _, o := range overruns {
+		violations = append(violations, fmt.Sprintf("package %s took %.2fs, over its %gs budget", o.Package, o.Duration, o.Budget))
+	}
+	return violations
+}
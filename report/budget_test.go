@@ -0,0 +1,34 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckBudgets(t *testing.T) {
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestA":     {Name: "TestA", Package: "example.com/api", Duration: 20},
+		"TestB":     {Name: "TestB", Package: "example.com/api", Duration: 15},
+		"TestB/sub": {Name: "TestB/sub", Package: "example.com/api", Duration: 15, IsSubTest: true},
+		"TestC":     {Name: "TestC", Package: "example.com/api/admin", Duration: 12},
+		"TestD":     {Name: "TestD", Package: "example.com/db", Duration: 100},
+		"TestE":     {Name: "TestE", Package: "example.com/web", Duration: 5},
+	}}
+	budgets := []Budget{
+		{Match: "example.com/api", Seconds: 30},
+		{Match: "example.com/api/admin/...", Seconds: 10},
+		{Match: "example.com/web", Seconds: 10},
+	}
+
+	want := []BudgetOverrun{
+		{Package: "example.com/api", Budget: 30, Duration: 35},
+		{Package: "example.com/api/admin", Budget: 10, Duration: 12},
+	}
+	overruns := CheckBudgets(data, budgets)
+	if !reflect.DeepEqual(overruns, want) {
+		t.Errorf("got %+v, want %+v", overruns, want)
+	}
+	if got := BudgetViolations(overruns); len(got) != 2 || got[0] != "package example.com/api took 35.00s, over its 30s budget" {
+		t.Errorf("violations: got %q", got)
+	}
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Uncategorized is the category of packages no Category matches
+const Uncategorized = "uncategorized"
+
+// Category groups packages, e.g. unit, integration or e2e tests, whose
+// failures have different implications. Match holds import paths, which
+// cover the packages below them too, or patterns in which "..." matches any
+// string as with the go command, e.g. ".../e2e/...". A negative MinPassRate
+// disables the category's gate.
+type Category struct {
+	Name        string
+	Match       []string
+	MinPassRate float64 // percent of the category's tests that passed
+}
+
+// CategorySummary counts the top-level tests of one category
+type CategorySummary struct {
+	Category    string  `json:"category"`
+	Total       int     `json:"total"`
+	Passed      int     `json:"passed"`
+	Failed      int     `json:"failed"`
+	Skipped     int     `json:"skipped"`
+	Flaky       int     `json:"flaky"`
+	Duration    float64 `json:"duration"`
+	MinPassRate float64 `json:"minPassRate,omitempty"`
+}
+
+// PassRate returns the percent of the category's tests that passed
+func (s CategorySummary) PassRate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Passed) / float64(s.Total) * 100
+}
+
+// CategorySummaries counts the top-level tests of each category, in the order
+// of categories, followed by Uncategorized when packages matched none.
+// Categories without tests are left out. A package belongs to the first
+// category matching it, so specific patterns go before broader ones.
+func CategorySummaries(data *ReportData, categories []Category) []CategorySummary {
+	byName := make(map[string]*CategorySummary)
+	byPackage := make(map[string]string)
+	for _, result := range data.Results {
+		if result.IsSubTest {
+			continue
+		}
+		name, ok := byPackage[result.Package]
+		if !ok {
+			name = categorize(categories, result.Package)
+			byPackage[result.Package] = name
+		}
+		s, ok := byName[name]
+		if !ok {
+			s = &CategorySummary{Category: name}
+			byName[name] = s
+		}
+		s.Total++
+		s.Duration += result.Duration
+		switch result.Status {
+		case "PASS":
+			s.Passed++
+		case "FAIL":
+			s.Failed++
+		case "SKIP":
+			s.Skipped++
+		case "FLAKY":
+			s.Flaky++
+		}
+	}
+
+	var summaries []CategorySummary
+	for _, category := range categories {
+		if s, ok := byName[category.Name]; ok {
+			s.MinPassRate = max(category.MinPassRate, 0)
+			summaries = append(summaries, *s)
+			delete(byName, category.Name)
+		}
+	}
+	if s, ok := byName[Uncategorized]; ok {
+		summaries = append(summaries, *s)
+	}
+	return summaries
+}
+
+// CategoryViolations describes the categories whose pass rate is below their
+// minimum as quality gate violations
+func CategoryViolations(summaries []CategorySummary, categories []Category) []string {
+	var violations []string
+	for _, s := range summaries {
+		for _, category := range categories {
+			if category.Name == s.Category && category.MinPassRate >= 0 && s.PassRate() < category.MinPassRate {
+				violations = append(violations, fmt.Sprintf("%s pass rate %.1f%% is below the minimum of %g%%", s.Category, s.PassRate(), category.MinPassRate))
+			}
+		}
+	}
+	return violations
+}
+
+// categorize returns the first category with a pattern matching pkg
+func categorize(categories []Category, pkg string) string {
+	for _, category := range categories {
+		for _, pattern := range category.Match {
+			if matchPackagePattern(pattern, pkg) {
+				return category.Name
+			}
+		}
+	}
+	return Uncategorized
+}
+
+// matchPackagePattern reports whether pkg matches pattern
+func matchPackagePattern(pattern, pkg string) bool {
+	if !strings.Contains(pattern, "...") {
+		return pkg == pattern || strings.HasPrefix(pkg, pattern+"/")
+	}
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\.\.\.`, `.*`)
+	// Like the go command, "x/..." matches x itself too
+	if trimmed, ok := strings.CutSuffix(expr, `/.*`); ok {
+		expr = trimmed + `(/.*)?`
+	}
+	return regexp.MustCompile("^" + expr + "$").MatchString(pkg)
+}
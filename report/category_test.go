@@ -0,0 +1,61 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchPackagePattern(t *testing.T) {
+	tests := []struct {
+		pattern, pkg string
+		want         bool
+	}{
+		{"github.com/acme/app", "github.com/acme/app", true},
+		{"github.com/acme/app", "github.com/acme/app/api", true},
+		{"github.com/acme/app", "github.com/acme/application", false},
+		{"github.com/acme/app/...", "github.com/acme/app", true},
+		{".../e2e/...", "github.com/acme/app/e2e", true},
+		{".../e2e/...", "github.com/acme/app/e2e/checkout", true},
+		{".../e2e/...", "github.com/acme/app/e2ex", false},
+		{"github.com/acme/.../integration", "github.com/acme/app/db/integration", true},
+	}
+	for _, tt := range tests {
+		if got := matchPackagePattern(tt.pattern, tt.pkg); got != tt.want {
+			t.Errorf("matchPackagePattern(%q, %q): got %v", tt.pattern, tt.pkg, got)
+		}
+	}
+}
+
+func TestCategorySummaries(t *testing.T) {
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestUnit":       {Name: "TestUnit", Package: "github.com/acme/app/api", Status: "PASS", Duration: 1},
+		"TestCheckout":   {Name: "TestCheckout", Package: "github.com/acme/app/e2e", Status: "FAIL", Duration: 5},
+		"TestCheckout/a": {Name: "TestCheckout/a", Package: "github.com/acme/app/e2e", Status: "FAIL", IsSubTest: true},
+		"TestLogin":      {Name: "TestLogin", Package: "github.com/acme/app/e2e/auth", Status: "PASS", Duration: 3},
+		"TestTool":       {Name: "TestTool", Package: "github.com/acme/tools", Status: "SKIP"},
+	}}
+	categories := []Category{
+		{Name: "e2e", Match: []string{".../e2e/..."}, MinPassRate: 90},
+		{Name: "unit", Match: []string{"github.com/acme/app"}, MinPassRate: 100},
+	}
+
+	summaries := CategorySummaries(data, categories)
+	want := []CategorySummary{
+		{Category: "e2e", Total: 2, Passed: 1, Failed: 1, Duration: 8, MinPassRate: 90},
+		{Category: "unit", Total: 1, Passed: 1, Duration: 1, MinPassRate: 100},
+		{Category: Uncategorized, Total: 1, Skipped: 1},
+	}
+	if len(summaries) != len(want) {
+		t.Fatalf("got %+v", summaries)
+	}
+	for i := range want {
+		if summaries[i] != want[i] {
+			t.Errorf("summary %d: got %+v, want %+v", i, summaries[i], want[i])
+		}
+	}
+
+	violations := CategoryViolations(summaries, categories)
+	if len(violations) != 1 || !strings.Contains(violations[0], "e2e pass rate 50.0% is below the minimum of 90%") {
+		t.Errorf("got violations %q", violations)
+	}
+}
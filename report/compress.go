@@ -0,0 +1,97 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Decompress sniffs the first bytes of reader and transparently unwraps gzip
+// or zstd compressed input. Uncompressed input is returned as-is.
+//
+// zstd input is piped through the zstd command-line tool, which must be
+// available on PATH.
+func Decompress(reader io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(reader)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("error reading input: %v", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("error opening gzip input: %v", err)
+		}
+		// Concatenated gzip members are common when shards are compressed separately
+		gz.Multistream(true)
+		return gz, nil
+
+	case bytes.HasPrefix(magic, zstdMagic):
+		return newZstdReader(br)
+	}
+
+	return br, nil
+}
+
+// zstdReader streams zstd-compressed input through an external zstd process
+type zstdReader struct {
+	io.ReadCloser
+	cmd  *exec.Cmd
+	done bool
+}
+
+func newZstdReader(reader io.Reader) (io.Reader, error) {
+	path, err := exec.LookPath("zstd")
+	if err != nil {
+		return nil, fmt.Errorf("input is zstd-compressed but the zstd tool was not found on PATH; decompress it with 'zstd -d' first")
+	}
+
+	cmd := exec.Command(path, "-d", "-c", "-q")
+	cmd.Stdin = reader
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error starting zstd: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting zstd: %v", err)
+	}
+
+	return &zstdReader{ReadCloser: stdout, cmd: cmd}, nil
+}
+
+func (z *zstdReader) Read(p []byte) (int, error) {
+	if z.done {
+		// Wait closed the pipe; keep reporting the end of the stream
+		return 0, io.EOF
+	}
+	n, err := z.ReadCloser.Read(p)
+	if err == io.EOF && !z.done {
+		z.done = true
+		// Surface decompression failures instead of a silently truncated stream
+		if waitErr := z.cmd.Wait(); waitErr != nil {
+			return n, fmt.Errorf("error decompressing zstd input: %v", waitErr)
+		}
+	}
+	return n, err
+}
+
+// Close stops reading and reaps the zstd process
+func (z *zstdReader) Close() error {
+	err := z.ReadCloser.Close()
+	if !z.done {
+		z.done = true
+		// The process may exit with a broken pipe when closed early
+		z.cmd.Wait()
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os/exec"
+	"testing"
+)
+
+const compressTestInput = `{"Time":"2023-04-01T10:00:00Z","Action":"run","Test":"TestExample","Package":"pkg/example"}
+{"Time":"2023-04-01T10:00:02Z","Action":"pass","Test":"TestExample","Package":"pkg/example","Elapsed":1.5}
+`
+
+func TestDecompress(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	if _, err := w.Write([]byte(compressTestInput)); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"plain", []byte(compressTestInput)},
+		{"gzip", gz.Bytes()},
+		{"empty", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := Decompress(bytes.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			want := compressTestInput
+			if tt.input == nil {
+				want = ""
+			}
+			if string(got) != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestParseCompressedInput(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd tool not available")
+	}
+
+	cmd := exec.Command("zstd", "-c", "-q")
+	cmd.Stdin = bytes.NewReader([]byte(compressTestInput))
+	compressed, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("compressing input: %v", err)
+	}
+
+	data, err := Parse(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data.TotalTests != 1 || data.PassedTests != 1 {
+		t.Errorf("got %d total / %d passed, want 1 / 1", data.TotalTests, data.PassedTests)
+	}
+}
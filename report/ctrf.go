@@ -0,0 +1,138 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CTRF (Common Test Report Format, https://ctrf.io) as read: one JSON
+// document whose results list every test with its status and output
+type ctrfReport struct {
+	Results struct {
+		Tool struct {
+			Name string `json:"name"`
+		} `json:"tool"`
+		Tests []ctrfTest `json:"tests"`
+	} `json:"results"`
+}
+
+type ctrfTest struct {
+	Name     string          `json:"name"`
+	Status   string          `json:"status"`   // passed, failed, skipped, pending or other
+	Duration float64         `json:"duration"` // milliseconds
+	Message  string          `json:"message"`
+	Trace    string          `json:"trace"`
+	Suite    json.RawMessage `json:"suite"` // a name, or the path of nested suites
+	FilePath string          `json:"filePath"`
+	Stdout   []string        `json:"stdout"`
+	Stderr   []string        `json:"stderr"`
+	Retries  int             `json:"retries"`
+	Flaky    bool            `json:"flaky"`
+}
+
+// isCTRF reports whether the start of an input is a CTRF document rather
+// than go test output: a JSON object whose first key is one CTRF starts with
+func isCTRF(head []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(head))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return false
+	}
+	key, err := dec.Token()
+	if err != nil {
+		return false
+	}
+	switch key {
+	case "results", "reportFormat", "specVersion":
+		return true
+	}
+	return false
+}
+
+// readCTRF turns a CTRF document into the events go test -json would have
+// produced for its tests. A test's package is its suite, or its file without
+// one, or the tool that ran it; its output is the message, the trace, then
+// stdout and stderr. Pending tests count as skipped, and tests with status
+// other as not having reported a result. A flaky test that passed after
+// retries is FLAKY, with the retries as failed attempts.
+func (a *aggregator) readCTRF(reader io.Reader) error {
+	var doc ctrfReport
+	if err := json.NewDecoder(reader).Decode(&doc); err != nil {
+		return fmt.Errorf("error reading CTRF: %v", err)
+	}
+	for _, test := range doc.Results.Tests {
+		pkg := ctrfSuite(test.Suite)
+		if pkg == "" {
+			pkg = test.FilePath
+		}
+		if pkg == "" {
+			pkg = doc.Results.Tool.Name
+		}
+		for _, event := range ctrfEvents(test, pkg) {
+			a.event = event
+			if err := a.addEvent(); err != nil {
+				return err
+			}
+		}
+		if test.Flaky && test.Status == "passed" && test.Retries > 0 {
+			if a.flaky == nil {
+				a.flaky = make(map[string]int)
+			}
+			a.flaky[test.Name] = test.Retries
+		}
+	}
+	return nil
+}
+
+// ctrfSuite returns the suite of a test, joining the path of nested suites
+func ctrfSuite(raw json.RawMessage) string {
+	var name string
+	if json.Unmarshal(raw, &name) == nil {
+		return name
+	}
+	var path []string
+	if json.Unmarshal(raw, &path) == nil {
+		return strings.Join(path, " > ")
+	}
+	return ""
+}
+
+// ctrfEvents returns the events of one test: its start, output and result
+func ctrfEvents(test ctrfTest, pkg string) []TestEvent {
+	events := []TestEvent{{Action: "run", Package: pkg, Test: test.Name}}
+	addOutput := func(text string) {
+		text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		for _, line := range strings.Split(text, "\n") {
+			events = append(events, TestEvent{Action: "output", Package: pkg, Test: test.Name, Output: line})
+		}
+	}
+	if !strings.Contains(test.Trace, test.Message) {
+		addOutput(test.Message)
+	}
+	addOutput(test.Trace)
+	for _, line := range test.Stdout {
+		addOutput(line)
+	}
+	for _, line := range test.Stderr {
+		addOutput(line)
+	}
+
+	action := ""
+	switch test.Status {
+	case "passed":
+		action = "pass"
+	case "failed":
+		action = "fail"
+	case "skipped", "pending":
+		action = "skip"
+	}
+	if action != "" {
+		events = append(events, TestEvent{Action: action, Package: pkg, Test: test.Name, Elapsed: test.Duration / 1000})
+	}
+	return events
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const ctrfInput = `{
+  "reportFormat": "CTRF",
+  "specVersion": "0.0.0",
+  "results": {
+    "tool": { "name": "jest" },
+    "summary": { "tests": 5, "passed": 2, "failed": 1, "skipped": 1, "pending": 0, "other": 1 },
+    "tests": [
+      { "name": "renders the cart", "status": "passed", "duration": 250, "suite": "cart.test.js" },
+      { "name": "applies coupons", "status": "failed", "duration": 1500, "suite": ["checkout", "coupons"],
+        "message": "expected 10 to be 9", "trace": "Error: expected 10 to be 9\n    at coupons.test.js:12:5",
+        "stdout": ["applying SAVE10"] },
+      { "name": "ships abroad", "status": "pending", "duration": 0, "filePath": "tests/shipping.spec.ts", "message": "needs a carrier sandbox" },
+      { "name": "retries payment", "status": "passed", "duration": 400, "flaky": true, "retries": 2 },
+      { "name": "exports csv", "status": "other", "duration": 0 }
+    ]
+  }
+}`
+
+func TestParseCTRF(t *testing.T) {
+	data, err := ParseWithOptions(strings.NewReader(ctrfInput), ParseOptions{KeepPassOutput: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type summary struct {
+		pkg, status string
+		duration    float64
+	}
+	got := make(map[string]summary)
+	for name, result := range data.Results {
+		got[name] = summary{result.Package, result.Status, result.Duration}
+	}
+	want := map[string]summary{
+		"renders the cart": {"cart.test.js", "PASS", 0.25},
+		"applies coupons":  {"checkout > coupons", "FAIL", 1.5},
+		"ships abroad":     {"tests/shipping.spec.ts", "SKIP", 0},
+		"retries payment":  {"jest", "FLAKY", 0.4},
+		"exports csv":      {"jest", "UNKNOWN", 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results:\ngot  %v\nwant %v", got, want)
+	}
+
+	if got := data.Results["applies coupons"].Output; !reflect.DeepEqual(got, []string{
+		"Error: expected 10 to be 9",
+		"    at coupons.test.js:12:5",
+		"applying SAVE10",
+	}) {
+		t.Errorf("failure output: got %q", got)
+	}
+	if got := data.Results["ships abroad"].SkipReason; got != "needs a carrier sandbox" {
+		t.Errorf("skip reason: got %q", got)
+	}
+	if got := len(data.Results["retries payment"].Attempts); got != 2 {
+		t.Errorf("flaky test: got %d attempts, want 2", got)
+	}
+	if data.TotalTests != 5 || data.PassedTests != 1 || data.FailedTests != 1 || data.SkippedTests != 1 || data.FlakyTests != 1 {
+		t.Errorf("counts: got total %d, passed %d, failed %d, skipped %d, flaky %d",
+			data.TotalTests, data.PassedTests, data.FailedTests, data.SkippedTests, data.FlakyTests)
+	}
+}
+
+func TestIsCTRF(t *testing.T) {
+	for _, tt := range []struct {
+		head string
+		want bool
+	}{
+		{`{"results": {"tests": []}}`, true},
+		{"{\n  \"reportFormat\": \"CTRF\",", true},
+		{`{"Time":"2024-01-01T10:00:00Z","Action":"run","Test":"TestA"}`, false},
+		{`{"Action":"run"}`, false},
+		{"=== RUN   TestA", false},
+		{"", false},
+	} {
+		if got := isCTRF([]byte(tt.head)); got != tt.want {
+			t.Errorf("isCTRF(%q): got %v, want %v", tt.head, got, tt.want)
+		}
+	}
+}
+
+func TestParseShardsMixesCTRFAndGo(t *testing.T) {
+	dir := t.TempDir()
+	goPath := filepath.Join(dir, "go.json")
+	ctrfPath := filepath.Join(dir, "ctrf.json")
+	os.WriteFile(goPath, []byte(`{"Action":"run","Test":"TestA","Package":"example.com/a"}
+{"Action":"pass","Test":"TestA","Package":"example.com/a","Elapsed":0.1}
+`), 0o644)
+	os.WriteFile(ctrfPath, []byte(ctrfInput), 0o644)
+
+	data, err := ParseShards([]string{goPath, ctrfPath}, 2, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.TotalTests != 6 || data.Results["TestA"] == nil || data.Results["applies coupons"] == nil {
+		t.Errorf("got %d tests, want the go test and CTRF tests together", data.TotalTests)
+	}
+
+	if _, err := ParseWithOptions(strings.NewReader(`{"results": {"tests": [`), ParseOptions{}); err == nil {
+		t.Error("truncated CTRF: got no error")
+	}
+}
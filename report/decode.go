@@ -0,0 +1,416 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// errSlowPath signals that a line is outside what the fast decoder handles and
+// must be decoded by encoding/json instead.
+var errSlowPath = errors.New("fall back to encoding/json")
+
+// eventDecoder decodes the flat JSON objects emitted by test2json without
+// going through reflection. Output events dominate verbose logs, so the fast
+// path avoids every allocation except the retained output string: test and
+// package names are interned, timestamps are only parsed for events that use
+// them, and escaped strings are unescaped into a reused scratch buffer.
+//
+// Anything unexpected (nested values, malformed JSON, odd key casing) falls
+// back to json.Unmarshal, so error messages and semantics stay unchanged.
+type eventDecoder struct {
+	names   map[string]string
+	scratch []byte
+}
+
+func newEventDecoder() *eventDecoder {
+	return &eventDecoder{
+		names:   make(map[string]string, 1024),
+		scratch: make([]byte, 0, 4096),
+	}
+}
+
+// decode fills event from line. Output is only populated (with the trailing
+// newline removed) for output events and Time is left zero for them.
+func (d *eventDecoder) decode(line []byte, event *TestEvent) error {
+	*event = TestEvent{}
+	if err := d.decodeFast(line, event); err == nil {
+		return nil
+	}
+
+	*event = TestEvent{}
+	if err := json.Unmarshal(line, event); err != nil {
+		return err
+	}
+	d.normalize(event)
+	return nil
+}
+
+// decodeFrom fills event from the next JSON value of dec, for lines too long
+// to be buffered whole
+func (d *eventDecoder) decodeFrom(dec *json.Decoder, event *TestEvent) error {
+	*event = TestEvent{}
+	if err := dec.Decode(event); err != nil {
+		return err
+	}
+	d.normalize(event)
+	return nil
+}
+
+// normalize gives an event decoded by encoding/json the shape decodeFast
+// produces
+func (d *eventDecoder) normalize(event *TestEvent) {
+	event.Test = d.intern(event.Test)
+	event.Package = d.intern(event.Package)
+	if event.Action == "output" {
+		event.Output = trimNewline(event.Output)
+		event.Time = time.Time{}
+	}
+}
+
+func (d *eventDecoder) decodeFast(line []byte, event *TestEvent) error {
+	var rawTime, rawOutput []byte
+	outputEscaped := false
+
+	i := skipSpace(line, 0)
+	if i >= len(line) || line[i] != '{' {
+		return errSlowPath
+	}
+	i = skipSpace(line, i+1)
+	if i < len(line) && line[i] == '}' {
+		return checkTrailing(line, i+1)
+	}
+
+	for {
+		key, keyEscaped, next, err := scanString(line, i)
+		if err != nil || keyEscaped {
+			return errSlowPath
+		}
+		i = skipSpace(line, next)
+		if i >= len(line) || line[i] != ':' {
+			return errSlowPath
+		}
+		i = skipSpace(line, i+1)
+		if i >= len(line) {
+			return errSlowPath
+		}
+
+		if line[i] == '"' {
+			value, escaped, next, err := scanString(line, i)
+			if err != nil {
+				return err
+			}
+			i = next
+
+			switch string(key) {
+			case "Time":
+				rawTime = value
+			case "Action":
+				if escaped {
+					return errSlowPath
+				}
+				event.Action = actionString(value)
+			case "Test":
+				if escaped {
+					return errSlowPath
+				}
+				event.Test = d.internBytes(value)
+			case "Package":
+				if escaped {
+					return errSlowPath
+				}
+				event.Package = d.internBytes(value)
+			case "Output":
+				rawOutput, outputEscaped = value, escaped
+			default:
+				if isKnownKeyFold(key) {
+					return errSlowPath
+				}
+			}
+		} else {
+			end := scanScalar(line, i)
+			if end == i {
+				return errSlowPath
+			}
+			value := line[i:end]
+			i = end
+
+			switch string(key) {
+			case "Elapsed":
+				elapsed, err := strconv.ParseFloat(string(value), 64)
+				if err != nil {
+					return errSlowPath
+				}
+				event.Elapsed = elapsed
+			case "Time", "Action", "Test", "Package", "Output":
+				// null or a non-string value; let encoding/json decide
+				return errSlowPath
+			default:
+				if isKnownKeyFold(key) {
+					return errSlowPath
+				}
+			}
+		}
+
+		i = skipSpace(line, i)
+		if i >= len(line) {
+			return errSlowPath
+		}
+		if line[i] == ',' {
+			i = skipSpace(line, i+1)
+			continue
+		}
+		if line[i] == '}' {
+			if err := checkTrailing(line, i+1); err != nil {
+				return err
+			}
+			break
+		}
+		return errSlowPath
+	}
+
+	if rawOutput != nil {
+		output, ok := d.unescape(rawOutput, outputEscaped)
+		if !ok {
+			return errSlowPath
+		}
+		if event.Action == "output" {
+			// Time is unused for output events, so skip parsing it
+			event.Output = string(bytes.TrimSuffix(output, []byte("\n")))
+			return nil
+		}
+		event.Output = string(output)
+	}
+	if event.Action == "output" {
+		return nil
+	}
+	if rawTime != nil {
+		t, err := time.Parse(time.RFC3339Nano, string(rawTime))
+		if err != nil {
+			return errSlowPath
+		}
+		event.Time = t
+	}
+	return nil
+}
+
+// unescape returns the decoded contents of a raw JSON string, reusing the
+// scratch buffer when escapes have to be resolved
+func (d *eventDecoder) unescape(raw []byte, escaped bool) ([]byte, bool) {
+	if !escaped {
+		return raw, true
+	}
+	var ok bool
+	d.scratch, ok = appendUnescaped(d.scratch[:0], raw)
+	return d.scratch, ok
+}
+
+// intern returns a canonical copy of s so repeated names share one allocation
+func (d *eventDecoder) intern(s string) string {
+	if s == "" {
+		return ""
+	}
+	if canonical, ok := d.names[s]; ok {
+		return canonical
+	}
+	d.names[s] = s
+	return s
+}
+
+func (d *eventDecoder) internBytes(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	// The compiler avoids allocating for map lookups keyed by string(b)
+	if canonical, ok := d.names[string(b)]; ok {
+		return canonical
+	}
+	s := string(b)
+	d.names[s] = s
+	return s
+}
+
+// actionString maps known actions to constant strings to avoid allocating
+func actionString(b []byte) string {
+	switch string(b) {
+	case "output":
+		return "output"
+	case "run":
+		return "run"
+	case "pass":
+		return "pass"
+	case "fail":
+		return "fail"
+	case "skip":
+		return "skip"
+	case "pause":
+		return "pause"
+	case "cont":
+		return "cont"
+	case "bench":
+		return "bench"
+	case "start":
+		return "start"
+	}
+	return string(b)
+}
+
+// isKnownKeyFold reports whether key matches a TestEvent field only
+// case-insensitively, which encoding/json would still honor.
+func isKnownKeyFold(key []byte) bool {
+	for _, name := range []string{"Time", "Action", "Test", "Package", "Output", "Elapsed"} {
+		if bytes.EqualFold(key, []byte(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+func trimNewline(s string) string {
+	if len(s) > 0 && s[len(s)-1] == '\n' {
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+func skipSpace(b []byte, i int) int {
+	for i < len(b) && (b[i] == ' ' || b[i] == '\t' || b[i] == '\r' || b[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+func checkTrailing(b []byte, i int) error {
+	if skipSpace(b, i) != len(b) {
+		return errSlowPath
+	}
+	return nil
+}
+
+// scanString scans the JSON string starting at b[i] (which must be a quote) and
+// returns its raw contents, whether it contains escapes, and the index after
+// the closing quote.
+func scanString(b []byte, i int) ([]byte, bool, int, error) {
+	if i >= len(b) || b[i] != '"' {
+		return nil, false, 0, errSlowPath
+	}
+	start := i + 1
+	escaped := false
+	for j := start; j < len(b); j++ {
+		switch c := b[j]; {
+		case c == '"':
+			return b[start:j], escaped, j + 1, nil
+		case c == '\\':
+			escaped = true
+			j++
+		case c < 0x20 || c >= utf8.RuneSelf:
+			// Control characters are invalid and non-ASCII needs validation
+			escaped = true
+		}
+	}
+	return nil, false, 0, errSlowPath
+}
+
+// scanScalar returns the end of a number, true, false or null literal
+func scanScalar(b []byte, i int) int {
+	j := i
+	for j < len(b) {
+		c := b[j]
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || c == '-' || c == '+' || c == '.' || c == 'E' {
+			j++
+			continue
+		}
+		break
+	}
+	return j
+}
+
+// appendUnescaped decodes the JSON string contents src onto dst. Invalid UTF-8
+// is replaced with U+FFFD just like encoding/json does.
+func appendUnescaped(dst, src []byte) ([]byte, bool) {
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(src) {
+				return dst, false
+			}
+			switch src[i+1] {
+			case '"', '\\', '/':
+				dst = append(dst, src[i+1])
+			case 'b':
+				dst = append(dst, '\b')
+			case 'f':
+				dst = append(dst, '\f')
+			case 'n':
+				dst = append(dst, '\n')
+			case 'r':
+				dst = append(dst, '\r')
+			case 't':
+				dst = append(dst, '\t')
+			case 'u':
+				r, ok := hexRune(src, i+2)
+				if !ok {
+					return dst, false
+				}
+				i += 6
+				if utf16.IsSurrogate(r) {
+					if i+1 < len(src) && src[i] == '\\' && src[i+1] == 'u' {
+						if r2, ok := hexRune(src, i+2); ok {
+							if dec := utf16.DecodeRune(r, r2); dec != utf8.RuneError {
+								dst = utf8.AppendRune(dst, dec)
+								i += 6
+								continue
+							}
+						}
+					}
+					r = utf8.RuneError
+				}
+				dst = utf8.AppendRune(dst, r)
+				continue
+			default:
+				return dst, false
+			}
+			i += 2
+		case c < 0x20:
+			return dst, false
+		case c < utf8.RuneSelf:
+			dst = append(dst, c)
+			i++
+		default:
+			r, size := utf8.DecodeRune(src[i:])
+			if r == utf8.RuneError && size == 1 {
+				dst = utf8.AppendRune(dst, utf8.RuneError)
+			} else {
+				dst = append(dst, src[i:i+size]...)
+			}
+			i += size
+		}
+	}
+	return dst, true
+}
+
+func hexRune(b []byte, i int) (rune, bool) {
+	if i+4 > len(b) {
+		return 0, false
+	}
+	var r rune
+	for _, c := range b[i : i+4] {
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, false
+		}
+		r = r<<4 | rune(c)
+	}
+	return r, true
+}
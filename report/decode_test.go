@@ -0,0 +1,111 @@
+package report
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventDecoderMatchesEncodingJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"run event", `{"Time":"2023-04-01T10:00:00.123456789+02:00","Action":"run","Package":"pkg/example","Test":"TestExample"}`},
+		{"pass event", `{"Time":"2023-04-01T10:00:02Z","Action":"pass","Package":"pkg/example","Test":"TestExample","Elapsed":1.5}`},
+		{"output with escapes", `{"Time":"2023-04-01T10:00:01Z","Action":"output","Test":"TestExample","Output":"got \"a\\tb\"\n"}`},
+		{"output with unicode escapes", `{"Action":"output","Test":"TestExample","Output":"été 😀 <b>\n"}`},
+		{"output with raw utf-8", `{"Action":"output","Test":"TestExample","Output":"température ✅\n"}`},
+		{"output with lone surrogate", `{"Action":"output","Test":"TestExample","Output":"\ud83d oops\n"}`},
+		{"output without trailing newline", `{"Action":"output","Test":"TestExample","Output":"partial"}`},
+		{"unicode test name", `{"Action":"run","Test":"TestNames/héllo_wörld"}`},
+		{"escaped test name", `{"Action":"run","Test":"TestNames/quote\"d"}`},
+		{"extra keys", `{"Action":"pass","Test":"TestExample","FailedBuild":"pkg","Source":{"file":"x.go"},"Elapsed":0}`},
+		{"lowercase keys", `{"action":"fail","test":"TestExample","elapsed":2}`},
+		{"null elapsed", `{"Action":"pass","Test":"TestExample","Elapsed":null}`},
+		{"whitespace", `  { "Action" : "skip" , "Test" : "TestExample" }  `},
+		{"empty object", `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want TestEvent
+			if err := json.Unmarshal([]byte(tt.line), &want); err != nil {
+				t.Fatalf("encoding/json rejected test input: %v", err)
+			}
+			if want.Action == "output" {
+				want.Output = strings.TrimSuffix(want.Output, "\n")
+				want.Time = time.Time{}
+			}
+
+			var got TestEvent
+			if err := newEventDecoder().decode([]byte(tt.line), &got); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !got.Time.Equal(want.Time) {
+				t.Errorf("Time: got %v, want %v", got.Time, want.Time)
+			}
+			got.Time, want.Time = time.Time{}, time.Time{}
+			if got != want {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestEventDecoderRejectsInvalidJSON(t *testing.T) {
+	for _, line := range []string{
+		`{"Action":"run","Test":"TestExample"`,
+		`{"Action":"run",}`,
+		`{"Action":"output","Output":"bad \x escape"}`,
+		`not json`,
+		`{"Action":"run"} trailing`,
+	} {
+		var event TestEvent
+		if err := newEventDecoder().decode([]byte(line), &event); err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+}
+
+func TestEventDecoderInternsNames(t *testing.T) {
+	d := newEventDecoder()
+	var a, b TestEvent
+	if err := d.decode([]byte(`{"Action":"run","Test":"TestExample","Package":"pkg"}`), &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.decode([]byte(`{"Action":"output","Test":"TestExample","Package":"pkg","Output":"x\n"}`), &b); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.names) != 2 {
+		t.Errorf("expected 2 interned names, got %d", len(d.names))
+	}
+}
+
+func BenchmarkEventDecoderOutput(b *testing.B) {
+	line := []byte(`{"Time":"2023-04-01T10:00:00.000002Z","Action":"output","Package":"github.com/acme/platform/internal/billing","Test":"TestIntegration/case_1","Output":"    billing_test.go:12: processed invoice 12 with \"status\"=ok\tin 3ms\n"}`)
+	d := newEventDecoder()
+	var event TestEvent
+	b.SetBytes(int64(len(line)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if err := d.decode(line, &event); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEventDecoderOutputEncodingJSON(b *testing.B) {
+	line := []byte(`{"Time":"2023-04-01T10:00:00.000002Z","Action":"output","Package":"github.com/acme/platform/internal/billing","Test":"TestIntegration/case_1","Output":"    billing_test.go:12: processed invoice 12 with \"status\"=ok\tin 3ms\n"}`)
+	var event TestEvent
+	b.SetBytes(int64(len(line)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if err := json.Unmarshal(line, &event); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TestChange describes how a single test changed between two runs. Status and
+// duration fields are empty/zero on the side where the test did not exist.
+type TestChange struct {
+	Name        string  `json:"name"`
+	Package     string  `json:"package"`
+	OldStatus   string  `json:"oldStatus,omitempty"`
+	NewStatus   string  `json:"newStatus,omitempty"`
+	OldDuration float64 `json:"oldDuration"`
+	NewDuration float64 `json:"newDuration"`
+}
+
+// DurationDelta returns how much slower (positive) or faster (negative) the
+// test ran in the new run, in seconds.
+func (t TestChange) DurationDelta() float64 {
+	return t.NewDuration - t.OldDuration
+}
+
+// DiffData is the comparison of two runs as computed by Diff.
+type DiffData struct {
+	OldTotal    int     `json:"oldTotal"`
+	NewTotal    int     `json:"newTotal"`
+	OldPassed   int     `json:"oldPassed"`
+	NewPassed   int     `json:"newPassed"`
+	OldFailed   int     `json:"oldFailed"`
+	NewFailed   int     `json:"newFailed"`
+	OldSkipped  int     `json:"oldSkipped"`
+	NewSkipped  int     `json:"newSkipped"`
+	OldDuration float64 `json:"oldDuration"`
+	NewDuration float64 `json:"newDuration"`
+
+	NewFailures  []TestChange `json:"newFailures"`  // failing now, passing or absent before
+	Fixed        []TestChange `json:"fixed"`        // failing before, no longer failing
+	StillFailing []TestChange `json:"stillFailing"` // failing in both runs
+	Added        []TestChange `json:"added"`        // only present in the new run
+	Removed      []TestChange `json:"removed"`      // only present in the old run
+	Common       []TestChange `json:"common"`       // present in both runs
+
+	// Packages that had tests in the old run and have none in the new one
+	MissingPackages []string `json:"missingPackages"`
+}
+
+// LargeDropPercent is the share of old tests that may go missing before the
+// comparison warns about it. Drops that large are rarely intended and usually
+// mean a build tag or a package was left out of the run.
+const LargeDropPercent = 10
+
+// Diff compares two runs, treating old as the baseline. Tests are matched by
+// name and every result, including subtests, takes part in the comparison.
+// Either side may be nil, which is treated as an empty run.
+func Diff(old, new *ReportData) *DiffData {
+	if old == nil {
+		old = &ReportData{}
+	}
+	if new == nil {
+		new = &ReportData{}
+	}
+
+	d := &DiffData{
+		OldTotal:    old.TotalTests,
+		NewTotal:    new.TotalTests,
+		OldPassed:   old.PassedTests,
+		NewPassed:   new.PassedTests,
+		OldFailed:   old.FailedTests,
+		NewFailed:   new.FailedTests,
+		OldSkipped:  old.SkippedTests,
+		NewSkipped:  new.SkippedTests,
+		OldDuration: old.TotalDuration,
+		NewDuration: new.TotalDuration,
+	}
+
+	names := make(map[string]bool, len(old.Results)+len(new.Results))
+	for name := range old.Results {
+		names[name] = true
+	}
+	for name := range new.Results {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	newPackages := make(map[string]bool)
+	for _, result := range new.Results {
+		newPackages[result.Package] = true
+	}
+	missing := make(map[string]bool)
+
+	for _, name := range sorted {
+		oldResult, inOld := old.Results[name]
+		newResult, inNew := new.Results[name]
+
+		td := TestChange{Name: name}
+		if inOld {
+			td.Package = oldResult.Package
+			td.OldStatus = oldResult.Status
+			td.OldDuration = oldResult.Duration
+		}
+		if inNew {
+			td.Package = newResult.Package
+			td.NewStatus = newResult.Status
+			td.NewDuration = newResult.Duration
+		}
+
+		switch {
+		case !inOld:
+			d.Added = append(d.Added, td)
+		case !inNew:
+			d.Removed = append(d.Removed, td)
+			if td.Package != "" && !newPackages[td.Package] {
+				missing[td.Package] = true
+			}
+		default:
+			d.Common = append(d.Common, td)
+		}
+
+		oldFailed := td.OldStatus == "FAIL"
+		newFailed := td.NewStatus == "FAIL"
+		switch {
+		case newFailed && oldFailed:
+			d.StillFailing = append(d.StillFailing, td)
+		case newFailed:
+			d.NewFailures = append(d.NewFailures, td)
+		case oldFailed && inNew:
+			d.Fixed = append(d.Fixed, td)
+		}
+	}
+
+	d.MissingPackages = make([]string, 0, len(missing))
+	for pkg := range missing {
+		d.MissingPackages = append(d.MissingPackages, pkg)
+	}
+	sort.Strings(d.MissingPackages)
+
+	return d
+}
+
+// HasRegressions reports whether the new run introduced failures that were
+// not present in the baseline.
+func (d *DiffData) HasRegressions() bool {
+	return len(d.NewFailures) > 0
+}
+
+// LargeDrop reports whether the new run has more than LargeDropPercent fewer
+// tests than the old one, or lost whole packages
+func (d *DiffData) LargeDrop() bool {
+	if len(d.MissingPackages) > 0 {
+		return true
+	}
+	return d.OldTotal > 0 && float64(d.OldTotal-d.NewTotal) > float64(d.OldTotal)*LargeDropPercent/100
+}
+
+// JSON renders the diff as indented JSON.
+func (d *DiffData) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// Markdown renders the diff as a Markdown section suitable for PR comments.
+func (d *DiffData) Markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString("## 🔀 Comparison with Baseline\n\n")
+	sb.WriteString("| Metric | Baseline | Current | Change |\n")
+	sb.WriteString("| ------ | -------- | ------- | ------ |\n")
+	sb.WriteString(fmt.Sprintf("| Total | %d | %d | %+d |\n", d.OldTotal, d.NewTotal, d.NewTotal-d.OldTotal))
+	sb.WriteString(fmt.Sprintf("| Passed | %d | %d | %+d |\n", d.OldPassed, d.NewPassed, d.NewPassed-d.OldPassed))
+	sb.WriteString(fmt.Sprintf("| Failed | %d | %d | %+d |\n", d.OldFailed, d.NewFailed, d.NewFailed-d.OldFailed))
+	sb.WriteString(fmt.Sprintf("| Skipped | %d | %d | %+d |\n", d.OldSkipped, d.NewSkipped, d.NewSkipped-d.OldSkipped))
+	sb.WriteString(fmt.Sprintf("| Duration | %.2fs | %.2fs | %+.2fs |\n\n", d.OldDuration, d.NewDuration, d.NewDuration-d.OldDuration))
+
+	if d.LargeDrop() {
+		sb.WriteString(fmt.Sprintf("> ⚠️ **Tests went missing since the baseline** (%d → %d tests). Check for packages or build tags that were left out of this run.\n", d.OldTotal, d.NewTotal))
+		if len(d.MissingPackages) > 0 {
+			sb.WriteString(fmt.Sprintf("> Packages without tests in this run: `%s`\n", strings.Join(d.MissingPackages, "`, `")))
+		}
+		sb.WriteString("\n")
+	}
+
+	writeDiffList(&sb, "🆕 New failures", d.NewFailures)
+	writeDiffList(&sb, "✅ Fixed since baseline", d.Fixed)
+	writeDiffList(&sb, "🔁 Still failing", d.StillFailing)
+
+	if d.OldFailed == 0 && d.NewFailed == 0 {
+		sb.WriteString("> No failures in either run.\n\n")
+	}
+
+	writeDiffDetails(&sb, "➕ Added tests", d.Added)
+	writeDiffDetails(&sb, "➖ Removed tests", d.Removed)
+
+	return sb.String()
+}
+
+// writeDiffList writes a subsection listing tests, skipping it when empty
+func writeDiffList(sb *strings.Builder, title string, tests []TestChange) {
+	if len(tests) == 0 {
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("### %s (%s)\n\n", title, countChanges(tests)))
+	for _, t := range tests {
+		if t.Package != "" {
+			sb.WriteString(fmt.Sprintf("- `%s` (%s)\n", t.Name, t.Package))
+		} else {
+			sb.WriteString(fmt.Sprintf("- `%s`\n", t.Name))
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// writeDiffDetails writes a collapsed list of tests, skipping it when empty.
+// Added and removed tests are mostly renames, so they stay out of the way.
+func writeDiffDetails(sb *strings.Builder, title string, tests []TestChange) {
+	if len(tests) == 0 {
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("<details>\n<summary>%s (%s)</summary>\n\n", title, countChanges(tests)))
+	for _, t := range tests {
+		if t.Package != "" {
+			sb.WriteString(fmt.Sprintf("- `%s` (%s)\n", t.Name, t.Package))
+		} else {
+			sb.WriteString(fmt.Sprintf("- `%s`\n", t.Name))
+		}
+	}
+	sb.WriteString("\n</details>\n\n")
+}
+
+// countChanges counts the top-level tests of a list the way the summary
+// table does, and its subtests separately
+func countChanges(tests []TestChange) string {
+	var top, sub int
+	for _, t := range tests {
+		if strings.Contains(t.Name, "/") {
+			sub++
+		} else {
+			top++
+		}
+	}
+	count := func(n int, noun string) string {
+		if n != 1 {
+			noun += "s"
+		}
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	switch {
+	case sub == 0:
+		return fmt.Sprint(top)
+	case top == 0:
+		return count(sub, "subtest")
+	default:
+		return count(top, "test") + ", " + count(sub, "subtest")
+	}
+}
@@ -0,0 +1,216 @@
+package report
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := &ReportData{
+		TotalTests:  3,
+		PassedTests: 2,
+		FailedTests: 1,
+		Results: map[string]*TestResult{
+			"TestStable":  {Name: "TestStable", Status: "PASS", Duration: 0.1},
+			"TestBroken":  {Name: "TestBroken", Status: "FAIL", Duration: 0.2},
+			"TestRemoved": {Name: "TestRemoved", Status: "PASS", Duration: 0.3},
+		},
+	}
+	new := &ReportData{
+		TotalTests:  4,
+		PassedTests: 1,
+		FailedTests: 3,
+		Results: map[string]*TestResult{
+			"TestStable":  {Name: "TestStable", Status: "FAIL", Duration: 0.5},
+			"TestBroken":  {Name: "TestBroken", Status: "FAIL", Duration: 0.2},
+			"TestAdded":   {Name: "TestAdded", Status: "FAIL", Duration: 0.1},
+			"TestPassing": {Name: "TestPassing", Status: "PASS", Duration: 0.1},
+		},
+	}
+
+	d := Diff(old, new)
+
+	names := func(tests []TestChange) string {
+		var parts []string
+		for _, td := range tests {
+			parts = append(parts, td.Name)
+		}
+		return strings.Join(parts, ",")
+	}
+
+	tests := []struct {
+		name string
+		got  []TestChange
+		want string
+	}{
+		{"new failures", d.NewFailures, "TestAdded,TestStable"},
+		{"fixed", d.Fixed, ""},
+		{"still failing", d.StillFailing, "TestBroken"},
+		{"added", d.Added, "TestAdded,TestPassing"},
+		{"removed", d.Removed, "TestRemoved"},
+		{"common", d.Common, "TestBroken,TestStable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(tt.got); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if !d.HasRegressions() {
+		t.Error("expected regressions to be reported")
+	}
+	if delta := d.Common[1].DurationDelta(); delta < 0.39 || delta > 0.41 {
+		t.Errorf("TestStable duration delta: got %.2f, want 0.40", delta)
+	}
+}
+
+func TestDiffFixedAndNilInputs(t *testing.T) {
+	old := &ReportData{
+		Results: map[string]*TestResult{
+			"TestFlaky": {Name: "TestFlaky", Status: "FAIL"},
+		},
+	}
+	new := &ReportData{
+		Results: map[string]*TestResult{
+			"TestFlaky": {Name: "TestFlaky", Status: "PASS"},
+		},
+	}
+
+	d := Diff(old, new)
+	if len(d.Fixed) != 1 || d.Fixed[0].Name != "TestFlaky" {
+		t.Errorf("expected TestFlaky to be fixed, got %+v", d.Fixed)
+	}
+	if d.HasRegressions() {
+		t.Error("did not expect regressions")
+	}
+
+	d = Diff(nil, new)
+	if len(d.Added) != 1 {
+		t.Errorf("expected 1 added test against nil baseline, got %d", len(d.Added))
+	}
+}
+
+func TestDiffRenderers(t *testing.T) {
+	old := &ReportData{
+		TotalTests:  1,
+		FailedTests: 1,
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Package: "pkg/a", Status: "FAIL"},
+		},
+	}
+	new := &ReportData{
+		TotalTests:  1,
+		PassedTests: 1,
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Package: "pkg/a", Status: "PASS"},
+		},
+	}
+	d := Diff(old, new)
+
+	markdown := d.Markdown()
+	for _, want := range []string{
+		"## 🔀 Comparison with Baseline",
+		"| Failed | 1 | 0 | -1 |",
+		"### ✅ Fixed since baseline (1)",
+		"- `TestA` (pkg/a)",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Markdown missing %q", want)
+		}
+	}
+	if strings.Contains(markdown, "New failures") {
+		t.Error("Markdown should omit empty sections")
+	}
+
+	raw, err := d.JSON()
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	var decoded DiffData
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("decoding JSON: %v", err)
+	}
+	if len(decoded.Fixed) != 1 || decoded.Fixed[0].OldStatus != "FAIL" {
+		t.Errorf("unexpected decoded diff: %+v", decoded.Fixed)
+	}
+}
+
+func TestDiffAddedAndRemovedTests(t *testing.T) {
+	old := &ReportData{
+		Results: map[string]*TestResult{
+			"TestKept":   {Name: "TestKept", Package: "pkg/a", Status: "PASS"},
+			"TestRename": {Name: "TestRename", Package: "pkg/a", Status: "PASS"},
+			"TestTagged": {Name: "TestTagged", Package: "pkg/b", Status: "PASS"},
+		},
+	}
+	new := &ReportData{
+		Results: map[string]*TestResult{
+			"TestKept":    {Name: "TestKept", Package: "pkg/a", Status: "PASS"},
+			"TestRenamed": {Name: "TestRenamed", Package: "pkg/a", Status: "PASS"},
+		},
+	}
+	summarize(old)
+	summarize(new)
+	d := Diff(old, new)
+
+	if !reflect.DeepEqual(d.MissingPackages, []string{"pkg/b"}) {
+		t.Errorf("MissingPackages: got %q, want [pkg/b]", d.MissingPackages)
+	}
+	if !d.LargeDrop() {
+		t.Error("expected a large drop")
+	}
+	markdown := d.Markdown()
+	for _, want := range []string{
+		"> ⚠️ **Tests went missing since the baseline** (3 → 2 tests).",
+		"> Packages without tests in this run: `pkg/b`\n",
+		"<summary>➕ Added tests (1)</summary>\n\n- `TestRenamed` (pkg/a)\n",
+		"<summary>➖ Removed tests (2)</summary>\n\n- `TestRename` (pkg/a)\n- `TestTagged` (pkg/b)\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Markdown missing %q:\n%s", want, markdown)
+		}
+	}
+
+	// Subtests are counted apart from the tests the summary table counts
+	old.Results["TestTagged/case"] = &TestResult{Name: "TestTagged/case", Package: "pkg/b", Status: "FAIL", IsSubTest: true}
+	old.Results["TestTagged"].Status = "FAIL"
+	summarize(old)
+	markdown = Diff(old, new).Markdown()
+	if !strings.Contains(markdown, "<summary>➖ Removed tests (2 tests, 1 subtest)</summary>") {
+		t.Errorf("Markdown should count removed subtests apart:\n%s", markdown)
+	}
+	if strings.Contains(markdown, "No failures in either run") {
+		t.Errorf("Markdown claims no failures although the baseline had one:\n%s", markdown)
+	}
+	delete(old.Results, "TestTagged/case")
+	old.Results["TestTagged"].Status = "PASS"
+
+	// A renamed test alone is no reason for alarm
+	delete(old.Results, "TestTagged")
+	summarize(old)
+	if d := Diff(old, new); d.LargeDrop() || strings.Contains(d.Markdown(), "went missing") {
+		t.Error("unexpected large drop after a rename")
+	}
+}
+
+func TestDiffLargeDropThreshold(t *testing.T) {
+	tests := []struct {
+		oldTotal, newTotal int
+		want               bool
+	}{
+		{100, 90, false},
+		{100, 89, true},
+		{0, 0, false},
+		{10, 12, false},
+	}
+	for _, tt := range tests {
+		d := &DiffData{OldTotal: tt.oldTotal, NewTotal: tt.newTotal}
+		if got := d.LargeDrop(); got != tt.want {
+			t.Errorf("LargeDrop(%d → %d): got %v, want %v", tt.oldTotal, tt.newTotal, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+)
+
+// Environment describes the machine and CI job a report comes from, so
+// reports from different runners can be told apart
+type Environment struct {
+	GoVersion string `json:"goVersion"`
+	GOOS      string `json:"goos"`
+	GOARCH    string `json:"goarch"`
+	NumCPU    int    `json:"numCPU"`
+
+	// CI variables that identify the runner and job, by name
+	CI map[string]string `json:"ci,omitempty"`
+}
+
+// CIVariables lists the environment variables recorded in Environment.CI
+var CIVariables = []string{
+	"RUNNER_OS",
+	"RUNNER_ARCH",
+	"RUNNER_NAME",
+	"GITHUB_WORKFLOW",
+	"GITHUB_JOB",
+	"GITHUB_RUN_ID",
+	"GITHUB_RUN_ATTEMPT",
+}
+
+// HostEnvironment describes the machine and CI job running this process
+func HostEnvironment() *Environment {
+	env := &Environment{
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		NumCPU:    runtime.NumCPU(),
+	}
+	for _, name := range CIVariables {
+		if value := os.Getenv(name); value != "" {
+			if env.CI == nil {
+				env.CI = make(map[string]string)
+			}
+			env.CI[name] = value
+		}
+	}
+	return env
+}
+
+// ParseGoEnv reads `go env -json` output and takes the Go version and target
+// platform from it. go env doesn't report CPUs, so the CPU count and CI
+// variables are those of the current host.
+func ParseGoEnv(reader io.Reader) (*Environment, error) {
+	var goEnv map[string]string
+	if err := json.NewDecoder(reader).Decode(&goEnv); err != nil {
+		return nil, fmt.Errorf("error parsing go env output: %v", err)
+	}
+
+	env := HostEnvironment()
+	if goEnv["GOVERSION"] != "" {
+		env.GoVersion = goEnv["GOVERSION"]
+	}
+	if goEnv["GOOS"] != "" {
+		env.GOOS = goEnv["GOOS"]
+	}
+	if goEnv["GOARCH"] != "" {
+		env.GOARCH = goEnv["GOARCH"]
+	}
+	return env, nil
+}
+
+// LoadGoEnv reads a file holding `go env -json` output
+func LoadGoEnv(path string) (*Environment, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening go env file: %v", err)
+	}
+	defer file.Close()
+	return ParseGoEnv(file)
+}
@@ -0,0 +1,53 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestHostEnvironment(t *testing.T) {
+	for _, name := range CIVariables {
+		t.Setenv(name, "")
+	}
+	t.Setenv("RUNNER_OS", "Linux")
+	t.Setenv("GITHUB_JOB", "unit-tests")
+
+	env := HostEnvironment()
+	if env.GoVersion != runtime.Version() || env.GOOS != runtime.GOOS || env.GOARCH != runtime.GOARCH || env.NumCPU < 1 {
+		t.Errorf("unexpected host environment: %+v", env)
+	}
+	if len(env.CI) != 2 || env.CI["RUNNER_OS"] != "Linux" || env.CI["GITHUB_JOB"] != "unit-tests" {
+		t.Errorf("CI variables: got %v", env.CI)
+	}
+}
+
+func TestLoadGoEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goenv.json")
+	goEnv := `{
+	"GOARCH": "arm64",
+	"GOOS": "darwin",
+	"GOROOT": "/usr/local/go",
+	"GOVERSION": "go1.22.4"
+}`
+	if err := os.WriteFile(path, []byte(goEnv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := LoadGoEnv(path)
+	if err != nil {
+		t.Fatalf("LoadGoEnv: %v", err)
+	}
+	if env.GoVersion != "go1.22.4" || env.GOOS != "darwin" || env.GOARCH != "arm64" || env.NumCPU != runtime.NumCPU() {
+		t.Errorf("unexpected environment: %+v", env)
+	}
+
+	if _, err := ParseGoEnv(strings.NewReader("GOOS=linux")); err == nil {
+		t.Error("expected an error for go env output that isn't JSON")
+	}
+	if _, err := LoadGoEnv(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
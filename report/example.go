@@ -0,0 +1,87 @@
+package report
+
+import "strings"
+
+// IsExample reports whether name is a testable example (func ExampleXxx)
+func IsExample(name string) bool {
+	return strings.HasPrefix(name, "Example") && !strings.Contains(name, "/")
+}
+
+// Examples returns the names of the examples in the report, sorted
+func Examples(data *ReportData) []string {
+	var names []string
+	for _, name := range sortedResultNames(data) {
+		if IsExample(data.Results[name].Name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ExampleMismatch extracts the got and want blocks that go test prints when
+// an example's output doesn't match its // Output: comment.
+func ExampleMismatch(output []string) (got, want []string, ok bool) {
+	var current *[]string
+	for _, line := range output {
+		line = strings.TrimRight(line, "\r\n")
+		switch strings.TrimSpace(line) {
+		case "got:":
+			current, ok = &got, true
+			continue
+		case "want:":
+			current, ok = &want, true
+			continue
+		}
+		if strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "=== ") {
+			current = nil
+			continue
+		}
+		if current != nil {
+			*current = append(*current, line)
+		}
+	}
+	return got, want, ok
+}
+
+// DiffLines returns a line diff turning want into got: unchanged lines are
+// prefixed with "  ", removed ones with "- " and added ones with "+ ".
+func DiffLines(want, got []string) []string {
+	// Longest common subsequence table, filled from the end
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(want) && j < len(got) {
+		switch {
+		case want[i] == got[j]:
+			diff = append(diff, "  "+want[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, "- "+want[i])
+			i++
+		default:
+			diff = append(diff, "+ "+got[j])
+			j++
+		}
+	}
+	for ; i < len(want); i++ {
+		diff = append(diff, "- "+want[i])
+	}
+	for ; j < len(got); j++ {
+		diff = append(diff, "+ "+got[j])
+	}
+	return diff
+}
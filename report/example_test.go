@@ -0,0 +1,79 @@
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// exampleOutput is the output of an example whose output doesn't match its
+// // Output: comment, as captured by go test -json
+var exampleOutput = []string{
+	"=== RUN   ExampleGreet\n",
+	"--- FAIL: ExampleGreet (0.00s)\n",
+	"got:\n",
+	"hello\n",
+	"world\n",
+	"want:\n",
+	"hello\n",
+	"there\n",
+}
+
+func TestIsExample(t *testing.T) {
+	tests := map[string]bool{
+		"Example":               true,
+		"ExampleGreet":          true,
+		"Example_suffix":        true,
+		"ExampleClient_Do_json": true,
+		"TestExample":           false,
+		"TestGreet/Example":     false,
+		"BenchmarkExample":      false,
+	}
+	for name, want := range tests {
+		if got := IsExample(name); got != want {
+			t.Errorf("IsExample(%q): got %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestExampleMismatch(t *testing.T) {
+	got, want, ok := ExampleMismatch(exampleOutput)
+	if !ok {
+		t.Fatal("expected a got/want mismatch")
+	}
+	if !reflect.DeepEqual(got, []string{"hello", "world"}) {
+		t.Errorf("got block: got %q", got)
+	}
+	if !reflect.DeepEqual(want, []string{"hello", "there"}) {
+		t.Errorf("want block: got %q", want)
+	}
+
+	if _, _, ok := ExampleMismatch([]string{"--- FAIL: ExampleGreet (0.00s)\n", "panic: boom\n"}); ok {
+		t.Error("expected no mismatch for a panicking example")
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		want, got []string
+		diff      string
+	}{
+		{[]string{"a", "b", "c"}, []string{"a", "b", "c"}, "  a|  b|  c"},
+		{[]string{"a", "b", "c"}, []string{"a", "x", "c"}, "  a|- b|+ x|  c"},
+		{[]string{"a"}, []string{"a", "b"}, "  a|+ b"},
+		{[]string{"a", "b"}, nil, "- a|- b"},
+		{[]string{"1", "2", "3", "4"}, []string{"1", "3", "4", "5"}, "  1|- 2|  3|  4|+ 5"},
+	}
+	for _, test := range tests {
+		if diff := strings.Join(DiffLines(test.want, test.got), "|"); diff != test.diff {
+			t.Errorf("DiffLines(%q, %q): got %q, want %q", test.want, test.got, diff, test.diff)
+		}
+	}
+}
+
+func TestExampleExcerpt(t *testing.T) {
+	excerpt := ExtractExcerpt(exampleOutput)
+	if excerpt.Category != CategoryExample || excerpt.Assertion != "example output mismatch" {
+		t.Errorf("unexpected excerpt: %+v", excerpt)
+	}
+}
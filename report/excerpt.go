@@ -0,0 +1,132 @@
+package report
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Failure categories reported in Excerpt.Category
+const (
+	CategoryAssertion = "assertion"
+	CategoryPanic     = "panic"
+	CategoryTimeout   = "timeout"
+	CategoryDataRace  = "data-race"
+	CategoryLeak      = "leak"
+	CategoryExample   = "example-output"
+	CategoryError     = "error"
+	CategoryUnknown   = "unknown"
+)
+
+// Excerpt is a short, normalized summary of a failure meant for bots that
+// route or label failures, so they don't have to parse raw test output.
+type Excerpt struct {
+	Assertion string `json:"assertion"`      // first assertion or error line, normalized
+	File      string `json:"file,omitempty"` // source file the failure points at
+	Line      int    `json:"line,omitempty"`
+	Category  string `json:"category"`
+}
+
+var (
+	// "    users_test.go:42: expected 3, got 4" as printed by t.Error and friends
+	testLogLine = regexp.MustCompile(`^\s*([\w.\-/]+\.go):(\d+): (.*)$`)
+	// "\t/src/app/users_test.go:42 +0x1d" in a panic stack trace
+	stackFrame = regexp.MustCompile(`^\s*(\S+_test\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// ExtractExcerpt summarizes failure output: the first assertion (or the panic
+// message), the file:line it was reported from, and a coarse category.
+func ExtractExcerpt(output []string) Excerpt {
+	excerpt := Excerpt{Category: CategoryUnknown}
+
+	var logLine, panicLine, timeoutLine string
+	var logFile, frameFile string
+	var logNo, frameNo int
+	race := false
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "WARNING: DATA RACE") || strings.Contains(trimmed, "race detected during execution of test"):
+			race = true
+		case strings.HasPrefix(trimmed, "panic: test timed out"):
+			if timeoutLine == "" {
+				timeoutLine = trimmed
+			}
+		case strings.HasPrefix(trimmed, "panic:"):
+			if panicLine == "" {
+				panicLine = trimmed
+			}
+		}
+
+		if logLine == "" {
+			if m := testLogLine.FindStringSubmatch(line); m != nil {
+				logFile, logLine = m[1], strings.TrimSpace(m[3])
+				logNo, _ = strconv.Atoi(m[2])
+			}
+		}
+		if frameFile == "" && panicLine != "" {
+			if m := stackFrame.FindStringSubmatch(line); m != nil {
+				frameFile = m[1]
+				frameNo, _ = strconv.Atoi(m[2])
+			}
+		}
+	}
+
+	switch {
+	case HasLeaks(output):
+		excerpt.Category = CategoryLeak
+		excerpt.Assertion = "found unexpected goroutines"
+		if leaks := ParseLeaks(output); len(leaks) > 0 && leaks[0].CreatedBy != "" {
+			excerpt.Assertion += ", created by " + leaks[0].CreatedBy
+		}
+		excerpt.File, excerpt.Line = logFile, logNo
+	case timeoutLine != "":
+		excerpt.Category = CategoryTimeout
+		excerpt.Assertion = timeoutLine
+	case panicLine != "":
+		excerpt.Category = CategoryPanic
+		excerpt.Assertion = panicLine
+		excerpt.File, excerpt.Line = frameFile, frameNo
+	case race:
+		excerpt.Category = CategoryDataRace
+		excerpt.Assertion = "WARNING: DATA RACE"
+		excerpt.File, excerpt.Line = logFile, logNo
+	case isExampleMismatch(output):
+		excerpt.Category = CategoryExample
+		excerpt.Assertion = "example output mismatch"
+	case logLine != "":
+		excerpt.Category = CategoryError
+		if isAssertion(logLine) {
+			excerpt.Category = CategoryAssertion
+		}
+		excerpt.Assertion = logLine
+		excerpt.File, excerpt.Line = logFile, logNo
+	default:
+		if message := failureMessage(output); message != "(no output)" {
+			excerpt.Category = CategoryError
+			excerpt.Assertion = message
+		}
+	}
+
+	if normalized := NormalizeFailure([]string{excerpt.Assertion}); len(normalized) > 0 {
+		excerpt.Assertion = normalized[0]
+	}
+	return excerpt
+}
+
+// isAssertion reports whether a logged message compares values
+func isAssertion(message string) bool {
+	lower := strings.ToLower(message)
+	for _, marker := range []string{"expected", "got", "want", "not equal", "should", "assert", "mismatch"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExampleMismatch reports whether output is an example's got/want failure
+func isExampleMismatch(output []string) bool {
+	_, _, ok := ExampleMismatch(output)
+	return ok
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExtractExcerpt(t *testing.T) {
+	tests := []struct {
+		name   string
+		output []string
+		want   Excerpt
+	}{
+		{
+			name: "assertion",
+			output: []string{
+				"=== RUN   TestCount",
+				"    users_test.go:42: expected 3 users, got 4",
+				"    users_test.go:43: second failure",
+				"--- FAIL: TestCount (0.01s)",
+			},
+			want: Excerpt{Assertion: "expected 3 users, got 4", File: "users_test.go", Line: 42, Category: CategoryAssertion},
+		},
+		{
+			name: "error with volatile details",
+			output: []string{
+				"    client_test.go:17: dial tcp 127.0.0.1:5432: connect: connection refused",
+			},
+			want: Excerpt{Assertion: "dial tcp <addr>: connect: connection refused", File: "client_test.go", Line: 17, Category: CategoryError},
+		},
+		{
+			name: "panic",
+			output: []string{
+				"--- FAIL: TestNil (0.00s)",
+				"panic: runtime error: invalid memory address or nil pointer dereference [recovered]",
+				"goroutine 7 [running]:",
+				"testing.tRunner.func1.2({0x5f0e40, 0x7a1c10})",
+				"\t/usr/local/go/src/testing/testing.go:1632 +0x230",
+				"example.com/app.TestNil(0xc000103040)",
+				"\t/src/app/nil_test.go:9 +0x1d",
+			},
+			want: Excerpt{Assertion: "panic: runtime error: invalid memory address or nil pointer dereference [recovered]", File: "/src/app/nil_test.go", Line: 9, Category: CategoryPanic},
+		},
+		{
+			name:   "timeout",
+			output: []string{"panic: test timed out after 10m0s", "running tests:", "\tTestSlow (10m0s)"},
+			want:   Excerpt{Assertion: "panic: test timed out after 10m0s", Category: CategoryTimeout},
+		},
+		{
+			name: "data race",
+			output: []string{
+				"==================",
+				"WARNING: DATA RACE",
+				"    testing.go:1465: race detected during execution of test",
+			},
+			want: Excerpt{Assertion: "WARNING: DATA RACE", File: "testing.go", Line: 1465, Category: CategoryDataRace},
+		},
+		{
+			name: "no output",
+			want: Excerpt{Category: CategoryUnknown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractExcerpt(tt.output); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	data := &ReportData{
+		TotalTests:  2,
+		FailedTests: 1,
+		PassedTests: 1,
+		Results: map[string]*TestResult{
+			"TestParent":      {Name: "TestParent", Package: "pkg/a", Status: "FAIL", SubTests: []string{"TestParent/case"}},
+			"TestParent/case": {Name: "TestParent/case", Package: "pkg/a", Status: "FAIL", IsSubTest: true, Output: []string{"    a_test.go:5: want 1, got 2"}},
+			"TestOK":          {Name: "TestOK", Package: "pkg/a", Status: "PASS"},
+		},
+	}
+
+	raw, err := data.JSON()
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	var decoded struct {
+		Failed    int
+		Failures  []Failure
+		Integrity struct{ Complete bool }
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("decoding JSON: %v\n%s", err, raw)
+	}
+
+	if decoded.Failed != 1 || !decoded.Integrity.Complete {
+		t.Errorf("unexpected summary: %+v", decoded)
+	}
+	if len(decoded.Failures) != 1 {
+		t.Fatalf("expected only the failing leaf test, got %+v", decoded.Failures)
+	}
+	failure := decoded.Failures[0]
+	if failure.Name != "TestParent/case" || failure.Excerpt.Line != 5 || failure.Excerpt.Category != CategoryAssertion {
+		t.Errorf("unexpected failure: %+v", failure)
+	}
+	if failure.Fingerprint != Fingerprint(data.Results["TestParent/case"].Output) {
+		t.Errorf("fingerprint mismatch: %s", failure.Fingerprint)
+	}
+}
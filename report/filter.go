@@ -0,0 +1,122 @@
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// logLevels orders the levels of structured log lines from least to most
+// severe. Aliases share a rank.
+var logLevels = map[string]int{
+	"trace":   0,
+	"debug":   1,
+	"info":    2,
+	"notice":  2,
+	"warn":    3,
+	"warning": 3,
+	"error":   4,
+	"err":     4,
+	"fatal":   5,
+	"panic":   5,
+}
+
+// logLevel finds the level of a structured log line, as written by slog,
+// logrus, zap and zerolog: level=info, "level":"info", [INFO] or a
+// tab-separated INFO. The last two are upper case only, so testify's tab
+// indented "Error:" lines aren't mistaken for log lines.
+var logLevel = regexp.MustCompile(`(?i:\blevel=|"level"\s*:\s*")(\w+)|\[(TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERROR|FATAL|PANIC)\]|\t(TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERROR|FATAL|PANIC)\t`)
+
+// lineLevel returns the rank in logLevels of a log line's level
+func lineLevel(line string) (int, bool) {
+	m := logLevel.FindStringSubmatch(line)
+	if m == nil {
+		return 0, false
+	}
+	rank, ok := logLevels[strings.ToLower(m[1]+m[2]+m[3])]
+	return rank, ok
+}
+
+// OutputFilter drops log noise from captured output, so framework chatter
+// doesn't bury the assertion that failed. Lines without a recognizable log
+// level, such as t.Error messages, are only dropped by Drop patterns.
+type OutputFilter struct {
+	Drop     []*regexp.Regexp // lines matching any of these are dropped
+	MinLevel string           // log lines below this level are dropped; "" keeps all
+}
+
+// ParseOutputFilter builds a filter from rules of the form drop=REGEX or
+// level=LEVEL, e.g. drop=^time= or level=warn. It returns nil without rules.
+func ParseOutputFilter(rules []string) (*OutputFilter, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	f := &OutputFilter{}
+	for _, rule := range rules {
+		kind, value, _ := strings.Cut(rule, "=")
+		switch kind {
+		case "drop":
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid output filter %q: %v", rule, err)
+			}
+			f.Drop = append(f.Drop, re)
+		case "level":
+			if _, ok := logLevels[strings.ToLower(value)]; !ok {
+				return nil, fmt.Errorf("invalid output filter %q (want a level such as debug, info, warn or error)", rule)
+			}
+			f.MinLevel = strings.ToLower(value)
+		default:
+			return nil, fmt.Errorf("invalid output filter %q (want drop=REGEX or level=LEVEL)", rule)
+		}
+	}
+	return f, nil
+}
+
+// Keep reports whether a line of output passes the filter
+func (f *OutputFilter) Keep(line string) bool {
+	if f == nil {
+		return true
+	}
+	for _, re := range f.Drop {
+		if re.MatchString(line) {
+			return false
+		}
+	}
+	if f.MinLevel != "" {
+		if rank, ok := lineLevel(line); ok && rank < logLevels[f.MinLevel] {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply drops the filtered lines from the output and earlier attempts of
+// every test in data, including the invocations and suites it combines.
+func (f *OutputFilter) Apply(data *ReportData) {
+	if f == nil || data == nil {
+		return
+	}
+	for _, result := range data.Results {
+		result.Output = f.filterLines(result.Output)
+		for i := range result.Attempts {
+			result.Attempts[i].Output = f.filterLines(result.Attempts[i].Output)
+		}
+	}
+	for _, run := range data.Invocations {
+		f.Apply(run)
+	}
+	for _, suite := range data.Suites {
+		f.Apply(suite)
+	}
+}
+
+func (f *OutputFilter) filterLines(lines []string) []string {
+	kept := lines[:0:0]
+	for _, line := range lines {
+		if f.Keep(line) {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOutputFilter(t *testing.T) {
+	f, err := ParseOutputFilter([]string{`drop=^\s*--- healthcheck`, "level=warn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		line string
+		keep bool
+	}{
+		{`time=2024-05-01T10:00:00Z level=INFO msg="connected"`, false},
+		{`time=2024-05-01T10:00:00Z level=WARN msg="retrying"`, true},
+		{`{"level":"debug","msg":"query"}`, false},
+		{`{"level":"error","msg":"query failed"}`, true},
+		{"[INFO] starting server", false},
+		{"2024-05-01T10:00:00Z\tDEBUG\tpool/pool.go:12\tacquired", false},
+		{"    --- healthcheck ok", false},
+		{"    db_test.go:42: expected 4, got 3", true},
+		{"        \tError:      \tNot equal:", true},
+	}
+	for _, tt := range tests {
+		if got := f.Keep(tt.line); got != tt.keep {
+			t.Errorf("Keep(%q): got %v, want %v", tt.line, got, tt.keep)
+		}
+	}
+
+	if f, _ := ParseOutputFilter(nil); f != nil {
+		t.Error("expected no filter without rules")
+	}
+	for _, rule := range []string{"drop=(", "level=loud", "keep=x"} {
+		if _, err := ParseOutputFilter([]string{rule}); err == nil {
+			t.Errorf("expected an error for %q", rule)
+		}
+	}
+}
+
+func TestOutputFilterApply(t *testing.T) {
+	f, _ := ParseOutputFilter([]string{"level=warn"})
+	result := &TestResult{
+		Name:     "TestAPI",
+		Status:   "FAIL",
+		Output:   []string{"level=info msg=start", "    api_test.go:9: got 500"},
+		Attempts: []Attempt{{Status: "FAIL", Output: []string{"level=debug msg=retry", "    api_test.go:9: got 502"}}},
+	}
+	run := &ReportData{Results: map[string]*TestResult{"TestAPI": {Output: []string{"level=info msg=start"}}}}
+	data := &ReportData{Results: map[string]*TestResult{"TestAPI": result}, Invocations: []*ReportData{run}}
+	f.Apply(data)
+
+	if want := []string{"    api_test.go:9: got 500"}; !reflect.DeepEqual(result.Output, want) {
+		t.Errorf("output: got %q, want %q", result.Output, want)
+	}
+	if want := []string{"    api_test.go:9: got 502"}; !reflect.DeepEqual(result.Attempts[0].Output, want) {
+		t.Errorf("attempt output: got %q, want %q", result.Attempts[0].Output, want)
+	}
+	if got := run.Results["TestAPI"].Output; len(got) != 0 {
+		t.Errorf("invocation output: got %q, want none", got)
+	}
+}
@@ -0,0 +1,154 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Volatile fragments that differ between otherwise identical failures
+var fingerprintReplacements = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`), "<time>"},
+	{regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`), "<time>"},
+	{regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\b(\d{1,3}\.){3}\d{1,3}(:\d+)?\b`), "<addr>"},
+	{regexp.MustCompile(`\[::1?\]:\d+`), "<addr>"},
+	{regexp.MustCompile(`0x[0-9a-fA-F]+`), "0x?"},
+	{regexp.MustCompile(`goroutine \d+`), "goroutine N"},
+	{regexp.MustCompile(`\b\d+(\.\d+)?(ns|µs|us|ms|s|m|h)\b`), "<dur>"},
+}
+
+// NormalizeFailure strips the volatile parts (timestamps, addresses,
+// goroutine IDs, durations) from failure output and drops the test framework's
+// own RUN/FAIL lines, which embed test names, so identical failures compare
+// equal across tests.
+func NormalizeFailure(output []string) []string {
+	var normalized []string
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") {
+			continue
+		}
+		for _, r := range fingerprintReplacements {
+			trimmed = r.pattern.ReplaceAllString(trimmed, r.replacement)
+		}
+		normalized = append(normalized, trimmed)
+	}
+	return normalized
+}
+
+// Fingerprint returns a short stable hash of the normalized failure output.
+func Fingerprint(output []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(NormalizeFailure(output), "\n")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// FailureGroup is a set of tests that failed with the same normalized output
+type FailureGroup struct {
+	Fingerprint string   `json:"fingerprint"`
+	Message     string   `json:"message"` // first significant line of the failure
+	Tests       []string `json:"tests"`
+}
+
+// GroupFailures groups failing leaf tests (tests whose failure isn't just a
+// failing subtest) by fingerprint, largest groups first.
+func GroupFailures(data *ReportData) []FailureGroup {
+	groups := make(map[string]*FailureGroup)
+	for _, name := range sortedResultNames(data) {
+		result := data.Results[name]
+		if result.Status != "FAIL" || hasFailedSubTest(data, result) {
+			continue
+		}
+
+		fingerprint := Fingerprint(result.Output)
+		group, exists := groups[fingerprint]
+		if !exists {
+			group = &FailureGroup{Fingerprint: fingerprint, Message: failureMessage(result.Output)}
+			groups[fingerprint] = group
+		}
+		group.Tests = append(group.Tests, name)
+	}
+
+	sorted := make([]FailureGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, *group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i].Tests) != len(sorted[j].Tests) {
+			return len(sorted[i].Tests) > len(sorted[j].Tests)
+		}
+		return sorted[i].Tests[0] < sorted[j].Tests[0]
+	})
+	return sorted
+}
+
+// SubTestFailures buckets the failed subtests of a test by their normalized
+// output, in the order of its SubTests, so dozens of subtests failing the
+// same way can be shown once. Subtests with failed subtests of their own,
+// whose output is mostly their children's, get a bucket of their own.
+func SubTestFailures(data *ReportData, name string) [][]string {
+	result, exists := data.Results[name]
+	if !exists {
+		return nil
+	}
+	var buckets [][]string
+	byFingerprint := make(map[string]int)
+	for _, subTest := range result.SubTests {
+		sub, exists := data.Results[subTest]
+		if !exists || sub.Status != "FAIL" {
+			continue
+		}
+		if hasFailedSubTest(data, sub) {
+			buckets = append(buckets, []string{subTest})
+			continue
+		}
+		fingerprint := Fingerprint(sub.Output)
+		if i, seen := byFingerprint[fingerprint]; seen {
+			buckets[i] = append(buckets[i], subTest)
+			continue
+		}
+		byFingerprint[fingerprint] = len(buckets)
+		buckets = append(buckets, []string{subTest})
+	}
+	return buckets
+}
+
+// failureMessage picks the line that best summarizes a failure
+func failureMessage(output []string) string {
+	normalized := NormalizeFailure(output)
+	for _, line := range normalized {
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "error") || strings.Contains(lower, "panic") ||
+			strings.Contains(lower, "expected") || strings.Contains(lower, "got") ||
+			strings.Contains(lower, "want") || strings.Contains(lower, "fail") {
+			return line
+		}
+	}
+	if len(normalized) > 0 {
+		return normalized[0]
+	}
+	return "(no output)"
+}
+
+func hasFailedSubTest(data *ReportData, result *TestResult) bool {
+	for _, subTest := range result.SubTests {
+		if sub, exists := data.Results[subTest]; exists && sub.Status == "FAIL" {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedResultNames(data *ReportData) []string {
+	names := make([]string, 0, len(data.Results))
+	for name := range data.Results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,85 @@
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFingerprintIgnoresVolatileDetails(t *testing.T) {
+	a := []string{
+		"=== RUN   TestUsers",
+		"    client_test.go:42: 2024-05-02T10:11:12.345Z dial tcp 127.0.0.1:54321: connect: connection refused",
+		"goroutine 17 [running]: main.handler(0xc000123450) after 1.52s",
+		"--- FAIL: TestUsers (1.52s)",
+	}
+	b := []string{
+		"=== RUN   TestOrders/list",
+		"    client_test.go:42: 2024-05-03T08:00:00Z dial tcp 127.0.0.1:40001: connect: connection refused",
+		"goroutine 92 [running]: main.handler(0xc0000abcd0) after 300ms",
+		"--- FAIL: TestOrders/list (0.30s)",
+	}
+	c := []string{
+		"    client_test.go:42: expected 3, got 4",
+	}
+
+	if Fingerprint(a) != Fingerprint(b) {
+		t.Errorf("expected equal fingerprints, normalized:\n%v\n%v", NormalizeFailure(a), NormalizeFailure(b))
+	}
+	if Fingerprint(a) == Fingerprint(c) {
+		t.Error("different failures should not share a fingerprint")
+	}
+	if len(Fingerprint(a)) != 12 {
+		t.Errorf("unexpected fingerprint length: %q", Fingerprint(a))
+	}
+}
+
+func TestGroupFailures(t *testing.T) {
+	refused := []string{"    db_test.go:10: dial tcp 10.0.0.1:5432: connect: connection refused"}
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestA":        {Name: "TestA", Status: "FAIL", Output: refused},
+		"TestB":        {Name: "TestB", Status: "FAIL", Output: refused},
+		"TestParent":   {Name: "TestParent", Status: "FAIL", SubTests: []string{"TestParent/c"}, Output: []string{"--- FAIL: TestParent"}},
+		"TestParent/c": {Name: "TestParent/c", Status: "FAIL", IsSubTest: true, Output: refused},
+		"TestOther":    {Name: "TestOther", Status: "FAIL", Output: []string{"    x_test.go:3: expected 1, got 2"}},
+		"TestPassing":  {Name: "TestPassing", Status: "PASS"},
+	}}
+
+	groups := GroupFailures(data)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d: %+v", len(groups), groups)
+	}
+	if got := strings.Join(groups[0].Tests, ","); got != "TestA,TestB,TestParent/c" {
+		t.Errorf("largest group: got %s", got)
+	}
+	if !strings.Contains(groups[0].Message, "connection refused") {
+		t.Errorf("unexpected group message %q", groups[0].Message)
+	}
+	if got := strings.Join(groups[1].Tests, ","); got != "TestOther" {
+		t.Errorf("second group: got %s", got)
+	}
+}
+
+func TestSubTestFailures(t *testing.T) {
+	timeout := func(port string) []string {
+		return []string{"    api_test.go:20: dial tcp 127.0.0.1:" + port + ": i/o timeout"}
+	}
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestAPI":          {Name: "TestAPI", Status: "FAIL", SubTests: []string{"TestAPI/get", "TestAPI/put", "TestAPI/list", "TestAPI/ok", "TestAPI/nested"}},
+		"TestAPI/get":      {Name: "TestAPI/get", Status: "FAIL", IsSubTest: true, Output: timeout("8080")},
+		"TestAPI/put":      {Name: "TestAPI/put", Status: "FAIL", IsSubTest: true, Output: []string{"    api_test.go:31: expected 201, got 500"}},
+		"TestAPI/list":     {Name: "TestAPI/list", Status: "FAIL", IsSubTest: true, Output: timeout("9090")},
+		"TestAPI/ok":       {Name: "TestAPI/ok", Status: "PASS", IsSubTest: true},
+		"TestAPI/nested":   {Name: "TestAPI/nested", Status: "FAIL", IsSubTest: true, SubTests: []string{"TestAPI/nested/a"}},
+		"TestAPI/nested/a": {Name: "TestAPI/nested/a", Status: "FAIL", IsSubTest: true, Output: timeout("7070")},
+	}}
+
+	got := SubTestFailures(data, "TestAPI")
+	want := [][]string{{"TestAPI/get", "TestAPI/list"}, {"TestAPI/put"}, {"TestAPI/nested"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := SubTestFailures(data, "TestMissing"); got != nil {
+		t.Errorf("missing test: got %v", got)
+	}
+}
@@ -0,0 +1,69 @@
+package report
+
+import "fmt"
+
+// Gate holds quality thresholds a run must meet. Negative values disable a
+// threshold.
+type Gate struct {
+	MinPassRate float64 // percent of tests that passed
+	MaxFailures int
+	MaxSkipped  int
+}
+
+// GateResult is the outcome of checking a run against a Gate
+type GateResult struct {
+	Passed     bool     `json:"passed"`
+	Violations []string `json:"violations"`
+}
+
+// Enabled reports whether any threshold is set
+func (g Gate) Enabled() bool {
+	return g.MinPassRate >= 0 || g.MaxFailures >= 0 || g.MaxSkipped >= 0
+}
+
+// Check compares data against the thresholds and describes each one breached.
+// Quarantined tests don't count against MaxFailures.
+func (g Gate) Check(data *ReportData) *GateResult {
+	result := &GateResult{Violations: []string{}}
+	if g.MinPassRate >= 0 {
+		passRate := 0.0
+		if data.TotalTests > 0 {
+			passRate = float64(data.PassedTests) / float64(data.TotalTests) * 100
+		}
+		if passRate < g.MinPassRate {
+			result.Violations = append(result.Violations,
+				fmt.Sprintf("pass rate %.1f%% is below the minimum of %g%%", passRate, g.MinPassRate))
+		}
+	}
+	if failed := unquarantinedFailures(data); g.MaxFailures >= 0 && failed > g.MaxFailures {
+		result.Violations = append(result.Violations,
+			fmt.Sprintf("%d failed tests exceed the maximum of %d", failed, g.MaxFailures))
+	}
+	if g.MaxSkipped >= 0 && data.SkippedTests > g.MaxSkipped {
+		result.Violations = append(result.Violations,
+			fmt.Sprintf("%d skipped tests exceed the maximum of %d", data.SkippedTests, g.MaxSkipped))
+	}
+	result.Passed = len(result.Violations) == 0
+	return result
+}
+
+// unquarantinedFailures counts failed top-level tests that aren't quarantined
+func unquarantinedFailures(data *ReportData) int {
+	failed := 0
+	for _, result := range data.Results {
+		if !result.IsSubTest && result.Status == "FAIL" && !result.Quarantined {
+			failed++
+		}
+	}
+	return failed
+}
+
+// AddViolation records a threshold checked outside Gate as breached, creating
+// the gate result when no Gate thresholds were set
+func AddViolation(data *ReportData, violation string) {
+	if data.QualityGate == nil {
+		data.QualityGate = &GateResult{Violations: []string{}}
+	}
+	data.QualityGate.Violations = append(data.QualityGate.Violations, violation)
+	data.QualityGate.Passed = false
+}
@@ -0,0 +1,52 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGateCheck(t *testing.T) {
+	data := &ReportData{
+		Results: map[string]*TestResult{
+			"TestA":       {Name: "TestA", Status: "PASS"},
+			"TestB":       {Name: "TestB", Status: "PASS"},
+			"TestC":       {Name: "TestC", Status: "FAIL"},
+			"TestC/sub":   {Name: "TestC/sub", Status: "FAIL", IsSubTest: true},
+			"TestFlaky":   {Name: "TestFlaky", Status: "FAIL", Quarantined: true},
+			"TestSkipped": {Name: "TestSkipped", Status: "SKIP"},
+		},
+	}
+	summarize(data)
+
+	tests := []struct {
+		name string
+		gate Gate
+		want []string
+	}{
+		{"disabled", Gate{-1, -1, -1}, []string{}},
+		{"all met", Gate{40, 1, 1}, []string{}},
+		{"pass rate", Gate{50, -1, -1}, []string{"pass rate 40.0% is below the minimum of 50%"}},
+		{"quarantined failures don't count", Gate{-1, 0, -1}, []string{"1 failed tests exceed the maximum of 0"}},
+		{"every threshold", Gate{99.5, 0, 0}, []string{
+			"pass rate 40.0% is below the minimum of 99.5%",
+			"1 failed tests exceed the maximum of 0",
+			"1 skipped tests exceed the maximum of 0",
+		}},
+	}
+	for _, test := range tests {
+		result := test.gate.Check(data)
+		if !reflect.DeepEqual(result.Violations, test.want) {
+			t.Errorf("%s: got violations %q, want %q", test.name, result.Violations, test.want)
+		}
+		if result.Passed != (len(test.want) == 0) {
+			t.Errorf("%s: got passed %v", test.name, result.Passed)
+		}
+	}
+
+	if (Gate{-1, -1, -1}).Enabled() || !(Gate{-1, 0, -1}).Enabled() {
+		t.Error("Enabled should report whether any threshold is set")
+	}
+	if result := (Gate{100, -1, -1}).Check(&ReportData{}); result.Passed {
+		t.Error("a run without tests should fail a minimum pass rate")
+	}
+}
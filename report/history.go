@@ -0,0 +1,131 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// History is a rolling record of previous runs, persisted as a JSON file so
+// trends can be computed without an external database.
+type History struct {
+	Runs []HistoryRun `json:"runs"` // oldest first
+}
+
+// HistoryRun is the summary of one recorded run
+type HistoryRun struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Commit    string            `json:"commit,omitempty"`
+	Sanitizer string            `json:"sanitizer,omitempty"`
+	Total     int               `json:"total"`
+	Passed    int               `json:"passed"`
+	Failed    int               `json:"failed"`
+	Skipped   int               `json:"skipped"`
+	Flaky     int               `json:"flaky,omitempty"`
+	Duration  float64           `json:"duration"`
+	Tests     map[string]string `json:"tests"` // test name -> status
+}
+
+// LoadHistory reads a history file. A missing file yields an empty history so
+// the first run can create it.
+func LoadHistory(path string) (*History, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &History{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading history: %v", err)
+	}
+
+	var history History
+	if err := json.Unmarshal(content, &history); err != nil {
+		return nil, fmt.Errorf("error parsing history %s: %v", path, err)
+	}
+	return &history, nil
+}
+
+// Save writes the history to path.
+func (h *History) Save(path string) error {
+	content, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// Record appends data as the newest run, keeping at most maxRuns runs when
+// maxRuns is positive.
+func (h *History) Record(data *ReportData, timestamp time.Time, commit string, maxRuns int) {
+	run := HistoryRun{
+		Timestamp: timestamp,
+		Commit:    commit,
+		Sanitizer: data.Sanitizer,
+		Total:     data.TotalTests,
+		Passed:    data.PassedTests,
+		Failed:    data.FailedTests,
+		Skipped:   data.SkippedTests,
+		Flaky:     data.FlakyTests,
+		Duration:  data.TotalDuration,
+		Tests:     make(map[string]string, len(data.Results)),
+	}
+	for name, result := range data.Results {
+		run.Tests[name] = result.Status
+	}
+
+	h.Runs = append(h.Runs, run)
+	if maxRuns > 0 && len(h.Runs) > maxRuns {
+		h.Runs = h.Runs[len(h.Runs)-maxRuns:]
+	}
+}
+
+// ForSanitizer returns a view of the history containing only runs recorded
+// under the given sanitizer ("" selects runs without one), since failures
+// often only reproduce under a specific sanitizer.
+func (h *History) ForSanitizer(sanitizer string) *History {
+	filtered := &History{}
+	for _, run := range h.Runs {
+		if run.Sanitizer == sanitizer {
+			filtered.Runs = append(filtered.Runs, run)
+		}
+	}
+	return filtered
+}
+
+// Statuses returns the recorded status of a test in each of the last n runs,
+// oldest first. Runs in which the test did not exist are reported as "".
+func (h *History) Statuses(test string, n int) []string {
+	runs := h.Runs
+	if n > 0 && len(runs) > n {
+		runs = runs[len(runs)-n:]
+	}
+	statuses := make([]string, len(runs))
+	for i, run := range runs {
+		statuses[i] = run.Tests[test]
+	}
+	return statuses
+}
+
+// FailingStreak returns how many of the latest runs in a row a test failed in
+// and when the first of them was recorded; runs is 0 when the test didn't fail
+// in the newest run.
+func (h *History) FailingStreak(test string) (runs int, since time.Time) {
+	for i := len(h.Runs) - 1; i >= 0 && h.Runs[i].Tests[test] == "FAIL"; i-- {
+		runs, since = runs+1, h.Runs[i].Timestamp
+	}
+	return runs, since
+}
+
+// FirstFailure returns the newest run a test passed in and the run after it,
+// which started the test's current failing streak, so the commits between
+// them can be bisected. ok is false when the test didn't fail in the newest
+// run or no recorded run shows it passing before the streak.
+func (h *History) FirstFailure(test string) (lastPass, firstFail HistoryRun, ok bool) {
+	runs, _ := h.FailingStreak(test)
+	start := len(h.Runs) - runs
+	if runs == 0 || start == 0 || h.Runs[start-1].Tests[test] != "PASS" {
+		return HistoryRun{}, HistoryRun{}, false
+	}
+	return h.Runs[start-1], h.Runs[start], true
+}
@@ -0,0 +1,139 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runWith(statuses map[string]string) *ReportData {
+	data := &ReportData{Results: map[string]*TestResult{}}
+	for name, status := range statuses {
+		data.Results[name] = &TestResult{Name: name, Status: status}
+	}
+	summarize(data)
+	return data
+}
+
+func TestHistoryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	history, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("missing history should load empty: %v", err)
+	}
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		status := "PASS"
+		if i == 1 {
+			status = "FAIL"
+		}
+		history.Record(runWith(map[string]string{"TestA": status}), start.AddDate(0, 0, i), "sha", 3)
+	}
+	if err := history.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Runs) != 3 {
+		t.Fatalf("expected history trimmed to 3 runs, got %d", len(loaded.Runs))
+	}
+	if got := strings.Join(loaded.Statuses("TestA", 5), ","); got != "FAIL,PASS,PASS" {
+		t.Errorf("Statuses: got %s", got)
+	}
+	if got := strings.Join(loaded.Statuses("TestMissing", 2), ","); got != "," {
+		t.Errorf("Statuses for unknown test: got %q", got)
+	}
+	if !loaded.Runs[0].Timestamp.Equal(start.AddDate(0, 0, 1)) {
+		t.Errorf("unexpected oldest run: %v", loaded.Runs[0].Timestamp)
+	}
+}
+
+func TestApplyQuarantine(t *testing.T) {
+	history := &History{}
+	for i := 0; i < 3; i++ {
+		history.Record(runWith(map[string]string{"TestRecovered": "PASS", "TestStillFlaky": "PASS"}), time.Now(), "", 0)
+	}
+	history.Record(runWith(map[string]string{"TestRecovered": "PASS", "TestStillFlaky": "FAIL"}), time.Now(), "", 0)
+
+	data := runWith(map[string]string{"TestRecovered": "PASS", "TestStillFlaky": "FAIL", "TestNormal": "PASS"})
+	quarantined := map[string]bool{"TestRecovered": true, "TestStillFlaky": true}
+
+	ApplyQuarantine(data, quarantined, history, 3)
+
+	if !data.Results["TestRecovered"].Quarantined || data.Results["TestNormal"].Quarantined {
+		t.Error("quarantine flags not applied correctly")
+	}
+	if got := strings.Join(data.UnquarantineCandidates, ","); got != "TestRecovered" {
+		t.Errorf("candidates: got %q, want TestRecovered", got)
+	}
+
+	// Not enough history yet to judge
+	ApplyQuarantine(data, quarantined, history, 10)
+	if len(data.UnquarantineCandidates) != 0 {
+		t.Errorf("expected no candidates with short history, got %v", data.UnquarantineCandidates)
+	}
+}
+
+func TestHistoryForSanitizer(t *testing.T) {
+	history := &History{}
+	plain := runWith(map[string]string{"TestA": "PASS"})
+	race := runWith(map[string]string{"TestA": "FAIL"})
+	race.Sanitizer = "race"
+
+	history.Record(plain, time.Now(), "", 0)
+	history.Record(race, time.Now(), "", 0)
+	history.Record(plain, time.Now(), "", 0)
+
+	if got := strings.Join(history.ForSanitizer("race").Statuses("TestA", 0), ","); got != "FAIL" {
+		t.Errorf("race history: got %q, want FAIL", got)
+	}
+	if got := strings.Join(history.ForSanitizer("").Statuses("TestA", 0), ","); got != "PASS,PASS" {
+		t.Errorf("plain history: got %q, want PASS,PASS", got)
+	}
+}
+
+func TestFailingStreak(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	history := &History{}
+	for i, status := range []string{"FAIL", "PASS", "FAIL", "FAIL", "FAIL"} {
+		history.Record(runWith(map[string]string{"TestRot": status, "TestFixed": "FAIL", "TestNew": "PASS"}), start.AddDate(0, 0, i), "", 0)
+	}
+	history.Runs[4].Tests["TestFixed"] = "PASS"
+	history.Runs[4].Tests["TestNew"] = "FAIL"
+
+	if runs, since := history.FailingStreak("TestRot"); runs != 3 || !since.Equal(start.AddDate(0, 0, 2)) {
+		t.Errorf("TestRot: got %d runs since %v", runs, since)
+	}
+	if runs, _ := history.FailingStreak("TestNew"); runs != 1 {
+		t.Errorf("TestNew: got %d runs", runs)
+	}
+	if runs, _ := history.FailingStreak("TestFixed"); runs != 0 {
+		t.Errorf("TestFixed: got %d runs", runs)
+	}
+}
+
+func TestFirstFailure(t *testing.T) {
+	history := &History{}
+	for i, status := range []string{"PASS", "PASS", "FAIL", "FAIL"} {
+		history.Record(runWith(map[string]string{"TestBroken": status, "TestAlwaysFailed": "FAIL"}), time.Now(), fmt.Sprint("sha", i), 0)
+	}
+
+	lastPass, firstFail, ok := history.FirstFailure("TestBroken")
+	if !ok || lastPass.Commit != "sha1" || firstFail.Commit != "sha2" {
+		t.Errorf("TestBroken: got %q..%q, %v", lastPass.Commit, firstFail.Commit, ok)
+	}
+	if _, _, ok := history.FirstFailure("TestAlwaysFailed"); ok {
+		t.Error("a test failing in every recorded run has no last passing run")
+	}
+	history.Runs[3].Tests["TestBroken"] = "PASS"
+	if _, _, ok := history.FirstFailure("TestBroken"); ok {
+		t.Error("a passing test has no first failure")
+	}
+}
@@ -0,0 +1,151 @@
+package report
+
+import (
+	"encoding/json"
+	"runtime"
+	"time"
+)
+
+// Failure describes one failing test in the JSON report. Excerpt is the
+// machine-consumable summary; Output keeps the raw lines for humans.
+type Failure struct {
+	Name        string   `json:"name"`
+	Package     string   `json:"package"`
+	Duration    float64  `json:"duration"`
+	Quarantined bool     `json:"quarantined,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	Fingerprint string   `json:"fingerprint"`
+	Excerpt     Excerpt  `json:"excerpt"`
+	Output      []string `json:"output"`
+}
+
+// jsonReport is the document written by ReportData.JSON
+type jsonReport struct {
+	Total     int     `json:"total"`
+	Passed    int     `json:"passed"`
+	Failed    int     `json:"failed"`
+	Skipped   int     `json:"skipped"`
+	Flaky     int     `json:"flaky"`
+	Duration  float64 `json:"duration"`
+	WallClock float64 `json:"wallClock,omitempty"`
+	// Test time per second of wall clock
+	Parallelism float64        `json:"parallelism,omitempty"`
+	Sanitizer   string         `json:"sanitizer,omitempty"`
+	Suites      []jsonSuite    `json:"suites,omitempty"`
+	Labels      []LabelSummary `json:"labels,omitempty"`
+	Failures    []Failure      `json:"failures"`
+
+	ShuffleSeeds map[string]string `json:"shuffleSeeds,omitempty"`
+	Environment  *Environment      `json:"environment,omitempty"`
+	Benchmarks   []BenchmarkResult `json:"benchmarks,omitempty"`
+	Repeated     []RepeatedTest    `json:"repeated,omitempty"`
+	QualityGate  *GateResult       `json:"qualityGate,omitempty"`
+
+	DurationRegressions []DurationRegression `json:"durationRegressions,omitempty"`
+	BudgetOverruns      []BudgetOverrun      `json:"budgetOverruns,omitempty"`
+	Categories          []CategorySummary    `json:"categories,omitempty"`
+	Modules             []ModuleSummary      `json:"modules,omitempty"`
+	Comparison          *DiffData            `json:"comparison,omitempty"`
+	ReportURL           string               `json:"reportUrl,omitempty"`
+	Integrity           IntegritySummary     `json:"integrity"`
+	Metrics             *Metrics             `json:"metrics,omitempty"`
+}
+
+// jsonSuite summarizes one suite of a report combining several
+type jsonSuite struct {
+	Name     string  `json:"name"`
+	Total    int     `json:"total"`
+	Passed   int     `json:"passed"`
+	Failed   int     `json:"failed"`
+	Skipped  int     `json:"skipped"`
+	Flaky    int     `json:"flaky"`
+	Duration float64 `json:"duration"`
+}
+
+// Failures returns the failing leaf tests (tests whose failure isn't just a
+// failing subtest) sorted by name, each with its excerpt and fingerprint.
+func Failures(data *ReportData) []Failure {
+	failures := []Failure{}
+	for _, name := range sortedResultNames(data) {
+		result := data.Results[name]
+		if result.Status != "FAIL" || hasFailedSubTest(data, result) {
+			continue
+		}
+
+		output := result.Output
+		if output == nil {
+			output = []string{}
+		}
+		failures = append(failures, Failure{
+			Name:        name,
+			Package:     result.Package,
+			Duration:    result.Duration,
+			Quarantined: result.Quarantined,
+			Labels:      result.Labels,
+			Fingerprint: Fingerprint(result.Output),
+			Excerpt:     ExtractExcerpt(result.Output),
+			Output:      output,
+		})
+	}
+	return failures
+}
+
+// JSON renders the run summary and its failures as indented JSON. When the
+// report carries Metrics, they are completed with the time spent building
+// the document and the peak memory.
+func (d *ReportData) JSON() ([]byte, error) {
+	start := time.Now()
+	doc := jsonReport{
+		Total:     d.TotalTests,
+		Passed:    d.PassedTests,
+		Failed:    d.FailedTests,
+		Skipped:   d.SkippedTests,
+		Flaky:     d.FlakyTests,
+		Duration:  d.TotalDuration,
+		WallClock: d.WallClock(),
+		Sanitizer: d.Sanitizer,
+
+		Parallelism: d.Parallelism(),
+		Labels:      LabelSummaries(d),
+		Failures:    Failures(d),
+
+		ShuffleSeeds: d.ShuffleSeeds,
+		Environment:  d.Environment,
+		Benchmarks:   d.Benchmarks,
+		Repeated:     RepeatedTests(d),
+		QualityGate:  d.QualityGate,
+
+		DurationRegressions: d.DurationRegressions,
+		BudgetOverruns:      d.BudgetOverruns,
+		Categories:          d.Categories,
+		Modules:             d.Modules,
+		Comparison:          d.Comparison,
+		ReportURL:           d.ReportURL,
+	}
+	for _, suite := range d.Suites {
+		doc.Suites = append(doc.Suites, jsonSuite{
+			Name:     suite.Suite,
+			Total:    suite.TotalTests,
+			Passed:   suite.PassedTests,
+			Failed:   suite.FailedTests,
+			Skipped:  suite.SkippedTests,
+			Flaky:    suite.FlakyTests,
+			Duration: suite.TotalDuration,
+		})
+	}
+	doc.Integrity = d.Integrity.Summary()
+	if d.Metrics != nil {
+		d.Metrics.RenderSeconds = time.Since(start).Seconds()
+		d.Metrics.PeakMemoryBytes = PeakMemory()
+		doc.Metrics = d.Metrics
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// PeakMemory returns the memory the Go runtime has obtained from the OS so
+// far, which never shrinks and so bounds the peak heap, stacks and metadata.
+func PeakMemory() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Sys
+}
@@ -0,0 +1,164 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JUnit XML as read: a testsuites root or a single testsuite, whose suites
+// may nest
+type junitSuites struct {
+	Suites []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Error     *junitMessage `xml:"error"`
+	Skipped   *junitMessage `xml:"skipped"`
+	SystemOut string        `xml:"system-out"`
+	SystemErr string        `xml:"system-err"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// isJUnit reports whether the start of an input is JUnit XML: an XML
+// document with a testsuites or testsuite element
+func isJUnit(head []byte) bool {
+	head = bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\ufeff")), " \t\r\n")
+	return bytes.HasPrefix(head, []byte("<")) && bytes.Contains(head, []byte("<testsuite"))
+}
+
+// readJUnit turns JUnit XML into the events go test -json would have
+// produced for its test cases. A case's package is its classname, or the
+// name of its suite without one; names with a slash are subtests as in go
+// test output. Errors count as failures, except the "incomplete" ones
+// renderJUnit writes for tests that never reported a result.
+func (a *aggregator) readJUnit(reader io.Reader) error {
+	dec := xml.NewDecoder(reader)
+	var suites []junitSuite
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return fmt.Errorf("error reading JUnit XML: no testsuites or testsuite element")
+		}
+		if err != nil {
+			return fmt.Errorf("error reading JUnit XML: %v", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "testsuites":
+			var root junitSuites
+			if err := dec.DecodeElement(&root, &start); err != nil {
+				return fmt.Errorf("error reading JUnit XML: %v", err)
+			}
+			suites = root.Suites
+		case "testsuite":
+			var suite junitSuite
+			if err := dec.DecodeElement(&suite, &start); err != nil {
+				return fmt.Errorf("error reading JUnit XML: %v", err)
+			}
+			suites = []junitSuite{suite}
+		default:
+			return fmt.Errorf("error reading JUnit XML: unexpected root element <%s>", start.Name.Local)
+		}
+		break
+	}
+
+	for _, suite := range suites {
+		if err := a.addJUnitSuite(suite); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addJUnitSuite applies the events of a suite's cases and nested suites
+func (a *aggregator) addJUnitSuite(suite junitSuite) error {
+	for _, testCase := range suite.Cases {
+		pkg := testCase.Classname
+		if pkg == "" {
+			pkg = suite.Name
+		}
+		for _, event := range junitEvents(testCase, pkg) {
+			a.event = event
+			if err := a.addEvent(); err != nil {
+				return err
+			}
+		}
+	}
+	for _, nested := range suite.Suites {
+		if err := a.addJUnitSuite(nested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// junitEvents returns the events of one test case: its start, its output
+// (the failure, error or skip message and body, then system-out and
+// system-err) and its result
+func junitEvents(testCase junitCase, pkg string) []TestEvent {
+	events := []TestEvent{{Action: "run", Package: pkg, Test: testCase.Name}}
+	addOutput := func(text string) {
+		text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		for _, line := range strings.Split(text, "\n") {
+			events = append(events, TestEvent{Action: "output", Package: pkg, Test: testCase.Name, Output: line})
+		}
+	}
+	addMessage := func(message *junitMessage) {
+		// Go tools put the test output in the body and an excerpt of it
+		// in the message; other runners a summary in the message and the
+		// stack trace in the body
+		if message.Message != "" && !strings.Contains(message.Body, message.Message) {
+			addOutput(message.Message)
+		}
+		addOutput(message.Body)
+	}
+
+	action := "pass"
+	switch {
+	case testCase.Failure != nil:
+		action = "fail"
+		addMessage(testCase.Failure)
+	case testCase.Error != nil:
+		action = "fail"
+		if testCase.Error.Type == "incomplete" {
+			action = ""
+		}
+		addMessage(testCase.Error)
+	case testCase.Skipped != nil:
+		action = "skip"
+		addMessage(testCase.Skipped)
+	}
+	addOutput(testCase.SystemOut)
+	addOutput(testCase.SystemErr)
+
+	if action != "" {
+		elapsed, _ := strconv.ParseFloat(strings.TrimSpace(testCase.Time), 64)
+		events = append(events, TestEvent{Action: action, Package: pkg, Test: testCase.Name, Elapsed: elapsed})
+	}
+	return events
+}
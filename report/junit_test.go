@@ -0,0 +1,126 @@
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const junitInput = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="6">
+  <testsuite name="com.acme.BillingTest" tests="4">
+    <testcase name="chargesCard" classname="com.acme.BillingTest" time="0.25"/>
+    <testcase name="refunds" classname="com.acme.BillingTest" time="1.5">
+      <failure message="expected:&lt;1&gt; but was:&lt;2&gt;" type="org.opentest4j.AssertionFailedError">org.opentest4j.AssertionFailedError: expected:&lt;1&gt; but was:&lt;2&gt;
+	at com.acme.BillingTest.refunds(BillingTest.java:42)</failure>
+      <system-out>refunding order 7</system-out>
+    </testcase>
+    <testcase name="invoices" classname="com.acme.BillingTest" time="0">
+      <skipped message="requires a mail server"/>
+    </testcase>
+    <testcase name="exports" classname="com.acme.BillingTest" time="0.1">
+      <error message="java.io.IOException: disk full" type="java.io.IOException"/>
+    </testcase>
+  </testsuite>
+  <testsuite name="demo/a">
+    <testsuite name="nested">
+      <testcase name="TestBad/sub" time="0.100">
+        <failure message="expected 1, got 2" type="failure">=== RUN   TestBad/sub
+    a_test.go:7: expected 1, got 2
+--- FAIL: TestBad/sub (0.10s)</failure>
+      </testcase>
+    </testsuite>
+    <testcase name="TestBad" classname="demo/a" time="0.200">
+      <failure message="test failed" type="failure"></failure>
+    </testcase>
+    <testcase name="TestHang" classname="demo/a" time="0.000">
+      <error message="test did not report a result" type="incomplete">=== RUN   TestHang</error>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+
+func TestParseJUnit(t *testing.T) {
+	data, err := ParseWithOptions(strings.NewReader(junitInput), ParseOptions{KeepPassOutput: true, Format: InputJUnit})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type summary struct {
+		pkg, status string
+		duration    float64
+	}
+	got := make(map[string]summary)
+	for name, result := range data.Results {
+		got[name] = summary{result.Package, result.Status, result.Duration}
+	}
+	want := map[string]summary{
+		"chargesCard": {"com.acme.BillingTest", "PASS", 0.25},
+		"refunds":     {"com.acme.BillingTest", "FAIL", 1.5},
+		"invoices":    {"com.acme.BillingTest", "SKIP", 0},
+		"exports":     {"com.acme.BillingTest", "FAIL", 0.1},
+		"TestBad":     {"demo/a", "FAIL", 0.2},
+		"TestBad/sub": {"nested", "FAIL", 0.1},
+		"TestHang":    {"demo/a", "UNKNOWN", 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results:\ngot  %v\nwant %v", got, want)
+	}
+
+	if got := data.Results["refunds"].Output; !reflect.DeepEqual(got, []string{
+		"org.opentest4j.AssertionFailedError: expected:<1> but was:<2>",
+		"\tat com.acme.BillingTest.refunds(BillingTest.java:42)",
+		"refunding order 7",
+	}) {
+		t.Errorf("failure output: got %q", got)
+	}
+	if got := data.Results["exports"].Output; !reflect.DeepEqual(got, []string{"java.io.IOException: disk full"}) {
+		t.Errorf("error output: got %q", got)
+	}
+	if got := data.Results["invoices"].SkipReason; got != "requires a mail server" {
+		t.Errorf("skip reason: got %q", got)
+	}
+	if got := ExtractExcerpt(data.Results["TestBad/sub"].Output); got.Assertion != "expected 1, got 2" || got.File != "a_test.go" || got.Line != 7 {
+		t.Errorf("excerpt of a Go failure: got %+v", got)
+	}
+	if got := data.Results["TestBad"].SubTests; !reflect.DeepEqual(got, []string{"TestBad/sub"}) {
+		t.Errorf("subtests: got %v", got)
+	}
+	if got := data.Integrity.IncompleteTests; !reflect.DeepEqual(got, []string{"TestHang"}) {
+		t.Errorf("incomplete tests: got %v", got)
+	}
+	if data.TotalTests != 6 || data.FailedTests != 3 || data.PassedTests != 1 || data.SkippedTests != 1 {
+		t.Errorf("counts: got total %d, failed %d, passed %d, skipped %d", data.TotalTests, data.FailedTests, data.PassedTests, data.SkippedTests)
+	}
+}
+
+func TestParseJUnitSingleSuite(t *testing.T) {
+	input := `<testsuite name="tests.test_api"><testcase classname="" name="test_get"/></testsuite>`
+	data, err := ParseWithOptions(strings.NewReader(input), ParseOptions{Format: InputJUnit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := data.Results["test_get"]; result == nil || result.Package != "tests.test_api" || result.Status != "PASS" {
+		t.Errorf("got %+v, want test_get passing in the suite's package", result)
+	}
+
+	for _, input := range []string{"", `{"Action":"run"}`, `<html></html>`, `<testsuites><testsuite>`} {
+		if _, err := ParseWithOptions(strings.NewReader(input), ParseOptions{Format: InputJUnit}); err == nil {
+			t.Errorf("%q: got no error", input)
+		}
+	}
+}
+
+func TestParseJUnitDetected(t *testing.T) {
+	input := "\ufeff<?xml version=\"1.0\"?>\n<testsuites><testsuite name=\"demo\"><testcase name=\"TestA\" classname=\"demo\"/></testsuite></testsuites>"
+	data, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := data.Results["TestA"]; result == nil || result.Status != "PASS" {
+		t.Errorf("got %+v, want TestA read from the JUnit XML", result)
+	}
+	if isJUnit([]byte(`{"Action":"run"}`)) || isJUnit([]byte("<html><body>")) {
+		t.Error("isJUnit: got true for input that isn't JUnit XML")
+	}
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// LabelMarker starts the line a test logs to label itself, e.g.
+// t.Log("gotest-report: labels=integration,db"). Labels group and filter
+// tests in the report.
+const LabelMarker = "gotest-report: labels="
+
+// ParseLabels returns the labels of a LabelMarker line, as logged with t.Log
+// or printed, or nil for other lines.
+func ParseLabels(line string) []string {
+	// Most lines aren't markers, and this runs on every line of output
+	if !strings.Contains(line, LabelMarker) {
+		return nil
+	}
+	message := strings.TrimSpace(line)
+	if m := testLogLine.FindStringSubmatch(message); m != nil {
+		message = strings.TrimSpace(m[3])
+	}
+	list, ok := strings.CutPrefix(message, LabelMarker)
+	if !ok {
+		return nil
+	}
+	var labels []string
+	for _, label := range strings.Split(list, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// addLabels adds labels to a test and the tests it is a subtest of, so a
+// top-level test carries the labels of all its subtests
+func addLabels(results map[string]*TestResult, name string, labels []string) {
+	for result := results[name]; result != nil; result = results[result.ParentTest] {
+		for _, label := range labels {
+			if !slices.Contains(result.Labels, label) {
+				result.Labels = append(result.Labels, label)
+			}
+		}
+		sort.Strings(result.Labels)
+	}
+}
+
+// LabelSummary counts the top-level tests carrying one label
+type LabelSummary struct {
+	Label    string  `json:"label"`
+	Total    int     `json:"total"`
+	Passed   int     `json:"passed"`
+	Failed   int     `json:"failed"`
+	Skipped  int     `json:"skipped"`
+	Flaky    int     `json:"flaky"`
+	Duration float64 `json:"duration"`
+}
+
+// LabelSummaries counts the top-level tests of each label, by label. A test
+// with several labels is counted under each of them.
+func LabelSummaries(data *ReportData) []LabelSummary {
+	byLabel := make(map[string]*LabelSummary)
+	for _, result := range data.Results {
+		if result.IsSubTest {
+			continue
+		}
+		for _, label := range result.Labels {
+			s, ok := byLabel[label]
+			if !ok {
+				s = &LabelSummary{Label: label}
+				byLabel[label] = s
+			}
+			s.Total++
+			s.Duration += result.Duration
+			switch result.Status {
+			case "PASS":
+				s.Passed++
+			case "FAIL":
+				s.Failed++
+			case "SKIP":
+				s.Skipped++
+			case "FLAKY":
+				s.Flaky++
+			}
+		}
+	}
+
+	summaries := make([]LabelSummary, 0, len(byLabel))
+	for _, s := range byLabel {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Label < summaries[j].Label })
+	return summaries
+}
+
+// FilterLabels keeps the top-level tests carrying at least one of labels,
+// with their subtests, and recomputes the summary. Benchmarks and the rest of
+// the run are kept as they are.
+func FilterLabels(data *ReportData, labels []string) {
+	keep := func(result *TestResult) bool {
+		for result.IsSubTest && data.Results[result.ParentTest] != nil {
+			result = data.Results[result.ParentTest]
+		}
+		for _, label := range labels {
+			if slices.Contains(result.Labels, label) {
+				return true
+			}
+		}
+		return false
+	}
+
+	results := make(map[string]*TestResult, len(data.Results))
+	for name, result := range data.Results {
+		if keep(result) {
+			results[name] = result
+		}
+	}
+	data.Results = results
+	data.Integrity.IncompleteTests = slices.DeleteFunc(data.Integrity.IncompleteTests, func(name string) bool {
+		return results[name] == nil
+	})
+	data.UnquarantineCandidates = slices.DeleteFunc(data.UnquarantineCandidates, func(name string) bool {
+		return results[name] == nil
+	})
+	summarize(data)
+}
@@ -0,0 +1,85 @@
+package report
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"    db_test.go:12: gotest-report: labels=integration, db\n", "integration,db"},
+		{"gotest-report: labels=e2e", "e2e"},
+		{"    db_test.go:12: connecting to gotest-report: labels=db", ""},
+		{"    db_test.go:12: connected", ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(ParseLabels(tt.line), ","); got != tt.want {
+			t.Errorf("ParseLabels(%q): got %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	input := `{"Action":"run","Test":"TestDB","Package":"pkg"}
+{"Action":"output","Test":"TestDB","Package":"pkg","Output":"    db_test.go:9: gotest-report: labels=integration,db\n"}
+{"Action":"fail","Test":"TestDB","Package":"pkg","Elapsed":2}
+{"Action":"run","Test":"TestAPI","Package":"pkg"}
+{"Action":"run","Test":"TestAPI/slow","Package":"pkg"}
+{"Action":"output","Test":"TestAPI/slow","Package":"pkg","Output":"    api_test.go:4: gotest-report: labels=integration\n"}
+{"Action":"pass","Test":"TestAPI/slow","Package":"pkg","Elapsed":1}
+{"Action":"pass","Test":"TestAPI","Package":"pkg","Elapsed":1}
+{"Action":"run","Test":"TestUnit","Package":"pkg"}
+{"Action":"pass","Test":"TestUnit","Package":"pkg"}
+`
+	data, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(data.Results["TestAPI"].Labels, ","); got != "integration" {
+		t.Errorf("subtest labels should carry over to the parent, got %q", got)
+	}
+
+	summaries := LabelSummaries(data)
+	want := []LabelSummary{
+		{Label: "db", Total: 1, Failed: 1, Duration: 2},
+		{Label: "integration", Total: 2, Passed: 1, Failed: 1, Duration: 3},
+	}
+	if len(summaries) != len(want) {
+		t.Fatalf("got %+v", summaries)
+	}
+	for i := range want {
+		if summaries[i] != want[i] {
+			t.Errorf("summary %d: got %+v, want %+v", i, summaries[i], want[i])
+		}
+	}
+
+	encoded, err := data.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Labels   []LabelSummary `json:"labels"`
+		Failures []Failure      `json:"failures"`
+	}
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Labels) != 2 || strings.Join(doc.Failures[0].Labels, ",") != "db,integration" {
+		t.Errorf("JSON report missing labels:\n%s", encoded)
+	}
+
+	FilterLabels(data, []string{"db", "e2e"})
+	if data.TotalTests != 1 || data.FailedTests != 1 || data.Results["TestDB"] == nil {
+		t.Errorf("filtering by db: got %d tests, %v", data.TotalTests, data.SortedTestNames)
+	}
+
+	data, _ = Parse(strings.NewReader(input))
+	FilterLabels(data, []string{"integration"})
+	if got := strings.Join(data.SortedTestNames, ","); got != "TestAPI,TestDB" || data.Results["TestAPI/slow"] == nil {
+		t.Errorf("filtering by integration: got %s", got)
+	}
+}
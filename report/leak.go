@@ -0,0 +1,86 @@
+package report
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LeakedGoroutine is one goroutine reported by go.uber.org/goleak
+type LeakedGoroutine struct {
+	ID          int
+	State       string
+	TopFunction string // function on top of the stack
+	CreatedBy   string // function that started the goroutine
+	Stack       []string
+}
+
+var (
+	leakHeader    = regexp.MustCompile(`^\[?Goroutine (\d+) in state ([^,]+), with (\S+) on top of the stack:$`)
+	leakCreatedBy = regexp.MustCompile(`^created by (\S+)`)
+)
+
+// HasLeaks reports whether output contains a goleak report
+func HasLeaks(output []string) bool {
+	for _, line := range output {
+		if strings.Contains(line, "found unexpected goroutines:") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLeaks extracts the goroutines listed in goleak's "found unexpected
+// goroutines" failure from test output.
+func ParseLeaks(output []string) []LeakedGoroutine {
+	var leaks []LeakedGoroutine
+	var current *LeakedGoroutine
+	inReport := false
+
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if strings.Contains(trimmed, "found unexpected goroutines:") {
+			inReport = true
+			continue
+		}
+		if !inReport {
+			continue
+		}
+
+		if m := leakHeader.FindStringSubmatch(trimmed); m != nil {
+			id, _ := strconv.Atoi(m[1])
+			leaks = append(leaks, LeakedGoroutine{ID: id, State: m[2], TopFunction: m[3]})
+			current = &leaks[len(leaks)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		end := strings.HasSuffix(trimmed, "]")
+		trimmed = strings.TrimSuffix(trimmed, "]")
+		if trimmed != "" {
+			if m := leakCreatedBy.FindStringSubmatch(trimmed); m != nil {
+				current.CreatedBy = m[1]
+			}
+			current.Stack = append(current.Stack, trimmed)
+		}
+		if end {
+			current = nil
+			inReport = false
+		}
+	}
+	return leaks
+}
+
+// LeakFailures returns the failing tests whose output holds a goleak report
+func LeakFailures(data *ReportData) []string {
+	var names []string
+	for _, name := range sortedResultNames(data) {
+		result := data.Results[name]
+		if result.Status == "FAIL" && HasLeaks(result.Output) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
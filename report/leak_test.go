@@ -0,0 +1,63 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+// goleakOutput is the output of a test failing goleak.VerifyNone
+var goleakOutput = []string{
+	"=== RUN   TestWorkers",
+	"    workers_test.go:21: found unexpected goroutines:",
+	"        [Goroutine 7 in state chan receive, with example.com/app/pool.(*Pool).worker on top of the stack:",
+	"        example.com/app/pool.(*Pool).worker(0xc0000a4000)",
+	"        \t/src/app/pool/pool.go:48 +0x65",
+	"        created by example.com/app/pool.New in goroutine 6",
+	"        \t/src/app/pool/pool.go:30 +0x1d",
+	"        ",
+	"         Goroutine 8 in state select, with example.com/app/pool.(*Pool).janitor on top of the stack:",
+	"        example.com/app/pool.(*Pool).janitor(0xc0000a4000)",
+	"        \t/src/app/pool/pool.go:61 +0x8c",
+	"        created by example.com/app/pool.New",
+	"        \t/src/app/pool/pool.go:31 +0x3a",
+	"        ]",
+	"--- FAIL: TestWorkers (0.45s)",
+}
+
+func TestParseLeaks(t *testing.T) {
+	leaks := ParseLeaks(goleakOutput)
+	if len(leaks) != 2 {
+		t.Fatalf("expected 2 leaked goroutines, got %d: %+v", len(leaks), leaks)
+	}
+
+	first := leaks[0]
+	if first.ID != 7 || first.State != "chan receive" || first.TopFunction != "example.com/app/pool.(*Pool).worker" {
+		t.Errorf("unexpected first goroutine: %+v", first)
+	}
+	if first.CreatedBy != "example.com/app/pool.New" || len(first.Stack) != 4 {
+		t.Errorf("unexpected first goroutine stack: %q created by %q", first.Stack, first.CreatedBy)
+	}
+	if second := leaks[1]; second.ID != 8 || second.State != "select" || second.CreatedBy != "example.com/app/pool.New" {
+		t.Errorf("unexpected second goroutine: %+v", second)
+	}
+
+	if ParseLeaks([]string{"    x_test.go:1: expected 1, got 2"}) != nil {
+		t.Error("expected no leaks in an ordinary failure")
+	}
+}
+
+func TestLeakFailures(t *testing.T) {
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestWorkers": {Name: "TestWorkers", Status: "FAIL", Output: goleakOutput},
+		"TestOther":   {Name: "TestOther", Status: "FAIL", Output: []string{"boom"}},
+	}}
+	if got := strings.Join(LeakFailures(data), ","); got != "TestWorkers" {
+		t.Errorf("LeakFailures: got %q", got)
+	}
+
+	excerpt := ExtractExcerpt(goleakOutput)
+	if excerpt.Category != CategoryLeak || excerpt.Line != 21 ||
+		excerpt.Assertion != "found unexpected goroutines, created by example.com/app/pool.New" {
+		t.Errorf("unexpected excerpt: %+v", excerpt)
+	}
+}
@@ -0,0 +1,150 @@
+package report
+
+import "sort"
+
+// statusRank orders statuses from least to most severe when merging
+var statusRank = map[string]int{
+	"UNKNOWN": 0,
+	"SKIP":    1,
+	"PASS":    2,
+	"FLAKY":   3,
+	"FAIL":    4,
+}
+
+// Merge combines several runs into one report. A test that appears in more
+// than one run takes its most severe status (and that run's output) and its
+// longest duration. When labels are given, one per run, every test also
+// records its status per label in Variants so the runs can be compared side
+// by side.
+func Merge(runs []*ReportData, labels []string) *ReportData {
+	useLabels := len(labels) == len(runs) && len(labels) > 0
+	m := newMerger()
+	for i, run := range runs {
+		label := ""
+		if useLabels {
+			label = labels[i]
+		}
+		m.add(run, label)
+	}
+
+	if useLabels {
+		seen := make(map[string]bool)
+		for _, label := range labels {
+			if !seen[label] {
+				seen[label] = true
+				m.merged.Variants = append(m.merged.Variants, label)
+			}
+		}
+	}
+	return m.finish()
+}
+
+// merger folds runs into one report one at a time, so callers can let go of
+// each run once it is merged
+type merger struct {
+	merged *ReportData
+	// Whether a run was added yet, for the shared sanitizer
+	started bool
+}
+
+func newMerger() *merger {
+	return &merger{merged: &ReportData{Results: make(map[string]*TestResult)}}
+}
+
+// add merges run into the report, recording its statuses under label in
+// Variants unless label is empty. Nil runs are ignored.
+func (m *merger) add(run *ReportData, label string) {
+	if run == nil {
+		return
+	}
+	merged := m.merged
+
+	for name, result := range run.Results {
+		existing, exists := merged.Results[name]
+		if !exists {
+			copied := *result
+			copied.SubTests = append([]string(nil), result.SubTests...)
+			copied.Labels = append([]string(nil), result.Labels...)
+			copied.Variants = nil
+			existing = &copied
+			merged.Results[name] = existing
+		} else {
+			if statusRank[result.Status] > statusRank[existing.Status] {
+				existing.Status = result.Status
+				existing.Output = result.Output
+				existing.Attempts = result.Attempts
+				existing.SkipReason = result.SkipReason
+				existing.Repeats = result.Repeats
+			}
+			if result.Duration > existing.Duration {
+				existing.Duration = result.Duration
+			}
+			for _, subTest := range result.SubTests {
+				if !containsString(existing.SubTests, subTest) {
+					existing.SubTests = append(existing.SubTests, subTest)
+				}
+			}
+			for _, label := range result.Labels {
+				if !containsString(existing.Labels, label) {
+					existing.Labels = append(existing.Labels, label)
+				}
+			}
+			sort.Strings(existing.Labels)
+			existing.Quarantined = existing.Quarantined || result.Quarantined
+		}
+
+		if label != "" {
+			if existing.Variants == nil {
+				existing.Variants = make(map[string]string)
+			}
+			existing.Variants[label] = result.Status
+		}
+	}
+
+	// Keep the seed from the first run that shuffled each package
+	for pkg, seed := range run.ShuffleSeeds {
+		if merged.ShuffleSeeds == nil {
+			merged.ShuffleSeeds = make(map[string]string)
+		}
+		if _, exists := merged.ShuffleSeeds[pkg]; !exists {
+			merged.ShuffleSeeds[pkg] = seed
+		}
+	}
+
+	merged.Benchmarks = append(merged.Benchmarks, run.Benchmarks...)
+
+	if !run.Started.IsZero() && (merged.Started.IsZero() || run.Started.Before(merged.Started)) {
+		merged.Started = run.Started
+	}
+	if run.Finished.After(merged.Finished) {
+		merged.Finished = run.Finished
+	}
+
+	if run.Metrics != nil {
+		if merged.Metrics == nil {
+			merged.Metrics = &Metrics{}
+		}
+		merged.Metrics.InputBytes += run.Metrics.InputBytes
+		merged.Metrics.ParseSeconds += run.Metrics.ParseSeconds
+	}
+
+	merged.Integrity.EventsParsed += run.Integrity.EventsParsed
+	merged.Integrity.LinesSkipped += run.Integrity.LinesSkipped
+	merged.Integrity.IncompleteTests = append(merged.Integrity.IncompleteTests, run.Integrity.IncompleteTests...)
+	merged.Integrity.Truncations = append(merged.Integrity.Truncations, run.Integrity.Truncations...)
+
+	// Keep the sanitizer only when every run shares it
+	if !m.started {
+		merged.Sanitizer = run.Sanitizer
+	} else if run.Sanitizer != merged.Sanitizer {
+		merged.Sanitizer = ""
+	}
+	m.started = true
+}
+
+// finish returns the merged report with its counts
+func (m *merger) finish() *ReportData {
+	sort.Strings(m.merged.Integrity.IncompleteTests)
+	summarize(m.merged)
+	return m.merged
+}
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	shard1, err := Parse(strings.NewReader(`
+{"Action":"run","Test":"TestA","Package":"pkg/a"}
+{"Action":"pass","Test":"TestA","Package":"pkg/a","Elapsed":0.1}
+{"Action":"run","Test":"TestShared","Package":"pkg/s"}
+{"Action":"pass","Test":"TestShared","Package":"pkg/s","Elapsed":0.5}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shard2, err := Parse(strings.NewReader(`
+{"Action":"run","Test":"TestB","Package":"pkg/b"}
+{"Action":"skip","Test":"TestB","Package":"pkg/b"}
+{"Action":"run","Test":"TestShared","Package":"pkg/s"}
+{"Action":"output","Test":"TestShared","Package":"pkg/s","Output":"boom\n"}
+{"Action":"fail","Test":"TestShared","Package":"pkg/s","Elapsed":0.2}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shard1.Sanitizer = "race"
+	shard2.Sanitizer = "race"
+
+	merged := Merge([]*ReportData{shard1, shard2}, nil)
+
+	if merged.TotalTests != 3 || merged.PassedTests != 1 || merged.FailedTests != 1 || merged.SkippedTests != 1 {
+		t.Errorf("unexpected counts: %+v", merged)
+	}
+	shared := merged.Results["TestShared"]
+	if shared.Status != "FAIL" || shared.Duration != 0.5 || len(shared.Output) != 1 {
+		t.Errorf("unexpected merged TestShared: %+v", shared)
+	}
+	if merged.Sanitizer != "race" {
+		t.Errorf("shared sanitizer should be kept, got %q", merged.Sanitizer)
+	}
+	if len(merged.Variants) != 0 || shared.Variants != nil {
+		t.Error("variants should only be recorded when labels are given")
+	}
+	if merged.Integrity.EventsParsed != 9 {
+		t.Errorf("EventsParsed: got %d, want 9", merged.Integrity.EventsParsed)
+	}
+
+	// The inputs must not be modified by merging
+	if shard1.Results["TestShared"].Status != "PASS" {
+		t.Error("Merge modified its input")
+	}
+}
+
+func TestMergeWallClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	merged := Merge([]*ReportData{
+		{Results: map[string]*TestResult{}, Started: start.Add(time.Second), Finished: start.Add(5 * time.Second)},
+		{Results: map[string]*TestResult{}},
+		{Results: map[string]*TestResult{}, Started: start, Finished: start.Add(3 * time.Second)},
+	}, nil)
+	if !merged.Started.Equal(start) || merged.WallClock() != 5 {
+		t.Errorf("got %v to %v, want the span of every run", merged.Started, merged.Finished)
+	}
+}
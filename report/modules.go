@@ -0,0 +1,76 @@
+package report
+
+import (
+	"sort"
+	"strings"
+)
+
+// ModuleSummary counts the packages and top-level tests of one Go module of
+// a multi-module repository
+type ModuleSummary struct {
+	Module   string  `json:"module"`
+	Packages int     `json:"packages"`
+	Total    int     `json:"total"`
+	Passed   int     `json:"passed"`
+	Failed   int     `json:"failed"`
+	Skipped  int     `json:"skipped"`
+	Flaky    int     `json:"flaky"`
+	Duration float64 `json:"duration"`
+}
+
+// ModuleSummaries rolls the top-level tests up into the module, out of the
+// given module paths, that each package belongs to: the longest module path
+// it is under. Packages of no given module are left out. Modules are sorted by
+// path and those without tests are left out.
+func ModuleSummaries(data *ReportData, modules []string) []ModuleSummary {
+	byModule := make(map[string]*ModuleSummary)
+	packages := make(map[string]bool)
+	for _, result := range data.Results {
+		if result.IsSubTest {
+			continue
+		}
+		module, ok := moduleOf(modules, result.Package)
+		if !ok {
+			continue
+		}
+		s, ok := byModule[module]
+		if !ok {
+			s = &ModuleSummary{Module: module}
+			byModule[module] = s
+		}
+		if !packages[result.Package] {
+			packages[result.Package] = true
+			s.Packages++
+		}
+		s.Total++
+		s.Duration += result.Duration
+		switch result.Status {
+		case "PASS":
+			s.Passed++
+		case "FAIL":
+			s.Failed++
+		case "SKIP":
+			s.Skipped++
+		case "FLAKY":
+			s.Flaky++
+		}
+	}
+
+	summaries := make([]ModuleSummary, 0, len(byModule))
+	for _, s := range byModule {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Module < summaries[j].Module })
+	return summaries
+}
+
+// moduleOf returns the longest of modules that pkg is in
+func moduleOf(modules []string, pkg string) (string, bool) {
+	best := ""
+	for _, module := range modules {
+		if (pkg == module || strings.HasPrefix(pkg, module+"/")) && len(module) > len(best) {
+			best = module
+		}
+	}
+	return best, best != ""
+}
@@ -0,0 +1,26 @@
+package report
+
+import "testing"
+
+func TestModuleSummaries(t *testing.T) {
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestA":   {Name: "TestA", Package: "example.com/mono/api", Status: "PASS", Duration: 1},
+		"TestB":   {Name: "TestB", Package: "example.com/mono/api/v2", Status: "FAIL", Duration: 2},
+		"TestB/x": {Name: "TestB/x", Package: "example.com/mono/api/v2", Status: "FAIL", IsSubTest: true},
+		"TestC":   {Name: "TestC", Package: "example.com/mono/tools", Status: "SKIP"},
+		"TestD":   {Name: "TestD", Package: "example.com/other", Status: "PASS"},
+	}}
+	summaries := ModuleSummaries(data, []string{"example.com/mono", "example.com/mono/api", "example.com/mono/web"})
+	want := []ModuleSummary{
+		{Module: "example.com/mono", Packages: 1, Total: 1, Skipped: 1},
+		{Module: "example.com/mono/api", Packages: 2, Total: 2, Passed: 1, Failed: 1, Duration: 3},
+	}
+	if len(summaries) != len(want) {
+		t.Fatalf("got %+v", summaries)
+	}
+	for i := range want {
+		if summaries[i] != want[i] {
+			t.Errorf("summary %d: got %+v, want %+v", i, summaries[i], want[i])
+		}
+	}
+}
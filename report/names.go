@@ -0,0 +1,46 @@
+package report
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SubTestName returns the last element of a subtest's name, decoded for
+// display. go test writes the spaces of t.Run names as underscores, and some
+// table-test helpers percent-escape characters such as the slash, which
+// would otherwise start another level of subtests, e.g. "empty%2Finput" for
+// "empty/input". Percent-escapes are decoded when they form valid UTF-8, and
+// underscores become spaces with underscores set, since names can hold real
+// underscores too. The raw name is what -run patterns must match.
+func SubTestName(name string, underscores bool) string {
+	element := name[strings.LastIndex(name, "/")+1:]
+	element = unescapePercent(element)
+	if underscores {
+		element = strings.ReplaceAll(element, "_", " ")
+	}
+	return element
+}
+
+// unescapePercent decodes the %XX escapes of s, leaving s as it was when
+// that doesn't produce valid UTF-8
+func unescapePercent(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var decoded []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if b, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				decoded = append(decoded, byte(b))
+				i += 2
+				continue
+			}
+		}
+		decoded = append(decoded, s[i])
+	}
+	if !utf8.Valid(decoded) {
+		return s
+	}
+	return string(decoded)
+}
@@ -0,0 +1,25 @@
+package report
+
+import "testing"
+
+func TestSubTestName(t *testing.T) {
+	tests := []struct {
+		name        string
+		underscores bool
+		want        string
+	}{
+		{"TestParse/empty%2Finput", false, "empty/input"},
+		{"TestParse/valid_input", false, "valid_input"},
+		{"TestParse/valid_input", true, "valid input"},
+		{"TestParse/caf%C3%A9", false, "café"},
+		{"TestParse/100%", false, "100%"},
+		{"TestParse/50%_off", true, "50% off"},
+		{"TestParse/bad%FF", false, "bad%FF"},
+		{"TestParse", false, "TestParse"},
+	}
+	for _, tt := range tests {
+		if got := SubTestName(tt.name, tt.underscores); got != tt.want {
+			t.Errorf("SubTestName(%q, %v): got %q, want %q", tt.name, tt.underscores, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// TestRecord is one test in the NDJSON stream: its final state after
+// re-runs, subtest resolution and merging, with its output concatenated
+type TestRecord struct {
+	Package     string            `json:"package"`
+	Name        string            `json:"name"`
+	Parent      string            `json:"parent,omitempty"`
+	SubTests    []string          `json:"subtests,omitempty"`
+	Status      string            `json:"status"`
+	Duration    float64           `json:"duration"`
+	SkipReason  string            `json:"skipReason,omitempty"`
+	Attempts    int               `json:"attempts"` // 1 unless the test was re-run
+	Quarantined bool              `json:"quarantined,omitempty"`
+	Variants    map[string]string `json:"variants,omitempty"`
+	Output      string            `json:"output"`
+}
+
+// IntegrityRecord ends the NDJSON stream, telling consumers whether the
+// records before it are complete
+type IntegrityRecord struct {
+	Integrity IntegritySummary `json:"integrity"`
+}
+
+// WriteNDJSON writes one TestRecord per line, by package and name, so other
+// tools can consume the final results without resolving parents, subtests
+// and re-runs from raw go test -json events themselves, followed by an
+// IntegrityRecord
+func (d *ReportData) WriteNDJSON(w io.Writer) error {
+	results := make([]*TestResult, 0, len(d.Results))
+	for _, result := range d.Results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Package != results[j].Package {
+			return results[i].Package < results[j].Package
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, result := range results {
+		var output strings.Builder
+		for _, line := range result.Output {
+			output.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				output.WriteByte('\n')
+			}
+		}
+		record := TestRecord{
+			Package:     result.Package,
+			Name:        result.Name,
+			Parent:      result.ParentTest,
+			SubTests:    result.SubTests,
+			Status:      result.Status,
+			Duration:    result.Duration,
+			SkipReason:  result.SkipReason,
+			Attempts:    len(result.Attempts) + 1,
+			Quarantined: result.Quarantined,
+			Variants:    result.Variants,
+			Output:      output.String(),
+		}
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return encoder.Encode(IntegrityRecord{Integrity: d.Integrity.Summary()})
+}
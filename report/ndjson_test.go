@@ -0,0 +1,46 @@
+package report
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteNDJSON(t *testing.T) {
+	data := &ReportData{Integrity: Integrity{EventsParsed: 12, IncompleteTests: []string{"TestC"}}, Results: map[string]*TestResult{
+		"TestB": {Name: "TestB", Package: "pkg/b", Status: "FLAKY", Duration: 0.5,
+			Attempts: []Attempt{{Status: "FAIL", Duration: 0.4}}, Output: []string{"=== RUN   TestB\n", "--- PASS: TestB (0.50s)"}},
+		"TestA":     {Name: "TestA", Package: "pkg/a", Status: "FAIL", Duration: 1, SubTests: []string{"TestA/sub"}},
+		"TestA/sub": {Name: "TestA/sub", Package: "pkg/a", Status: "FAIL", Duration: 1, ParentTest: "TestA", IsSubTest: true, Output: []string{"    a_test.go:3: <nil>"}},
+	}}
+
+	var sb strings.Builder
+	if err := data.WriteNDJSON(&sb); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	want := []TestRecord{
+		{Package: "pkg/a", Name: "TestA", SubTests: []string{"TestA/sub"}, Status: "FAIL", Duration: 1, Attempts: 1},
+		{Package: "pkg/a", Name: "TestA/sub", Parent: "TestA", Status: "FAIL", Duration: 1, Attempts: 1, Output: "    a_test.go:3: <nil>\n"},
+		{Package: "pkg/b", Name: "TestB", Status: "FLAKY", Duration: 0.5, Attempts: 2, Output: "=== RUN   TestB\n--- PASS: TestB (0.50s)\n"},
+	}
+	if len(lines) != len(want)+1 {
+		t.Fatalf("got %d lines, want %d and the integrity record:\n%s", len(lines), len(want), sb.String())
+	}
+	for i, line := range lines[:len(want)] {
+		var got TestRecord
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("line %d: got %+v, want %+v", i+1, got, want[i])
+		}
+	}
+	if !strings.Contains(lines[1], `"output":"    a_test.go:3: <nil>\n"`) {
+		t.Errorf("HTML characters should not be escaped: %s", lines[1])
+	}
+	if want := `{"integrity":{"eventsParsed":12,"linesSkipped":0,"incompleteTests":["TestC"],"truncations":[],"complete":false}}`; lines[3] != want {
+		t.Errorf("integrity record: got %s, want %s", lines[3], want)
+	}
+}
@@ -0,0 +1,46 @@
+package report
+
+import "sort"
+
+// PackageDuration is the time spent in one package's tests
+type PackageDuration struct {
+	Package  string
+	Tests    int
+	Failed   int
+	Duration float64
+}
+
+// PackageDurations adds up the durations of each package's top-level tests,
+// slowest package first. Subtests are already part of their parent's time.
+// Packages run in parallel, so the slowest ones bound the wall-clock time of
+// the whole run.
+func PackageDurations(data *ReportData) []PackageDuration {
+	byPackage := make(map[string]*PackageDuration)
+	for _, result := range data.Results {
+		if result.IsSubTest {
+			continue
+		}
+		p, ok := byPackage[result.Package]
+		if !ok {
+			p = &PackageDuration{Package: result.Package}
+			byPackage[result.Package] = p
+		}
+		p.Tests++
+		p.Duration += result.Duration
+		if result.Status == "FAIL" {
+			p.Failed++
+		}
+	}
+
+	durations := make([]PackageDuration, 0, len(byPackage))
+	for _, p := range byPackage {
+		durations = append(durations, *p)
+	}
+	sort.Slice(durations, func(i, j int) bool {
+		if durations[i].Duration != durations[j].Duration {
+			return durations[i].Duration > durations[j].Duration
+		}
+		return durations[i].Package < durations[j].Package
+	})
+	return durations
+}
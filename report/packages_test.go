@@ -0,0 +1,27 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPackageDurations(t *testing.T) {
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestA1":     {Name: "TestA1", Package: "pkg/a", Status: "PASS", Duration: 1},
+		"TestA2":     {Name: "TestA2", Package: "pkg/a", Status: "FAIL", Duration: 2},
+		"TestA2/sub": {Name: "TestA2/sub", Package: "pkg/a", Status: "FAIL", Duration: 2, IsSubTest: true},
+		"TestB":      {Name: "TestB", Package: "pkg/b", Status: "PASS", Duration: 5},
+		"TestC":      {Name: "TestC", Package: "pkg/c", Status: "SKIP"},
+		"TestD":      {Name: "TestD", Package: "pkg/d", Status: "PASS"},
+	}}
+
+	want := []PackageDuration{
+		{Package: "pkg/b", Tests: 1, Duration: 5},
+		{Package: "pkg/a", Tests: 2, Failed: 1, Duration: 3},
+		{Package: "pkg/c", Tests: 1},
+		{Package: "pkg/d", Tests: 1},
+	}
+	if got := PackageDurations(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("PackageDurations: got %+v, want %+v", got, want)
+	}
+}
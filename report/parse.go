@@ -0,0 +1,586 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Parse reads go test -json events from reader and aggregates them into a
+// ReportData. Package-level events are ignored, except for the seed printed
+// by -shuffle=on and benchmark results. Gzip and zstd compressed
+// input is detected and decompressed automatically, and so are classic
+// go test -v text, JUnit XML and CTRF JSON, which are parsed into the events
+// test2json would produce.
+//
+// Logs that concatenate several go test invocations (e.g. a Makefile testing
+// one module after another) are split wherever a package that already
+// finished starts again. The runs are then merged with Merge and kept
+// separately in Invocations.
+//
+// Parse keeps the output of every test; see ParseWithOptions to bound memory
+// on large logs.
+func Parse(reader io.Reader) (*ReportData, error) {
+	return ParseWithOptions(reader, ParseOptions{KeepPassOutput: true})
+}
+
+// ParseWithOptions is Parse with control over which output is kept in memory
+func ParseWithOptions(reader io.Reader, opts ParseOptions) (*ReportData, error) {
+	start := time.Now()
+	counter := &countingReader{reader: reader}
+	reader, err := Decompress(counter)
+	if err != nil {
+		return nil, err
+	}
+
+	agg := newAggregator(opts)
+	defer agg.close()
+	agg.input = counter
+	if err := agg.read(reader); err != nil {
+		return nil, err
+	}
+	data, err := agg.finish()
+	if err != nil {
+		return nil, err
+	}
+	data.Metrics = &Metrics{InputBytes: counter.n.Load(), ParseSeconds: time.Since(start).Seconds()}
+	return data, nil
+}
+
+// countingReader counts the bytes read through it. The count may be read
+// while another goroutine reads, as the zstd decompressor does.
+type countingReader struct {
+	reader io.Reader
+	n      atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// DefaultMaxLineBytes is the longest line read into memory as a whole when
+// ParseOptions leave MaxLineBytes unset
+const DefaultMaxLineBytes = 10 * 1024 * 1024
+
+// scanLines calls fn for every non-blank line of reader of up to maxLine
+// bytes. The line is only valid until fn returns. Longer lines, e.g. tests
+// logging whole protobuf dumps, are handed to oversized instead, with a
+// json.Decoder positioned at their start that is read up to the end of one
+// value; the rest of the line is then skipped.
+func scanLines(reader io.Reader, maxLine int, fn func(line []byte) error, oversized func(dec *json.Decoder) error) error {
+	br := bufio.NewReaderSize(reader, lineBufferSize(maxLine))
+	if maxLine <= 0 {
+		maxLine = DefaultMaxLineBytes
+	}
+
+	var long []byte
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			long = append(long, chunk...)
+			if len(long) <= maxLine {
+				continue
+			}
+			dec := json.NewDecoder(io.MultiReader(bytes.NewReader(long), br))
+			if err := oversized(dec); err != nil {
+				return err
+			}
+			long = long[:0]
+			br = bufio.NewReaderSize(io.MultiReader(dec.Buffered(), br), br.Size())
+			if err := skipLine(br); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(long) > 0 {
+			chunk = append(long, chunk...)
+			long = long[:0]
+		}
+
+		line := bytes.TrimSuffix(bytes.TrimSuffix(chunk, []byte("\n")), []byte("\r"))
+		// Skip blank lines that can occur in piped or concatenated outputs
+		if len(bytes.TrimSpace(line)) > 0 {
+			if err := fn(line); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading input: %v", err)
+		}
+	}
+}
+
+// lineBufferSize is the read buffer used for lines of up to maxLine bytes
+func lineBufferSize(maxLine int) int {
+	if maxLine <= 0 {
+		maxLine = DefaultMaxLineBytes
+	}
+	return min(maxLine, 1024*1024)
+}
+
+// skipLine discards the rest of the current line
+func skipLine(br *bufio.Reader) error {
+	for {
+		_, err := br.ReadSlice('\n')
+		switch err {
+		case bufio.ErrBufferFull:
+			continue
+		case nil, io.EOF:
+			return nil
+		default:
+			return fmt.Errorf("error reading input: %v", err)
+		}
+	}
+}
+
+// aggregator folds a stream of events into per-test results
+type aggregator struct {
+	decoder       *eventDecoder
+	results       map[string]*TestResult
+	testOutputMap map[string]*outputBuffer
+	testStartTime map[string]time.Time
+	integrity     Integrity
+	shuffleSeeds  map[string]string
+	benchmarks    []BenchmarkResult
+	event         TestEvent
+	opts          ParseOptions
+
+	// The name of a benchmark whose result line was split, by package
+	benchmarkNames map[string]string
+	// When each test last finished, across invocations; only tracked when
+	// non-nil, for combining shards
+	testEndTime map[string]time.Time
+
+	// Flaky tests of a CTRF document, with how often they were retried
+	flaky map[string]int
+
+	// The last run of each finished test, and the earlier runs of tests that
+	// ran several times, as with -count
+	lastRuns map[testKey]Attempt
+	repeats  map[testKey][]Attempt
+	// When each running test last started or continued, with
+	// ParseOptions.Timeline
+	running map[string]time.Time
+	// The first and last event times of the invocation
+	firstEvent, lastEvent time.Time
+
+	// Packages that reported their final result, and the runs completed
+	// before one of them started again
+	finished    map[string]bool
+	invocations []*ReportData
+
+	// Output events for a test usually arrive back to back, so remember the
+	// last output buffer to skip the map lookup on the hot path.
+	lastOutputTest string
+	lastOutput     *outputBuffer
+
+	// The input as read, and the events and bytes of it not yet reported to
+	// ParseOptions.Progress
+	input           *countingReader
+	progressEvents  int
+	progressedBytes int64
+}
+
+func newAggregator(opts ParseOptions) *aggregator {
+	a := &aggregator{decoder: newEventDecoder(), opts: opts}
+	a.reset()
+	return a
+}
+
+// reset starts aggregating a new go test invocation
+func (a *aggregator) reset() {
+	a.results = make(map[string]*TestResult)
+	a.testOutputMap = make(map[string]*outputBuffer)
+	a.testStartTime = make(map[string]time.Time)
+	a.finished = make(map[string]bool)
+	a.flaky = nil
+	a.lastRuns = nil
+	a.repeats = nil
+	a.running = nil
+	a.firstEvent, a.lastEvent = time.Time{}, time.Time{}
+	a.integrity = Integrity{}
+	a.shuffleSeeds = nil
+	a.benchmarks = nil
+	a.benchmarkNames = nil
+	a.lastOutputTest = ""
+	a.lastOutput = nil
+}
+
+// close releases the output spooled to temporary files
+func (a *aggregator) close() {
+	for _, output := range a.testOutputMap {
+		output.discard()
+	}
+}
+
+// read aggregates every event of reader, which holds either go test -json
+// events, JUnit XML, a CTRF document or, as a fallback, classic go test -v
+// text
+func (a *aggregator) read(reader io.Reader) error {
+	defer a.reportProgress()
+	br := bufio.NewReaderSize(reader, lineBufferSize(a.opts.MaxLineBytes))
+	head, _ := br.Peek(64 * 1024)
+	switch {
+	case a.opts.Format == InputJUnit || a.opts.Format != InputCTRF && isJUnit(head):
+		return a.readJUnit(br)
+	case a.opts.Format == InputCTRF || isCTRF(head):
+		return a.readCTRF(br)
+	case isTextLog(head):
+		return a.readText(br)
+	}
+	return scanLines(br, a.opts.MaxLineBytes, a.addLine, a.addOversized)
+}
+
+// progressInterval is how many events are parsed between calls of
+// ParseOptions.Progress
+const progressInterval = 4096
+
+// reportProgress passes the events and bytes read since the last call on to
+// ParseOptions.Progress
+func (a *aggregator) reportProgress() {
+	if a.opts.Progress == nil {
+		return
+	}
+	var read int64
+	if a.input != nil {
+		read = a.input.n.Load()
+	}
+	if a.progressEvents == 0 && read == a.progressedBytes {
+		return
+	}
+	a.opts.Progress(a.progressEvents, read-a.progressedBytes)
+	a.progressEvents, a.progressedBytes = 0, read
+}
+
+// addLine decodes one JSON event and applies it
+func (a *aggregator) addLine(line []byte) error {
+	if err := a.decoder.decode(line, &a.event); err != nil {
+		return a.badLine(err)
+	}
+	return a.addEvent()
+}
+
+// addOversized decodes an event from a line longer than MaxLineBytes and
+// applies it
+func (a *aggregator) addOversized(dec *json.Decoder) error {
+	if err := a.decoder.decodeFrom(dec, &a.event); err != nil {
+		return a.badLine(err)
+	}
+	return a.addEvent()
+}
+
+// badLine fails the parse on a line that isn't an event, or counts it as
+// skipped when parsing leniently
+func (a *aggregator) badLine(err error) error {
+	if !a.opts.Lenient {
+		return fmt.Errorf("error unmarshalling JSON: %v", err)
+	}
+	a.integrity.LinesSkipped++
+	return nil
+}
+
+// addEvent applies the event just decoded
+func (a *aggregator) addEvent() error {
+	event := &a.event
+	if a.finished[event.Package] {
+		// The package already finished, so a new go test invocation started
+		run, err := a.finishRun()
+		if err != nil {
+			return err
+		}
+		a.invocations = append(a.invocations, run)
+		a.reset()
+	}
+	a.integrity.EventsParsed++
+	if !event.Time.IsZero() {
+		if a.firstEvent.IsZero() || event.Time.Before(a.firstEvent) {
+			a.firstEvent = event.Time
+		}
+		if event.Time.After(a.lastEvent) {
+			a.lastEvent = event.Time
+		}
+	}
+	if a.opts.Progress != nil {
+		a.progressEvents++
+		if a.progressEvents == progressInterval {
+			a.reportProgress()
+		}
+	}
+
+	results := a.results
+	testFullName := event.Test
+	if testFullName == "" {
+		// Skip package-level events, but remember when a package is done,
+		// which shuffle seed it used and the benchmark results it printed
+		switch event.Action {
+		case "pass", "fail", "skip":
+			a.finished[event.Package] = true
+		case "output":
+			a.addBenchmark(event)
+			if seed, ok := shuffleSeed(event.Output); ok {
+				if a.shuffleSeeds == nil {
+					a.shuffleSeeds = make(map[string]string)
+				}
+				a.shuffleSeeds[event.Package] = seed
+			}
+		}
+		return nil
+	}
+
+	isLifecycle := event.Action == "run" || event.Action == "pass" || event.Action == "fail" || event.Action == "skip"
+	if _, exists := results[testFullName]; isLifecycle && !exists {
+		results[testFullName] = &TestResult{
+			Name:      testFullName,
+			Package:   event.Package,
+			Status:    "UNKNOWN",
+			Duration:  0,
+			Output:    []string{},
+			IsSubTest: strings.Contains(testFullName, "/"),
+		}
+
+		if results[testFullName].IsSubTest {
+			parentName := testFullName[:strings.LastIndex(testFullName, "/")]
+			results[testFullName].ParentTest = parentName
+
+			if _, exists := results[parentName]; !exists {
+				results[parentName] = &TestResult{
+					Name:      parentName,
+					Package:   event.Package,
+					Status:    "UNKNOWN",
+					Duration:  0,
+					Output:    []string{},
+					SubTests:  []string{},
+					IsSubTest: strings.Contains(parentName, "/"),
+				}
+			}
+
+			results[parentName].SubTests = append(results[parentName].SubTests, testFullName)
+		}
+	}
+
+	if a.testEndTime != nil && isLifecycle && event.Action != "run" {
+		a.testEndTime[testFullName] = event.Time
+	}
+	if a.opts.Timeline && !event.Time.IsZero() {
+		a.addSpan(results[testFullName], event)
+	}
+
+	switch event.Action {
+	case "run":
+		a.testStartTime[testFullName] = event.Time
+
+	case "pass":
+		results[testFullName].Status = "PASS"
+		if event.Elapsed > 0 {
+			results[testFullName].Duration = event.Elapsed
+		} else if !a.testStartTime[testFullName].IsZero() {
+			results[testFullName].Duration = event.Time.Sub(a.testStartTime[testFullName]).Seconds()
+		}
+		a.repeat(event.Package, results[testFullName])
+		if !a.opts.KeepPassOutput && !a.failedBefore(event.Package, testFullName) {
+			a.dropOutput(testFullName)
+		}
+
+	case "fail":
+		results[testFullName].Status = "FAIL"
+		if event.Elapsed > 0 {
+			results[testFullName].Duration = event.Elapsed
+		} else if !a.testStartTime[testFullName].IsZero() {
+			results[testFullName].Duration = event.Time.Sub(a.testStartTime[testFullName]).Seconds()
+		}
+		a.repeat(event.Package, results[testFullName])
+
+	case "skip":
+		results[testFullName].Status = "SKIP"
+
+	case "output":
+		// Collect test output lines (the decoder already strips the trailing newline)
+		if a.lastOutput == nil || a.lastOutputTest != testFullName {
+			a.lastOutput = a.testOutputMap[testFullName]
+			if a.lastOutput == nil {
+				a.lastOutput = &outputBuffer{lines: make([]string, 0, 16)}
+				a.testOutputMap[testFullName] = a.lastOutput
+			}
+			a.lastOutputTest = testFullName
+		}
+		if event.Output != "" {
+			a.lastOutput.add(event.Output, a.opts.SpoolThreshold)
+		}
+		if labels := ParseLabels(event.Output); labels != nil {
+			addLabels(results, testFullName, labels)
+		}
+		a.addBenchmark(event)
+	}
+	return nil
+}
+
+// dropOutput forgets the output collected for a test
+func (a *aggregator) dropOutput(name string) {
+	if output, ok := a.testOutputMap[name]; ok {
+		output.discard()
+		delete(a.testOutputMap, name)
+	}
+	if a.lastOutputTest == name {
+		a.lastOutputTest, a.lastOutput = "", nil
+	}
+}
+
+// addBenchmark records event's output when it is a benchmark result. Go
+// prints the result of a benchmark's first run on the benchmark's own events
+// and those of repeated runs (-count) on package-level ones. Benchmarks that
+// take a while print their name before running, which splits the result
+// line across two events.
+func (a *aggregator) addBenchmark(event *TestEvent) {
+	output := event.Output
+	if name, ok := a.benchmarkNames[event.Package]; ok {
+		delete(a.benchmarkNames, event.Package)
+		output = name + output
+	}
+	if !strings.HasPrefix(output, "Benchmark") {
+		return
+	}
+	if strings.HasSuffix(output, "\t") && !strings.Contains(strings.TrimSpace(output), "\t") {
+		if a.benchmarkNames == nil {
+			a.benchmarkNames = make(map[string]string)
+		}
+		a.benchmarkNames[event.Package] = output
+		return
+	}
+	if result, ok := parseBenchmarkLine(output); ok {
+		result.Package = event.Package
+		a.benchmarks = append(a.benchmarks, result)
+	}
+}
+
+// finish returns the aggregated report, merging the invocations when the
+// input held more than one
+func (a *aggregator) finish() (*ReportData, error) {
+	last, err := a.finishRun()
+	if err != nil {
+		return nil, err
+	}
+	if len(a.invocations) == 0 {
+		return last, nil
+	}
+
+	runs := append(a.invocations, last)
+	merged := Merge(runs, nil)
+	merged.Invocations = runs
+	return merged, nil
+}
+
+// finishRun attaches the collected output of the current invocation and
+// computes its summary
+func (a *aggregator) finishRun() (*ReportData, error) {
+	results := a.results
+	a.finishRepeats()
+
+	// Add collected output to each test, except what passing tests logged
+	// after they passed when their output isn't kept
+	for testName, output := range a.testOutputMap {
+		result, exists := results[testName]
+		if !exists || result.Status == "PASS" && !a.opts.KeepPassOutput {
+			output.discard()
+			continue
+		}
+		lines, err := output.result()
+		if err != nil {
+			return nil, err
+		}
+		result.Output = lines
+	}
+	for _, result := range results {
+		if result.Status == "SKIP" {
+			result.SkipReason = SkipReason(result.Output)
+		}
+	}
+	for name, retries := range a.flaky {
+		if result := results[name]; result != nil && result.Status == "PASS" {
+			result.Status = "FLAKY"
+			for range retries {
+				result.Attempts = append(result.Attempts, Attempt{Status: "FAIL"})
+			}
+		}
+	}
+
+	integrity := a.integrity
+	integrity.IncompleteTests = []string{}
+	for name, result := range results {
+		if result.Status == "UNKNOWN" {
+			integrity.IncompleteTests = append(integrity.IncompleteTests, name)
+		}
+	}
+	sort.Strings(integrity.IncompleteTests)
+
+	reportData := &ReportData{
+		Results:      results,
+		Integrity:    integrity,
+		ShuffleSeeds: a.shuffleSeeds,
+		Benchmarks:   a.benchmarks,
+		Started:      a.firstEvent,
+		Finished:     a.lastEvent,
+	}
+	summarize(reportData)
+
+	return reportData, nil
+}
+
+// summarize recomputes the summary counts and sorted test names from Results
+func summarize(reportData *ReportData) {
+	reportData.TotalTests = 0
+	reportData.PassedTests = 0
+	reportData.FailedTests = 0
+	reportData.SkippedTests = 0
+	reportData.FlakyTests = 0
+	reportData.TotalDuration = 0
+
+	var sortedNames []string
+	for name, result := range reportData.Results {
+		// Only count root tests in summary (not subtests)
+		if !result.IsSubTest {
+			sortedNames = append(sortedNames, name)
+			reportData.TotalTests++
+			reportData.TotalDuration += result.Duration
+
+			switch result.Status {
+			case "PASS":
+				reportData.PassedTests++
+			case "FAIL":
+				reportData.FailedTests++
+			case "SKIP":
+				reportData.SkippedTests++
+			case "FLAKY":
+				reportData.FlakyTests++
+			}
+		}
+	}
+
+	sort.Strings(sortedNames)
+	reportData.SortedTestNames = sortedNames
+}
+
+// shuffleSeed extracts the seed from the "-test.shuffle 1700000000" line a
+// test binary prints when run with -shuffle=on
+func shuffleSeed(output string) (string, bool) {
+	seed, found := strings.CutPrefix(strings.TrimSpace(output), "-test.shuffle ")
+	if !found {
+		return "", false
+	}
+	if _, err := strconv.ParseInt(seed, 10, 64); err != nil {
+		return "", false
+	}
+	return seed, true
+}
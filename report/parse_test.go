@@ -0,0 +1,311 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// outputHeavyInput builds an event stream dominated by output events, which is
+// the shape of verbose integration test logs.
+func outputHeavyInput(tests, linesPerTest int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < tests; i++ {
+		name := fmt.Sprintf("TestIntegration/case_%d", i)
+		fmt.Fprintf(&buf, `{"Time":"2023-04-01T10:00:00.000001Z","Action":"run","Package":"github.com/acme/platform/internal/billing","Test":%q}`+"\n", name)
+		for j := 0; j < linesPerTest; j++ {
+			fmt.Fprintf(&buf, `{"Time":"2023-04-01T10:00:00.000002Z","Action":"output","Package":"github.com/acme/platform/internal/billing","Test":%q,"Output":"    billing_test.go:%d: processed invoice %d with \"status\"=ok\tin 3ms\n"}`+"\n", name, j, j)
+		}
+		fmt.Fprintf(&buf, `{"Time":"2023-04-01T10:00:01Z","Action":"pass","Package":"github.com/acme/platform/internal/billing","Test":%q,"Elapsed":0.01}`+"\n", name)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkParseOutputHeavy(b *testing.B) {
+	input := outputHeavyInput(100, 200)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(bytes.NewReader(input)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseIntegrity(t *testing.T) {
+	input := `
+{"Time":"2023-04-01T10:00:00Z","Action":"run","Test":"TestDone","Package":"pkg/example"}
+{"Time":"2023-04-01T10:00:01Z","Action":"pass","Test":"TestDone","Package":"pkg/example","Elapsed":1}
+{"Time":"2023-04-01T10:00:02Z","Action":"run","Test":"TestCutOff","Package":"pkg/example"}
+{"Time":"2023-04-01T10:00:02Z","Action":"output","Test":"TestCutOff","Output":"still running\n"}
+`
+	data, err := Parse(bytes.NewReader([]byte(input)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	integrity := data.Integrity
+	if integrity.EventsParsed != 4 {
+		t.Errorf("EventsParsed: got %d, want 4", integrity.EventsParsed)
+	}
+	if len(integrity.IncompleteTests) != 1 || integrity.IncompleteTests[0] != "TestCutOff" {
+		t.Errorf("IncompleteTests: got %v, want [TestCutOff]", integrity.IncompleteTests)
+	}
+	if integrity.Complete() {
+		t.Error("report with an unfinished test should not be complete")
+	}
+
+	truncated := Integrity{}.WithTruncation("outputs trimmed")
+	if truncated.Complete() || len(truncated.Truncations) != 1 {
+		t.Errorf("unexpected truncated integrity: %+v", truncated)
+	}
+}
+
+func TestParseSplitsInvocations(t *testing.T) {
+	// A Makefile ran the same package twice, then another one
+	input := `{"Action":"start","Package":"pkg/a"}
+{"Action":"run","Test":"TestShared","Package":"pkg/a"}
+{"Action":"output","Test":"TestShared","Package":"pkg/a","Output":"first run\n"}
+{"Action":"fail","Test":"TestShared","Package":"pkg/a","Elapsed":0.1}
+{"Action":"fail","Package":"pkg/a","Elapsed":0.2}
+{"Action":"start","Package":"pkg/a"}
+{"Action":"run","Test":"TestShared","Package":"pkg/a"}
+{"Action":"output","Test":"TestShared","Package":"pkg/a","Output":"second run\n"}
+{"Action":"pass","Test":"TestShared","Package":"pkg/a","Elapsed":0.3}
+{"Action":"pass","Package":"pkg/a","Elapsed":0.4}
+{"Action":"run","Test":"TestOther","Package":"pkg/b"}
+{"Action":"pass","Test":"TestOther","Package":"pkg/b","Elapsed":0.1}
+{"Action":"pass","Package":"pkg/b","Elapsed":0.1}
+`
+	data, err := Parse(bytes.NewReader([]byte(input)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(data.Invocations) != 2 {
+		t.Fatalf("expected 2 invocations, got %d", len(data.Invocations))
+	}
+	first, second := data.Invocations[0], data.Invocations[1]
+	if first.FailedTests != 1 || first.TotalTests != 1 {
+		t.Errorf("first invocation: got %d total, %d failed", first.TotalTests, first.FailedTests)
+	}
+	if second.PassedTests != 2 || second.Results["TestShared"].Output[0] != "second run" {
+		t.Errorf("second invocation: got %d passed, output %v", second.PassedTests, second.Results["TestShared"].Output)
+	}
+
+	// The merged view keeps the worst outcome together with its own output
+	shared := data.Results["TestShared"]
+	if shared.Status != "FAIL" || len(shared.Output) != 1 || shared.Output[0] != "first run" {
+		t.Errorf("merged TestShared: got %s %v", shared.Status, shared.Output)
+	}
+	if data.TotalTests != 2 || data.Integrity.EventsParsed != 13 {
+		t.Errorf("merged view: got %d tests, %d events", data.TotalTests, data.Integrity.EventsParsed)
+	}
+
+	single, err := Parse(bytes.NewReader([]byte(outputHeavyInput(2, 2))))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if single.Invocations != nil {
+		t.Errorf("a single invocation should not be split, got %d", len(single.Invocations))
+	}
+}
+
+func TestParseMetrics(t *testing.T) {
+	input := outputHeavyInput(3, 5)
+	data, err := Parse(bytes.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data.Metrics == nil || data.Metrics.InputBytes != int64(len(input)) || data.Metrics.ParseSeconds <= 0 {
+		t.Errorf("unexpected metrics: %+v", data.Metrics)
+	}
+
+	merged := Merge([]*ReportData{data, data}, nil)
+	if merged.Metrics.InputBytes != 2*int64(len(input)) {
+		t.Errorf("merged InputBytes: got %d, want %d", merged.Metrics.InputBytes, 2*len(input))
+	}
+}
+
+func TestParseShuffleSeeds(t *testing.T) {
+	input := `{"Action":"start","Package":"example.com/a"}
+{"Action":"output","Package":"example.com/a","Output":"-test.shuffle 1792112875204333477\n"}
+{"Action":"run","Package":"example.com/a","Test":"TestA"}
+{"Action":"pass","Package":"example.com/a","Test":"TestA","Elapsed":0.01}
+{"Action":"pass","Package":"example.com/a","Elapsed":0.02}
+{"Action":"output","Package":"example.com/b","Output":"-test.shuffle off\n"}
+{"Action":"run","Package":"example.com/b","Test":"TestB"}
+{"Action":"pass","Package":"example.com/b","Test":"TestB","Elapsed":0.01}
+{"Action":"pass","Package":"example.com/b","Elapsed":0.02}
+`
+	data, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := map[string]string{"example.com/a": "1792112875204333477"}
+	if !reflect.DeepEqual(data.ShuffleSeeds, want) {
+		t.Errorf("ShuffleSeeds: got %v, want %v", data.ShuffleSeeds, want)
+	}
+
+	// A second invocation with another seed keeps the first one
+	again := strings.Replace(input, "1792112875204333477", "42", 1)
+	data, err = Parse(strings.NewReader(input + again))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(data.Invocations) != 2 || !reflect.DeepEqual(data.ShuffleSeeds, want) {
+		t.Errorf("merged ShuffleSeeds: got %v in %d invocations", data.ShuffleSeeds, len(data.Invocations))
+	}
+	if seed := data.Invocations[1].ShuffleSeeds["example.com/a"]; seed != "42" {
+		t.Errorf("second invocation seed: got %q, want 42", seed)
+	}
+}
+
+func TestParseWithOptionsPassOutput(t *testing.T) {
+	input := `{"Action":"run","Test":"TestPass","Package":"pkg/a"}
+{"Action":"output","Test":"TestPass","Package":"pkg/a","Output":"pass log\n"}
+{"Action":"pass","Test":"TestPass","Package":"pkg/a","Elapsed":0.1}
+{"Action":"run","Test":"TestFail","Package":"pkg/a"}
+{"Action":"output","Test":"TestFail","Package":"pkg/a","Output":"fail log\n"}
+{"Action":"fail","Test":"TestFail","Package":"pkg/a","Elapsed":0.1}
+{"Action":"run","Test":"TestSkip","Package":"pkg/a"}
+{"Action":"output","Test":"TestSkip","Package":"pkg/a","Output":"skip log\n"}
+{"Action":"skip","Test":"TestSkip","Package":"pkg/a","Elapsed":0}
+{"Action":"fail","Package":"pkg/a"}
+`
+	tests := []struct {
+		opts     ParseOptions
+		passLogs int
+	}{
+		{ParseOptions{}, 0},
+		{ParseOptions{KeepPassOutput: true}, 1},
+		{ParseOptions{SpoolThreshold: 1}, 0},
+		{ParseOptions{KeepPassOutput: true, SpoolThreshold: 1}, 1},
+	}
+	for _, tt := range tests {
+		data, err := ParseWithOptions(strings.NewReader(input), tt.opts)
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.opts, err)
+		}
+		if got := len(data.Results["TestPass"].Output); got != tt.passLogs {
+			t.Errorf("%+v: TestPass output: got %d lines, want %d", tt.opts, got, tt.passLogs)
+		}
+		for _, name := range []string{"TestFail", "TestSkip"} {
+			if got := data.Results[name].Output; len(got) != 1 {
+				t.Errorf("%+v: %s output: got %q", tt.opts, name, got)
+			}
+		}
+	}
+}
+
+func TestParseOversizedLines(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	input := `{"Action":"run","Test":"TestDump","Package":"pkg/a"}
+{"Action":"output","Test":"TestDump","Package":"pkg/a","Output":"` + long + `\n"}   
+{"Action":"output","Test":"TestDump","Package":"pkg/a","Output":"short\n"}
+{"Action":"fail","Test":"TestDump","Package":"pkg/a","Elapsed":0.1}
+{"Action":"run","Test":"TestNext","Package":"pkg/a"}
+{"Action":"pass","Test":"TestNext","Package":"pkg/a","Elapsed":0.1}
+`
+	for _, maxLine := range []int{0, 64, 100} {
+		data, err := ParseWithOptions(strings.NewReader(input), ParseOptions{MaxLineBytes: maxLine})
+		if err != nil {
+			t.Fatalf("MaxLineBytes %d: %v", maxLine, err)
+		}
+		if got := data.Results["TestDump"].Output; !reflect.DeepEqual(got, []string{long, "short"}) {
+			t.Errorf("MaxLineBytes %d: TestDump output: got %q", maxLine, got)
+		}
+		if data.TotalTests != 2 || data.FailedTests != 1 || data.Integrity.EventsParsed != 6 {
+			t.Errorf("MaxLineBytes %d: got %d tests, %d failed, %d events", maxLine, data.TotalTests, data.FailedTests, data.Integrity.EventsParsed)
+		}
+	}
+
+	_, err := ParseWithOptions(strings.NewReader(`{"Action":"output","Output":"`+long), ParseOptions{MaxLineBytes: 64})
+	if err == nil || !strings.Contains(err.Error(), "error unmarshalling JSON") {
+		t.Errorf("truncated oversized line: got %v", err)
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	input := `make[1]: Entering directory '/src'
+{"Action":"run","Test":"TestA","Package":"pkg/a"}
+ok  	pkg/a	0.1s
+{"Action":"pass","Test":"TestA","Package":"pkg/a","Elapsed":0.1}
+make: *** ` + long + `
+{"Action":"run","Test":"TestB","Package":"pkg/a"}
+{"Action":"fail","Test":"TestB","Package":"pkg/a","Elapsed":0.1}
+`
+	if _, err := ParseWithOptions(strings.NewReader(input), ParseOptions{}); err == nil {
+		t.Fatal("expected an error without Lenient")
+	}
+
+	for _, maxLine := range []int{0, 64} {
+		data, err := ParseWithOptions(strings.NewReader(input), ParseOptions{Lenient: true, MaxLineBytes: maxLine})
+		if err != nil {
+			t.Fatalf("MaxLineBytes %d: %v", maxLine, err)
+		}
+		if data.Integrity.LinesSkipped != 3 || data.Integrity.EventsParsed != 4 {
+			t.Errorf("MaxLineBytes %d: got %d lines skipped, %d events", maxLine, data.Integrity.LinesSkipped, data.Integrity.EventsParsed)
+		}
+		if data.PassedTests != 1 || data.FailedTests != 1 {
+			t.Errorf("MaxLineBytes %d: got %d passed, %d failed", maxLine, data.PassedTests, data.FailedTests)
+		}
+		if data.Integrity.Complete() {
+			t.Errorf("MaxLineBytes %d: a report with skipped lines must not be complete", maxLine)
+		}
+	}
+}
+
+func TestParseProgress(t *testing.T) {
+	var input strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&input, `{"Action":"run","Package":"pkg","Test":"Test%d"}`+"\n", i)
+		fmt.Fprintf(&input, `{"Action":"pass","Package":"pkg","Test":"Test%d"}`+"\n", i)
+	}
+
+	var calls, events int
+	var bytes int64
+	data, err := ParseWithOptions(strings.NewReader(input.String()), ParseOptions{Progress: func(e int, b int64) {
+		calls++
+		events += e
+		bytes += b
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 5 || events != data.Integrity.EventsParsed || bytes != int64(input.Len()) {
+		t.Errorf("progress: got %d calls for %d events and %d bytes, want 5 calls for %d events and %d bytes",
+			calls, events, bytes, data.Integrity.EventsParsed, input.Len())
+	}
+}
+
+func TestParseWallClock(t *testing.T) {
+	input := `{"Time":"2024-01-01T10:00:00Z","Action":"run","Test":"TestA","Package":"pkg/a"}
+{"Time":"2024-01-01T10:00:00Z","Action":"run","Test":"TestB","Package":"pkg/a"}
+{"Time":"2024-01-01T10:00:03Z","Action":"pass","Test":"TestA","Package":"pkg/a","Elapsed":3}
+{"Time":"2024-01-01T10:00:04Z","Action":"pass","Test":"TestB","Package":"pkg/a","Elapsed":4}
+{"Time":"2024-01-01T10:00:04Z","Action":"pass","Package":"pkg/a","Elapsed":4}
+`
+	data, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.TotalDuration != 7 || data.WallClock() != 4 || data.Parallelism() != 1.75 {
+		t.Errorf("got %vs of test time in %vs, parallelism %v", data.TotalDuration, data.WallClock(), data.Parallelism())
+	}
+
+	data, err = Parse(strings.NewReader(`{"Action":"run","Test":"TestA"}
+{"Action":"pass","Test":"TestA","Elapsed":1}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.WallClock() != 0 || data.Parallelism() != 0 {
+		t.Errorf("without timestamps: got wall clock %v, parallelism %v", data.WallClock(), data.Parallelism())
+	}
+}
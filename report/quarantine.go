@@ -0,0 +1,63 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LoadQuarantine reads a quarantine list: one test name per line, with blank
+// lines and lines starting with # ignored.
+func LoadQuarantine(path string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening quarantine list: %v", err)
+	}
+	defer file.Close()
+
+	quarantined := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		quarantined[line] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading quarantine list: %v", err)
+	}
+	return quarantined, nil
+}
+
+// ApplyQuarantine marks quarantined tests in data and, when a history is
+// available, records which of them passed in each of the last passRuns runs
+// as candidates to take out of quarantine.
+func ApplyQuarantine(data *ReportData, quarantined map[string]bool, history *History, passRuns int) {
+	data.UnquarantineCandidates = nil
+
+	for name := range quarantined {
+		result, exists := data.Results[name]
+		if exists {
+			result.Quarantined = true
+		}
+
+		if history == nil || passRuns <= 0 || len(history.Runs) < passRuns {
+			continue
+		}
+		consistent := true
+		for _, status := range history.Statuses(name, passRuns) {
+			if status != "PASS" {
+				consistent = false
+				break
+			}
+		}
+		if consistent {
+			data.UnquarantineCandidates = append(data.UnquarantineCandidates, name)
+		}
+	}
+
+	sort.Strings(data.UnquarantineCandidates)
+}
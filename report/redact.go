@@ -0,0 +1,96 @@
+package report
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Redacted replaces the secrets a Redactor finds
+const Redacted = "[REDACTED]"
+
+// redactRule masks what re matches with replacement, which may keep a
+// submatch such as the name of a header
+type redactRule struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// builtinSecrets match credentials commonly printed by tests, keeping what
+// identifies them so the output stays readable
+var builtinSecrets = []redactRule{
+	// AWS access key ids and secret access keys
+	{regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), Redacted},
+	{regexp.MustCompile(`(?i)(aws_secret_access_key["']?\s*[:=]\s*["']?)[A-Za-z0-9/+=]{40}`), "${1}" + Redacted},
+	// Authorization headers, whatever their scheme
+	{regexp.MustCompile(`(?i)(\bauthorization["']?\s*[:=]\s*["']?)(?:(?:bearer|basic|token|digest)\s+)?[^\s"',;]+`), "${1}" + Redacted},
+	{regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9\-._~+/]+=*`), "${1}" + Redacted},
+	// GitHub tokens
+	{regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), Redacted},
+	{regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`), Redacted},
+}
+
+// Redactor masks secrets in captured test output before it is rendered,
+// since reports are often posted where more people can read them than the
+// CI logs.
+type Redactor struct {
+	rules []redactRule
+}
+
+// NewRedactor returns a Redactor for patterns, regular expressions whose
+// whole match is masked, and with builtin for common credentials too. It
+// returns nil when there is nothing to redact.
+func NewRedactor(builtin bool, patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	if builtin {
+		r.rules = append(r.rules, builtinSecrets...)
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %v", pattern, err)
+		}
+		r.rules = append(r.rules, redactRule{re, Redacted})
+	}
+	if len(r.rules) == 0 {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// Redact masks the secrets in one line of output
+func (r *Redactor) Redact(line string) string {
+	if r == nil {
+		return line
+	}
+	for _, rule := range r.rules {
+		line = rule.re.ReplaceAllString(line, rule.replacement)
+	}
+	return line
+}
+
+// Apply masks the secrets in the output, earlier attempts and skip reasons
+// of every test in data, including the invocations and suites it combines.
+func (r *Redactor) Apply(data *ReportData) {
+	if r == nil || data == nil {
+		return
+	}
+	for _, result := range data.Results {
+		r.redactLines(result.Output)
+		for i := range result.Attempts {
+			r.redactLines(result.Attempts[i].Output)
+		}
+		result.SkipReason = r.Redact(result.SkipReason)
+	}
+	for _, run := range data.Invocations {
+		r.Apply(run)
+	}
+	for _, suite := range data.Suites {
+		r.Apply(suite)
+	}
+}
+
+func (r *Redactor) redactLines(lines []string) {
+	for i, line := range lines {
+		lines[i] = r.Redact(line)
+	}
+}
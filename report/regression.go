@@ -0,0 +1,77 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DurationRegression is a test, or the whole run, that got slower than the
+// baseline allows
+type DurationRegression struct {
+	Name     string  `json:"name"` // empty for the total duration of the run
+	Package  string  `json:"package,omitempty"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+}
+
+// Increase is how much slower the current run was, in percent of the baseline
+func (r DurationRegression) Increase() float64 {
+	if r.Baseline <= 0 {
+		return 0
+	}
+	return (r.Current - r.Baseline) / r.Baseline * 100
+}
+
+// DurationRegressions compares the total duration and every test present in
+// both runs, and returns those that got more than maxIncrease percent slower.
+// Tests that took less than minDuration seconds in the current run are left
+// out, since their timing is mostly noise, as are tests skipped in either run.
+// The total comes first, then tests by decreasing increase.
+func DurationRegressions(baseline, current *ReportData, maxIncrease, minDuration float64) []DurationRegression {
+	var regressions []DurationRegression
+	regressed := func(r DurationRegression) bool {
+		return r.Baseline > 0 && r.Current >= minDuration && r.Increase() > maxIncrease
+	}
+
+	total := DurationRegression{Baseline: baseline.TotalDuration, Current: current.TotalDuration}
+	if regressed(total) {
+		regressions = append(regressions, total)
+	}
+
+	var tests []DurationRegression
+	for name, result := range current.Results {
+		old, ok := baseline.Results[name]
+		if !ok || old.Status == "SKIP" || result.Status == "SKIP" {
+			continue
+		}
+		r := DurationRegression{Name: name, Package: result.Package, Baseline: old.Duration, Current: result.Duration}
+		if regressed(r) {
+			tests = append(tests, r)
+		}
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		if tests[i].Increase() != tests[j].Increase() {
+			return tests[i].Increase() > tests[j].Increase()
+		}
+		return tests[i].Name < tests[j].Name
+	})
+	return append(regressions, tests...)
+}
+
+// DurationViolations describes regressions as quality gate violations
+func DurationViolations(regressions []DurationRegression, maxIncrease float64) []string {
+	var violations []string
+	tests := 0
+	for _, r := range regressions {
+		if r.Name == "" {
+			violations = append(violations, fmt.Sprintf("total duration %.2fs is %.0f%% slower than the baseline %.2fs (limit %g%%)",
+				r.Current, r.Increase(), r.Baseline, maxIncrease))
+		} else {
+			tests++
+		}
+	}
+	if tests > 0 {
+		violations = append(violations, fmt.Sprintf("%d tests are more than %g%% slower than the baseline", tests, maxIncrease))
+	}
+	return violations
+}
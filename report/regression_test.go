@@ -0,0 +1,59 @@
+package report
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDurationRegressions(t *testing.T) {
+	baseline := &ReportData{Results: map[string]*TestResult{
+		"TestSteady":  {Name: "TestSteady", Status: "PASS", Duration: 2},
+		"TestSlower":  {Name: "TestSlower", Status: "PASS", Duration: 1},
+		"TestMuch":    {Name: "TestMuch", Status: "PASS", Duration: 1},
+		"TestTiny":    {Name: "TestTiny", Status: "PASS", Duration: 0.001},
+		"TestSkipped": {Name: "TestSkipped", Status: "SKIP", Duration: 0.1},
+	}}
+	current := &ReportData{Results: map[string]*TestResult{
+		"TestSteady":  {Name: "TestSteady", Status: "PASS", Duration: 2.1},
+		"TestSlower":  {Name: "TestSlower", Status: "PASS", Duration: 1.5},
+		"TestMuch":    {Name: "TestMuch", Status: "FAIL", Duration: 3},
+		"TestTiny":    {Name: "TestTiny", Status: "PASS", Duration: 0.05},
+		"TestSkipped": {Name: "TestSkipped", Status: "PASS", Duration: 5},
+		"TestNew":     {Name: "TestNew", Status: "PASS", Duration: 9},
+	}}
+	summarize(baseline)
+	summarize(current)
+
+	var names []string
+	regressions := DurationRegressions(baseline, current, 20, 0.1)
+	for _, r := range regressions {
+		names = append(names, r.Name)
+	}
+	if want := []string{"", "TestMuch", "TestSlower"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("regressions: got %q, want %q", names, want)
+	}
+	if total := regressions[0]; math.Abs(total.Baseline-4.101) > 1e-9 || math.Abs(total.Current-20.65) > 1e-9 {
+		t.Errorf("unexpected total: %+v", total)
+	}
+	if increase := regressions[2].Increase(); increase != 50 {
+		t.Errorf("TestSlower increase: got %v, want 50", increase)
+	}
+
+	violations := DurationViolations(regressions, 20)
+	if len(violations) != 2 || violations[1] != "2 tests are more than 20% slower than the baseline" {
+		t.Errorf("unexpected violations: %q", violations)
+	}
+
+	if regressions := DurationRegressions(baseline, current, 500, 0.1); len(regressions) != 0 {
+		t.Errorf("expected no regressions over 500%%, got %+v", regressions)
+	}
+}
+
+func TestAddViolation(t *testing.T) {
+	data := &ReportData{}
+	AddViolation(data, "too slow")
+	if data.QualityGate == nil || data.QualityGate.Passed || !reflect.DeepEqual(data.QualityGate.Violations, []string{"too slow"}) {
+		t.Errorf("unexpected gate result: %+v", data.QualityGate)
+	}
+}
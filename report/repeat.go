@@ -0,0 +1,119 @@
+package report
+
+import (
+	"math"
+	"sort"
+)
+
+// RunStats summarizes the runs of a test repeated with go test -count, in
+// seconds
+type RunStats struct {
+	Runs     int     `json:"runs"`
+	Failures int     `json:"failures"`
+	Min      float64 `json:"min"`
+	Median   float64 `json:"median"`
+	P95      float64 `json:"p95"`
+	Max      float64 `json:"max"`
+}
+
+// FailureRate returns the share of runs that failed, from 0 to 1
+func (s *RunStats) FailureRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Runs)
+}
+
+// RepeatedTest is a test that ran more than once in one go test invocation
+type RepeatedTest struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	RunStats
+}
+
+// RepeatedTests returns the tests run more than once, the ones failing most
+// often first, then by name
+func RepeatedTests(data *ReportData) []RepeatedTest {
+	var tests []RepeatedTest
+	for name, result := range data.Results {
+		if result.Repeats != nil {
+			tests = append(tests, RepeatedTest{Name: name, Package: result.Package, RunStats: *result.Repeats})
+		}
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		if ri, rj := tests[i].FailureRate(), tests[j].FailureRate(); ri != rj {
+			return ri > rj
+		}
+		return tests[i].Name < tests[j].Name
+	})
+	return tests
+}
+
+// testKey identifies a test across packages, which may share test names
+type testKey struct {
+	pkg, name string
+}
+
+// repeat records that a test finished, moving its previous run, if it
+// finished before as it does with -count, to its earlier runs
+func (a *aggregator) repeat(pkg string, result *TestResult) {
+	if result.Status != "PASS" && result.Status != "FAIL" {
+		return
+	}
+	if a.lastRuns == nil {
+		a.lastRuns = make(map[testKey]Attempt)
+		a.repeats = make(map[testKey][]Attempt)
+	}
+	key := testKey{pkg: pkg, name: result.Name}
+	if last, ok := a.lastRuns[key]; ok {
+		a.repeats[key] = append(a.repeats[key], last)
+	}
+	a.lastRuns[key] = Attempt{Status: result.Status, Duration: result.Duration}
+}
+
+// failedBefore reports whether an earlier run of a repeated test failed
+func (a *aggregator) failedBefore(pkg, name string) bool {
+	for _, run := range a.repeats[testKey{pkg: pkg, name: name}] {
+		if run.Status == "FAIL" {
+			return true
+		}
+	}
+	return false
+}
+
+// finishRepeats gives every repeated test its run statistics. A test fails
+// when any of its runs did, and takes the median duration.
+func (a *aggregator) finishRepeats() {
+	for key, runs := range a.repeats {
+		result := a.results[key.name]
+		stats := runStats(append(runs, a.lastRuns[key]))
+		result.Repeats = stats
+		result.Duration = stats.Median
+		if stats.Failures > 0 {
+			result.Status = "FAIL"
+		}
+	}
+}
+
+// runStats computes the statistics of a test's runs
+func runStats(runs []Attempt) *RunStats {
+	durations := make([]float64, len(runs))
+	stats := &RunStats{Runs: len(runs)}
+	for i, run := range runs {
+		durations[i] = run.Duration
+		if run.Status == "FAIL" {
+			stats.Failures++
+		}
+	}
+	sort.Float64s(durations)
+
+	n := len(durations)
+	stats.Min, stats.Max = durations[0], durations[n-1]
+	stats.Median = durations[n/2]
+	if n%2 == 0 {
+		stats.Median = (durations[n/2-1] + durations[n/2]) / 2
+	}
+	// Nearest rank
+	stats.P95 = durations[int(math.Ceil(0.95*float64(n)))-1]
+	return stats
+}
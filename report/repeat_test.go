@@ -0,0 +1,72 @@
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRepeatedRuns(t *testing.T) {
+	input := `{"Action":"run","Test":"TestA","Package":"pkg/a"}
+{"Action":"pass","Test":"TestA","Package":"pkg/a","Elapsed":0.1}
+{"Action":"run","Test":"TestA","Package":"pkg/a"}
+{"Action":"output","Test":"TestA","Package":"pkg/a","Output":"    a_test.go:5: boom\n"}
+{"Action":"fail","Test":"TestA","Package":"pkg/a","Elapsed":0.4}
+{"Action":"run","Test":"TestA","Package":"pkg/a"}
+{"Action":"pass","Test":"TestA","Package":"pkg/a","Elapsed":0.2}
+{"Action":"run","Test":"TestA","Package":"pkg/a"}
+{"Action":"pass","Test":"TestA","Package":"pkg/a","Elapsed":0.3}
+{"Action":"run","Test":"TestB","Package":"pkg/a"}
+{"Action":"pass","Test":"TestB","Package":"pkg/a","Elapsed":0.1}
+{"Action":"fail","Package":"pkg/a","Elapsed":1}
+`
+	data, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := data.Results["TestA"]
+	want := &RunStats{Runs: 4, Failures: 1, Min: 0.1, Median: 0.25, P95: 0.4, Max: 0.4}
+	if !reflect.DeepEqual(result.Repeats, want) {
+		t.Errorf("stats: got %+v, want %+v", result.Repeats, want)
+	}
+	if result.Status != "FAIL" || result.Duration != 0.25 {
+		t.Errorf("TestA: got %s in %vs, want FAIL in the median 0.25s", result.Status, result.Duration)
+	}
+	if got := strings.Join(result.Output, ","); got != "    a_test.go:5: boom" {
+		t.Errorf("output of the failed run: got %q", got)
+	}
+	if data.Results["TestB"].Repeats != nil {
+		t.Error("TestB ran once but got run statistics")
+	}
+
+	repeated := RepeatedTests(data)
+	if len(repeated) != 1 || repeated[0].Name != "TestA" || repeated[0].FailureRate() != 0.25 {
+		t.Errorf("repeated tests: got %+v", repeated)
+	}
+}
+
+func TestParseSameNameInTwoPackages(t *testing.T) {
+	input := `{"Action":"run","Test":"TestShared","Package":"pkg/a"}
+{"Action":"fail","Test":"TestShared","Package":"pkg/a","Elapsed":0.4}
+{"Action":"run","Test":"TestShared","Package":"pkg/b"}
+{"Action":"pass","Test":"TestShared","Package":"pkg/b","Elapsed":0.1}
+{"Action":"fail","Package":"pkg/a","Elapsed":1}
+{"Action":"pass","Package":"pkg/b","Elapsed":1}
+`
+	data, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := data.Results["TestShared"]
+	if result.Repeats != nil {
+		t.Errorf("runs in two packages counted as repeats: got %+v", result.Repeats)
+	}
+	if result.Duration != 0.1 {
+		t.Errorf("duration: got %v, want the last run's 0.1", result.Duration)
+	}
+	if repeated := RepeatedTests(data); len(repeated) != 0 {
+		t.Errorf("repeated tests: got %+v", repeated)
+	}
+}
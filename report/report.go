@@ -0,0 +1,185 @@
+// Package report holds the data model behind gotest-report together with the
+// parser for go test -json output and helpers for comparing runs, so CI bots
+// can embed it instead of shelling out to the CLI.
+package report
+
+import "time"
+
+// TestEvent represents a single event from go test -json output
+type TestEvent struct {
+	Time    time.Time // Time when the event occurred
+	Action  string    // Action: "run", "pause", "cont", "pass", "bench", "fail", "skip", "output"
+	Test    string    // Test name
+	Package string    // Package being tested
+	Output  string    // Output text (for "output" action)
+	Elapsed float64   // Elapsed time in seconds for "pass" or "fail" events
+}
+
+// TestResult holds the aggregated result for a single test
+type TestResult struct {
+	Name        string
+	Package     string
+	Status      string  // "PASS", "FAIL", "SKIP", or "FLAKY" when it passed on a re-run
+	Duration    float64 // The median when the test ran several times
+	Output      []string
+	ParentTest  string // For subtests
+	SubTests    []string
+	IsSubTest   bool
+	Attempts    []Attempt // Earlier attempts when the test was re-run
+	Quarantined bool      // Listed in the quarantine list
+	SkipReason  string    // Message passed to t.Skip, for skipped tests
+	Repeats     *RunStats // When the test ran several times, as with -count
+	Spans       []Span    // When the test was running, with ParseOptions.Timeline
+	Labels      []string  // Logged with LabelMarker by the test or its subtests, sorted
+
+	// Status per run label (e.g. the sanitizer) when several runs were merged
+	Variants map[string]string
+}
+
+// Attempt is the outcome of one earlier execution of a re-run test
+type Attempt struct {
+	Status   string
+	Duration float64
+	Output   []string
+}
+
+// ReportData contains all data needed for the report
+type ReportData struct {
+	TotalTests      int
+	PassedTests     int
+	FailedTests     int
+	SkippedTests    int
+	FlakyTests      int
+	TotalDuration   float64 // The sum of the top-level tests' durations
+	Results         map[string]*TestResult
+	SortedTestNames []string
+	Integrity       Integrity
+	Sanitizer       string   // "race", "asan" or "msan" when the run used one
+	Variants        []string // Run labels when several runs were merged, in input order
+	Labels          []string // "key=value" pairs describing the run, e.g. os=linux
+	// The keys of Labels that Variants combine, e.g. os and go, when the
+	// merged runs had labels
+	Dimensions []string
+
+	// Quarantined tests that passed consistently in recent history
+	UnquarantineCandidates []string
+
+	// The -test.shuffle seed each package ran with, by package, when the
+	// run used -shuffle=on
+	ShuffleSeeds map[string]string
+
+	// Where the tests ran, when requested
+	Environment *Environment
+
+	// Benchmark results in the order they were printed, one per run
+	Benchmarks []BenchmarkResult
+
+	// The outcome of the quality gate, when thresholds were set
+	QualityGate *GateResult
+
+	// Tests, and the total, that got slower than a baseline allows
+	DurationRegressions []DurationRegression
+
+	// Packages whose tests took longer than their budget
+	BudgetOverruns []BudgetOverrun
+
+	// The counts of each category of packages, when categories were given
+	Categories []CategorySummary
+
+	// The counts of each module, when the tests span several modules of a
+	// multi-module repository
+	Modules []ModuleSummary
+
+	// How failures changed since the baseline, when one was given
+	Comparison *DiffData
+
+	// The separate go test invocations found in the input, when there was
+	// more than one; the rest of ReportData is their merged view
+	Invocations []*ReportData
+
+	// The suite the run was given as, e.g. with -suite-name, and the suites
+	// of a report combining several, in input order; the rest of ReportData
+	// is then their merged view
+	Suite  string
+	Suites []*ReportData
+
+	// What producing the report cost, when measured
+	Metrics *Metrics
+
+	// Where the rendered report was uploaded, once it was
+	ReportURL string
+
+	// When the first and last timestamped events of the run happened; zero
+	// for input without timestamps
+	Started  time.Time
+	Finished time.Time
+}
+
+// WallClock returns the seconds from the first event of the run to the
+// last, or 0 when the input had no timestamps.
+func (d *ReportData) WallClock() float64 {
+	if d.Started.IsZero() || d.Finished.IsZero() {
+		return 0
+	}
+	return d.Finished.Sub(d.Started).Seconds()
+}
+
+// Parallelism returns the test time per second of wall clock, e.g. 4 when
+// tests took 40s in total but the run 10s, or 0 without timestamps.
+func (d *ReportData) Parallelism() float64 {
+	wall := d.WallClock()
+	if wall <= 0 {
+		return 0
+	}
+	return d.TotalDuration / wall
+}
+
+// Metrics records the tool's own performance so regressions on real
+// workloads are easy to spot. Parse fills in the input side; renderers add
+// the rest.
+type Metrics struct {
+	InputBytes      int64   `json:"inputBytes"` // as read, i.e. compressed size for compressed input
+	ParseSeconds    float64 `json:"parseSeconds"`
+	RenderSeconds   float64 `json:"renderSeconds"`
+	PeakMemoryBytes uint64  `json:"peakMemoryBytes"` // memory obtained from the OS by the Go runtime
+}
+
+// Integrity records how faithfully the input was turned into a report, so
+// downstream consumers can tell whether a report is complete and trustworthy.
+type Integrity struct {
+	EventsParsed    int      `json:"eventsParsed"`
+	LinesSkipped    int      `json:"linesSkipped"`
+	IncompleteTests []string `json:"incompleteTests"` // started but never reported a result
+	Truncations     []string `json:"truncations"`     // content a renderer left out
+}
+
+// Complete reports whether nothing was skipped, lost or truncated.
+func (i Integrity) Complete() bool {
+	return i.LinesSkipped == 0 && len(i.IncompleteTests) == 0 && len(i.Truncations) == 0
+}
+
+// IntegritySummary is Integrity as reports embed it, with Complete spelled
+// out and empty lists rather than null ones.
+type IntegritySummary struct {
+	Integrity
+	Complete bool `json:"complete"`
+}
+
+// Summary returns i with whether it is complete, for embedding in reports.
+func (i Integrity) Summary() IntegritySummary {
+	if i.IncompleteTests == nil {
+		i.IncompleteTests = []string{}
+	}
+	if i.Truncations == nil {
+		i.Truncations = []string{}
+	}
+	return IntegritySummary{Integrity: i, Complete: i.Complete()}
+}
+
+// WithTruncation returns a copy of i with an additional truncation note.
+func (i Integrity) WithTruncation(note string) Integrity {
+	truncations := make([]string, len(i.Truncations), len(i.Truncations)+1)
+	copy(truncations, i.Truncations)
+	i.Truncations = append(truncations, note)
+	return i
+}
@@ -0,0 +1,66 @@
+package report
+
+// MergeRerun folds the results of re-running tests into base. Each re-run
+// test keeps its previous outcome as an Attempt and takes the latest one as
+// its result; a test that passes after having failed is marked FLAKY.
+func MergeRerun(base, rerun *ReportData) {
+	if base.Results == nil {
+		base.Results = make(map[string]*TestResult)
+	}
+
+	for name, latest := range rerun.Results {
+		previous, exists := base.Results[name]
+		if !exists {
+			base.Results[name] = latest
+			continue
+		}
+
+		attempts := append(previous.Attempts, Attempt{
+			Status:   previous.Status,
+			Duration: previous.Duration,
+			Output:   previous.Output,
+		})
+
+		status := latest.Status
+		if status == "PASS" && (previous.Status == "FAIL" || previous.Status == "FLAKY") {
+			status = "FLAKY"
+		}
+
+		previous.Status = status
+		previous.Duration = latest.Duration
+		previous.Output = latest.Output
+		previous.Attempts = attempts
+
+		for _, subTest := range latest.SubTests {
+			if !containsString(previous.SubTests, subTest) {
+				previous.SubTests = append(previous.SubTests, subTest)
+			}
+		}
+	}
+
+	base.Integrity.EventsParsed += rerun.Integrity.EventsParsed
+	base.Integrity.LinesSkipped += rerun.Integrity.LinesSkipped
+	summarize(base)
+}
+
+// FailingRootTests returns the names of failing top-level tests grouped by
+// package, which is the granularity go test -run can re-run reliably.
+func FailingRootTests(data *ReportData) map[string][]string {
+	failing := make(map[string][]string)
+	for _, name := range data.SortedTestNames {
+		result := data.Results[name]
+		if result.Status == "FAIL" && !result.IsSubTest {
+			failing[result.Package] = append(failing[result.Package], name)
+		}
+	}
+	return failing
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeRerun(t *testing.T) {
+	base, err := Parse(strings.NewReader(`
+{"Action":"run","Test":"TestStable","Package":"pkg/a"}
+{"Action":"pass","Test":"TestStable","Package":"pkg/a","Elapsed":0.1}
+{"Action":"run","Test":"TestFlaky","Package":"pkg/a"}
+{"Action":"output","Test":"TestFlaky","Package":"pkg/a","Output":"connection reset\n"}
+{"Action":"fail","Test":"TestFlaky","Package":"pkg/a","Elapsed":0.2}
+{"Action":"run","Test":"TestBroken","Package":"pkg/b"}
+{"Action":"fail","Test":"TestBroken","Package":"pkg/b","Elapsed":0.3}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failing := FailingRootTests(base)
+	if strings.Join(failing["pkg/a"], ",") != "TestFlaky" || strings.Join(failing["pkg/b"], ",") != "TestBroken" {
+		t.Fatalf("unexpected failing tests: %v", failing)
+	}
+
+	rerun, err := Parse(strings.NewReader(`
+{"Action":"run","Test":"TestFlaky","Package":"pkg/a"}
+{"Action":"pass","Test":"TestFlaky","Package":"pkg/a","Elapsed":0.4}
+{"Action":"run","Test":"TestBroken","Package":"pkg/b"}
+{"Action":"fail","Test":"TestBroken","Package":"pkg/b","Elapsed":0.3}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	MergeRerun(base, rerun)
+
+	flaky := base.Results["TestFlaky"]
+	if flaky.Status != "FLAKY" {
+		t.Errorf("TestFlaky status: got %s, want FLAKY", flaky.Status)
+	}
+	if len(flaky.Attempts) != 1 || flaky.Attempts[0].Status != "FAIL" || flaky.Attempts[0].Output[0] != "connection reset" {
+		t.Errorf("TestFlaky attempts: got %+v", flaky.Attempts)
+	}
+	if flaky.Duration != 0.4 {
+		t.Errorf("TestFlaky duration: got %v, want 0.4", flaky.Duration)
+	}
+	if base.Results["TestBroken"].Status != "FAIL" {
+		t.Errorf("TestBroken should still fail")
+	}
+
+	if base.TotalTests != 3 || base.PassedTests != 1 || base.FailedTests != 1 || base.FlakyTests != 1 {
+		t.Errorf("unexpected counts: total=%d passed=%d failed=%d flaky=%d",
+			base.TotalTests, base.PassedTests, base.FailedTests, base.FlakyTests)
+	}
+}
@@ -0,0 +1,273 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxOpenFiles is the number of shard files ParseShards keeps open at
+// once when no limit is given.
+const DefaultMaxOpenFiles = 64
+
+// ParseShards parses several go test -json files that together make up one
+// run, e.g. the shards of a CI matrix, into a single ReportData. The files are
+// parsed in parallel, by at most maxOpen workers (and no more than there are
+// CPUs) that each keep one file open, and each partial report is merged into
+// the result, in input order, as soon as it is ready. Workers don't get more
+// than a couple of files ahead of the merge, so only a few partial reports
+// are held in memory at once however many shards there are. A test found in
+// several shards, e.g. re-run by a retry job, keeps every run as an attempt:
+// one that passed after failing elsewhere is FLAKY, and otherwise the run
+// that finished last is the result. opts apply to every shard as in
+// ParseWithOptions.
+func ParseShards(paths []string, maxOpen int, opts ParseOptions) (*ReportData, error) {
+	start := time.Now()
+	var inputBytes int64
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			inputBytes += info.Size()
+		}
+	}
+
+	if maxOpen <= 0 {
+		maxOpen = DefaultMaxOpenFiles
+	}
+	workers := min(maxOpen, runtime.GOMAXPROCS(0), len(paths))
+
+	type parsed struct {
+		index int
+		part  *shardPart
+		err   error
+	}
+	// A slot is taken for each file handed to a worker and given back once
+	// its report is merged, which bounds the reports waiting for an earlier
+	// file to finish
+	slots := make(chan struct{}, 2*workers)
+	jobs := make(chan int)
+	results := make(chan parsed)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				part, err := parseShard(paths[i], opts)
+				results <- parsed{i, part, err}
+			}
+		}()
+	}
+	go func() {
+		for i := range paths {
+			slots <- struct{}{}
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	combiner := newShardCombiner()
+	pending := make(map[int]*shardPart)
+	errs := make([]error, len(paths))
+	next := 0
+	for result := range results {
+		if result.err != nil {
+			errs[result.index] = result.err
+		}
+		pending[result.index] = result.part
+		for ; next < len(paths); next++ {
+			part, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if part != nil {
+				combiner.add(next, part)
+			}
+			<-slots
+		}
+	}
+
+	// Report the first bad shard in input order, whichever worker hit it first
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data := combiner.finish()
+	data.Metrics = &Metrics{InputBytes: inputBytes, ParseSeconds: time.Since(start).Seconds()}
+	return data, nil
+}
+
+// shardPart is the report of one shard with the time each test finished
+type shardPart struct {
+	data    *ReportData
+	endTime map[string]time.Time
+}
+
+// parseShard parses one shard file
+func parseShard(path string, opts ParseOptions) (*shardPart, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening input file: %v", err)
+	}
+	defer file.Close()
+
+	counter := &countingReader{reader: file}
+	reader, err := Decompress(counter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	agg := newAggregator(opts)
+	defer agg.close()
+	agg.input = counter
+	agg.testEndTime = make(map[string]time.Time)
+	if err := agg.read(reader); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	data, err := agg.finish()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &shardPart{data: data, endTime: agg.testEndTime}, nil
+}
+
+// shardRun is one run of a test in a shard, with what combineAttempts needs
+// of its result
+type shardRun struct {
+	index  int
+	end    time.Time
+	result TestResult
+}
+
+// shardCombiner merges the shard reports with a merger as they come. Only
+// the runs of each test are kept from a report once it is merged, so those
+// found in more than one shard, e.g. in a retry job, can be folded into
+// attempts with combineAttempts at the end.
+type shardCombiner struct {
+	merger *merger
+	runs   map[string][]shardRun
+	// The first report, returned as it is when it is the only one
+	first *ReportData
+	count int
+}
+
+func newShardCombiner() *shardCombiner {
+	return &shardCombiner{merger: newMerger(), runs: make(map[string][]shardRun)}
+}
+
+// add merges the report of the shard at index in the input list
+func (c *shardCombiner) add(index int, part *shardPart) {
+	for name, result := range part.data.Results {
+		run := TestResult{Status: result.Status, Duration: result.Duration, Output: result.Output, Attempts: result.Attempts}
+		c.runs[name] = append(c.runs[name], shardRun{index: index, end: part.endTime[name], result: run})
+	}
+	c.count++
+	switch c.count {
+	case 1:
+		c.first = part.data
+		return
+	case 2:
+		c.merger.add(c.first, "")
+		c.first = nil
+	}
+	c.merger.add(part.data, "")
+}
+
+// finish returns the combined report
+func (c *shardCombiner) finish() *ReportData {
+	if c.count == 0 {
+		return c.merger.finish()
+	}
+	if c.count == 1 {
+		return c.first
+	}
+	merged := c.merger.finish()
+	for name, runs := range c.runs {
+		if len(runs) < 2 {
+			continue
+		}
+		// In the order the runs finished; ties go to the later shard, as
+		// they did when shards were stream-merged in timestamp order
+		sort.SliceStable(runs, func(a, b int) bool {
+			if runs[a].end.Equal(runs[b].end) {
+				return runs[a].index < runs[b].index
+			}
+			return runs[a].end.Before(runs[b].end)
+		})
+		results := make([]*TestResult, len(runs))
+		for i, run := range runs {
+			results[i] = &run.result
+		}
+		combineAttempts(merged.Results[name], results)
+	}
+
+	// A test cut short in one shard may have finished in another
+	merged.Integrity.IncompleteTests = []string{}
+	for name, result := range merged.Results {
+		if result.Status == "UNKNOWN" {
+			merged.Integrity.IncompleteTests = append(merged.Integrity.IncompleteTests, name)
+		}
+	}
+	sort.Strings(merged.Integrity.IncompleteTests)
+
+	summarize(merged)
+	return merged
+}
+
+// combineAttempts sets result from the runs of a test in several shards, in
+// the order they finished, each with its own earlier attempts. Runs cut
+// short are ignored when another finished. A test that both passed and
+// failed is FLAKY: its last passing run is the result and every other run
+// an earlier attempt. Otherwise the last run is the result.
+func combineAttempts(result *TestResult, runs []*TestResult) {
+	var attempts []Attempt
+	for _, run := range runs {
+		if run.Status == "UNKNOWN" && len(runs) > 1 {
+			continue
+		}
+		attempts = append(attempts, run.Attempts...)
+		status := run.Status
+		if status == "FLAKY" {
+			status = "PASS"
+		}
+		attempts = append(attempts, Attempt{Status: status, Duration: run.Duration, Output: run.Output})
+	}
+	if len(attempts) == 0 {
+		return
+	}
+
+	final := len(attempts) - 1
+	passed, failed := false, false
+	for i, attempt := range attempts {
+		switch attempt.Status {
+		case "PASS":
+			passed = true
+			final = i
+		case "FAIL":
+			failed = true
+		}
+	}
+	if !passed || !failed {
+		final = len(attempts) - 1
+	}
+
+	last := attempts[final]
+	result.Status = last.Status
+	if passed && failed {
+		result.Status = "FLAKY"
+	}
+	result.Duration = last.Duration
+	result.Output = last.Output
+	result.Attempts = append(attempts[:final:final], attempts[final+1:]...)
+	result.SkipReason = ""
+	if result.Status == "SKIP" {
+		result.SkipReason = SkipReason(result.Output)
+	}
+}
@@ -0,0 +1,154 @@
+package report
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeShards writes one file per shard and returns their paths
+func writeShards(t *testing.T, shards []string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for i, content := range shards {
+		path := filepath.Join(dir, fmt.Sprintf("shard-%d.json", i))
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func shardEvents(test string, second int, action string) string {
+	return fmt.Sprintf(`{"Time":"2024-01-01T10:00:%02dZ","Action":"run","Test":"%s","Package":"pkg/%s"}
+{"Time":"2024-01-01T10:00:%02dZ","Action":"output","Test":"%s","Package":"pkg/%s","Output":"log from %s\n"}
+{"Time":"2024-01-01T10:00:%02dZ","Action":"%s","Test":"%s","Package":"pkg/%s","Elapsed":0.5}
+`, second, test, test, second, test, test, test, second+1, action, test, test)
+}
+
+func TestParseShards(t *testing.T) {
+	var shards []string
+	for i := 0; i < 7; i++ {
+		action := "pass"
+		if i == 3 {
+			action = "fail"
+		}
+		shards = append(shards, shardEvents(fmt.Sprintf("Test%d", i), i*2, action))
+	}
+	paths := writeShards(t, shards)
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	for _, maxOpen := range []int{0, 1, 3, 4} {
+		t.Run(fmt.Sprintf("maxOpen=%d", maxOpen), func(t *testing.T) {
+			// Spool every test's output to exercise the temporary files
+			data, err := ParseShards(paths, maxOpen, ParseOptions{SpoolThreshold: 1})
+			if err != nil {
+				t.Fatalf("ParseShards: %v", err)
+			}
+			if data.TotalTests != 7 || data.PassedTests != 6 || data.FailedTests != 1 {
+				t.Errorf("got %d total, %d passed, %d failed", data.TotalTests, data.PassedTests, data.FailedTests)
+			}
+			if data.Integrity.EventsParsed != 21 {
+				t.Errorf("EventsParsed: got %d, want 21", data.Integrity.EventsParsed)
+			}
+			if got := strings.Join(data.Results["Test3"].Output, ","); got != "log from Test3" {
+				t.Errorf("Test3 output: got %q", got)
+			}
+		})
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(tmp, "gotest-report-*"))
+	if len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestParseShardsTimestampOrder(t *testing.T) {
+	// The re-run in the first file happened after the failure in the second,
+	// so it is the result even though the file is listed first, and the
+	// failure an earlier attempt
+	rerun := `{"Time":"2024-01-01T10:05:00Z","Action":"run","Test":"TestRetry","Package":"pkg/a"}
+{"Time":"2024-01-01T10:05:01Z","Action":"pass","Test":"TestRetry","Package":"pkg/a","Elapsed":1}
+`
+	first := `{"Time":"2024-01-01T10:00:00Z","Action":"run","Test":"TestRetry","Package":"pkg/a"}
+{"Time":"2024-01-01T10:00:01Z","Action":"fail","Test":"TestRetry","Package":"pkg/a","Elapsed":1}
+`
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(first))
+	w.Close()
+
+	paths := writeShards(t, []string{rerun, gz.String(), ""})
+	data, err := ParseShards(paths, 0, ParseOptions{})
+	if err != nil {
+		t.Fatalf("ParseShards: %v", err)
+	}
+	result := data.Results["TestRetry"]
+	if result.Status != "FLAKY" || len(result.Attempts) != 1 || result.Attempts[0].Status != "FAIL" {
+		t.Errorf("TestRetry: got %s with attempts %+v, want FLAKY after one failure", result.Status, result.Attempts)
+	}
+	if data.FlakyTests != 1 || data.FailedTests != 0 {
+		t.Errorf("got %d flaky, %d failed", data.FlakyTests, data.FailedTests)
+	}
+}
+
+func TestParseShardsRetriesFailing(t *testing.T) {
+	retry := `{"Time":"2024-01-01T10:05:00Z","Action":"run","Test":"TestBroken","Package":"pkg/a"}
+{"Time":"2024-01-01T10:05:00Z","Action":"output","Test":"TestBroken","Package":"pkg/a","Output":"second try\n"}
+{"Time":"2024-01-01T10:05:02Z","Action":"fail","Test":"TestBroken","Package":"pkg/a","Elapsed":2}
+`
+	first := `{"Time":"2024-01-01T10:00:00Z","Action":"run","Test":"TestBroken","Package":"pkg/a"}
+{"Time":"2024-01-01T10:00:00Z","Action":"output","Test":"TestBroken","Package":"pkg/a","Output":"first try\n"}
+{"Time":"2024-01-01T10:00:01Z","Action":"fail","Test":"TestBroken","Package":"pkg/a","Elapsed":1}
+`
+	data, err := ParseShards(writeShards(t, []string{retry, first}), 0, ParseOptions{})
+	if err != nil {
+		t.Fatalf("ParseShards: %v", err)
+	}
+	result := data.Results["TestBroken"]
+	if result.Status != "FAIL" || result.Duration != 2 || strings.Join(result.Output, ",") != "second try" {
+		t.Errorf("TestBroken: got %s in %vs with output %q, want the retry's failure", result.Status, result.Duration, result.Output)
+	}
+	if len(result.Attempts) != 1 || result.Attempts[0].Duration != 1 || strings.Join(result.Attempts[0].Output, ",") != "first try" {
+		t.Errorf("attempts: got %+v, want the first failure", result.Attempts)
+	}
+}
+
+func TestParseShardsIncompleteElsewhere(t *testing.T) {
+	// The first shard was cut off mid-test; the second ran it to the end
+	cutOff := `{"Time":"2024-01-01T10:00:00Z","Action":"run","Test":"TestSlow","Package":"pkg/a"}
+`
+	finished := `{"Time":"2024-01-01T10:01:00Z","Action":"run","Test":"TestSlow","Package":"pkg/a"}
+{"Time":"2024-01-01T10:01:05Z","Action":"pass","Test":"TestSlow","Package":"pkg/a","Elapsed":5}
+`
+	for _, order := range [][]string{{cutOff, finished}, {finished, cutOff}} {
+		data, err := ParseShards(writeShards(t, order), 0, ParseOptions{})
+		if err != nil {
+			t.Fatalf("ParseShards: %v", err)
+		}
+		if status := data.Results["TestSlow"].Status; status != "PASS" || data.PassedTests != 1 {
+			t.Errorf("TestSlow: got %s, %d passed", status, data.PassedTests)
+		}
+		if len(data.Integrity.IncompleteTests) != 0 {
+			t.Errorf("incomplete tests: got %v", data.Integrity.IncompleteTests)
+		}
+	}
+}
+
+func TestParseShardsErrors(t *testing.T) {
+	paths := writeShards(t, []string{shardEvents("TestA", 0, "pass"), "not json\n"})
+	if _, err := ParseShards(paths, 0, ParseOptions{}); err == nil || !strings.Contains(err.Error(), "shard-1.json") {
+		t.Errorf("expected error naming the bad shard, got %v", err)
+	}
+
+	if _, err := ParseShards([]string{filepath.Join(t.TempDir(), "missing.json")}, 0, ParseOptions{}); err == nil {
+		t.Error("expected error for missing shard")
+	}
+}
@@ -0,0 +1,73 @@
+package report
+
+import (
+	"sort"
+	"strings"
+)
+
+// NoSkipReason is reported for tests skipped without a message
+const NoSkipReason = "(no reason given)"
+
+// SkipReason returns the message a skipped test passed to t.Skip, taken from
+// the last line it logged before the "--- SKIP" line.
+func SkipReason(output []string) string {
+	reason := ""
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--- SKIP") {
+			break
+		}
+		if strings.HasPrefix(trimmed, "=== ") || trimmed == "" {
+			continue
+		}
+		if m := testLogLine.FindStringSubmatch(line); m != nil {
+			reason = strings.TrimSpace(m[3])
+		} else if reason == "" {
+			reason = trimmed
+		}
+	}
+	if reason == "" {
+		return NoSkipReason
+	}
+	return reason
+}
+
+// SkipGroup is a skip reason shared by one or more tests
+type SkipGroup struct {
+	Reason string
+	Tests  []string
+}
+
+// SkipReasons groups the skipped tests, including subtests, by reason, most
+// common first.
+func SkipReasons(data *ReportData) []SkipGroup {
+	groups := make(map[string]*SkipGroup)
+	for _, name := range sortedResultNames(data) {
+		result := data.Results[name]
+		if result.Status != "SKIP" {
+			continue
+		}
+		reason := result.SkipReason
+		if reason == "" {
+			reason = NoSkipReason
+		}
+		group, exists := groups[reason]
+		if !exists {
+			group = &SkipGroup{Reason: reason}
+			groups[reason] = group
+		}
+		group.Tests = append(group.Tests, name)
+	}
+
+	sorted := make([]SkipGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, *group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i].Tests) != len(sorted[j].Tests) {
+			return len(sorted[i].Tests) > len(sorted[j].Tests)
+		}
+		return sorted[i].Reason < sorted[j].Reason
+	})
+	return sorted
+}
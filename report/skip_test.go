@@ -0,0 +1,51 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSkipReason(t *testing.T) {
+	tests := []struct {
+		output []string
+		want   string
+	}{
+		{[]string{"=== RUN   TestDocker", "    docker_test.go:12: requires docker", "--- SKIP: TestDocker (0.00s)"}, "requires docker"},
+		{[]string{"=== RUN   TestA", "    a_test.go:3: setting up", "    a_test.go:5: skipping in -short mode", "--- SKIP: TestA (0.00s)"}, "skipping in -short mode"},
+		{[]string{"=== RUN   TestB", "--- SKIP: TestB (0.00s)"}, NoSkipReason},
+		{nil, NoSkipReason},
+	}
+	for _, tt := range tests {
+		if got := SkipReason(tt.output); got != tt.want {
+			t.Errorf("SkipReason(%q): got %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestSkipReasons(t *testing.T) {
+	input := `{"Action":"run","Test":"TestDB","Package":"pkg"}
+{"Action":"output","Test":"TestDB","Package":"pkg","Output":"    db_test.go:9: requires docker\n"}
+{"Action":"skip","Test":"TestDB","Package":"pkg"}
+{"Action":"run","Test":"TestCache","Package":"pkg"}
+{"Action":"output","Test":"TestCache","Package":"pkg","Output":"    cache_test.go:4: requires docker\n"}
+{"Action":"skip","Test":"TestCache","Package":"pkg"}
+{"Action":"run","Test":"TestSlow","Package":"pkg"}
+{"Action":"output","Test":"TestSlow","Package":"pkg","Output":"    slow_test.go:2: skipping in short mode\n"}
+{"Action":"skip","Test":"TestSlow","Package":"pkg"}
+`
+	data, err := Parse(bytes.NewReader([]byte(input)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := data.Results["TestDB"].SkipReason; got != "requires docker" {
+		t.Errorf("TestDB SkipReason: got %q", got)
+	}
+
+	groups := SkipReasons(data)
+	if len(groups) != 2 || groups[0].Reason != "requires docker" || len(groups[0].Tests) != 2 {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+	if groups[1].Reason != "skipping in short mode" {
+		t.Errorf("second group: got %q", groups[1].Reason)
+	}
+}
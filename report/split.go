@@ -0,0 +1,67 @@
+package report
+
+import "strings"
+
+// SplitByPackage breaks a report into one report per package, keyed by import
+// path. Each part keeps the run-wide settings (sanitizer, variants,
+// environment) and only its own tests, shuffle seed, benchmarks and
+// incomplete tests.
+func SplitByPackage(data *ReportData) map[string]*ReportData {
+	parts := make(map[string]*ReportData)
+	part := func(pkg string) *ReportData {
+		if parts[pkg] == nil {
+			parts[pkg] = &ReportData{
+				Results:     make(map[string]*TestResult),
+				Integrity:   Integrity{IncompleteTests: []string{}},
+				Sanitizer:   data.Sanitizer,
+				Variants:    data.Variants,
+				Environment: data.Environment,
+			}
+			if seed, ok := data.ShuffleSeeds[pkg]; ok {
+				parts[pkg].ShuffleSeeds = map[string]string{pkg: seed}
+			}
+		}
+		return parts[pkg]
+	}
+
+	for name, result := range data.Results {
+		part(result.Package).Results[name] = result
+	}
+	for _, result := range data.Benchmarks {
+		p := part(result.Package)
+		p.Benchmarks = append(p.Benchmarks, result)
+	}
+	for _, name := range data.Integrity.IncompleteTests {
+		if result, ok := data.Results[name]; ok {
+			p := part(result.Package)
+			p.Integrity.IncompleteTests = append(p.Integrity.IncompleteTests, name)
+		}
+	}
+	for _, name := range data.UnquarantineCandidates {
+		if result, ok := data.Results[name]; ok {
+			p := part(result.Package)
+			p.UnquarantineCandidates = append(p.UnquarantineCandidates, name)
+		}
+	}
+
+	for _, p := range parts {
+		summarize(p)
+	}
+	return parts
+}
+
+// PackageFileName turns an import path into a file name without an
+// extension, e.g. "github.com/acme/app/internal/db" becomes
+// "github.com_acme_app_internal_db".
+func PackageFileName(pkg string) string {
+	if pkg == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, pkg)
+}
@@ -0,0 +1,59 @@
+package report
+
+import "testing"
+
+func TestSplitByPackage(t *testing.T) {
+	data := &ReportData{
+		Results: map[string]*TestResult{
+			"TestA":      {Name: "TestA", Package: "example.com/a", Status: "PASS", Duration: 1, SubTests: []string{"TestA/sub"}},
+			"TestA/sub":  {Name: "TestA/sub", Package: "example.com/a", Status: "PASS", IsSubTest: true},
+			"TestB":      {Name: "TestB", Package: "example.com/b", Status: "FAIL", Duration: 2},
+			"TestB2":     {Name: "TestB2", Package: "example.com/b", Status: "UNKNOWN"},
+			"TestCached": {Name: "TestCached", Package: "example.com/b", Status: "SKIP"},
+		},
+		Integrity:    Integrity{EventsParsed: 12, IncompleteTests: []string{"TestB2"}},
+		Sanitizer:    "race",
+		ShuffleSeeds: map[string]string{"example.com/b": "7"},
+		Benchmarks:   []BenchmarkResult{{Name: "BenchmarkA", Package: "example.com/a", Metrics: map[string]float64{"ns/op": 1}}},
+	}
+	summarize(data)
+
+	parts := SplitByPackage(data)
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 packages, got %d", len(parts))
+	}
+
+	a, b := parts["example.com/a"], parts["example.com/b"]
+	if a.TotalTests != 1 || a.PassedTests != 1 || len(a.Results) != 2 || a.TotalDuration != 1 {
+		t.Errorf("unexpected example.com/a report: %+v", a)
+	}
+	if b.TotalTests != 3 || b.FailedTests != 1 || b.SkippedTests != 1 || b.TotalDuration != 2 {
+		t.Errorf("unexpected example.com/b report: %+v", b)
+	}
+	if a.Sanitizer != "race" || b.Sanitizer != "race" {
+		t.Error("expected the sanitizer on every part")
+	}
+	if a.ShuffleSeeds != nil || b.ShuffleSeeds["example.com/b"] != "7" {
+		t.Errorf("shuffle seeds: got %v and %v", a.ShuffleSeeds, b.ShuffleSeeds)
+	}
+	if len(a.Benchmarks) != 1 || len(b.Benchmarks) != 0 {
+		t.Errorf("benchmarks: got %v and %v", a.Benchmarks, b.Benchmarks)
+	}
+	if len(a.Integrity.IncompleteTests) != 0 || len(b.Integrity.IncompleteTests) != 1 {
+		t.Errorf("incomplete tests: got %v and %v", a.Integrity.IncompleteTests, b.Integrity.IncompleteTests)
+	}
+}
+
+func TestPackageFileName(t *testing.T) {
+	tests := map[string]string{
+		"github.com/acme/app/internal/db": "github.com_acme_app_internal_db",
+		"example.com/v2/pkg-name":         "example.com_v2_pkg-name",
+		"":                                "_",
+		"weird path/ü":                    "weird_path__",
+	}
+	for pkg, want := range tests {
+		if got := PackageFileName(pkg); got != want {
+			t.Errorf("PackageFileName(%q): got %q, want %q", pkg, got, want)
+		}
+	}
+}
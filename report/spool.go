@@ -0,0 +1,159 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultSpoolThreshold is how much output a running test holds in memory
+// before the CLI spools the rest to a temporary file
+const DefaultSpoolThreshold = 8 * 1024 * 1024
+
+// ParseOptions controls how much of the input Parse keeps in memory
+type ParseOptions struct {
+	// Keep the output of passing tests. Without it, a test's output is
+	// dropped as soon as it passes, which bounds memory to the output of
+	// running and failed tests.
+	KeepPassOutput bool
+	// Bytes of output a test may hold in memory; anything beyond is spooled
+	// to a temporary file until the test finishes. 0 never spools.
+	SpoolThreshold int
+	// Longest line read into memory as a whole (DefaultMaxLineBytes when 0).
+	// Longer lines are decoded from the stream by encoding/json instead of
+	// failing the parse.
+	MaxLineBytes int
+	// Skip lines that aren't go test -json events, e.g. make output mixed
+	// into the log, counting them in Integrity.LinesSkipped instead of
+	// failing the parse
+	Lenient bool
+	// Import path of the package whose raw test binary output (e.g.
+	// ./pkg.test -test.v) is parsed. Test binaries don't name their package
+	// the way go test does; without it their tests have no package.
+	Package string
+	// Format of the input: InputGo (the default when empty), InputCTRF or
+	// InputJUnit
+	Format string
+	// Record when each test was running, between its run or cont and its
+	// pause or result, in TestResult.Spans for BuildTimeline
+	Timeline bool
+	// Called every few thousand events with the number of events and input
+	// bytes (as read, before decompression) parsed since the previous call,
+	// and once more when the input ends, so callers can show progress on
+	// large logs. ParseShards calls it from several goroutines at once.
+	Progress func(events int, bytes int64)
+}
+
+// Input formats for ParseOptions.Format
+const (
+	// go test -json events, go test -v text, JUnit XML or CTRF JSON, told
+	// apart automatically
+	InputGo = "go"
+	// CTRF JSON (https://ctrf.io), as written by reporters for Jest,
+	// Playwright, Cypress, pytest and others
+	InputCTRF = "ctrf"
+	// JUnit XML as written by go-junit-report, gotestsum, Maven Surefire,
+	// pytest and most other test runners
+	InputJUnit = "junit"
+)
+
+// outputBuffer collects the output lines of one test, spilling them to a
+// temporary file once they outgrow the spool threshold
+type outputBuffer struct {
+	lines []string
+	size  int
+	spool *os.File
+	w     *bufio.Writer
+	err   error // the first spooling error, reported by lines
+}
+
+// add appends a line, spooling once the buffer holds more than threshold bytes
+func (b *outputBuffer) add(line string, threshold int) {
+	if b.spool != nil {
+		b.write(line)
+		return
+	}
+	b.lines = append(b.lines, line)
+	b.size += len(line)
+	if threshold > 0 && b.size > threshold {
+		b.spill()
+	}
+}
+
+// spill moves the buffered lines to a new temporary file
+func (b *outputBuffer) spill() {
+	file, err := os.CreateTemp("", "gotest-report-output-*")
+	if err != nil {
+		// Keep going in memory rather than losing output
+		b.size = 0
+		return
+	}
+	b.spool, b.w = file, bufio.NewWriterSize(file, 64*1024)
+	for _, line := range b.lines {
+		b.write(line)
+	}
+	b.lines, b.size = nil, 0
+}
+
+// write appends one line to the spool file, quoted so embedded newlines and
+// invalid UTF-8 survive the round trip
+func (b *outputBuffer) write(line string) {
+	if b.err != nil {
+		return
+	}
+	if _, err := b.w.WriteString(strconv.Quote(line) + "\n"); err != nil {
+		b.err = fmt.Errorf("error spooling test output: %v", err)
+	}
+}
+
+// result returns every collected line, reading back spooled output, and
+// releases the spool file
+func (b *outputBuffer) result() ([]string, error) {
+	if b.spool == nil {
+		return b.lines, nil
+	}
+	defer b.discard()
+	if b.err != nil {
+		return nil, b.err
+	}
+	if err := b.w.Flush(); err != nil {
+		return nil, fmt.Errorf("error spooling test output: %v", err)
+	}
+	if _, err := b.spool.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("error reading spooled test output: %v", err)
+	}
+
+	// Spooled lines can be longer than any input line limit, so they are
+	// read without one
+	var lines []string
+	r := bufio.NewReader(b.spool)
+	for {
+		quoted, err := r.ReadString('\n')
+		if quoted != "" {
+			line, uerr := strconv.Unquote(strings.TrimSuffix(quoted, "\n"))
+			if uerr != nil {
+				return nil, fmt.Errorf("error reading spooled test output: %v", uerr)
+			}
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading spooled test output: %v", err)
+		}
+	}
+}
+
+// discard drops the collected output and removes the spool file
+func (b *outputBuffer) discard() {
+	if b.spool != nil {
+		b.spool.Close()
+		os.Remove(b.spool.Name())
+		b.spool, b.w = nil, nil
+	}
+	b.lines, b.size = nil, 0
+}
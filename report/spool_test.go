@@ -0,0 +1,47 @@
+package report
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestOutputBufferSpool(t *testing.T) {
+	lines := []string{"first line\n", "tab\tand \"quotes\"\n", "no newline", "\xff invalid utf-8\n"}
+
+	var b outputBuffer
+	for _, line := range lines {
+		b.add(line, 16)
+	}
+	if b.spool == nil {
+		t.Fatal("expected output past the threshold to be spooled")
+	}
+	name := b.spool.Name()
+
+	got, err := b.result()
+	if err != nil {
+		t.Fatalf("result: %v", err)
+	}
+	if !reflect.DeepEqual(got, lines) {
+		t.Errorf("lines: got %q, want %q", got, lines)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("spool file %s still exists after result", name)
+	}
+}
+
+func TestOutputBufferDiscard(t *testing.T) {
+	var b outputBuffer
+	b.add("some output\n", 1)
+	name := b.spool.Name()
+	b.discard()
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("spool file %s still exists after discard", name)
+	}
+
+	var inMemory outputBuffer
+	inMemory.add("some output\n", 0)
+	if inMemory.spool != nil {
+		t.Error("threshold 0 must never spool")
+	}
+}
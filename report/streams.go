@@ -0,0 +1,107 @@
+package report
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// MergeStreams writes the go test -json events of several streams to w as one
+// stream ordered by event time, so the result reads like a single go test run.
+// Each stream is expected to be in time order already, as go test writes it,
+// and compressed streams are unwrapped as in Parse. Lines without a time, such
+// as output that isn't JSON, stay right after the line they followed; events
+// with equal times keep the order of the streams.
+func MergeStreams(w io.Writer, readers []io.Reader) error {
+	out := bufio.NewWriter(w)
+	h := &streamHeap{}
+	for i, reader := range readers {
+		reader, err := Decompress(reader)
+		if err != nil {
+			return err
+		}
+		s := &mergeStream{index: i, reader: bufio.NewReader(reader)}
+		if ok, err := s.next(); err != nil {
+			return err
+		} else if ok {
+			heap.Push(h, s)
+		}
+	}
+
+	for h.Len() > 0 {
+		s := (*h)[0]
+		if _, err := out.Write(s.line); err != nil {
+			return err
+		}
+		ok, err := s.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return out.Flush()
+}
+
+// mergeStream is one input of MergeStreams with its next line
+type mergeStream struct {
+	index  int
+	reader *bufio.Reader
+	line   []byte
+	// Time of line, or of the last line before it that had one
+	time time.Time
+}
+
+// next reads the next line, reporting false at the end of the stream
+func (s *mergeStream) next() (bool, error) {
+	line, err := s.reader.ReadBytes('\n')
+	if len(line) == 0 {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, err
+	}
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	if line[len(line)-1] != '\n' {
+		line = append(line, '\n')
+	}
+
+	var event struct {
+		Time time.Time
+	}
+	if json.Unmarshal(line, &event) == nil && !event.Time.IsZero() {
+		s.time = event.Time
+	}
+	s.line = line
+	return true, nil
+}
+
+// streamHeap orders streams by the time of their next line
+type streamHeap []*mergeStream
+
+func (h streamHeap) Len() int { return len(h) }
+
+func (h streamHeap) Less(i, j int) bool {
+	if !h[i].time.Equal(h[j].time) {
+		return h[i].time.Before(h[j].time)
+	}
+	return h[i].index < h[j].index
+}
+
+func (h streamHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *streamHeap) Push(x any) { *h = append(*h, x.(*mergeStream)) }
+
+func (h *streamHeap) Pop() any {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
@@ -0,0 +1,31 @@
+package report
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMergeStreams(t *testing.T) {
+	a := `{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"pkg/a","Test":"TestA"}
+make: entering directory
+{"Time":"2024-01-01T00:00:04Z","Action":"pass","Package":"pkg/a","Test":"TestA"}`
+	b := `{"Time":"2024-01-01T00:00:02Z","Action":"run","Package":"pkg/b","Test":"TestB"}
+{"Time":"2024-01-01T00:00:04Z","Action":"fail","Package":"pkg/b","Test":"TestB"}
+`
+	var out strings.Builder
+	if err := MergeStreams(&out, []io.Reader{strings.NewReader(a), strings.NewReader(b)}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"pkg/a","Test":"TestA"}`,
+		`make: entering directory`,
+		`{"Time":"2024-01-01T00:00:02Z","Action":"run","Package":"pkg/b","Test":"TestB"}`,
+		`{"Time":"2024-01-01T00:00:04Z","Action":"pass","Package":"pkg/a","Test":"TestA"}`,
+		`{"Time":"2024-01-01T00:00:04Z","Action":"fail","Package":"pkg/b","Test":"TestB"}`,
+	}
+	if got := out.String(); got != strings.Join(want, "\n")+"\n" {
+		t.Errorf("merged stream:\ngot:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
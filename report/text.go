@@ -0,0 +1,213 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Markers a test binary run with -test.v=test2json adds to its output:
+// frameMarker starts the lines about its tests, which may follow output
+// lacking a newline, and errors reported with t.Error are bracketed by
+// errorStart and errorEnd
+const (
+	frameMarker = "\x16"
+	errorStart  = "\x0f"
+	errorEnd    = "\x0e"
+)
+
+var (
+	// "--- FAIL: TestName (0.12s)", indented for subtests
+	textResultLine = regexp.MustCompile(`^(\s*)--- (PASS|FAIL|SKIP): (\S+) \(([\d.]+)s\)`)
+	// "=== RUN   TestName", and PAUSE, CONT and NAME for parallel tests; NAME
+	// is printed without one when output goes back to the package
+	textMarkerLine = regexp.MustCompile(`^=== (RUN|PAUSE|CONT|NAME)\s*(\S*)`)
+	// "ok  	pkg	0.12s", "FAIL	pkg	[build failed]", "?   	pkg	[no test files]"
+	textPackageLine = regexp.MustCompile(`^(ok|FAIL|\?)\s+(\S+)\s+(\(cached\)|[\d.]+s|\[[^\]]*\])`)
+)
+
+// isTextLog reports whether the start of an input is classic go test -v text,
+// or the raw output of a test binary, rather than go test -json events: the
+// first line that is either a JSON object or a line go test prints decides.
+// Anything else, e.g. make output, is skipped over, and input that never
+// decides is treated as JSON.
+func isTextLog(head []byte) bool {
+	for len(head) > 0 {
+		line := head
+		if i := bytes.IndexByte(head, '\n'); i >= 0 {
+			line, head = head[:i], head[i+1:]
+		} else {
+			head = nil
+		}
+		line = bytes.TrimPrefix(line, []byte(frameMarker))
+		trimmed := bytes.TrimSpace(line)
+		switch {
+		case len(trimmed) == 0:
+		case trimmed[0] == '{':
+			return false
+		case textMarkerLine.Match(line), textResultLine.Match(line), textPackageLine.Match(line),
+			string(trimmed) == "PASS", string(trimmed) == "FAIL":
+			return true
+		}
+	}
+	return false
+}
+
+// textParser turns go test -v text into the events test2json would have
+// produced for it. go test only names the package in the "ok" or "FAIL" line
+// printed after its tests, so events are held back until then. A test binary
+// prints no such line at all; its output ends with a bare "PASS" or "FAIL"
+// and its package comes from ParseOptions.
+type textParser struct {
+	emit    func(event TestEvent) error
+	pending []TestEvent
+	// The last bare "PASS" or "FAIL" line not yet followed by a package line
+	result string
+
+	// The test that output is attributed to: the last one that started or
+	// continued, or the last one that reported its result
+	current string
+	// Results printed since the last marker, with their indentation; lines
+	// indented deeper than a result belong to its test
+	results []textResult
+}
+
+type textResult struct {
+	indent int
+	test   string
+}
+
+// readText aggregates the go test -v text of reader
+func (a *aggregator) readText(reader io.Reader) error {
+	p := &textParser{emit: func(event TestEvent) error {
+		a.event = event
+		return a.addEvent()
+	}}
+	br := bufio.NewReader(reader)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			// Framing lines of -test.v=test2json can start mid-line
+			line = strings.NewReplacer(errorStart, "", errorEnd, "").Replace(strings.TrimRight(line, "\r\n"))
+			parts := strings.Split(line, frameMarker)
+			for _, part := range parts {
+				if part == "" && len(parts) > 1 {
+					continue
+				}
+				if perr := p.line(part); perr != nil {
+					return perr
+				}
+			}
+		}
+		if err == io.EOF {
+			return p.finishBinary(a.opts.Package)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// line handles one line of go test -v output
+func (p *textParser) line(line string) error {
+	if m := textPackageLine.FindStringSubmatch(line); m != nil {
+		return p.finishPackage(line, m[1], m[2], m[3])
+	}
+
+	if m := textMarkerLine.FindStringSubmatch(line); m != nil {
+		p.current, p.results = m[2], nil
+		switch m[1] {
+		case "NAME":
+			// Only says whose output follows; test2json drops it
+			return nil
+		case "RUN":
+			p.add(TestEvent{Action: "run", Test: m[2]})
+		}
+		p.add(TestEvent{Action: "output", Test: m[2], Output: line})
+		return nil
+	}
+
+	if m := textResultLine.FindStringSubmatch(line); m != nil {
+		elapsed, _ := strconv.ParseFloat(m[4], 64)
+		// test2json reports subtest results without their indentation
+		p.add(TestEvent{Action: "output", Test: m[3], Output: strings.TrimLeft(line, " \t")})
+		p.add(TestEvent{Action: strings.ToLower(m[2]), Test: m[3], Elapsed: elapsed})
+		indent := len(m[1])
+		for len(p.results) > 0 && p.results[len(p.results)-1].indent >= indent {
+			p.results = p.results[:len(p.results)-1]
+		}
+		p.results = append(p.results, textResult{indent: indent, test: m[3]})
+		p.current = m[3]
+		return nil
+	}
+
+	switch result := strings.TrimSpace(line); result {
+	case "PASS", "FAIL":
+		// The package's overall result, printed after its last test
+		p.current, p.results = "", nil
+		p.result = result
+	}
+	p.add(TestEvent{Action: "output", Test: p.owner(line), Output: line})
+	return nil
+}
+
+// owner returns the test an output line belongs to
+func (p *textParser) owner(line string) string {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	for i := len(p.results) - 1; i >= 0; i-- {
+		if p.results[i].indent < indent {
+			return p.results[i].test
+		}
+	}
+	return p.current
+}
+
+func (p *textParser) add(event TestEvent) {
+	p.pending = append(p.pending, event)
+}
+
+// finishPackage emits the package's events followed by its result
+func (p *textParser) finishPackage(line, result, pkg, detail string) error {
+	action := "pass"
+	switch result {
+	case "FAIL":
+		action = "fail"
+	case "?":
+		action = "skip"
+	}
+	elapsed, _ := strconv.ParseFloat(strings.TrimSuffix(detail, "s"), 64)
+
+	p.add(TestEvent{Action: "output", Output: line})
+	p.add(TestEvent{Action: action, Elapsed: elapsed})
+	p.current, p.results, p.result = "", nil, ""
+	return p.flush(pkg)
+}
+
+// finishBinary emits the events left at the end of the input as those of
+// pkg: the output of a test binary, or go test output cut off before its
+// package line
+func (p *textParser) finishBinary(pkg string) error {
+	switch p.result {
+	case "PASS":
+		p.add(TestEvent{Action: "pass"})
+	case "FAIL":
+		p.add(TestEvent{Action: "fail"})
+	}
+	p.result = ""
+	return p.flush(pkg)
+}
+
+// flush emits the held back events as events of pkg
+func (p *textParser) flush(pkg string) error {
+	for _, event := range p.pending {
+		event.Package = pkg
+		if err := p.emit(event); err != nil {
+			return err
+		}
+	}
+	p.pending = p.pending[:0]
+	return nil
+}
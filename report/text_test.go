@@ -0,0 +1,138 @@
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const verboseTextInput = `=== RUN   TestOK
+--- PASS: TestOK (0.01s)
+=== RUN   TestBad
+=== RUN   TestBad/sub
+    a_test.go:7: expected 1, got 2
+--- FAIL: TestBad (0.20s)
+    --- FAIL: TestBad/sub (0.10s)
+=== RUN   TestSkip
+    a_test.go:9: requires docker
+--- SKIP: TestSkip (0.00s)
+=== RUN   ExampleHello
+--- FAIL: ExampleHello (0.00s)
+got:
+hello
+want:
+bye
+FAIL
+FAIL	demo/a	0.003s
+=== RUN   TestParallel
+=== PAUSE TestParallel
+=== RUN   TestOther
+--- PASS: TestOther (0.00s)
+=== CONT  TestParallel
+    b_test.go:5: parallel log
+--- PASS: TestParallel (1.50s)
+PASS
+ok  	demo/b	1.6s
+?   	demo/c	[no test files]
+FAIL
+`
+
+func TestParseVerboseText(t *testing.T) {
+	data, err := Parse(strings.NewReader(verboseTextInput))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if data.TotalTests != 6 || data.PassedTests != 3 || data.FailedTests != 2 || data.SkippedTests != 1 {
+		t.Errorf("got %d total, %d passed, %d failed, %d skipped",
+			data.TotalTests, data.PassedTests, data.FailedTests, data.SkippedTests)
+	}
+
+	tests := []struct {
+		name, pkg, status string
+		duration          float64
+		output            []string
+	}{
+		{"TestBad/sub", "demo/a", "FAIL", 0.1, []string{"=== RUN   TestBad/sub", "    a_test.go:7: expected 1, got 2", "--- FAIL: TestBad/sub (0.10s)"}},
+		{"TestSkip", "demo/a", "SKIP", 0, []string{"=== RUN   TestSkip", "    a_test.go:9: requires docker", "--- SKIP: TestSkip (0.00s)"}},
+		{"ExampleHello", "demo/a", "FAIL", 0, []string{"=== RUN   ExampleHello", "--- FAIL: ExampleHello (0.00s)", "got:", "hello", "want:", "bye"}},
+		{"TestParallel", "demo/b", "PASS", 1.5, []string{"=== RUN   TestParallel", "=== PAUSE TestParallel", "=== CONT  TestParallel", "    b_test.go:5: parallel log", "--- PASS: TestParallel (1.50s)"}},
+	}
+	for _, tt := range tests {
+		result := data.Results[tt.name]
+		if result == nil {
+			t.Errorf("%s: missing", tt.name)
+			continue
+		}
+		if result.Package != tt.pkg || result.Status != tt.status || result.Duration != tt.duration {
+			t.Errorf("%s: got %s %s %gs, want %s %s %gs", tt.name, result.Package, result.Status, result.Duration, tt.pkg, tt.status, tt.duration)
+		}
+		if !reflect.DeepEqual(result.Output, tt.output) {
+			t.Errorf("%s output: got %q, want %q", tt.name, result.Output, tt.output)
+		}
+	}
+	if got := data.Results["TestSkip"].SkipReason; got != "requires docker" {
+		t.Errorf("skip reason: got %q", got)
+	}
+}
+
+func TestIsTextLog(t *testing.T) {
+	tests := []struct {
+		head string
+		want bool
+	}{
+		{"=== RUN   TestA\n", true},
+		{"go: downloading example.com/x v1.0.0\n--- FAIL: TestA (0.00s)\n", true},
+		{"?   \tdemo/c\t[no test files]\n", true},
+		{`{"Action":"run","Test":"TestA"}` + "\n=== RUN   TestA\n", false},
+		{"make[1]: Entering directory '/src'\n" + `{"Action":"start"}`, false},
+		{"not a test log\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isTextLog([]byte(tt.head)); got != tt.want {
+			t.Errorf("isTextLog(%q): got %v, want %v", tt.head, got, tt.want)
+		}
+	}
+}
+
+func TestParseTestBinaryOutput(t *testing.T) {
+	// ./a.test -test.v=test2json: framing lines start with ^V, possibly after
+	// output lacking a newline, and t.Error output is bracketed by ^O and ^N
+	input := "\x16=== RUN   TestOK\n" +
+		"no newline\x16--- PASS: TestOK (0.25s)\n" +
+		"\x16=== NAME  \n" +
+		"\x16=== RUN   TestBad\n" +
+		"\x0f    a_test.go:7: expected 1, got 2\x0e\n" +
+		"\x16--- FAIL: TestBad (0.00s)\n" +
+		"\x16=== NAME  \n" +
+		"\x16FAIL\n"
+
+	data, err := ParseWithOptions(strings.NewReader(input), ParseOptions{KeepPassOutput: true, Package: "demo/a"})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if data.PassedTests != 1 || data.FailedTests != 1 || len(data.Integrity.IncompleteTests) != 0 {
+		t.Errorf("got %d passed, %d failed, incomplete %v", data.PassedTests, data.FailedTests, data.Integrity.IncompleteTests)
+	}
+
+	ok, bad := data.Results["TestOK"], data.Results["TestBad"]
+	if ok.Package != "demo/a" || ok.Duration != 0.25 {
+		t.Errorf("TestOK: got %s %gs", ok.Package, ok.Duration)
+	}
+	if want := []string{"=== RUN   TestOK", "no newline", "--- PASS: TestOK (0.25s)"}; !reflect.DeepEqual(ok.Output, want) {
+		t.Errorf("TestOK output: got %q, want %q", ok.Output, want)
+	}
+	if want := []string{"=== RUN   TestBad", "    a_test.go:7: expected 1, got 2", "--- FAIL: TestBad (0.00s)"}; !reflect.DeepEqual(bad.Output, want) {
+		t.Errorf("TestBad output: got %q, want %q", bad.Output, want)
+	}
+
+	// Plain -test.v output without a package still parses
+	data, err = Parse(strings.NewReader("=== RUN   TestOK\n--- PASS: TestOK (0.00s)\nPASS\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if result := data.Results["TestOK"]; result.Status != "PASS" || result.Package != "" {
+		t.Errorf("TestOK: got %+v", result)
+	}
+}
@@ -0,0 +1,149 @@
+package report
+
+import (
+	"sort"
+	"time"
+)
+
+// Span is a stretch of time during which a test was running
+type Span struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Timeline shows when tests ran and how many ran at once. Only tests
+// without subtests count, since a parent test mostly waits for its subtests.
+type Timeline struct {
+	Start time.Time
+	End   time.Time
+	// The most tests running at once
+	Peak int
+	// Tests running on average while any test was
+	Average float64
+	// Seconds during which exactly one test was running
+	SerialSeconds float64
+	// Seconds during which at least one test was running
+	BusySeconds float64
+	// Average number of tests running in each of the equal slices of the
+	// run BuildTimeline was asked for
+	Profile []float64
+	// The tests that ran, ordered by when they started
+	Tests []TimelineTest
+}
+
+// TimelineTest is a test with the spans during which it ran
+type TimelineTest struct {
+	Name    string
+	Package string
+	Spans   []Span
+}
+
+// Seconds returns the total time the test was running
+func (t TimelineTest) Seconds() float64 {
+	var seconds float64
+	for _, span := range t.Spans {
+		seconds += span.End.Sub(span.Start).Seconds()
+	}
+	return seconds
+}
+
+// addSpan tracks when a test starts, pauses, continues and finishes
+func (a *aggregator) addSpan(result *TestResult, event *TestEvent) {
+	if result == nil {
+		return
+	}
+	switch event.Action {
+	case "run", "cont":
+		if a.running == nil {
+			a.running = make(map[string]time.Time)
+		}
+		a.running[result.Name] = event.Time
+	case "pause", "pass", "fail", "skip":
+		start, ok := a.running[result.Name]
+		if !ok {
+			return
+		}
+		delete(a.running, result.Name)
+		if !event.Time.Before(start) {
+			result.Spans = append(result.Spans, Span{Start: start, End: event.Time})
+		}
+	}
+}
+
+// BuildTimeline computes the timeline of a run parsed with
+// ParseOptions.Timeline, with its concurrency profile in slices equal
+// parts. It returns nil when the input had no timestamps.
+func BuildTimeline(data *ReportData, slices int) *Timeline {
+	timeline := &Timeline{}
+	type change struct {
+		at    time.Time
+		delta int
+	}
+	var changes []change
+	for _, name := range sortedResultNames(data) {
+		result := data.Results[name]
+		if len(result.SubTests) > 0 || len(result.Spans) == 0 {
+			continue
+		}
+		timeline.Tests = append(timeline.Tests, TimelineTest{Name: name, Package: result.Package, Spans: result.Spans})
+		for _, span := range result.Spans {
+			changes = append(changes, change{span.Start, 1}, change{span.End, -1})
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	sort.SliceStable(timeline.Tests, func(i, j int) bool {
+		return timeline.Tests[i].Spans[0].Start.Before(timeline.Tests[j].Spans[0].Start)
+	})
+	// A test finishing as another starts doesn't overlap it
+	sort.Slice(changes, func(i, j int) bool {
+		if !changes[i].at.Equal(changes[j].at) {
+			return changes[i].at.Before(changes[j].at)
+		}
+		return changes[i].delta < changes[j].delta
+	})
+	timeline.Start, timeline.End = changes[0].at, changes[len(changes)-1].at
+
+	total := timeline.End.Sub(timeline.Start).Seconds()
+	timeline.Profile = make([]float64, max(slices, 0))
+	var weighted float64
+	running := 0
+	for i, c := range changes {
+		if i > 0 && running > 0 {
+			from := changes[i-1].at.Sub(timeline.Start).Seconds()
+			to := c.at.Sub(timeline.Start).Seconds()
+			timeline.BusySeconds += to - from
+			weighted += float64(running) * (to - from)
+			if running == 1 {
+				timeline.SerialSeconds += to - from
+			}
+			addToProfile(timeline.Profile, total, from, to, running)
+		}
+		running += c.delta
+		timeline.Peak = max(timeline.Peak, running)
+	}
+	if timeline.BusySeconds > 0 {
+		timeline.Average = weighted / timeline.BusySeconds
+	}
+	return timeline
+}
+
+// addToProfile spreads running tests over the seconds from and to of a run
+// lasting total seconds across the slices of profile
+func addToProfile(profile []float64, total, from, to float64, running int) {
+	if len(profile) == 0 || total <= 0 {
+		return
+	}
+	width := total / float64(len(profile))
+	for i := min(int(from/width), len(profile)-1); i < len(profile); i++ {
+		start, end := float64(i)*width, float64(i+1)*width
+		if start >= to {
+			break
+		}
+		overlap := min(end, to) - max(start, from)
+		if overlap > 0 {
+			profile[i] += float64(running) * overlap / width
+		}
+	}
+}
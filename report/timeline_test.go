@@ -0,0 +1,55 @@
+package report
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const timelineInput = `{"Time":"2024-01-01T10:00:00Z","Action":"run","Test":"TestA","Package":"pkg/a"}
+{"Time":"2024-01-01T10:00:01Z","Action":"pause","Test":"TestA","Package":"pkg/a"}
+{"Time":"2024-01-01T10:00:01Z","Action":"run","Test":"TestB","Package":"pkg/a"}
+{"Time":"2024-01-01T10:00:01Z","Action":"run","Test":"TestB/sub","Package":"pkg/a"}
+{"Time":"2024-01-01T10:00:03Z","Action":"pass","Test":"TestB/sub","Package":"pkg/a","Elapsed":2}
+{"Time":"2024-01-01T10:00:03Z","Action":"pass","Test":"TestB","Package":"pkg/a","Elapsed":2}
+{"Time":"2024-01-01T10:00:02Z","Action":"cont","Test":"TestA","Package":"pkg/a"}
+{"Time":"2024-01-01T10:00:04Z","Action":"pass","Test":"TestA","Package":"pkg/a","Elapsed":4}
+{"Time":"2024-01-01T10:00:04Z","Action":"pass","Package":"pkg/a","Elapsed":4}
+`
+
+func TestBuildTimeline(t *testing.T) {
+	data, err := ParseWithOptions(strings.NewReader(timelineInput), ParseOptions{Timeline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(data.Results["TestA"].Spans); got != 2 {
+		t.Fatalf("TestA: got %d spans, want 2 around its pause", got)
+	}
+
+	timeline := BuildTimeline(data, 4)
+	if timeline == nil {
+		t.Fatal("got no timeline")
+	}
+	// TestA runs 0-1s and 2-4s, TestB/sub 1-3s; TestB only waits for its subtest
+	if len(timeline.Tests) != 2 || timeline.Tests[0].Name != "TestA" || timeline.Tests[1].Name != "TestB/sub" {
+		t.Errorf("tests: got %+v", timeline.Tests)
+	}
+	if timeline.Peak != 2 || timeline.BusySeconds != 4 || timeline.SerialSeconds != 3 || timeline.Average != 1.25 {
+		t.Errorf("got peak %d, busy %vs, serial %vs, average %v", timeline.Peak, timeline.BusySeconds, timeline.SerialSeconds, timeline.Average)
+	}
+	want := []float64{1, 1, 2, 1}
+	for i := range want {
+		if math.Abs(timeline.Profile[i]-want[i]) > 1e-9 {
+			t.Errorf("profile: got %v, want %v", timeline.Profile, want)
+			break
+		}
+	}
+
+	data, err = Parse(strings.NewReader(timelineInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if BuildTimeline(data, 4) != nil {
+		t.Error("got a timeline without ParseOptions.Timeline")
+	}
+}
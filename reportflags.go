@@ -0,0 +1,533 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// reportFlags are the flags report and run share: how the report is rendered
+// and what is done with the results once the tests are in
+type reportFlags struct {
+	output                   *string
+	format                   *string
+	configFile               *string
+	historyFile              *string
+	historySize              *int
+	historyRuns              *int
+	trendRuns                *int
+	testLabels               *string
+	dbFile                   *string
+	dbRun                    *string
+	quarantineFile           *string
+	unquarantineAfter        *int
+	embedSource              *bool
+	sourceContext            *int
+	blame                    *bool
+	triageChecklist          *bool
+	redactSecrets            *bool
+	outputFilters            outputFilterList
+	workspace                *string
+	indexSources             *bool
+	minPassRate              *float64
+	maxFailures              *int
+	maxSkipped               *int
+	baselineFile             *string
+	fileIssues               *bool
+	issueLabels              *string
+	saveBaseline             *string
+	maxDurationIncrease      *float64
+	minTestDuration          *float64
+	durationRegressions      *string
+	durationBudgets          *string
+	target                   *string
+	splitBy                  *string
+	splitSize                *int
+	slowestPackages          *int
+	topSlow                  *int
+	subTestSpaces            *bool
+	aggregateParentDurations *bool
+	slowAnnotations          *bool
+	slowThreshold            *time.Duration
+	includePassOutput        *bool
+	timeline                 *bool
+	mermaid                  *bool
+	environment              *bool
+	summary                  *bool
+	colorMode                *string
+	bitbucket                *bool
+	datadog                  *bool
+	allureResults            *string
+	commitStatus             *string
+	reviewComments           *bool
+	badgeFile                *string
+	influxURL                *string
+	reportPortalURL          *string
+	reportPortalProject      *string
+	reportPortalToken        *string
+	reportPortalLaunch       *string
+	testRailURL              *string
+	testRailUser             *string
+	testRailProject          *int
+	testRailSuite            *int
+	testRailRun              *int
+	testRailMap              *string
+	webhookURL               *string
+	webhookTemplate          *string
+	webhookHeaders           headerList
+	upload                   *string
+	uploadExpires            *time.Duration
+	gitlab                   *bool
+}
+
+// addReportFlags registers the flags report and run share on fs
+func addReportFlags(fs *flag.FlagSet) *reportFlags {
+	f := &reportFlags{}
+	f.output = fs.String("output", "test-report.md", "Output markdown file (use - for stdout)")
+	f.format = fs.String("format", "markdown", "Report format: markdown, json, ndjson, html, junit, xunit, nunit3, trx, pdf, jira, xlsx or influx")
+	f.configFile = fs.String("config", "", "JSON config file (package display names, ...)")
+	f.historyFile = fs.String("history", "", "JSON file recording results of previous runs (created if missing)")
+	f.historySize = fs.Int("history-size", 50, "Maximum number of runs kept in the history file")
+	f.historyRuns = fs.Int("history-runs", 10, "Latest runs of the -history file shown as a pass/fail sparkline next to each test in the results table (0 leaves it out)")
+	f.testLabels = fs.String("test-label", "", "Only report tests labelled with one of these comma-separated labels, as logged with \""+report.LabelMarker+"...\"")
+	f.trendRuns = fs.Int("trend-runs", 20, "Latest runs of the -history file charted in the pass rate and duration trend (0 leaves it out)")
+	f.dbFile = fs.String("db", "", "SQLite database recording the run, its packages and test results (created if missing; needs sqlite3)")
+	f.dbRun = fs.String("db-run", "", "Id of the run in -db; recording the same id again replaces it (default: the current time)")
+	f.quarantineFile = fs.String("quarantine", "", "File listing quarantined test names, one per line")
+	f.unquarantineAfter = fs.Int("unquarantine-after", 5, "Suggest un-quarantining tests that passed in this many consecutive recorded runs")
+	f.embedSource = fs.Bool("embed-source", false, "Include the test source around file:line references in failure details")
+	f.sourceContext = fs.Int("context", 3, "Lines of source shown before and after each reference with -embed-source")
+	f.blame = fs.Bool("blame", false, "Name the latest commit to each failed test's function in failure details, per git blame")
+	f.triageChecklist = fs.Bool("triage-checklist", false, "Append a task list of the failed tests to the report, naming their owners per CODEOWNERS, to track triage in the comment")
+	f.redactSecrets = fs.Bool("redact-secrets", true, "Mask AWS keys, Authorization headers, bearer tokens and GitHub tokens in captured output, on top of the redact patterns of the -config file")
+	fs.Var(&f.outputFilters, "output-filter", "Drop log noise from captured output: drop=REGEX drops matching lines, level=LEVEL (e.g. warn) log lines below LEVEL; repeat for more, on top of the outputFilter rules of the -config file")
+	f.workspace = fs.String("workspace", "", "go.work file of a multi-module repository, for per-module counts (default: the enclosing go.work, else every go.mod in the repository)")
+	f.indexSources = fs.Bool("source-index", false, "Find where each test is defined with go list; show its doc comment in the results table and both in failure details")
+	f.minPassRate = fs.Float64("min-pass-rate", -1, "Quality gate: fail when fewer than this percent of tests passed (-1 disables)")
+	f.maxFailures = fs.Int("max-failures", -1, "Quality gate: fail when more than this many tests failed, not counting quarantined ones (-1 disables)")
+	f.maxSkipped = fs.Int("max-skipped", -1, "Quality gate: fail when more than this many tests were skipped (-1 disables)")
+	f.baselineFile = fs.String("baseline", "", "Baseline summary written by -save-baseline; the report lists new, fixed and still failing tests")
+	f.fileIssues = fs.Bool("file-issues", false, "With -baseline, open or update a GitHub issue per fingerprint of the new failures (needs GITHUB_TOKEN)")
+	f.issueLabels = fs.String("issue-labels", "test-failure", "Comma-separated labels of the issues -file-issues opens; the first one is used to find them again")
+	f.saveBaseline = fs.String("save-baseline", "", "Write a baseline summary of this run to this file for later comparisons")
+	f.maxDurationIncrease = fs.Float64("max-duration-increase", -1, "With -baseline, flag the total and tests more than this percent slower than the baseline (-1 disables)")
+	f.minTestDuration = fs.Float64("min-test-duration", 0.1, "Seconds a test must take to be checked for duration regressions")
+	f.durationRegressions = fs.String("duration-regressions", "warn", "What duration regressions do: warn (report only) or fail (quality gate)")
+	f.durationBudgets = fs.String("duration-budgets", "warn", "What packages over their durationBudgets in the -config file do: warn (report only) or fail (quality gate)")
+	f.target = fs.String("target", "file", "Where the Markdown report goes: file, comment (trimmed to 65,536 characters), step-summary (trimmed to 1 MiB) or gitlab-note (trimmed to 1,000,000 characters)")
+	f.splitBy = fs.String("split-by", "", "Write one Markdown report per package plus an index at -output: package")
+	f.splitSize = fs.Int("split-size", 0, "Split the report by package when it would be larger than this many bytes (0 never splits)")
+	f.slowestPackages = fs.Int("slowest-packages", 10, "Rows in the slowest packages table (0 leaves it out)")
+	f.topSlow = fs.Int("top-slow", 15, "Rows in the test durations table of the longest-running tests (0 leaves it out)")
+	f.subTestSpaces = fs.Bool("subtest-spaces", false, "Show the underscores go test writes for spaces in subtest names as spaces (percent-escapes like %2F are always decoded for display)")
+	f.aggregateParentDurations = fs.Bool("aggregate-parent-durations", false, "Show a parent test with the total duration of its subtests in the results and durations tables when that is larger than its own")
+	f.slowAnnotations = fs.Bool("slow-annotations", true, "In GitHub Actions: add a warning annotation at the declaration of each test slower than -slow-threshold")
+	f.slowThreshold = fs.Duration("slow-threshold", 0, "Mark tests that took longer than this (e.g. 2s) with 🐢 in the results table (0 marks none)")
+	f.includePassOutput = fs.Bool("include-pass-output", false, "Attach the captured output of passing tests in collapsed blocks")
+	f.timeline = fs.Bool("timeline", false, "Add a Concurrency Timeline section showing when each test ran and how many ran at once")
+	f.mermaid = fs.Bool("mermaid", false, "Add Mermaid charts of the test statuses and the -history trend to the summary, for hosts that render Mermaid")
+	f.environment = fs.Bool("environment", false, "Add an Environment section with the Go version, platform, CPU count and CI runner")
+	f.summary = fs.Bool("summary", true, "Print the counts, failed tests and slowest tests after writing the report")
+	f.colorMode = fs.String("color", "auto", "Color the summary: auto (when the console is a terminal and NO_COLOR is unset), always or never")
+	f.bitbucket = fs.Bool("bitbucket", true, "In Bitbucket Pipelines: publish a Code Insights report with an annotation per failed test")
+	f.datadog = fs.Bool("datadog", true, "With DD_API_KEY set: send the results to Datadog CI Visibility")
+	f.allureResults = fs.String("allure-results", "", "Also write Allure results to this directory (e.g. allure-results)")
+	f.commitStatus = fs.String("commit-status", "", "Set a GitHub commit status with this context (e.g. tests) from the results, linking to the uploaded report or the run (needs GITHUB_TOKEN)")
+	f.reviewComments = fs.Bool("review-comments", false, "On a pull request: post a review comment at each failing assertion on a line the pull request changed (needs GITHUB_TOKEN)")
+	f.badgeFile = fs.String("badge", "", "Also write a shields.io endpoint badge of the counts to this file (e.g. badge.json)")
+	f.influxURL = fs.String("influx-url", "", "Push the counts and test durations as InfluxDB line protocol to this write URL (token in INFLUX_TOKEN)")
+	f.reportPortalURL = fs.String("reportportal-url", "", "Publish the results as a launch to the ReportPortal server at this URL")
+	f.reportPortalProject = fs.String("reportportal-project", "", "ReportPortal project the -reportportal-url launch goes to")
+	f.reportPortalToken = fs.String("reportportal-token", "", "ReportPortal API key (default $RP_API_KEY)")
+	f.reportPortalLaunch = fs.String("reportportal-launch", "go test", "Name of the ReportPortal launch")
+	f.testRailURL = fs.String("testrail-url", "", "Submit the results of tests mapped to TestRail cases to the TestRail instance at this URL (API key in TESTRAIL_API_KEY)")
+	f.testRailUser = fs.String("testrail-user", "", "TestRail user the API key belongs to")
+	f.testRailProject = fs.Int("testrail-project", 0, "TestRail project to add a run of the mapped cases to")
+	f.testRailSuite = fs.Int("testrail-suite", 0, "TestRail suite of the new run, for projects with several suites")
+	f.testRailRun = fs.Int("testrail-run", 0, "Existing TestRail run to add results to instead of adding a run")
+	f.testRailMap = fs.String("testrail-map", "", "File mapping TestRail cases to tests, one per line: C1234 TestName")
+	f.webhookURL = fs.String("webhook-url", "", "POST the JSON report, or the -webhook-template body, to this URL")
+	f.webhookTemplate = fs.String("webhook-template", "", "text/template file rendering the -webhook-url request body")
+	fs.Var(&f.webhookHeaders, "webhook-header", "Header sent to -webhook-url, as Name: value; repeat for more")
+	f.upload = fs.String("upload", "", "Upload the report and the go test output to s3://bucket/prefix, gs://bucket/prefix or az://account/container/prefix")
+	f.uploadExpires = fs.Duration("upload-expires", 7*24*time.Hour, "How long the URLs printed for -upload stay valid, when they can be signed")
+	f.gitlab = fs.Bool("gitlab", false, "On GitLab CI: also write "+gitlabJUnitFile+" and post the report as a merge request note (needs GITLAB_TOKEN)")
+	return f
+}
+
+// reporter renders and publishes the results as the report flags say. It is
+// set up before the tests are in, so bad flags fail before any work is done.
+type reporter struct {
+	flags           *reportFlags
+	cfg             *config
+	color           bool
+	redactor        *report.Redactor
+	outputFilter    *report.OutputFilter
+	reportPortal    *reportPortalClient
+	testRail        *testRailClient
+	testRailMapping map[string][]int
+	issues          *githubClient
+	statuses        *githubClient
+	reviews         *githubClient
+	hook            *webhook
+	uploads         *uploadTarget
+	// Previous runs and this one, saved in full after the report is
+	// written; nil without -history
+	history *report.History
+}
+
+// newReporter checks the report flags, loads the -config file and sets up
+// the clients of the services the results go to. console is where the
+// summary is printed, for -color.
+func (f *reportFlags) newReporter(console *os.File) (*reporter, error) {
+	cfg, err := loadConfig(*f.configFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %v", err)
+	}
+	if err := checkFormat(*f.format); err != nil {
+		return nil, err
+	}
+	if err := checkSplit(*f.splitBy, *f.splitSize, *f.format, *f.output); err != nil {
+		return nil, err
+	}
+	if cfg.target, err = checkTarget(*f.target, *f.format); err != nil {
+		return nil, err
+	}
+	if *f.minPassRate > 100 {
+		return nil, fmt.Errorf("-min-pass-rate must be at most 100, got %g", *f.minPassRate)
+	}
+	if *f.durationBudgets != "warn" && *f.durationBudgets != "fail" {
+		return nil, fmt.Errorf("unknown -duration-budgets value %q (want warn or fail)", *f.durationBudgets)
+	}
+	if *f.durationRegressions != "warn" && *f.durationRegressions != "fail" {
+		return nil, fmt.Errorf("unknown -duration-regressions value %q (want warn or fail)", *f.durationRegressions)
+	}
+	if *f.maxDurationIncrease >= 0 && *f.baselineFile == "" {
+		return nil, fmt.Errorf("-max-duration-increase needs a -baseline")
+	}
+
+	r := &reporter{flags: f, cfg: cfg}
+	if r.color, err = useColor(*f.colorMode, console); err != nil {
+		return nil, err
+	}
+	// loadConfig already rejected invalid patterns of the config file
+	if r.redactor, err = report.NewRedactor(*f.redactSecrets, cfg.Redact); err != nil {
+		return nil, err
+	}
+	if r.outputFilter, err = report.ParseOutputFilter(append(cfg.OutputFilter, f.outputFilters...)); err != nil {
+		return nil, err
+	}
+	if *f.reportPortalURL != "" {
+		if r.reportPortal, err = newReportPortalClient(*f.reportPortalURL, *f.reportPortalProject, *f.reportPortalToken); err != nil {
+			return nil, err
+		}
+	}
+	if *f.testRailURL != "" {
+		if r.testRail, err = newTestRailClient(*f.testRailURL, *f.testRailUser); err != nil {
+			return nil, err
+		}
+		if *f.testRailMap != "" {
+			if r.testRailMapping, err = loadTestRailMap(*f.testRailMap); err != nil {
+				return nil, fmt.Errorf("loading TestRail mapping: %v", err)
+			}
+		}
+	}
+	if *f.fileIssues {
+		if *f.baselineFile == "" {
+			return nil, fmt.Errorf("-file-issues needs a -baseline to tell new failures from old ones")
+		}
+		if r.issues, err = githubFromEnv("-file-issues"); err != nil {
+			return nil, err
+		}
+	}
+	if *f.commitStatus != "" {
+		if r.statuses, err = githubFromEnv("-commit-status"); err != nil {
+			return nil, err
+		}
+	}
+	if *f.reviewComments {
+		if r.reviews, err = githubFromEnv("-review-comments"); err != nil {
+			return nil, err
+		}
+	}
+	if *f.webhookURL != "" {
+		if r.hook, err = newWebhook(*f.webhookURL, *f.webhookTemplate, f.webhookHeaders); err != nil {
+			return nil, fmt.Errorf("loading webhook template: %v", err)
+		}
+	}
+	if *f.upload != "" {
+		if *f.output == "-" {
+			return nil, fmt.Errorf("-upload needs an -output file")
+		}
+		if r.uploads, err = parseUploadTarget(*f.upload); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.sourceLinks = sourceLinkerFromEnv()
+	cfg.sourceSnippets = snippetEmbedderFromFlags(*f.embedSource, *f.sourceContext)
+	cfg.blame = blamerFromFlags(*f.blame)
+	cfg.triageChecklist = *f.triageChecklist
+	cfg.owners = codeOwnersFromFlags(*f.triageChecklist)
+	cfg.includePassOutput = *f.includePassOutput
+	cfg.timeline = *f.timeline
+	cfg.mermaid = *f.mermaid
+	cfg.slowestPackages = *f.slowestPackages
+	cfg.topSlow = *f.topSlow
+	cfg.slowThreshold = f.slowThreshold.Seconds()
+	cfg.aggregateParentDurations = *f.aggregateParentDurations
+	cfg.subTestSpaces = *f.subTestSpaces
+	return r, nil
+}
+
+// clean masks secrets in and filters log noise from the captured output of
+// data, and keeps only the tests with a -test-label
+func (r *reporter) clean(data *ReportData) {
+	r.redactor.Apply(data)
+	r.outputFilter.Apply(data)
+	if *r.flags.testLabels != "" {
+		var labels []string
+		for _, label := range strings.Split(*r.flags.testLabels, ",") {
+			labels = append(labels, strings.TrimSpace(label))
+		}
+		report.FilterLabels(data, labels)
+	}
+}
+
+// process adds what the flags ask for to the results of runs, combined in
+// data: module counts, history, quarantine, quality gates and the baseline
+// comparison. The history is recorded here but only saved by publish.
+func (r *reporter) process(data *ReportData, runs []*ReportData) error {
+	f, cfg := r.flags, r.cfg
+	cfg.sourceIndex = sourceIndexFromFlags(*f.indexSources, data)
+	if err := rollUpModules(data, *f.workspace); err != nil {
+		return fmt.Errorf("finding modules: %v", err)
+	}
+
+	if *f.historyFile != "" {
+		history, err := report.LoadHistory(*f.historyFile)
+		if err != nil {
+			return fmt.Errorf("loading history: %v", err)
+		}
+		recordHistory(history, runs, time.Now().UTC(), os.Getenv("GITHUB_SHA"), *f.historySize)
+		// The history is saved in full; the report compares against the runs
+		// under its own sanitizer
+		r.history = history
+		cfg.history, cfg.historyRuns, cfg.trendRuns = history.ForSanitizer(data.Sanitizer), *f.historyRuns, *f.trendRuns
+	}
+
+	if *f.quarantineFile != "" {
+		quarantined, err := report.LoadQuarantine(*f.quarantineFile)
+		if err != nil {
+			return fmt.Errorf("loading quarantine list: %v", err)
+		}
+		report.ApplyQuarantine(data, quarantined, cfg.history, *f.unquarantineAfter)
+	}
+
+	gate := report.Gate{MinPassRate: *f.minPassRate, MaxFailures: *f.maxFailures, MaxSkipped: *f.maxSkipped}
+	if gate.Enabled() {
+		data.QualityGate = gate.Check(data)
+	}
+	if len(cfg.categories) > 0 {
+		data.Categories = report.CategorySummaries(data, cfg.categories)
+		for _, violation := range report.CategoryViolations(data.Categories, cfg.categories) {
+			report.AddViolation(data, violation)
+		}
+	}
+
+	if len(cfg.budgets) > 0 {
+		data.BudgetOverruns = report.CheckBudgets(data, cfg.budgets)
+		if *f.durationBudgets == "fail" {
+			for _, violation := range report.BudgetViolations(data.BudgetOverruns) {
+				report.AddViolation(data, violation)
+			}
+		}
+	}
+
+	if *f.baselineFile != "" {
+		baseline, err := report.LoadBaseline(*f.baselineFile)
+		if err != nil {
+			return fmt.Errorf("loading baseline: %v", err)
+		}
+		data.Comparison = report.Diff(baseline.ReportData(), data)
+		if *f.maxDurationIncrease >= 0 {
+			data.DurationRegressions = report.DurationRegressions(baseline.ReportData(), data, *f.maxDurationIncrease, *f.minTestDuration)
+			if *f.durationRegressions == "fail" {
+				for _, violation := range report.DurationViolations(data.DurationRegressions, *f.maxDurationIncrease) {
+					report.AddViolation(data, violation)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// publish writes the report and the other files the flags ask for, sends
+// the results to the services they name and prints the summary to console
+// (nil prints nothing). inputs are the go test output files -upload uploads
+// along with the report.
+func (r *reporter) publish(data *ReportData, console io.Writer, inputs []inputSpec) error {
+	f, cfg := r.flags, r.cfg
+	content, err := renderReport(data, cfg, *f.format)
+	if err != nil {
+		return fmt.Errorf("rendering report: %v", err)
+	}
+
+	generated := *f.output
+	split := shouldSplit(*f.splitBy, *f.splitSize, content)
+	if split {
+		packages, err := writeSplitReport(data, cfg, *f.output)
+		if err != nil {
+			return fmt.Errorf("writing report: %v", err)
+		}
+		generated = fmt.Sprintf("%s (index of %d package reports)", *f.output, packages)
+	} else if err := writeReport(*f.output, content); err != nil {
+		return fmt.Errorf("writing report: %v", err)
+	}
+
+	if *f.badgeFile != "" {
+		if err := writeBadge(*f.badgeFile, data); err != nil {
+			return fmt.Errorf("writing badge: %v", err)
+		}
+	}
+	if *f.allureResults != "" {
+		if err := writeAllureResults(*f.allureResults, data, time.Now()); err != nil {
+			return fmt.Errorf("writing Allure results: %v", err)
+		}
+	}
+	if *f.saveBaseline != "" {
+		if err := report.NewBaseline(data, os.Getenv("GITHUB_SHA")).Save(*f.saveBaseline); err != nil {
+			return fmt.Errorf("saving baseline: %v", err)
+		}
+	}
+	if r.history != nil {
+		if err := r.history.Save(*f.historyFile); err != nil {
+			return fmt.Errorf("saving history: %v", err)
+		}
+	}
+	if err := fileJiraTickets(data, cfg.history, cfg); err != nil {
+		return fmt.Errorf("filing Jira tickets: %v", err)
+	}
+	if *f.dbFile != "" {
+		if err := saveResultsDB(*f.dbFile, data, *f.dbRun, os.Getenv("GITHUB_SHA"), time.Now()); err != nil {
+			return fmt.Errorf("saving results database: %v", err)
+		}
+	}
+
+	// Upload first, so the notifications below can link to the report
+	if r.uploads != nil {
+		files, err := reportUploads(*f.output, *f.format, split, inputs)
+		if err != nil {
+			return fmt.Errorf("uploading report: %v", err)
+		}
+		for _, file := range files {
+			source := file.path
+			if file.input && r.redactor != nil {
+				// Upload the go test output with its secrets masked, as in the report
+				if file, err = redactedInput(file, r.redactor); err != nil {
+					return fmt.Errorf("redacting %s: %v", source, err)
+				}
+				defer os.Remove(file.path)
+			}
+			link, err := r.uploads.upload(file.path, file.name, file.contentType, *f.uploadExpires)
+			if err != nil {
+				return fmt.Errorf("uploading report: %v", err)
+			}
+			if console != nil {
+				fmt.Fprintf(console, "Uploaded %s: %s\n", source, link)
+			}
+			if file.path == *f.output {
+				data.ReportURL = link
+			}
+		}
+	}
+
+	if *f.gitlab {
+		if err := publishGitLab(data, cfg); err != nil {
+			return fmt.Errorf("publishing to GitLab: %v", err)
+		}
+	}
+	if r.issues != nil {
+		var labels []string
+		for _, label := range strings.Split(*f.issueLabels, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				labels = append(labels, label)
+			}
+		}
+		if err := r.issues.fileIssues(newFailureIssues(data), labels, cfg); err != nil {
+			return fmt.Errorf("filing issues: %v", err)
+		}
+	}
+	if *f.bitbucket {
+		publishBitbucket(data, cfg)
+	}
+	if *f.datadog {
+		publishDatadog(data)
+	}
+	if r.reportPortal != nil {
+		if err := r.reportPortal.publish(data, *f.reportPortalLaunch, time.Now()); err != nil {
+			return fmt.Errorf("publishing to ReportPortal: %v", err)
+		}
+	}
+	if r.testRail != nil {
+		results := testRailResults(data, r.testRailMapping)
+		if err := r.testRail.submit(results, *f.testRailProject, *f.testRailSuite, *f.testRailRun, testRailRunName(time.Now())); err != nil {
+			return fmt.Errorf("submitting to TestRail: %v", err)
+		}
+	}
+	if *f.influxURL != "" {
+		if err := pushInflux(*f.influxURL, data, time.Now()); err != nil {
+			return fmt.Errorf("pushing to InfluxDB: %v", err)
+		}
+	}
+	if r.hook != nil {
+		if err := r.hook.send(data); err != nil {
+			return fmt.Errorf("sending webhook: %v", err)
+		}
+	}
+
+	if console != nil {
+		if *f.summary {
+			writeTerminalSummary(console, data, r.color)
+		}
+		if *f.slowAnnotations {
+			writeSlowAnnotations(console, data, cfg, findSourceTree("."))
+		}
+		if *f.output != "-" {
+			fmt.Fprintf(console, "Report generated successfully: %s\n", generated)
+		}
+	}
+
+	if r.statuses != nil {
+		_, reportLink := workflowRun()
+		if data.ReportURL != "" {
+			reportLink = data.ReportURL
+		}
+		if err := r.statuses.setCommitStatus(githubHeadSHA(), *f.commitStatus, data, reportLink); err != nil {
+			return fmt.Errorf("setting commit status: %v", err)
+		}
+	}
+	if r.reviews != nil {
+		if number, sha := githubPullRequest(); number == 0 {
+			logger.Warn("not running for a pull request; no review comments posted")
+		} else if err := r.reviews.postReviewComments(data, findSourceTree("."), number, sha, cfg); err != nil {
+			return fmt.Errorf("posting review comments: %v", err)
+		}
+	}
+	return nil
+}
+
+// gateFailed logs the violations of a failed quality gate
+func gateFailed(data *ReportData) bool {
+	gate := data.QualityGate
+	if gate == nil || gate.Passed {
+		return false
+	}
+	logger.Error("quality gate failed", "violations", strings.Join(gate.Violations, "; "))
+	return true
+}
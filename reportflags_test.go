@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestReporterHistory(t *testing.T) {
+	dir := t.TempDir()
+	historyFile := filepath.Join(dir, "history.json")
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags := addReportFlags(fs)
+	if err := fs.Parse([]string{"-output", filepath.Join(dir, "report.md"), "-history", historyFile,
+		"-summary=false", "-slow-annotations=false", "-bitbucket=false", "-datadog=false"}); err != nil {
+		t.Fatal(err)
+	}
+	reporter, err := flags.newReporter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestA": {Name: "TestA", Package: "example.com/a", Status: "PASS"},
+	}, SortedTestNames: []string{"TestA"}, TotalTests: 1, PassedTests: 1}
+	for i := 0; i < 2; i++ {
+		if err := reporter.process(data, []*ReportData{data}); err != nil {
+			t.Fatal(err)
+		}
+		if err := reporter.publish(data, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := report.LoadHistory(historyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Runs) != 2 || history.Runs[1].Tests["TestA"] != "PASS" {
+		t.Errorf("history: got %+v", history.Runs)
+	}
+}
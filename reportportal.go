@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// reportPortalClient publishes a launch to one ReportPortal project through
+// its synchronous API
+type reportPortalClient struct {
+	api    string // e.g. https://rp.example.com/api/v1/my_project
+	token  string
+	launch string // UUID of the launch being published
+	http   *http.Client
+}
+
+// newReportPortalClient returns a client for project on the ReportPortal
+// server at endpoint. An empty token is taken from RP_API_KEY.
+func newReportPortalClient(endpoint, project, token string) (*reportPortalClient, error) {
+	if token == "" {
+		token = os.Getenv("RP_API_KEY")
+	}
+	if project == "" || token == "" {
+		return nil, fmt.Errorf("-reportportal-url needs -reportportal-project and a -reportportal-token or RP_API_KEY")
+	}
+	return &reportPortalClient{
+		api:   strings.TrimSuffix(endpoint, "/") + "/api/v1/" + url.PathEscape(project),
+		token: token,
+		http:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+type rpAttribute struct {
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value"`
+}
+
+type rpStartLaunch struct {
+	Name        string        `json:"name"`
+	StartTime   int64         `json:"startTime"` // Unix milliseconds
+	Description string        `json:"description,omitempty"`
+	Attributes  []rpAttribute `json:"attributes,omitempty"`
+	Mode        string        `json:"mode"`
+}
+
+type rpStartItem struct {
+	Name       string `json:"name"`
+	StartTime  int64  `json:"startTime"`
+	Type       string `json:"type"` // SUITE, TEST or STEP
+	LaunchUUID string `json:"launchUuid"`
+	CodeRef    string `json:"codeRef,omitempty"`
+	Retry      bool   `json:"retry,omitempty"`
+}
+
+type rpFinishItem struct {
+	EndTime    int64    `json:"endTime"`
+	Status     string   `json:"status,omitempty"` // passed, failed or skipped
+	LaunchUUID string   `json:"launchUuid"`
+	Issue      *rpIssue `json:"issue,omitempty"`
+}
+
+// rpIssue marks a skipped test as not a defect, so ReportPortal doesn't
+// ask for it to be investigated
+type rpIssue struct {
+	IssueType string `json:"issueType"`
+}
+
+type rpLog struct {
+	LaunchUUID string `json:"launchUuid"`
+	ItemUUID   string `json:"itemUuid"`
+	Time       int64  `json:"time"`
+	Message    string `json:"message"`
+	Level      string `json:"level"` // info or error
+}
+
+type rpCreated struct {
+	ID string `json:"id"`
+}
+
+// publish creates a launch ending at now with a suite per package, a test
+// per top-level test and a step per subtest, with each test's output as a
+// log. As go test doesn't record when tests started, they are placed one
+// after the other from the start of the run. Earlier attempts of re-run
+// tests are published as failed items followed by retries.
+func (c *reportPortalClient) publish(data *ReportData, name string, now time.Time) error {
+	start := now.Add(-time.Duration(data.TotalDuration * float64(time.Second)))
+	launch := rpStartLaunch{Name: name, StartTime: start.UnixMilli(), Mode: "DEFAULT"}
+	if _, link := workflowRun(); link != "" {
+		launch.Description = "Results of the " + workflowRunLink()
+	}
+	if data.Sanitizer != "" {
+		launch.Attributes = append(launch.Attributes, rpAttribute{Key: "sanitizer", Value: data.Sanitizer})
+	}
+	if commit := firstEnv("GITHUB_SHA", "CI_COMMIT_SHA", "BITBUCKET_COMMIT"); commit != "" {
+		launch.Attributes = append(launch.Attributes, rpAttribute{Key: "commit", Value: commit})
+	}
+	var created rpCreated
+	if err := c.do(http.MethodPost, "/launch", launch, &created); err != nil {
+		return fmt.Errorf("starting the launch: %w", err)
+	}
+	c.launch = created.ID
+
+	offset := start
+	packages, byPackage := resultsByPackage(data)
+	for _, pkg := range packages {
+		suite, err := c.startItem("", rpStartItem{Name: pkg, StartTime: offset.UnixMilli(), Type: "SUITE", CodeRef: pkg})
+		if err != nil {
+			return err
+		}
+		for _, result := range byPackage[pkg] {
+			if result.IsSubTest {
+				continue
+			}
+			if offset, err = c.publishTest(data, suite, pkg, result, offset); err != nil {
+				return err
+			}
+		}
+		if err := c.finishItem(suite, rpFinishItem{EndTime: offset.UnixMilli()}); err != nil {
+			return err
+		}
+	}
+
+	end := now
+	if offset.After(end) {
+		end = offset
+	}
+	if err := c.do(http.MethodPut, "/launch/"+url.PathEscape(c.launch)+"/finish", rpFinishItem{EndTime: end.UnixMilli()}, nil); err != nil {
+		return fmt.Errorf("finishing the launch: %w", err)
+	}
+	logger.Info("published ReportPortal launch", "launch", c.launch, "tests", len(data.Results))
+	return nil
+}
+
+// publishTest publishes result, with its subtests, under parent starting at
+// start, and returns when it ended
+func (c *reportPortalClient) publishTest(data *ReportData, parent, pkg string, result *TestResult, start time.Time) (time.Time, error) {
+	itemType := "STEP"
+	if len(result.SubTests) > 0 {
+		itemType = "TEST"
+	}
+	codeRef := pkg + "." + result.Name
+
+	for i, attempt := range result.Attempts {
+		end := start.Add(time.Duration(attempt.Duration * float64(time.Second)))
+		item, err := c.startItem(parent, rpStartItem{Name: result.Name, StartTime: start.UnixMilli(), Type: "STEP", CodeRef: codeRef, Retry: i > 0})
+		if err != nil {
+			return start, err
+		}
+		if err := c.log(item, attempt.Output, "error", end); err != nil {
+			return start, err
+		}
+		if err := c.finishItem(item, rpFinishItem{EndTime: end.UnixMilli(), Status: "failed"}); err != nil {
+			return start, err
+		}
+		start = end
+	}
+
+	end := start.Add(time.Duration(result.Duration * float64(time.Second)))
+	item, err := c.startItem(parent, rpStartItem{Name: result.Name, StartTime: start.UnixMilli(), Type: itemType, CodeRef: codeRef, Retry: len(result.Attempts) > 0})
+	if err != nil {
+		return start, err
+	}
+	// Subtests are placed at the start of their parent
+	for _, name := range result.SubTests {
+		if sub := data.Results[name]; sub != nil {
+			if _, err := c.publishTest(data, item, pkg, sub, start); err != nil {
+				return start, err
+			}
+		}
+	}
+
+	finish := rpFinishItem{EndTime: end.UnixMilli()}
+	level := "info"
+	output := result.Output
+	switch result.Status {
+	case "PASS", "FLAKY":
+		finish.Status = "passed"
+	case "SKIP":
+		finish.Status = "skipped"
+		finish.Issue = &rpIssue{IssueType: "NOT_ISSUE"}
+	default:
+		finish.Status = "failed"
+		level = "error"
+		if result.Status != "FAIL" {
+			output = append([]string{"test did not report a result"}, output...)
+		}
+	}
+	if err := c.log(item, output, level, end); err != nil {
+		return start, err
+	}
+	if err := c.finishItem(item, finish); err != nil {
+		return start, err
+	}
+	return end, nil
+}
+
+func (c *reportPortalClient) startItem(parent string, item rpStartItem) (string, error) {
+	item.LaunchUUID = c.launch
+	path := "/item"
+	if parent != "" {
+		path += "/" + url.PathEscape(parent)
+	}
+	var created rpCreated
+	if err := c.do(http.MethodPost, path, item, &created); err != nil {
+		return "", fmt.Errorf("starting %s: %w", item.Name, err)
+	}
+	return created.ID, nil
+}
+
+func (c *reportPortalClient) finishItem(id string, finish rpFinishItem) error {
+	finish.LaunchUUID = c.launch
+	if err := c.do(http.MethodPut, "/item/"+url.PathEscape(id), finish, nil); err != nil {
+		return fmt.Errorf("finishing item %s: %w", id, err)
+	}
+	return nil
+}
+
+// log attaches output to an item as one log entry, if there is any
+func (c *reportPortalClient) log(id string, output []string, level string, at time.Time) error {
+	if len(output) == 0 {
+		return nil
+	}
+	entry := rpLog{LaunchUUID: c.launch, ItemUUID: id, Time: at.UnixMilli(), Message: joinOutput(output), Level: level}
+	if err := c.do(http.MethodPost, "/log", entry, nil); err != nil {
+		return fmt.Errorf("adding a log to item %s: %w", id, err)
+	}
+	return nil
+}
+
+// do sends a request with a JSON body and decodes the JSON response into out
+func (c *reportPortalClient) do(method, path string, in, out any) error {
+	_, err := doJSON(c.http, method, c.api+path, in, out, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	})
+	return err
+}
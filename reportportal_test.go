@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestReportPortalPublish(t *testing.T) {
+	// A fake server that numbers what is created and records the tree
+	var calls []string
+	parents := make(map[string]string)
+	names := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1/proj")
+		id := fmt.Sprintf("id%d", len(calls))
+		switch {
+		case r.Method == http.MethodPost && path == "/launch":
+			calls = append(calls, "start launch")
+		case r.Method == http.MethodPost && strings.HasPrefix(path, "/item"):
+			var item rpStartItem
+			json.Unmarshal(body, &item)
+			if item.LaunchUUID != "id0" {
+				t.Errorf("%s: got launch %q, want id0", item.Name, item.LaunchUUID)
+			}
+			names[id], parents[id] = item.Name, strings.TrimPrefix(path, "/item/")
+			retry := ""
+			if item.Retry {
+				retry = " (retry)"
+			}
+			calls = append(calls, "start "+item.Type+" "+item.Name+" under "+names[parents[id]]+retry)
+		case r.Method == http.MethodPut && strings.HasPrefix(path, "/item/"):
+			var finish rpFinishItem
+			json.Unmarshal(body, &finish)
+			calls = append(calls, "finish "+names[strings.TrimPrefix(path, "/item/")]+" "+finish.Status)
+		case r.Method == http.MethodPost && path == "/log":
+			var entry rpLog
+			json.Unmarshal(body, &entry)
+			calls = append(calls, "log "+names[entry.ItemUUID]+" "+entry.Level+": "+strings.TrimSpace(entry.Message))
+		case r.Method == http.MethodPut && path == "/launch/id0/finish":
+			calls = append(calls, "finish launch")
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(rpCreated{ID: id})
+	}))
+	defer server.Close()
+
+	data := &ReportData{
+		TotalDuration: 3,
+		Results: map[string]*TestResult{
+			"TestParent":   {Name: "TestParent", Package: "example.com/a", Status: "FAIL", Duration: 2, SubTests: []string{"TestParent/a"}},
+			"TestParent/a": {Name: "TestParent/a", Package: "example.com/a", Status: "FAIL", Duration: 2, IsSubTest: true, Output: []string{"    a_test.go:9: got 1"}},
+			"TestSkip":     {Name: "TestSkip", Package: "example.com/a", Status: "SKIP"},
+			"TestRetried": {Name: "TestRetried", Package: "example.com/b", Status: "FLAKY", Duration: 1,
+				Attempts: []report.Attempt{{Status: "FAIL", Output: []string{"    b_test.go:3: timeout"}}}},
+		},
+	}
+	client, err := newReportPortalClient(server.URL+"/", "proj", "tok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.publish(data, "go test", time.Unix(1700000000, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"start launch",
+		"start SUITE example.com/a under ",
+		"start TEST TestParent under example.com/a",
+		"start STEP TestParent/a under TestParent",
+		"log TestParent/a error: a_test.go:9: got 1",
+		"finish TestParent/a failed",
+		"finish TestParent failed",
+		"start STEP TestSkip under example.com/a",
+		"finish TestSkip skipped",
+		"finish example.com/a ",
+		"start SUITE example.com/b under ",
+		"start STEP TestRetried under example.com/b",
+		"log TestRetried error: b_test.go:3: timeout",
+		"finish TestRetried failed",
+		"start STEP TestRetried under example.com/b (retry)",
+		"finish TestRetried passed",
+		"finish example.com/b ",
+		"finish launch",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls: got\n%s\nwant\n%s", strings.Join(calls, "\n"), strings.Join(want, "\n"))
+	}
+
+	client.token = "wrong"
+	if err := client.publish(data, "go test", time.Now()); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("got error %v, want the response body in it", err)
+	}
+}
+
+func TestNewReportPortalClient(t *testing.T) {
+	t.Setenv("RP_API_KEY", "")
+	if _, err := newReportPortalClient("https://rp.example.com", "proj", ""); err == nil {
+		t.Error("got no error without a token")
+	}
+	if _, err := newReportPortalClient("https://rp.example.com", "", "tok"); err == nil {
+		t.Error("got no error without a project")
+	}
+	t.Setenv("RP_API_KEY", "env-token")
+	client, err := newReportPortalClient("https://rp.example.com/", "my proj", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.api != "https://rp.example.com/api/v1/my%20proj" || client.token != "env-token" {
+		t.Errorf("got api %q and token %q", client.api, client.token)
+	}
+}
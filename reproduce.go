@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// testPattern returns the -run pattern matching exactly the test name, one
+// anchored element per level of subtests, as go test splits the pattern at
+// its slashes. Names are the raw ones go test reports, not the decoded ones
+// shown in the report.
+func testPattern(name string) string {
+	elements := strings.Split(name, "/")
+	for i, element := range elements {
+		elements[i] = "^" + regexp.QuoteMeta(element) + "$"
+	}
+	return strings.Join(elements, "/")
+}
+
+// runPattern returns the -run pattern matching exactly the given tests,
+// which are all top-level tests or all subtests of the same parent
+func runPattern(names []string) string {
+	if len(names) == 1 {
+		return testPattern(names[0])
+	}
+	parent := ""
+	if i := strings.LastIndex(names[0], "/"); i >= 0 {
+		parent = testPattern(names[0][:i]) + "/"
+	}
+	alternatives := make([]string, len(names))
+	for i, name := range names {
+		alternatives[i] = regexp.QuoteMeta(name[strings.LastIndex(name, "/")+1:])
+	}
+	return parent + "^(" + strings.Join(alternatives, "|") + ")$"
+}
+
+// rerunCommand returns the go test command running only the tests pattern
+// matches, quoted for a POSIX shell
+func rerunCommand(pkg, pattern string) string {
+	return fmt.Sprintf("go test %s -run '%s' -v", pkg, strings.ReplaceAll(pattern, "'", `'\''`))
+}
+
+// writeRerunCommand writes the command re-running the tests pattern matches
+// in a code block, which hosts like GitHub offer to copy. Tests of unknown
+// packages, e.g. from JUnit input, get none.
+func writeRerunCommand(sb *strings.Builder, pkg, pattern string) {
+	if pkg == "" {
+		return
+	}
+	sb.WriteString("```sh\n" + rerunCommand(pkg, pattern) + "\n```\n\n")
+}
+
+// writeRerunFailed writes a command per package re-running its failed
+// top-level tests
+func writeRerunFailed(sb *strings.Builder, data *ReportData) {
+	failed := make(map[string][]string)
+	for _, name := range data.SortedTestNames {
+		result := data.Results[name]
+		if !result.IsSubTest && result.Status == "FAIL" && result.Package != "" {
+			failed[result.Package] = append(failed[result.Package], name)
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+	packages := make([]string, 0, len(failed))
+	for pkg := range failed {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+
+	sb.WriteString("## ▶️ Rerun Failed Tests\n\n")
+	sb.WriteString("To reproduce the failures locally, run the failed tests of each package:\n\n")
+	sb.WriteString("```sh\n")
+	for _, pkg := range packages {
+		sb.WriteString(rerunCommand(pkg, runPattern(failed[pkg])) + "\n")
+	}
+	sb.WriteString("```\n\n")
+}
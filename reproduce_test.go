@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRerunCommands(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{[]string{"TestParse"}, `^TestParse$`},
+		{[]string{"TestParse/empty%2Finput"}, `^TestParse$/^empty%2Finput$`},
+		{[]string{"TestParse/a+b_(sum)"}, `^TestParse$/^a\+b_\(sum\)$`},
+		{[]string{"TestParse/v1.2/x", "TestParse/v1.2/y"}, `^TestParse$/^v1\.2$/^(x|y)$`},
+	}
+	for _, tt := range tests {
+		if got := runPattern(tt.names); got != tt.want {
+			t.Errorf("runPattern(%q): got %q, want %q", tt.names, got, tt.want)
+		}
+	}
+
+	if got, want := rerunCommand("example.com/app", testPattern("TestQuote/it's")), `go test example.com/app -run '^TestQuote$/^it'\''s$' -v`; got != want {
+		t.Errorf("rerunCommand: got %q, want %q", got, want)
+	}
+}
+
+func TestRerunCommandsInReport(t *testing.T) {
+	markdown := generateMarkdownReport(&ReportData{
+		TotalTests:      3,
+		PassedTests:     1,
+		FailedTests:     2,
+		SortedTestNames: []string{"TestA", "TestB", "TestC"},
+		Results: map[string]*TestResult{
+			"TestA":        {Name: "TestA", Package: "example.com/app", Status: "FAIL", SubTests: []string{"TestA/case_1"}},
+			"TestA/case_1": {Name: "TestA/case_1", Package: "example.com/app", Status: "FAIL", IsSubTest: true, Output: []string{"    a_test.go:3: got 1"}},
+			"TestB":        {Name: "TestB", Package: "example.com/app", Status: "PASS"},
+			"TestC":        {Name: "TestC", Package: "example.com/app/db", Status: "FAIL", Output: []string{"    c_test.go:9: timeout"}},
+		},
+	})
+
+	for _, want := range []string{
+		"## ▶️ Rerun Failed Tests\n\nTo reproduce the failures locally, run the failed tests of each package:\n\n```sh\n" +
+			"go test example.com/app -run '^TestA$' -v\ngo test example.com/app/db -run '^TestC$' -v\n```\n",
+		"#### ❌ case_1\n\n```sh\ngo test example.com/app -run '^TestA$/^case_1$' -v\n```\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+}
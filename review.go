@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// reviewMarker ends the review comments posted by -review-comments, so a
+// rerun for the same commit doesn't post them again
+const reviewMarker = "<!-- gotest-report:review -->"
+
+// reviewOutputLines is how much of the failure output a review comment shows
+const reviewOutputLines = 30
+
+// hunkHeader matches "@@ -10,7 +10,8 @@" in a patch; a missing count is 1
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// reviewComment is a comment anchored to a line of the new version of a file
+type reviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"`
+	Body string `json:"body"`
+}
+
+// pullRequestFile is a file changed by a pull request with its diff
+type pullRequestFile struct {
+	Filename string `json:"filename"`
+	Patch    string `json:"patch"` // missing for binary and very large diffs
+}
+
+// diffLines returns the lines of the new version of a file a review comment
+// can be anchored to: those shown in the hunks of its patch
+func diffLines(patch string) map[int]bool {
+	lines := make(map[int]bool)
+	line := 0
+	for _, text := range strings.Split(patch, "\n") {
+		if m := hunkHeader.FindStringSubmatch(text); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if line == 0 || strings.HasPrefix(text, "-") || strings.HasPrefix(text, `\`) {
+			continue
+		}
+		lines[line] = true
+		line++
+	}
+	return lines
+}
+
+// failureReviewComments returns a comment for each failing assertion whose
+// file:line is part of the diff, combining the tests failing on the same
+// line. Quarantined tests are left out.
+func failureReviewComments(data *ReportData, tree *sourceTree, files []pullRequestFile, cfg *config) []reviewComment {
+	changed := make(map[string]map[int]bool)
+	for _, file := range files {
+		changed[file.Filename] = diffLines(file.Patch)
+	}
+
+	var comments []reviewComment
+	index := make(map[string]int)
+	failuresAt := make(map[string][]report.Failure)
+	for _, failure := range report.Failures(data) {
+		if failure.Quarantined || failure.Excerpt.File == "" {
+			continue
+		}
+		for _, location := range tree.locate(failure.Package, failure.Output, maxSourceLinks) {
+			if filepath.Base(location.path) != filepath.Base(failure.Excerpt.File) || location.line != failure.Excerpt.Line {
+				continue
+			}
+			path := filepath.ToSlash(location.rel)
+			if !changed[path][location.line] {
+				break
+			}
+			key := fmt.Sprintf("%s:%d", path, location.line)
+			if _, ok := index[key]; !ok {
+				index[key] = len(comments)
+				comments = append(comments, reviewComment{Path: path, Line: location.line, Side: "RIGHT"})
+			}
+			failuresAt[key] = append(failuresAt[key], failure)
+			break
+		}
+	}
+	for key, i := range index {
+		comments[i].Body = reviewCommentBody(failuresAt[key], cfg)
+	}
+	return comments
+}
+
+// reviewCommentBody renders the tests failing at a line, with the assertion
+// and the output of the first one
+func reviewCommentBody(failures []report.Failure, cfg *config) string {
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Name < failures[j].Name })
+	first := failures[0]
+	var sb strings.Builder
+	names := make([]string, len(failures))
+	for i, failure := range failures {
+		names[i] = "`" + failure.Name + "`"
+	}
+	verb := "fails"
+	if len(failures) > 1 {
+		verb = "fail"
+	}
+	sb.WriteString(fmt.Sprintf("❌ %s (%s) %s here in the %s:\n\n", strings.Join(names, ", "), cfg.packageName(first.Package), verb, workflowRunLink()))
+	sb.WriteString("> `" + strings.ReplaceAll(first.Excerpt.Assertion, "`", "'") + "`\n")
+	output := joinOutput(trimOutput(first.Output, reviewOutputLines))
+	sb.WriteString("\n<details>\n<summary>Output of " + markdownCell(first.Name) + "</summary>\n\n```text\n" + truncateRunes(output, 60000) + "```\n\n</details>\n")
+	sb.WriteString("\n" + reviewMarker + "\n")
+	return sb.String()
+}
+
+// postReviewComments posts the failures anchored to the diff of pull
+// request number as a review of commit sha. Comments already posted for
+// the same commit and line are left out, so reruns don't repeat them.
+func (c *githubClient) postReviewComments(data *ReportData, tree *sourceTree, number int, sha string, cfg *config) error {
+	if data.FailedTests == 0 {
+		return nil
+	}
+	var files []pullRequestFile
+	for page := 1; ; page++ {
+		var batch []pullRequestFile
+		if err := c.do(http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d/files?per_page=100&page=%d", c.repo, number, page), nil, &batch); err != nil {
+			return fmt.Errorf("listing changed files: %w", err)
+		}
+		files = append(files, batch...)
+		if len(batch) < 100 {
+			break
+		}
+	}
+	comments := failureReviewComments(data, tree, files, cfg)
+	if len(comments) == 0 {
+		return nil
+	}
+
+	posted := make(map[string]bool)
+	for page := 1; ; page++ {
+		var batch []struct {
+			Path     string `json:"path"`
+			Line     int    `json:"line"`
+			CommitID string `json:"commit_id"`
+			Body     string `json:"body"`
+		}
+		if err := c.do(http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d/comments?per_page=100&page=%d", c.repo, number, page), nil, &batch); err != nil {
+			return fmt.Errorf("listing review comments: %w", err)
+		}
+		for _, comment := range batch {
+			if comment.CommitID == sha && strings.Contains(comment.Body, reviewMarker) {
+				posted[fmt.Sprintf("%s:%d", comment.Path, comment.Line)] = true
+			}
+		}
+		if len(batch) < 100 {
+			break
+		}
+	}
+	var fresh []reviewComment
+	for _, comment := range comments {
+		if !posted[fmt.Sprintf("%s:%d", comment.Path, comment.Line)] {
+			fresh = append(fresh, comment)
+		}
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+
+	review := map[string]any{
+		"commit_id": sha,
+		"event":     "COMMENT",
+		"body":      fmt.Sprintf("%d test failures point at lines changed in this pull request.", len(fresh)),
+		"comments":  fresh,
+	}
+	if len(fresh) == 1 {
+		review["body"] = "A test failure points at a line changed in this pull request."
+	}
+	if err := c.do(http.MethodPost, fmt.Sprintf("/repos/%s/pulls/%d/reviews", c.repo, number), review, nil); err != nil {
+		return fmt.Errorf("posting review comments: %w", err)
+	}
+	logger.Info("posted review comments at failing lines", "pull_request", number, "comments", len(fresh))
+	return nil
+}
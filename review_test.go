@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	patch := "@@ -1,3 +1,4 @@\n package users\n+\n+import \"testing\"\n-import \"fmt\"\n@@ -40 +41,2 @@ func TestCount(t *testing.T) {\n-\tt.Error(1)\n+\tt.Errorf(\"expected 3, got 4\")\n+\treturn\n\\ No newline at end of file"
+	var got []int
+	lines := diffLines(patch)
+	for line := 1; line <= 50; line++ {
+		if lines[line] {
+			got = append(got, line)
+		}
+	}
+	if fmt.Sprint(got) != "[1 2 3 41 42]" {
+		t.Errorf("got lines %v, want [1 2 3 41 42]", got)
+	}
+}
+
+func TestPostReviewComments(t *testing.T) {
+	repo := t.TempDir()
+	for _, dir := range []string{filepath.Join(repo, ".git"), filepath.Join(repo, "users")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(repo, "go.mod"), []byte("module example.com/service\n"), 0o644)
+	os.WriteFile(filepath.Join(repo, "users", "users_test.go"), []byte("package users\n"), 0o644)
+	tree := findSourceTree(repo)
+
+	failed := func(name, line string) *TestResult {
+		return &TestResult{Name: name, Package: "example.com/service/users", Status: "FAIL", Output: []string{"=== RUN   " + name, "    " + line, "--- FAIL: " + name}}
+	}
+	data := &ReportData{
+		FailedTests: 4,
+		Results: map[string]*TestResult{
+			"TestCount":     failed("TestCount", "users_test.go:41: expected 3, got 4"),
+			"TestCountMore": failed("TestCountMore", "users_test.go:41: expected 3, got 5"),
+			"TestName":      failed("TestName", "users_test.go:12: got \"a\", want \"b\""),
+			"TestPosted":    failed("TestPosted", "users_test.go:42: unexpected error"),
+		},
+	}
+
+	var posted struct {
+		CommitID string          `json:"commit_id"`
+		Event    string          `json:"event"`
+		Comments []reviewComment `json:"comments"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/acme/service/pulls/7/files":
+			json.NewEncoder(w).Encode([]pullRequestFile{
+				{Filename: "users/users_test.go", Patch: "@@ -40 +41,2 @@\n-\tt.Error(1)\n+\tt.Errorf(\"expected 3, got 4\")\n+\tt.Error(err)"},
+				{Filename: "users/logo.png"},
+			})
+		case "GET /repos/acme/service/pulls/7/comments":
+			json.NewEncoder(w).Encode([]map[string]any{
+				{"path": "users/users_test.go", "line": 42, "commit_id": "head", "body": "earlier\n" + reviewMarker},
+				{"path": "users/users_test.go", "line": 41, "commit_id": "old", "body": "earlier\n" + reviewMarker},
+			})
+		case "POST /repos/acme/service/pulls/7/reviews":
+			body, _ := io.ReadAll(r.Body)
+			json.Unmarshal(body, &posted)
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("GITHUB_SERVER_URL", "")
+	t.Setenv("CI_JOB_URL", "")
+	client := &githubClient{api: server.URL, repo: "acme/service", token: "tok", http: server.Client()}
+	if err := client.postReviewComments(data, tree, 7, "head", defaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if posted.CommitID != "head" || posted.Event != "COMMENT" || len(posted.Comments) != 1 {
+		t.Fatalf("got review %+v, want one comment on the head commit", posted)
+	}
+	comment := posted.Comments[0]
+	if comment.Path != "users/users_test.go" || comment.Line != 41 || comment.Side != "RIGHT" {
+		t.Errorf("got comment at %s:%d (%s), want users/users_test.go:41", comment.Path, comment.Line, comment.Side)
+	}
+	for _, want := range []string{
+		"❌ `TestCount`, `TestCountMore` (example.com/service/users) fail here in the workflow run:",
+		"> `expected 3, got 4`",
+		reviewMarker,
+	} {
+		if !strings.Contains(comment.Body, want) {
+			t.Errorf("body: missing %q in\n%s", want, comment.Body)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// runCommand implements `gotest-report run [flags] [packages] [-- go test flags]`,
+// which invokes go test -json itself, streams the events into the parser while
+// printing a live summary, writes the report and returns go test's exit code.
+func runCommand(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	flags := addReportFlags(fs)
+	rerunFails := fs.Int("rerun-fails", 0, "Re-run failing tests up to N times; tests that pass on a re-run are reported as FLAKY")
+	lenient := fs.Bool("lenient", false, "Skip output lines that aren't go test -json events instead of failing")
+	maxLineBytes := fs.Int("max-line-bytes", report.DefaultMaxLineBytes, "Longest go test output line read into memory at once; longer lines are decoded as a stream")
+	sanitizer := fs.String("sanitizer", "", "Sanitizer (race, asan, msan) to tag the run with (detected from go test flags by default)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: gotest-report run [flags] [packages] [-- go test flags]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	if *sanitizer != "" {
+		var spec inputList
+		if err := (sanitizerFlag{&spec}).Set(*sanitizer); err != nil {
+			logger.Error(err.Error())
+			return 1
+		}
+	}
+
+	// Keep stdout clean when the report itself is being written there
+	consoleFile := os.Stdout
+	if *flags.output == "-" {
+		consoleFile = os.Stderr
+	}
+	console := io.Writer(consoleFile)
+	reporter, err := flags.newReporter(consoleFile)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	cfg := reporter.cfg
+	cfg.maxLineBytes = *maxLineBytes
+	cfg.lenient = *lenient
+
+	packages, testFlags := splitPassthroughArgs(fs.Args())
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+
+	opts := parseOptions(cfg, *flags.format)
+	run, err := runGoTest(testFlags, packages, opts, console)
+	if err != nil {
+		logger.Error("running go test", "error", err)
+		return 1
+	}
+	if run.parseErr != nil {
+		logger.Error("processing test events", "error", run.parseErr)
+		return max(run.exitCode, 1)
+	}
+
+	reportData := run.data
+	logParseStats("go test", reportData)
+	warnSkippedLines(reportData)
+	// Parsing ran alongside the tests, so its timing would measure go test
+	reportData.Metrics = nil
+	reportData.Sanitizer = detectSanitizer(testFlags)
+	if *sanitizer != "" {
+		reportData.Sanitizer = *sanitizer
+	}
+	if *flags.environment {
+		reportData.Environment = goEnvironment()
+	}
+	exitCode := run.exitCode
+	if exitCode == 1 && len(run.buildFailures) > 0 {
+		exitCode = exitBuildFailed
+	}
+	if *rerunFails > 0 && exitCode != 0 {
+		exitCode = rerunFailedTests(reportData, run.failedPackages, exitCode, testFlags, *rerunFails, opts, console)
+	}
+
+	// After re-runs, so the output of their attempts is masked and filtered too
+	reporter.clean(reportData)
+	if err := reporter.process(reportData, []*ReportData{reportData}); err != nil {
+		logger.Error(err.Error())
+		return max(exitCode, 1)
+	}
+	if len(run.buildFailures) > 0 {
+		pkgs := make([]string, 0, len(run.buildFailures))
+		for pkg := range run.buildFailures {
+			pkgs = append(pkgs, pkg)
+		}
+		sort.Strings(pkgs)
+		logger.Error("build failed", "packages", strings.Join(pkgs, ","))
+	}
+
+	if err := reporter.publish(reportData, console, nil); err != nil {
+		logger.Error(err.Error())
+		return max(exitCode, 1)
+	}
+	if gateFailed(reportData) {
+		return max(exitCode, 1)
+	}
+	return exitCode
+}
+
+// goEnvironment describes the environment as reported by the go command that
+// ran the tests, falling back to this process' own when go env fails
+func goEnvironment() *report.Environment {
+	output, err := exec.Command("go", "env", "-json").Output()
+	if err != nil {
+		return report.HostEnvironment()
+	}
+	env, err := report.ParseGoEnv(bytes.NewReader(output))
+	if err != nil {
+		return report.HostEnvironment()
+	}
+	return env
+}
+
+// exitBuildFailed is what the run command exits with when packages failed to
+// build. go test exits with 1 for those and for failing tests alike; telling
+// them apart lets CI distinguish broken code from failing tests. Other exit
+// codes of go test are passed through unchanged.
+const exitBuildFailed = 2
+
+// goTestRun is the outcome of a single go test invocation
+type goTestRun struct {
+	data           *ReportData
+	parseErr       error
+	exitCode       int
+	failedPackages map[string]bool
+	// Packages that failed to build or set up, a subset of failedPackages
+	buildFailures map[string]bool
+}
+
+func newGoTestRun() *goTestRun {
+	return &goTestRun{failedPackages: make(map[string]bool), buildFailures: make(map[string]bool)}
+}
+
+// runGoTest runs go test -json once, streaming its events into the parser
+func runGoTest(testFlags, packages []string, opts report.ParseOptions, console io.Writer) (*goTestRun, error) {
+	goArgs := append([]string{"test", "-json"}, testFlags...)
+	goArgs = append(goArgs, packages...)
+	cmd := exec.Command("go", goArgs...)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	run := newGoTestRun()
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(streamEvents(stdout, pw, console, run))
+	}()
+
+	run.data, run.parseErr = processTestEvents(pr, opts)
+	// Unblock the streaming goroutine if parsing stopped early
+	pr.Close()
+
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, err
+		}
+		run.exitCode = exitErr.ExitCode()
+	}
+
+	return run, nil
+}
+
+// rerunFailedTests re-runs failing top-level tests package by package up to
+// maxReruns times, merging every attempt into data. It returns the exit code
+// to propagate: zero once every failing package is explained by tests that
+// eventually passed, the original code otherwise (e.g. for build failures).
+func rerunFailedTests(data *ReportData, failedPackages map[string]bool, exitCode int, testFlags []string, maxReruns int, opts report.ParseOptions, console io.Writer) int {
+	for attempt := 1; attempt <= maxReruns; attempt++ {
+		failing := report.FailingRootTests(data)
+		if len(failing) == 0 {
+			break
+		}
+
+		pkgs := make([]string, 0, len(failing))
+		for pkg := range failing {
+			pkgs = append(pkgs, pkg)
+		}
+		sort.Strings(pkgs)
+
+		for _, pkg := range pkgs {
+			fmt.Fprintf(console, "🔁 Re-running %d failing test(s) in %s (attempt %d of %d)\n",
+				len(failing[pkg]), pkg, attempt, maxReruns)
+
+			flags := append(append([]string{}, testFlags...), "-run", runPattern(failing[pkg]))
+			run, err := runGoTest(flags, []string{pkg}, opts, console)
+			if err != nil {
+				logger.Error("re-running tests", "package", pkg, "error", err)
+				return exitCode
+			}
+			if run.parseErr != nil {
+				logger.Error("processing re-run events", "package", pkg, "error", run.parseErr)
+				return exitCode
+			}
+			logParseStats("go test re-run of "+pkg, run.data)
+			report.MergeRerun(data, run.data)
+		}
+	}
+
+	// A package failure is only forgiven when it had failing tests and none remain
+	testFailures := make(map[string]bool)
+	for _, result := range data.Results {
+		if len(result.Attempts) > 0 {
+			testFailures[result.Package] = true
+		}
+	}
+	for pkg := range failedPackages {
+		if !testFailures[pkg] {
+			return exitCode
+		}
+	}
+	if data.FailedTests > 0 {
+		return exitCode
+	}
+	return 0
+}
+
+// splitPassthroughArgs splits positional arguments at "--" into packages and
+// flags that are passed through to go test unchanged.
+func splitPassthroughArgs(args []string) (packages, passthrough []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// streamEvents copies go test -json output to w line by line, printing package
+// results and failing tests to console as they arrive and recording failed
+// and unbuildable packages in run. It keeps draining src after w is closed so
+// go test never blocks on a full pipe.
+//
+// Lines longer than the read buffer are copied through in pieces without a
+// console update; package and test results are never that long.
+func streamEvents(src io.Reader, w io.Writer, console io.Writer, run *goTestRun) error {
+	reader := bufio.NewReaderSize(src, 1024*1024)
+
+	var writeErr error
+	long := false
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if (err == nil || err == io.EOF) && !long {
+			handleLiveEvent(console, chunk, run)
+		}
+		long = err == bufio.ErrBufferFull
+
+		if writeErr == nil && len(chunk) > 0 {
+			if _, werr := w.Write(chunk); werr != nil {
+				writeErr = werr
+			}
+		}
+
+		switch err {
+		case nil, bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			if len(chunk) > 0 && writeErr == nil {
+				// Keep the output line-terminated for the parser
+				_, writeErr = w.Write([]byte("\n"))
+			}
+			return writeErr
+		default:
+			io.Copy(io.Discard, src)
+			return err
+		}
+	}
+}
+
+// handleLiveEvent prints a one-line console update for package results and
+// failing tests, and compiler errors as they come; everything else is left
+// for the final report.
+func handleLiveEvent(console io.Writer, line []byte, run *goTestRun) {
+	// Cheap pre-check so output events, the bulk of the stream, are never decoded here
+	if !bytes.Contains(line, []byte(`"Action":"pass"`)) &&
+		!bytes.Contains(line, []byte(`"Action":"fail"`)) &&
+		!bytes.Contains(line, []byte(`"Action":"skip"`)) &&
+		!bytes.Contains(line, []byte(`"Action":"build-output"`)) &&
+		!bytes.Contains(line, []byte(`[build failed]`)) &&
+		!bytes.Contains(line, []byte(`[setup failed]`)) {
+		return
+	}
+
+	var event struct {
+		TestEvent
+		// Set on the final event of a package that failed to build (Go 1.24+)
+		FailedBuild string
+	}
+	if err := json.Unmarshal(line, &event); err != nil {
+		return
+	}
+
+	switch {
+	case event.Action == "build-output":
+		fmt.Fprint(console, event.Output)
+	case event.Action == "output":
+		// Go before 1.24 only says so in the package's last line
+		if event.Test == "" && (strings.Contains(event.Output, "[build failed]") || strings.Contains(event.Output, "[setup failed]")) {
+			run.buildFailures[event.Package] = true
+		}
+	case event.Test != "" && event.Action == "fail":
+		fmt.Fprintf(console, "    --- FAIL: %s (%.2fs)\n", event.Test, event.Elapsed)
+	case event.Test != "":
+		// Passing and skipped tests only show up in the totals
+	case event.Action == "pass":
+		fmt.Fprintf(console, "✅ ok    %s\t%.2fs\n", event.Package, event.Elapsed)
+	case event.Action == "fail" && (event.FailedBuild != "" || run.buildFailures[event.Package]):
+		run.failedPackages[event.Package] = true
+		run.buildFailures[event.Package] = true
+		fmt.Fprintf(console, "❌ FAIL  %s\t[build failed]\n", event.Package)
+	case event.Action == "fail":
+		run.failedPackages[event.Package] = true
+		fmt.Fprintf(console, "❌ FAIL  %s\t%.2fs\n", event.Package, event.Elapsed)
+	case event.Action == "skip":
+		fmt.Fprintf(console, "⏭️ ?     %s\t[no test files]\n", event.Package)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSplitPassthroughArgs(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		wantPackages    string
+		wantPassthrough string
+	}{
+		{"packages only", []string{"./...", "./cmd"}, "./... ./cmd", ""},
+		{"with passthrough", []string{"./...", "--", "-race", "-count=1"}, "./...", "-race -count=1"},
+		{"passthrough only", []string{"--", "-short"}, "", "-short"},
+		{"empty", nil, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packages, passthrough := splitPassthroughArgs(tt.args)
+			if got := strings.Join(packages, " "); got != tt.wantPackages {
+				t.Errorf("packages: got %q, want %q", got, tt.wantPackages)
+			}
+			if got := strings.Join(passthrough, " "); got != tt.wantPassthrough {
+				t.Errorf("passthrough: got %q, want %q", got, tt.wantPassthrough)
+			}
+		})
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	input := `{"Action":"run","Package":"pkg/a","Test":"TestOK"}
+{"Action":"output","Package":"pkg/a","Test":"TestOK","Output":"--- PASS: TestOK\n"}
+{"Action":"pass","Package":"pkg/a","Test":"TestOK","Elapsed":0.1}
+{"Action":"fail","Package":"pkg/a","Test":"TestBad","Elapsed":0.2}
+{"Action":"fail","Package":"pkg/a","Elapsed":0.5}
+{"Action":"pass","Package":"pkg/b","Elapsed":1.25}
+{"Action":"skip","Package":"pkg/c"}
+`
+	var forwarded, console bytes.Buffer
+	run := newGoTestRun()
+	if err := streamEvents(strings.NewReader(input), &forwarded, &console, run); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if forwarded.String() != input {
+		t.Errorf("events were not forwarded unchanged:\n%s", forwarded.String())
+	}
+
+	for _, want := range []string{
+		"--- FAIL: TestBad (0.20s)",
+		"❌ FAIL  pkg/a\t0.50s",
+		"✅ ok    pkg/b\t1.25s",
+		"pkg/c\t[no test files]",
+	} {
+		if !strings.Contains(console.String(), want) {
+			t.Errorf("console output missing %q:\n%s", want, console.String())
+		}
+	}
+	if strings.Contains(console.String(), "TestOK") {
+		t.Error("passing tests should not be printed live")
+	}
+	if len(run.failedPackages) != 1 || !run.failedPackages["pkg/a"] || len(run.buildFailures) != 0 {
+		t.Errorf("failed packages: got %v (build %v), want [pkg/a]", run.failedPackages, run.buildFailures)
+	}
+}
+
+func TestStreamEventsBuildFailures(t *testing.T) {
+	input := `{"ImportPath":"pkg/a [pkg/a.test]","Action":"build-output","Output":"a_test.go:3:28: declared and not used: x\n"}
+{"ImportPath":"pkg/a [pkg/a.test]","Action":"build-fail"}
+{"Action":"output","Package":"pkg/a","Output":"FAIL\tpkg/a [build failed]\n"}
+{"Action":"fail","Package":"pkg/a","Elapsed":0,"FailedBuild":"pkg/a [pkg/a.test]"}
+{"Action":"output","Package":"pkg/old","Output":"FAIL\tpkg/old [build failed]\n"}
+{"Action":"fail","Package":"pkg/old","Elapsed":0}
+{"Action":"output","Package":"pkg/b","Test":"TestB","Output":"saw [build failed] in a fixture\n"}
+{"Action":"fail","Package":"pkg/b","Test":"TestB","Elapsed":0.1}
+{"Action":"fail","Package":"pkg/b","Elapsed":0.2}
+`
+	var forwarded, console bytes.Buffer
+	run := newGoTestRun()
+	if err := streamEvents(strings.NewReader(input), &forwarded, &console, run); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(run.failedPackages) != 3 || len(run.buildFailures) != 2 || !run.buildFailures["pkg/a"] || !run.buildFailures["pkg/old"] {
+		t.Errorf("got failed %v, build failures %v", run.failedPackages, run.buildFailures)
+	}
+	for _, want := range []string{
+		"a_test.go:3:28: declared and not used: x\n",
+		"❌ FAIL  pkg/a\t[build failed]",
+		"❌ FAIL  pkg/old\t[build failed]",
+		"❌ FAIL  pkg/b\t0.20s",
+	} {
+		if !strings.Contains(console.String(), want) {
+			t.Errorf("console output missing %q:\n%s", want, console.String())
+		}
+	}
+}
+
+func TestStreamEventsLongLines(t *testing.T) {
+	// An output line longer than the read buffer, which mentions a failure
+	// that must not be reported live, and no newline after the last event
+	long := `{"Action":"output","Package":"pkg/a","Test":"TestDump","Output":"` +
+		strings.Repeat("x", 3*1024*1024) + `\"Action\":\"fail\"\n"}` + "\n"
+	input := long + `{"Action":"fail","Package":"pkg/a","Elapsed":0.5}`
+
+	var forwarded, console bytes.Buffer
+	run := newGoTestRun()
+	if err := streamEvents(strings.NewReader(input), &forwarded, &console, run); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if forwarded.String() != input+"\n" {
+		t.Errorf("events were not forwarded unchanged (%d bytes, want %d)", forwarded.Len(), len(input)+1)
+	}
+	if !run.failedPackages["pkg/a"] || strings.Contains(console.String(), "TestDump") {
+		t.Errorf("console output: got %q", console.String())
+	}
+}
+
+func TestRunPattern(t *testing.T) {
+	got := runPattern([]string{"TestA", "TestB.v2"})
+	if want := `^(TestA|TestB\.v2)$`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+)
+
+// reportHandler serves the report of the inputs, re-reading them on every
+// request so a page refresh picks up tests that finished since
+type reportHandler struct {
+	inputs *inputFlags
+	cfg    *config
+}
+
+func (h reportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var format, contentType string
+	switch r.URL.Path {
+	case "/":
+		format, contentType = "html", "text/html; charset=utf-8"
+	case "/report.md":
+		format, contentType = "markdown", "text/markdown; charset=utf-8"
+	case "/report.json":
+		format, contentType = "json", "application/json"
+	case "/junit.xml":
+		format, contentType = "junit", "application/xml"
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	runs, err := h.inputs.load(h.cfg, format)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error processing test events: %v", err), http.StatusInternalServerError)
+		return
+	}
+	content, err := renderReport(combineRuns(runs), h.cfg, format)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering report: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	fmt.Fprint(w, content)
+}
+
+// serveCommand implements `gotest-report serve [flags]`, which serves the
+// HTML report of files that may still be growing, e.g. while go test runs
+func serveCommand(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	inputs := addInputFlags(fs)
+	addr := fs.String("addr", "localhost:8080", "Address to listen on")
+	configFile := fs.String("config", "", "JSON config file (package display names, ...)")
+	if err := parseFlags(fs, args); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	if len(inputs.inputs.specs) == 0 {
+		logger.Error("serve needs at least one -input file, since stdin can only be read once")
+		return 1
+	}
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		logger.Error("loading config", "error", err)
+		return 1
+	}
+	inputs.configure(cfg)
+
+	fmt.Printf("Serving the test report on http://%s/ (also /report.md, /report.json and /junit.xml)\n", *addr)
+	if err := http.ListenAndServe(*addr, reportHandler{inputs: inputs, cfg: cfg}); err != nil {
+		logger.Error("serving report", "error", err)
+		return 1
+	}
+	return 0
+}
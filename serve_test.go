@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReportHandler(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "test.json")
+	write := func(content string) {
+		if err := os.WriteFile(input, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"Action":"run","Package":"example.com/a","Test":"TestA"}` + "\n")
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	inputs := addInputFlags(fs)
+	if err := fs.Parse([]string{"-input", input}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := defaultConfig()
+	inputs.configure(cfg)
+	server := httptest.NewServer(reportHandler{inputs: inputs, cfg: cfg})
+	defer server.Close()
+
+	get := func(path string) (int, string, string) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+	}
+
+	if code, contentType, body := get("/"); code != http.StatusOK || !strings.HasPrefix(contentType, "text/html") || !strings.Contains(body, "TestA") {
+		t.Errorf("GET /: got %d %s\n%s", code, contentType, body)
+	}
+
+	// The input is read again for every request
+	write(`{"Action":"run","Package":"example.com/a","Test":"TestA"}` + "\n" +
+		`{"Action":"fail","Package":"example.com/a","Test":"TestA"}` + "\n")
+	if code, _, body := get("/report.json"); code != http.StatusOK || !strings.Contains(body, `"failed": 1`) {
+		t.Errorf("GET /report.json: got %d\n%s", code, body)
+	}
+	if code, _, body := get("/junit.xml"); code != http.StatusOK || !strings.Contains(body, `failures="1"`) {
+		t.Errorf("GET /junit.xml: got %d\n%s", code, body)
+	}
+	if code, _, _ := get("/missing"); code != http.StatusNotFound {
+		t.Errorf("GET /missing: got %d, want 404", code)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxSourceSnippets caps the snippets embedded per failure
+const maxSourceSnippets = 3
+
+// snippetEmbedder renders the source around file:line references in failure
+// output so reviewers see the failing assertion without opening an editor
+type snippetEmbedder struct {
+	tree    *sourceTree
+	context int                 // lines shown before and after the reference
+	files   map[string][]string // file contents by path, read once
+}
+
+// newSnippetEmbedder returns nil outside of a Go module
+func newSnippetEmbedder(dir string, context int) *snippetEmbedder {
+	tree := findSourceTree(dir)
+	if tree == nil {
+		return nil
+	}
+	return &snippetEmbedder{tree: tree, context: max(context, 0), files: make(map[string][]string)}
+}
+
+// snippetEmbedderFromFlags returns nil unless -embed-source was given
+func snippetEmbedderFromFlags(embed bool, context int) *snippetEmbedder {
+	if !embed {
+		return nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	return newSnippetEmbedder(wd, context)
+}
+
+// lines returns the lines of path, or nil when it can't be read
+func (e *snippetEmbedder) lines(path string) []string {
+	lines, cached := e.files[path]
+	if !cached {
+		content, err := os.ReadFile(path)
+		if err == nil {
+			lines = strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+		}
+		e.files[path] = lines
+	}
+	return lines
+}
+
+// writeSourceSnippets writes the source around each resolvable file:line
+// reference in a failure, marking the referenced line
+func writeSourceSnippets(sb *strings.Builder, cfg *config, pkg string, output []string) {
+	e := cfg.sourceSnippets
+	if e == nil {
+		return
+	}
+
+	for _, location := range e.tree.locate(pkg, output, maxSourceSnippets) {
+		lines := e.lines(location.path)
+		if location.line < 1 || location.line > len(lines) {
+			continue
+		}
+
+		first := max(location.line-e.context, 1)
+		last := min(location.line+e.context, len(lines))
+		width := len(fmt.Sprint(last))
+
+		sb.WriteString(fmt.Sprintf("📄 **%s**\n\n", location.label()))
+		sb.WriteString("```go\n")
+		for n := first; n <= last; n++ {
+			marker := " "
+			if n == location.line {
+				marker = ">"
+			}
+			sb.WriteString(fmt.Sprintf("%s %*d | %s\n", marker, width, n, lines[n-1]))
+		}
+		sb.WriteString("```\n\n")
+	}
+}
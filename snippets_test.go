@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSourceSnippets(t *testing.T) {
+	moduleRoot := t.TempDir()
+	source := "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif got := Add(1, 2); got != 4 {\n\t\tt.Errorf(\"expected 4, got %d\", got)\n\t}\n}\n"
+	if err := os.WriteFile(filepath.Join(moduleRoot, "go.mod"), []byte("module example.com/calc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(moduleRoot, "calc_test.go"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := defaultConfig()
+	cfg.sourceSnippets = newSnippetEmbedder(moduleRoot, 1)
+	if cfg.sourceSnippets == nil {
+		t.Fatal("expected an embedder inside a module")
+	}
+
+	var sb strings.Builder
+	writeSourceSnippets(&sb, cfg, "example.com/calc", []string{
+		"    calc_test.go:7: expected 4, got 3",
+		"    calc_test.go:99: beyond the end of the file",
+	})
+	want := "📄 **calc_test.go:7**\n\n```go\n  6 | \tif got := Add(1, 2); got != 4 {\n> 7 | \t\tt.Errorf(\"expected 4, got %d\", got)\n  8 | \t}\n```\n\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	sb.Reset()
+	writeSourceSnippets(&sb, defaultConfig(), "example.com/calc", []string{"    calc_test.go:7: expected 4, got 3"})
+	if sb.Len() != 0 {
+		t.Error("snippets should be off by default")
+	}
+}
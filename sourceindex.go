@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sourceIndex maps the test functions of the reported packages to where they
+// are defined, found by parsing their test files rather than relying on the
+// file:line references in their output
+type sourceIndex struct {
+	tree  *sourceTree
+	tests map[string]testSource // by package and top-level test
+}
+
+// testSource is where a test function is defined
+type testSource struct {
+	location sourceLocation
+	// First paragraph of the doc comment, on one line
+	doc string
+}
+
+// goListPackage is the part of `go list -json` output the index needs
+type goListPackage struct {
+	ImportPath   string
+	Dir          string
+	TestGoFiles  []string
+	XTestGoFiles []string
+}
+
+// sourceIndexFromFlags returns nil unless -source-index was given
+func sourceIndexFromFlags(enabled bool, data *ReportData) *sourceIndex {
+	if !enabled {
+		return nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	index, err := newSourceIndex(wd, reportedPackages(data))
+	if err != nil {
+		logger.Warn("not indexing test sources", "error", err)
+		return nil
+	}
+	return index
+}
+
+// reportedPackages returns the packages of the tests in data, sorted
+func reportedPackages(data *ReportData) []string {
+	seen := make(map[string]bool)
+	var packages []string
+	for _, result := range data.Results {
+		if result.Package != "" && !seen[result.Package] {
+			seen[result.Package] = true
+			packages = append(packages, result.Package)
+		}
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// newSourceIndex lists packages with the go command run in dir and indexes
+// the test functions in their test files. Packages go list can't find are
+// left out.
+func newSourceIndex(dir string, packages []string) (*sourceIndex, error) {
+	tree := findSourceTree(dir)
+	if tree == nil {
+		return nil, errors.New("not in a Go module")
+	}
+	index := &sourceIndex{tree: tree, tests: make(map[string]testSource)}
+	if len(packages) == 0 {
+		return index, nil
+	}
+
+	cmd := exec.Command("go", append([]string{"list", "-e", "-json", "--"}, packages...)...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var pkg goListPackage
+		if err := decoder.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("parsing go list output: %v", err)
+		}
+		for _, file := range append(pkg.TestGoFiles, pkg.XTestGoFiles...) {
+			index.add(pkg.ImportPath, filepath.Join(pkg.Dir, file))
+		}
+	}
+	return index, nil
+}
+
+// add indexes the tests, benchmarks, fuzz tests and examples declared in a
+// test file of pkg
+func (x *sourceIndex) add(pkg, path string) {
+	rel, err := filepath.Rel(x.tree.repoRoot, path)
+	if err != nil {
+		return
+	}
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return
+	}
+	for _, decl := range parsed.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || !isTestFunc(fn.Name.Name) {
+			continue
+		}
+		x.tests[pkg+"."+fn.Name.Name] = testSource{
+			location: sourceLocation{path: path, rel: rel, line: fset.Position(fn.Pos()).Line},
+			doc:      docSummary(fn.Doc.Text()),
+		}
+	}
+}
+
+// isTestFunc reports whether name is one go test runs
+func isTestFunc(name string) bool {
+	for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// docSummary returns the first paragraph of a doc comment on one line
+func docSummary(doc string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimSpace(doc), "\n\n")
+	return strings.Join(strings.Fields(paragraph), " ")
+}
+
+// lookup returns where the top-level test of a test in pkg is defined
+func (x *sourceIndex) lookup(pkg, test string) (testSource, bool) {
+	if x == nil {
+		return testSource{}, false
+	}
+	test, _, _ = strings.Cut(test, "/")
+	source, ok := x.tests[pkg+"."+test]
+	return source, ok
+}
+
+// maxDescription caps the runes of a doc comment shown in the results table
+const maxDescription = 120
+
+// description returns the doc comment of a top-level test in pkg, shortened
+// for the results table
+func (x *sourceIndex) description(pkg, test string) string {
+	if strings.Contains(test, "/") {
+		return ""
+	}
+	source, _ := x.lookup(pkg, test)
+	if runes := []rune(source.doc); len(runes) > maxDescription {
+		return strings.TrimSpace(string(runes[:maxDescription-1])) + "…"
+	}
+	return source.doc
+}
+
+// writeDefinition writes where a failed test is defined, linked to GitHub when
+// links are available, and its doc comment
+func writeDefinition(sb *strings.Builder, cfg *config, pkg, test string) {
+	source, ok := cfg.sourceIndex.lookup(pkg, test)
+	if !ok {
+		return
+	}
+	label := fmt.Sprintf("`%s:%d`", filepath.ToSlash(source.location.rel), source.location.line)
+	if url := cfg.sourceLinks.url(source.location); url != "" {
+		label = fmt.Sprintf("[%s](%s)", label, url)
+	}
+	sb.WriteString(fmt.Sprintf("🧭 **Defined at:** %s\n\n", label))
+	if source.doc != "" {
+		sb.WriteString(fmt.Sprintf("> %s\n\n", source.doc))
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSourceIndex(t *testing.T) {
+	moduleRoot := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module example.com/calc\n\ngo 1.21\n",
+		"calc.go":      "package calc\n\nfunc Add(a, b int) int { return a + b }\n",
+		"calc_test.go": "package calc\n\nimport \"testing\"\n\n// TestAdd checks that\n// small numbers add up.\n//\n// More detail.\nfunc TestAdd(t *testing.T) {}\n\nfunc helper() {}\n",
+		"x_test.go":    "package calc_test\n\nimport \"testing\"\n\nfunc TestExternal(t *testing.T) {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(moduleRoot, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index, err := newSourceIndex(moduleRoot, []string{"example.com/calc", "example.com/missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := index.lookup("example.com/calc", "helper"); ok {
+		t.Error("helpers should not be indexed")
+	}
+	if source, ok := index.lookup("example.com/calc", "TestExternal"); !ok || source.location.label() != "x_test.go:5" {
+		t.Errorf("TestExternal: got %+v, %v", source, ok)
+	}
+
+	cfg := defaultConfig()
+	cfg.sourceIndex = index
+	var sb strings.Builder
+	writeDefinition(&sb, cfg, "example.com/calc", "TestAdd/small")
+	want := "🧭 **Defined at:** `calc_test.go:9`\n\n> TestAdd checks that small numbers add up.\n\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	sb.Reset()
+	cfg.sourceLinks = newSourceLinker("https://github.com", "acme/calc", "abc123", moduleRoot)
+	writeDefinition(&sb, cfg, "example.com/calc", "TestExternal")
+	want = "🧭 **Defined at:** [`x_test.go:5`](https://github.com/acme/calc/blob/abc123/x_test.go#L5)\n\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	data := &ReportData{
+		TotalTests:      2,
+		PassedTests:     2,
+		SortedTestNames: []string{"TestAdd", "TestAdd/small"},
+		Results: map[string]*TestResult{
+			"TestAdd":       {Name: "TestAdd", Package: "example.com/calc", Status: "PASS", SubTests: []string{"TestAdd/small"}},
+			"TestAdd/small": {Name: "TestAdd/small", Package: "example.com/calc", Status: "PASS", IsSubTest: true},
+		},
+	}
+	if markdown := renderMarkdownReport(data, cfg); !strings.Contains(markdown, "| **TestAdd**<br><sub>TestAdd checks that small numbers add up.</sub> |") {
+		t.Errorf("results table missing the description:\n%s", markdown)
+	}
+	html, err := renderHTMLReport(data, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, `<td title="TestAdd checks that small numbers add up.">TestAdd</td>`) || !strings.Contains(html, "<td>TestAdd/small</td>") {
+		t.Errorf("HTML results table missing the description tooltip:\n%s", html)
+	}
+
+	index.tests["example.com/calc.TestLong"] = testSource{doc: strings.Repeat("word ", 40)}
+	if got := index.description("example.com/calc", "TestLong"); len([]rune(got)) > maxDescription || !strings.HasSuffix(got, "word…") {
+		t.Errorf("long description: got %q", got)
+	}
+
+	sb.Reset()
+	writeDefinition(&sb, defaultConfig(), "example.com/calc", "TestAdd")
+	if sb.Len() != 0 {
+		t.Error("definitions should be off by default")
+	}
+}
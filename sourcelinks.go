@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxSourceLinks caps the links shown per failure
+const maxSourceLinks = 5
+
+// sourceRef matches "users_test.go:42" as printed by t.Error, and absolute
+// paths such as "/src/app/users_test.go:42" in stack traces
+var sourceRef = regexp.MustCompile(`((?:/|\b)[\w.\-/]*\w\.go):(\d+)\b`)
+
+// sourceTree maps the file:line references in failure output to files in the
+// checkout, using the module enclosing the working directory
+type sourceTree struct {
+	repoRoot   string
+	moduleRoot string
+	modulePath string
+}
+
+// sourceLocation is a file:line reference resolved to a file in the checkout
+type sourceLocation struct {
+	path string // absolute path
+	rel  string // path relative to the repository root
+	line int
+}
+
+// findSourceTree finds the module enclosing dir and the repository root (the
+// nearest directory holding .git, or the module root). It returns nil outside
+// of a Go module.
+func findSourceTree(dir string) *sourceTree {
+	moduleRoot, modulePath := findModule(dir)
+	if moduleRoot == "" {
+		return nil
+	}
+
+	repoRoot := moduleRoot
+	for d := moduleRoot; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			repoRoot = d
+			break
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	return &sourceTree{repoRoot: repoRoot, moduleRoot: moduleRoot, modulePath: modulePath}
+}
+
+// findModule returns the directory and module path of the go.mod enclosing dir
+func findModule(dir string) (string, string) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", ""
+	}
+	for {
+		if path, err := modulePath(filepath.Join(dir, "go.mod")); err == nil {
+			if path == "" {
+				return "", ""
+			}
+			return dir, path
+		}
+		if filepath.Dir(dir) == dir {
+			return "", ""
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// modulePath reads the module path declared by a go.mod file, or "" when it
+// declares none
+func modulePath(goMod string) (string, error) {
+	file, err := os.Open(goMod)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if path, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(path), `"`), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// locate resolves the file:line references in the output of a test in pkg,
+// at most limit of them. References to files outside the repository (the
+// standard library, module cache) or that don't exist in the checkout are
+// dropped.
+func (t *sourceTree) locate(pkg string, output []string, limit int) []sourceLocation {
+	if t == nil {
+		return nil
+	}
+
+	pkgDir := t.packageDir(pkg)
+	var locations []sourceLocation
+	seen := make(map[string]bool)
+	for _, line := range output {
+		for _, m := range sourceRef.FindAllStringSubmatch(line, -1) {
+			file := m[1]
+			if !filepath.IsAbs(file) {
+				if pkgDir == "" {
+					continue
+				}
+				file = filepath.Join(pkgDir, file)
+			}
+
+			rel, err := filepath.Rel(t.repoRoot, file)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			lineNo, err := strconv.Atoi(m[2])
+			if err != nil {
+				continue
+			}
+			key := rel + ":" + m[2]
+			if seen[key] {
+				continue
+			}
+			if _, err := os.Stat(file); err != nil {
+				continue
+			}
+			seen[key] = true
+
+			locations = append(locations, sourceLocation{path: file, rel: rel, line: lineNo})
+			if len(locations) == limit {
+				return locations
+			}
+		}
+	}
+	return locations
+}
+
+// packageDir returns the directory of pkg when it belongs to the module, or
+// "" otherwise
+func (t *sourceTree) packageDir(pkg string) string {
+	if t == nil {
+		return ""
+	}
+	if pkg == t.modulePath {
+		return t.moduleRoot
+	}
+	if rest, ok := strings.CutPrefix(pkg, t.modulePath+"/"); ok {
+		return filepath.Join(t.moduleRoot, filepath.FromSlash(rest))
+	}
+	return ""
+}
+
+// label returns the short "users_test.go:42" form of the location
+func (l sourceLocation) label() string {
+	return fmt.Sprintf("%s:%d", filepath.Base(l.path), l.line)
+}
+
+// sourceLinker turns file:line references in failure output into GitHub
+// permalinks for the commit under test
+type sourceLinker struct {
+	baseURL string // <server>/<owner>/<repo>/blob/<sha>
+	tree    *sourceTree
+}
+
+// sourceLinkerFromEnv configures links from the GitHub Actions environment.
+// It returns nil outside of GitHub Actions or a Go module.
+func sourceLinkerFromEnv() *sourceLinker {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	return newSourceLinker(os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_SHA"), wd)
+}
+
+func newSourceLinker(serverURL, repository, sha, dir string) *sourceLinker {
+	if serverURL == "" || repository == "" || sha == "" {
+		return nil
+	}
+	tree := findSourceTree(dir)
+	if tree == nil {
+		return nil
+	}
+	return &sourceLinker{
+		baseURL: fmt.Sprintf("%s/%s/blob/%s", strings.TrimSuffix(serverURL, "/"), repository, sha),
+		tree:    tree,
+	}
+}
+
+// sourceLink is a resolved file:line reference
+type sourceLink struct {
+	label string
+	url   string
+}
+
+// links returns permalinks for the file:line references in the output of a
+// test in pkg
+func (l *sourceLinker) links(pkg string, output []string) []sourceLink {
+	if l == nil {
+		return nil
+	}
+
+	var links []sourceLink
+	for _, location := range l.tree.locate(pkg, output, maxSourceLinks) {
+		links = append(links, sourceLink{
+			label: location.label(),
+			url:   l.url(location),
+		})
+	}
+	return links
+}
+
+// url returns the permalink to a location, or "" without links
+func (l *sourceLinker) url(location sourceLocation) string {
+	if l == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s#L%d", l.baseURL, filepath.ToSlash(location.rel), location.line)
+}
+
+// writeSourceLinks writes a line of links to the source locations mentioned
+// in a failure, if any can be resolved
+func writeSourceLinks(sb *strings.Builder, cfg *config, pkg string, output []string) {
+	links := cfg.sourceLinks.links(pkg, output)
+	if len(links) == 0 {
+		return
+	}
+
+	parts := make([]string, len(links))
+	for i, link := range links {
+		parts[i] = fmt.Sprintf("[`%s`](%s)", link.label, link.url)
+	}
+	sb.WriteString(fmt.Sprintf("📍 **Source:** %s\n\n", strings.Join(parts, ", ")))
+}
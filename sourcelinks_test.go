@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSourceLinks(t *testing.T) {
+	repo := t.TempDir()
+	moduleRoot := filepath.Join(repo, "service")
+	for _, dir := range []string{filepath.Join(repo, ".git"), filepath.Join(moduleRoot, "users")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(moduleRoot, "go.mod"):                 "module example.com/service\n\ngo 1.23\n",
+		filepath.Join(moduleRoot, "users", "users_test.go"): "package users\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if newSourceLinker("", "acme/service", "abc123", moduleRoot) != nil {
+		t.Error("expected no linker outside of GitHub Actions")
+	}
+
+	linker := newSourceLinker("https://github.com/", "acme/service", "abc123", filepath.Join(moduleRoot, "users"))
+	if linker == nil {
+		t.Fatal("expected a linker")
+	}
+
+	output := []string{
+		"=== RUN   TestCount",
+		"    users_test.go:42: expected 3, got 4",
+		"    users_test.go:42: repeated",
+		"    testing.go:1465: race detected during execution of test",
+		"\t" + filepath.Join(moduleRoot, "users", "users_test.go") + ":9 +0x1d",
+		"\t/usr/local/go/src/testing/testing.go:1792 +0xf3",
+	}
+	links := linker.links("example.com/service/users", output)
+
+	want := []sourceLink{
+		{"users_test.go:42", "https://github.com/acme/service/blob/abc123/service/users/users_test.go#L42"},
+		{"users_test.go:9", "https://github.com/acme/service/blob/abc123/service/users/users_test.go#L9"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("got %d links, want %d: %+v", len(links), len(want), links)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Errorf("link %d: got %+v, want %+v", i, links[i], want[i])
+		}
+	}
+
+	if links := linker.links("example.com/other", output[:2]); len(links) != 0 {
+		t.Errorf("expected no links for a package outside the module, got %+v", links)
+	}
+
+	cfg := defaultConfig()
+	cfg.sourceLinks = linker
+	markdown := renderMarkdownReport(&ReportData{
+		TotalTests:      1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestCount"},
+		Results: map[string]*TestResult{
+			"TestCount": {Name: "TestCount", Package: "example.com/service/users", Status: "FAIL", Output: output},
+		},
+	}, cfg)
+	if !strings.Contains(markdown, "📍 **Source:** [`users_test.go:42`](https://github.com/acme/service/blob/abc123/service/users/users_test.go#L42), [`users_test.go:9`]") {
+		t.Error("report missing source links")
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// checkSplit validates -split-by and -split-size against the output flags
+func checkSplit(splitBy string, splitSize int, format, output string) error {
+	if splitBy != "" && splitBy != "package" {
+		return fmt.Errorf("unknown -split-by value %q (want package)", splitBy)
+	}
+	if splitBy == "" && splitSize <= 0 {
+		return nil
+	}
+	if format != "markdown" {
+		return fmt.Errorf("splitting the report needs -format markdown")
+	}
+	if output == "-" {
+		return fmt.Errorf("splitting the report needs an -output file")
+	}
+	return nil
+}
+
+// shouldSplit reports whether the rendered report should be replaced with
+// one report per package
+func shouldSplit(splitBy string, splitSize int, content string) bool {
+	return splitBy == "package" || splitSize > 0 && len(content) > splitSize
+}
+
+// writeSplitReport writes one Markdown report per package into a directory
+// named after the output file (test-report.md gets test-report/) and an index
+// linking to them at outputPath. It returns the number of package reports.
+func writeSplitReport(data *ReportData, cfg *config, outputPath string) (int, error) {
+	dir := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, fmt.Errorf("error creating report directory: %v", err)
+	}
+
+	parts := report.SplitByPackage(data)
+	packages := make([]string, 0, len(parts))
+	for pkg := range parts {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+
+	index := filepath.Base(outputPath)
+	links := make(map[string]string, len(packages))
+	used := make(map[string]bool, len(packages))
+	for _, pkg := range packages {
+		// Different import paths can map to the same file name
+		name := report.PackageFileName(pkg)
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s-%d", report.PackageFileName(pkg), i)
+		}
+		used[name] = true
+		name += ".md"
+
+		content := cfg.writeEmoji(fmt.Sprintf("📦 **Package:** `%s` · [⬅️ All packages](../%s)\n\n", pkg, index)) +
+			renderMarkdownReport(parts[pkg], cfg)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			return 0, fmt.Errorf("error writing package report: %v", err)
+		}
+		links[pkg] = path.Join(filepath.Base(dir), name)
+	}
+
+	content := renderSplitIndex(data, cfg, parts, links)
+	if err := os.WriteFile(outputPath, []byte(content), 0o644); err != nil {
+		return 0, err
+	}
+	return len(packages), nil
+}
+
+// renderSplitIndex renders the overall summary and a table linking to each
+// package report, failing packages first
+func renderSplitIndex(data *ReportData, cfg *config, parts map[string]*ReportData, links map[string]string) string {
+	packages := make([]string, 0, len(parts))
+	for pkg := range parts {
+		packages = append(packages, pkg)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		fi, fj := parts[packages[i]].FailedTests > 0, parts[packages[j]].FailedTests > 0
+		if fi != fj {
+			return fi
+		}
+		return packages[i] < packages[j]
+	})
+
+	var sb strings.Builder
+	sb.WriteString("# 🧪 Test Summary Report\n\n")
+	sb.WriteString("## 📊 Summary\n\n")
+	sb.WriteString(fmt.Sprintf("- 🧪 **Total Tests:** %d\n", data.TotalTests))
+	sb.WriteString(fmt.Sprintf("- ✅ **Passed:** %d\n", data.PassedTests))
+	sb.WriteString(fmt.Sprintf("- ❌ **Failed:** %d\n", data.FailedTests))
+	sb.WriteString(fmt.Sprintf("- ⏭️ **Skipped:** %d\n", data.SkippedTests))
+	if data.FlakyTests > 0 {
+		sb.WriteString(fmt.Sprintf("- 🔁 **Flaky:** %d (passed on re-run)\n", data.FlakyTests))
+	}
+	sb.WriteString(fmt.Sprintf("- 📦 **Packages:** %d, one report each\n", len(packages)))
+	sb.WriteString(fmt.Sprintf("- ⏱️ **Total Duration:** %.2fs\n\n", data.TotalDuration))
+
+	sb.WriteString("## 📦 Packages\n\n")
+	sb.WriteString("| Package | Status | Tests | ✅ | ❌ | ⏭️ | Duration |\n")
+	sb.WriteString("| ------- | ------ | ----- | -- | -- | -- | -------- |\n")
+	for _, pkg := range packages {
+		part := parts[pkg]
+		status := "✅"
+		switch {
+		case part.FailedTests > 0:
+			status = "❌"
+		case part.SkippedTests == part.TotalTests:
+			status = "⏭️"
+		}
+		sb.WriteString(fmt.Sprintf("| [%s](%s) | %s | %d | %d | %d | %d | %.2fs |\n",
+			markdownCell(cfg.packageName(pkg)), links[pkg], status,
+			part.TotalTests, part.PassedTests, part.FailedTests, part.SkippedTests, part.TotalDuration))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("---\n\n")
+	sb.WriteString(fmt.Sprintf("📅 **Report generated at:** %s\n\n", time.Now().Format("2006-01-02 15:04:05 MST")))
+	sb.WriteString(integrityComment(data.Integrity))
+	return cfg.writeEmoji(sb.String())
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckSplit(t *testing.T) {
+	tests := []struct {
+		splitBy   string
+		splitSize int
+		format    string
+		output    string
+		wantErr   bool
+	}{
+		{"", 0, "json", "-", false},
+		{"package", 0, "markdown", "report.md", false},
+		{"", 1000, "markdown", "report.md", false},
+		{"file", 0, "markdown", "report.md", true},
+		{"package", 0, "json", "report.json", true},
+		{"", 1000, "html", "report.html", true},
+		{"package", 0, "markdown", "-", true},
+	}
+	for _, test := range tests {
+		err := checkSplit(test.splitBy, test.splitSize, test.format, test.output)
+		if (err != nil) != test.wantErr {
+			t.Errorf("checkSplit(%q, %d, %q, %q): got error %v, want error %v",
+				test.splitBy, test.splitSize, test.format, test.output, err, test.wantErr)
+		}
+	}
+
+	if shouldSplit("", 0, strings.Repeat("x", 100)) || shouldSplit("", 100, strings.Repeat("x", 100)) {
+		t.Error("split a report within the size limit")
+	}
+	if !shouldSplit("", 99, strings.Repeat("x", 100)) || !shouldSplit("package", 0, "") {
+		t.Error("didn't split a report that should be split")
+	}
+}
+
+func TestWriteSplitReport(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      3,
+		PassedTests:     2,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestA", "TestB", "TestC"},
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Package: "example.com/app/a", Status: "PASS"},
+			"TestB": {Name: "TestB", Package: "example.com/app/b", Status: "PASS"},
+			"TestC": {Name: "TestC", Package: "example.com/app_b", Status: "FAIL", Output: []string{"boom"}},
+		},
+	}
+	dir := t.TempDir()
+	index := filepath.Join(dir, "test-report.md")
+
+	packages, err := writeSplitReport(data, defaultConfig(), index)
+	if err != nil {
+		t.Fatalf("writeSplitReport: %v", err)
+	}
+	if packages != 3 {
+		t.Errorf("package reports: got %d, want 3", packages)
+	}
+
+	content, err := os.ReadFile(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"- 📦 **Packages:** 3, one report each",
+		// Failing packages come first; colliding file names get a suffix
+		"| [example.com/app_b](test-report/example.com_app_b-2.md) | ❌ | 1 | 0 | 1 | 0 | 0.00s |\n" +
+			"| [example.com/app/a](test-report/example.com_app_a.md) | ✅ | 1 | 1 | 0 | 0 | 0.00s |\n" +
+			"| [example.com/app/b](test-report/example.com_app_b.md) | ✅ | 1 | 1 | 0 | 0 | 0.00s |\n",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("index missing %q:\n%s", want, content)
+		}
+	}
+
+	part, err := os.ReadFile(filepath.Join(dir, "test-report", "example.com_app_b-2.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"📦 **Package:** `example.com/app_b` · [⬅️ All packages](../test-report.md)",
+		"- 🧪 **Total Tests:** 1",
+		"### ❌ TestC",
+	} {
+		if !strings.Contains(string(part), want) {
+			t.Errorf("package report missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Emoji styles of the Markdown report
+const (
+	emojiUnicode   = "unicode"
+	emojiShortcode = "shortcode"
+)
+
+// defaultStatusIcons mark each test status in the results table
+var defaultStatusIcons = map[string]string{
+	"PASS":    "✅",
+	"FAIL":    "❌",
+	"SKIP":    "⏭️",
+	"FLAKY":   "🔁",
+	"UNKNOWN": "⏺️",
+}
+
+// defaultStatusBadges show the outcome of the run in the Test Status section
+var defaultStatusBadges = map[string]string{
+	"PASS": "https://img.shields.io/badge/Status-PASSED-brightgreen",
+	"FAIL": "https://img.shields.io/badge/Status-FAILED-red",
+	"SKIP": "https://img.shields.io/badge/Status-SKIPPED-yellow",
+}
+
+// statusIcon returns the icon of a test status, as configured in
+// statusIcons. Icons that are image URLs are rendered as images, which works
+// inside the HTML of the subtest tables too.
+func (c *config) statusIcon(status string) string {
+	icon, ok := c.StatusIcons[status]
+	if !ok {
+		icon, ok = defaultStatusIcons[status]
+	}
+	if !ok {
+		if icon, ok = c.StatusIcons["UNKNOWN"]; !ok {
+			icon = defaultStatusIcons["UNKNOWN"]
+		}
+	}
+	if isImageURL(icon) {
+		return fmt.Sprintf(`<img src="%s" alt="%s" height="16">`, html.EscapeString(icon), status)
+	}
+	return icon
+}
+
+// statusBadge returns the URL of the badge for the outcome of the run: PASS,
+// FAIL or SKIP
+func (c *config) statusBadge(outcome string) string {
+	if badge, ok := c.StatusBadges[outcome]; ok {
+		return badge
+	}
+	return defaultStatusBadges[outcome]
+}
+
+// isImageURL reports whether an icon is the URL of an image rather than text
+func isImageURL(icon string) bool {
+	return strings.HasPrefix(icon, "https://") || strings.HasPrefix(icon, "http://")
+}
+
+// emojiShortcodes names the emoji the Markdown report uses as GitHub
+// :shortcode:s
+var emojiShortcodes = map[string]string{
+	"↪️": ":arrow_right_hook:",
+	"⌛":  ":hourglass:",
+	"⏭️": ":next_track_button:",
+	"⏱️": ":stopwatch:",
+	"⏳":  ":hourglass_flowing_sand:",
+	"⏸️": ":pause_button:",
+	"⏺️": ":record_button:",
+	"⚙️": ":gear:",
+	"⚠️": ":warning:",
+	"⚡":  ":zap:",
+	"▶️": ":arrow_forward:",
+	"✂️": ":scissors:",
+	"✅":  ":white_check_mark:",
+	"✨":  ":sparkles:",
+	"❌":  ":x:",
+	"➕":  ":heavy_plus_sign:",
+	"➖":  ":heavy_minus_sign:",
+	"⬅️": ":arrow_left:",
+	"🆕":  ":new:",
+	"🎉":  ":tada:",
+	"🎯":  ":dart:",
+	"🏎️": ":racing_car:",
+	"🏷️": ":label:",
+	"🐛":  ":bug:",
+	"🐢":  ":turtle:",
+	"👤":  ":bust_in_silhouette:",
+	"💔":  ":broken_heart:",
+	"💥":  ":boom:",
+	"📄":  ":page_facing_up:",
+	"📅":  ":date:",
+	"📈":  ":chart_with_upwards_trend:",
+	"📋":  ":clipboard:",
+	"📊":  ":bar_chart:",
+	"📍":  ":round_pushpin:",
+	"📘":  ":blue_book:",
+	"📜":  ":scroll:",
+	"📝":  ":memo:",
+	"📦":  ":package:",
+	"🔀":  ":twisted_rightwards_arrows:",
+	"🔁":  ":repeat:",
+	"🔂":  ":repeat_one:",
+	"🔍":  ":mag:",
+	"🔎":  ":mag_right:",
+	"🔒":  ":lock:",
+	"🔓":  ":unlock:",
+	"🔴":  ":red_circle:",
+	"🕒":  ":clock3:",
+	"🖥️": ":desktop_computer:",
+	"🗂️": ":card_index_dividers:",
+	"🚦":  ":vertical_traffic_light:",
+	"🚫":  ":no_entry_sign:",
+	"🚰":  ":potable_water:",
+	"🧩":  ":jigsaw:",
+	"🧪":  ":test_tube:",
+	"🧬":  ":dna:",
+	"🧭":  ":compass:",
+	"🧮":  ":abacus:",
+	"🧱":  ":bricks:",
+	"🧾":  ":receipt:",
+	"🩺":  ":stethoscope:",
+}
+
+// shortcodeReplacer replaces the emoji of emojiShortcodes, with or without
+// their variation selector
+var shortcodeReplacer = func() *strings.Replacer {
+	var pairs []string
+	for emoji, shortcode := range emojiShortcodes {
+		pairs = append(pairs, emoji, shortcode)
+		if bare, ok := strings.CutSuffix(emoji, "️"); ok {
+			pairs = append(pairs, bare, shortcode)
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}()
+
+// writeEmoji writes the emoji of a Markdown report in the configured style.
+// In shortcode mode the emoji outside of code blocks become GitHub
+// :shortcode:s, for hosts that strip raw Unicode emoji; captured output is
+// left as it was.
+func (c *config) writeEmoji(markdown string) string {
+	if c.Emoji != emojiShortcode {
+		return markdown
+	}
+	lines := strings.SplitAfter(markdown, "\n")
+	inCode := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if !inCode {
+			lines[i] = shortcodeReplacer.Replace(line)
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatusIcons(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.StatusIcons = map[string]string{
+		"PASS": ":heavy_check_mark:",
+		"FAIL": "https://ci.example.com/icons/fail.png",
+	}
+	cfg.StatusBadges = map[string]string{"FAIL": "https://ci.example.com/badges/failed.svg"}
+	markdown := renderMarkdownReport(&ReportData{
+		TotalTests:      2,
+		PassedTests:     1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestA", "TestB"},
+		Results: map[string]*TestResult{
+			"TestA":         {Name: "TestA", Status: "PASS", Duration: 0.1},
+			"TestB":         {Name: "TestB", Status: "FAIL", Duration: 0.2, SubTests: []string{"TestB/skipped"}},
+			"TestB/skipped": {Name: "TestB/skipped", Status: "SKIP", IsSubTest: true},
+		},
+	}, cfg)
+
+	for _, want := range []string{
+		"| **TestA** | :heavy_check_mark: PASS |",
+		`| **TestB** | <img src="https://ci.example.com/icons/fail.png" alt="FAIL" height="16"> FAIL |`,
+		"<td>skipped</td><td>⏭️ SKIP</td>",
+		"⚠️ ![Status](https://ci.example.com/badges/failed.svg) ⚠️",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+}
+
+func TestShortcodeEmoji(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Emoji = emojiShortcode
+	markdown := renderMarkdownReport(&ReportData{
+		TotalTests:      1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestA"},
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Status: "FAIL", Output: []string{"    a_test.go:3: want ✅, got ❌"}},
+		},
+	}, cfg)
+
+	for _, want := range []string{
+		"# :test_tube: Test Summary Report",
+		"| **TestA** | :x: FAIL |",
+		":warning: ![Status](https://img.shields.io/badge/Status-FAILED-red) :warning:",
+		"want ✅, got ❌",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	// Captured output is quoted as it was; everything else is converted
+	if got := strings.Count(markdown, "✅") + strings.Count(markdown, "❌"); got != 2 {
+		t.Errorf("found %d emoji outside of the captured output", got-2)
+	}
+	if strings.Contains(markdown, "️") {
+		t.Error("variation selectors should go with the emoji they modify")
+	}
+}
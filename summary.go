@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// ANSI colors of the terminal summary
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+	ansiDim    = "\x1b[2m"
+)
+
+// Rows of the terminal summary's lists; tests quicker than 10ms aren't
+// listed as slow
+const (
+	summaryFailures = 10
+	summarySlowest  = 5
+)
+
+// isTerminal reports whether file is an interactive terminal
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor resolves the -color flag for output going to file. auto colors
+// terminals unless NO_COLOR is set (https://no-color.org).
+func useColor(mode string, file *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		return os.Getenv("NO_COLOR") == "" && isTerminal(file), nil
+	}
+	return false, fmt.Errorf("unknown -color value %q (want auto, always or never)", mode)
+}
+
+// writeTerminalSummary prints the counts, the failed tests and the slowest
+// tests of data, so a local run is useful without opening the report
+func writeTerminalSummary(w io.Writer, data *ReportData, color bool) {
+	paint := func(code, text string) string {
+		if !color {
+			return text
+		}
+		return code + text + ansiReset
+	}
+
+	counts := []string{paint(ansiGreen, fmt.Sprintf("%d passed", data.PassedTests))}
+	failed := fmt.Sprintf("%d failed", data.FailedTests)
+	if data.FailedTests > 0 {
+		failed = paint(ansiRed+ansiBold, failed)
+	}
+	counts = append(counts, failed, paint(ansiYellow, fmt.Sprintf("%d skipped", data.SkippedTests)))
+	if data.FlakyTests > 0 {
+		counts = append(counts, paint(ansiCyan, fmt.Sprintf("%d flaky", data.FlakyTests)))
+	}
+	fmt.Fprintf(w, "\n%s %s in %.2fs\n", paint(ansiBold, fmt.Sprintf("%d tests:", data.TotalTests)), strings.Join(counts, ", "), data.TotalDuration)
+
+	var failures, slowest []*TestResult
+	for _, result := range data.Results {
+		if result.Status == "FAIL" {
+			failures = append(failures, result)
+		}
+		if !result.IsSubTest && result.Duration >= 0.01 {
+			slowest = append(slowest, result)
+		}
+	}
+
+	if len(failures) > 0 {
+		sort.Slice(failures, func(i, j int) bool {
+			if failures[i].Package != failures[j].Package {
+				return failures[i].Package < failures[j].Package
+			}
+			return failures[i].Name < failures[j].Name
+		})
+		fmt.Fprintf(w, "\n%s\n", paint(ansiRed+ansiBold, "Failed tests:"))
+		for i, result := range failures {
+			if i == summaryFailures {
+				fmt.Fprintf(w, "  … and %d more\n", len(failures)-summaryFailures)
+				break
+			}
+			line := fmt.Sprintf("  ❌ %s %s", result.Name, paint(ansiDim, "("+result.Package+")"))
+			if assertion := report.ExtractExcerpt(result.Output).Assertion; assertion != "" {
+				line += ": " + assertion
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	if len(slowest) > 0 {
+		sort.Slice(slowest, func(i, j int) bool {
+			if slowest[i].Duration != slowest[j].Duration {
+				return slowest[i].Duration > slowest[j].Duration
+			}
+			return slowest[i].Name < slowest[j].Name
+		})
+		fmt.Fprintf(w, "\n%s\n", paint(ansiBold, "Slowest tests:"))
+		for i, result := range slowest {
+			if i == summarySlowest {
+				break
+			}
+			fmt.Fprintf(w, "  %7.2fs  %s %s\n", result.Duration, result.Name, paint(ansiDim, "("+result.Package+")"))
+		}
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteTerminalSummary(t *testing.T) {
+	data := &ReportData{
+		TotalTests: 14, PassedTests: 1, FailedTests: 12, SkippedTests: 1, TotalDuration: 3.5,
+		Results: map[string]*TestResult{
+			"TestSlow":  {Name: "TestSlow", Package: "example.com/a", Status: "PASS", Duration: 2.5},
+			"TestQuick": {Name: "TestQuick", Package: "example.com/a", Status: "SKIP", Duration: 0.001},
+		},
+	}
+	for i := 0; i < 12; i++ {
+		name := fmt.Sprintf("TestFail%02d", i)
+		data.Results[name] = &TestResult{Name: name, Package: "example.com/b", Status: "FAIL", Duration: 0.5,
+			Output: []string{"    b_test.go:9: got 1, want 2"}}
+	}
+
+	var out strings.Builder
+	writeTerminalSummary(&out, data, false)
+	summary := out.String()
+	for _, want := range []string{
+		"\n14 tests: 1 passed, 12 failed, 1 skipped in 3.50s\n",
+		"\nFailed tests:\n  ❌ TestFail00 (example.com/b): got 1, want 2\n",
+		"  ❌ TestFail09 (example.com/b): got 1, want 2\n  … and 2 more\n",
+		"\nSlowest tests:\n     2.50s  TestSlow (example.com/a)\n     0.50s  TestFail00 (example.com/b)\n",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+	if strings.Contains(summary, "TestQuick") || strings.Contains(summary, "\x1b[") {
+		t.Errorf("unexpected quick test or colors in summary:\n%s", summary)
+	}
+
+	out.Reset()
+	writeTerminalSummary(&out, data, true)
+	if !strings.Contains(out.String(), ansiRed+ansiBold+"12 failed"+ansiReset) {
+		t.Errorf("colored summary:\n%q", out.String())
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	for _, tc := range []struct {
+		mode string
+		want bool
+	}{{"always", true}, {"never", false}, {"auto", false}} {
+		got, err := useColor(tc.mode, file)
+		if err != nil || got != tc.want {
+			t.Errorf("useColor(%q): got %v, %v, want %v", tc.mode, got, err, tc.want)
+		}
+	}
+	if _, err := useColor("rainbow", file); err == nil {
+		t.Error("useColor(rainbow): expected an error")
+	}
+}
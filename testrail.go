@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// TestRail result statuses; skipped tests are left untested
+const (
+	testRailPassed = 1
+	testRailFailed = 5
+)
+
+// testRailCaseID matches the [C1234] convention for naming tests after the
+// TestRail cases they cover, e.g. t.Run("[C1234] valid password", ...)
+var testRailCaseID = regexp.MustCompile(`\[C(\d+)\]`)
+
+// loadTestRailMap reads a mapping file: one case per line as the case id and
+// a test name, e.g. "C1234 TestLogin/valid_password", with blank lines and
+// lines starting with # ignored. A test may cover several cases.
+func loadTestRailMap(path string) (map[string][]int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening TestRail mapping: %v", err)
+	}
+	defer file.Close()
+
+	cases := make(map[string][]int)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		id, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(fields[0]), "C"))
+		if len(fields) != 2 || err != nil || id <= 0 {
+			return nil, fmt.Errorf("%s:%d: want a case id and a test name, e.g. C1234 TestLogin", path, line)
+		}
+		cases[fields[1]] = append(cases[fields[1]], id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading TestRail mapping: %v", err)
+	}
+	return cases, nil
+}
+
+type testRailResult struct {
+	CaseID   int    `json:"case_id"`
+	StatusID int    `json:"status_id"`
+	Comment  string `json:"comment,omitempty"`
+	Elapsed  string `json:"elapsed,omitempty"`
+}
+
+// testRailResults returns a result per case covered by a test that ran, from
+// the mapping and the [C1234] names. A case covered by several tests fails
+// when any of them failed, and its comment lists each test.
+func testRailResults(data *ReportData, mapping map[string][]int) []testRailResult {
+	type caseResult struct {
+		failed   bool
+		seconds  float64
+		comments []string
+	}
+	byCase := make(map[int]*caseResult)
+	for _, name := range data.SortedTestNames {
+		result := data.Results[name]
+		if result == nil || result.Status == "SKIP" {
+			continue
+		}
+		ids := append([]int(nil), mapping[name]...)
+		for _, match := range testRailCaseID.FindAllStringSubmatch(name, -1) {
+			id, _ := strconv.Atoi(match[1])
+			ids = append(ids, id)
+		}
+		for _, id := range ids {
+			c := byCase[id]
+			if c == nil {
+				c = &caseResult{}
+				byCase[id] = c
+			}
+			c.seconds += result.Duration
+			switch result.Status {
+			case "PASS":
+				c.comments = append(c.comments, fmt.Sprintf("%s (%s) passed", name, result.Package))
+			case "FLAKY":
+				c.comments = append(c.comments, fmt.Sprintf("%s (%s) passed on attempt %d", name, result.Package, len(result.Attempts)+1))
+			default:
+				c.failed = true
+				comment := fmt.Sprintf("%s (%s) failed", name, result.Package)
+				if assertion := report.ExtractExcerpt(result.Output).Assertion; assertion != "" {
+					comment += ": " + assertion
+				}
+				c.comments = append(c.comments, comment)
+			}
+		}
+	}
+
+	ids := make([]int, 0, len(byCase))
+	for id := range byCase {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	results := make([]testRailResult, 0, len(ids))
+	for _, id := range ids {
+		c := byCase[id]
+		result := testRailResult{CaseID: id, StatusID: testRailPassed, Comment: strings.Join(c.comments, "\n")}
+		if c.failed {
+			result.StatusID = testRailFailed
+		}
+		// TestRail rejects elapsed times under a second
+		if seconds := int(c.seconds + 0.5); seconds > 0 {
+			result.Elapsed = fmt.Sprintf("%ds", seconds)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// testRailClient calls the TestRail API as a user with an API key
+type testRailClient struct {
+	api  string // e.g. https://example.testrail.io/index.php?/api/v2
+	user string
+	key  string
+	http *http.Client
+}
+
+func newTestRailClient(endpoint, user string) (*testRailClient, error) {
+	key := os.Getenv("TESTRAIL_API_KEY")
+	if user == "" || key == "" {
+		return nil, fmt.Errorf("-testrail-url needs -testrail-user and an API key in TESTRAIL_API_KEY")
+	}
+	return &testRailClient{
+		api:  strings.TrimSuffix(endpoint, "/") + "/index.php?/api/v2",
+		user: user,
+		key:  key,
+		http: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// submit adds results to run, or to a new run of suite in project named
+// name with just the cases that have results when run is 0
+func (c *testRailClient) submit(results []testRailResult, project, suite, run int, name string) error {
+	if len(results) == 0 {
+		logger.Warn("no tests map to TestRail cases; not submitting results")
+		return nil
+	}
+	if run == 0 {
+		if project == 0 {
+			return fmt.Errorf("-testrail-url needs -testrail-project or -testrail-run")
+		}
+		caseIDs := make([]int, len(results))
+		for i, result := range results {
+			caseIDs[i] = result.CaseID
+		}
+		var created struct {
+			ID  int    `json:"id"`
+			URL string `json:"url"`
+		}
+		request := map[string]any{"name": name, "include_all": false, "case_ids": caseIDs}
+		if suite != 0 {
+			request["suite_id"] = suite
+		}
+		if err := c.do(fmt.Sprintf("/add_run/%d", project), request, &created); err != nil {
+			return fmt.Errorf("adding a run: %w", err)
+		}
+		run = created.ID
+		logger.Info("added TestRail run", "run", run, "url", created.URL)
+	}
+	if err := c.do(fmt.Sprintf("/add_results_for_cases/%d", run), map[string]any{"results": results}, nil); err != nil {
+		return fmt.Errorf("adding results: %w", err)
+	}
+	logger.Info("submitted TestRail results", "run", run, "cases", len(results))
+	return nil
+}
+
+// do POSTs a JSON body and decodes the JSON response into out
+func (c *testRailClient) do(endpoint string, in, out any) error {
+	_, err := doJSON(c.http, http.MethodPost, c.api+endpoint, in, out, func(req *http.Request) {
+		req.SetBasicAuth(c.user, c.key)
+	})
+	return err
+}
+
+// testRailRunName names a new run after the time and, in CI, the commit
+func testRailRunName(now time.Time) string {
+	name := "go test " + now.UTC().Format("2006-01-02 15:04")
+	if commit := firstEnv("GITHUB_SHA", "CI_COMMIT_SHA", "BITBUCKET_COMMIT"); commit != "" {
+		name += " (" + commit[:min(len(commit), 7)] + ")"
+	}
+	return name
+}
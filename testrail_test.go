@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestLoadTestRailMap(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "testrail.txt")
+	os.WriteFile(path, []byte("# login cases\nC1 TestLogin\n\nc2 TestLogin\n3 TestLogout/expired\n"), 0o644)
+	got, err := loadTestRailMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]int{"TestLogin": {1, 2}, "TestLogout/expired": {3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"TestLogin", "C1 TestLogin extra", "Cx TestLogin", "C0 TestLogin"} {
+		os.WriteFile(path, []byte("C5 TestOK\n"+bad+"\n"), 0o644)
+		if _, err := loadTestRailMap(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+			t.Errorf("%q: got error %v, want one for line 2", bad, err)
+		}
+	}
+}
+
+func TestTestRailResults(t *testing.T) {
+	data := &ReportData{
+		Results: map[string]*TestResult{
+			"TestLogin":                  {Name: "TestLogin", Package: "app", Status: "PASS", Duration: 1.2},
+			"TestSignup/[C20]_duplicate": {Name: "TestSignup/[C20]_duplicate", Package: "app", Status: "FAIL", Duration: 0.1, Output: []string{"    signup_test.go:9: got 200, want 409"}},
+			"TestSignup/[C21][C22]_ok":   {Name: "TestSignup/[C21][C22]_ok", Package: "app", Status: "FLAKY", Duration: 0.2, Attempts: []report.Attempt{{Status: "FAIL"}}},
+			"TestSkipped[C30]":           {Name: "TestSkipped[C30]", Package: "app", Status: "SKIP"},
+			"TestUnmapped":               {Name: "TestUnmapped", Package: "app", Status: "FAIL"},
+		},
+		SortedTestNames: []string{"TestLogin", "TestSignup/[C20]_duplicate", "TestSignup/[C21][C22]_ok", "TestSkipped[C30]", "TestUnmapped"},
+	}
+	got := testRailResults(data, map[string][]int{"TestLogin": {10, 20}})
+	want := []testRailResult{
+		{CaseID: 10, StatusID: testRailPassed, Comment: "TestLogin (app) passed", Elapsed: "1s"},
+		{CaseID: 20, StatusID: testRailFailed, Comment: "TestLogin (app) passed\nTestSignup/[C20]_duplicate (app) failed: got 200, want 409", Elapsed: "1s"},
+		{CaseID: 21, StatusID: testRailPassed, Comment: "TestSignup/[C21][C22]_ok (app) passed on attempt 2"},
+		{CaseID: 22, StatusID: testRailPassed, Comment: "TestSignup/[C21][C22]_ok (app) passed on attempt 2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestTestRailSubmit(t *testing.T) {
+	var calls []string
+	var results []testRailResult
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, key, _ := r.BasicAuth()
+		if user != "qa@example.com" || key != "secret" {
+			http.Error(w, `{"error":"Authentication failed"}`, http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		calls = append(calls, r.URL.RawQuery+" "+strings.TrimSpace(string(body)))
+		switch {
+		case strings.HasPrefix(r.URL.RawQuery, "/api/v2/add_run/"):
+			w.Write([]byte(`{"id": 77, "url": "https://example.testrail.io/index.php?/runs/view/77"}`))
+		case strings.HasPrefix(r.URL.RawQuery, "/api/v2/add_results_for_cases/"):
+			var request struct{ Results []testRailResult }
+			json.Unmarshal(body, &request)
+			results = request.Results
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("TESTRAIL_API_KEY", "secret")
+	client, err := newTestRailClient(server.URL+"/", "qa@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	submitted := []testRailResult{{CaseID: 1, StatusID: testRailPassed}, {CaseID: 2, StatusID: testRailFailed}}
+	if err := client.submit(submitted, 5, 9, 0, "go test"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`/api/v2/add_run/5 {"case_ids":[1,2],"include_all":false,"name":"go test","suite_id":9}`,
+		`/api/v2/add_results_for_cases/77 {"results":[{"case_id":1,"status_id":1},{"case_id":2,"status_id":5}]}`,
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls: got\n%s\nwant\n%s", strings.Join(calls, "\n"), strings.Join(want, "\n"))
+	}
+	if !reflect.DeepEqual(results, submitted) {
+		t.Errorf("results: got %+v, want %+v", results, submitted)
+	}
+
+	// An existing run gets the results directly
+	calls = nil
+	if err := client.submit(submitted, 0, 0, 12, "go test"); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "/api/v2/add_results_for_cases/12 ") {
+		t.Errorf("calls: got %q, want only add_results_for_cases/12", calls)
+	}
+
+	client.key = "wrong"
+	if err := client.submit(submitted, 5, 0, 0, "go test"); err == nil || !strings.Contains(err.Error(), "Authentication failed") {
+		t.Errorf("got error %v, want the response body in it", err)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// reportTarget is where the Markdown report is meant to be posted
+type reportTarget struct {
+	limit       int    // bytes; GitHub counts characters, which are never more
+	description string // what the limit belongs to, for the trimmed note
+}
+
+// reportTargets lists the values accepted by -target
+var reportTargets = map[string]reportTarget{
+	"file":         {},
+	"comment":      {limit: 65536, description: "65,536-character limit of a GitHub comment"},
+	"step-summary": {limit: 1024 * 1024, description: "1 MiB limit of a GitHub step summary"},
+	"gitlab-note":  {limit: 1000000, description: "1,000,000-character limit of a GitLab note"},
+}
+
+// checkTarget validates -target and returns its size limit
+func checkTarget(target, format string) (reportTarget, error) {
+	t, ok := reportTargets[target]
+	if !ok {
+		return reportTarget{}, fmt.Errorf("unknown report target %q (want file, comment, step-summary or gitlab-note)", target)
+	}
+	if t.limit > 0 && format != "markdown" {
+		return reportTarget{}, fmt.Errorf("-target %s needs -format markdown", target)
+	}
+	return t, nil
+}
+
+// trimReserve is kept free for the trimmed note, the metrics footnote and
+// the header and links the GitHub Action adds around the report
+const trimReserve = 2048
+
+// trimming is the detail left out of a report to fit a size limit
+type trimming struct {
+	durations      bool // leave out the slowest packages and durations tables
+	passingRows    bool // list only tests that didn't pass in the results table
+	outputLines    int  // cut failure output to this many lines; 0 keeps all
+	failureDetails bool // leave out failure output altogether
+}
+
+// notes describes what was left out, for the integrity trailer
+func (t trimming) notes() []string {
+	var notes []string
+	if t.durations {
+		notes = append(notes, "durations tables left out")
+	}
+	if t.passingRows {
+		notes = append(notes, "passing tests left out of the results table")
+	}
+	if t.failureDetails {
+		notes = append(notes, "failure output left out")
+	} else if t.outputLines > 0 {
+		notes = append(notes, fmt.Sprintf("failure output cut to %d lines per test", t.outputLines))
+	}
+	return notes
+}
+
+// trimStages drop progressively more detail: the durations tables first, then
+// passing rows, then failure output
+var trimStages = []func(*trimming){
+	func(t *trimming) { t.durations = true },
+	func(t *trimming) { t.passingRows = true },
+	func(t *trimming) { t.outputLines = 40 },
+	func(t *trimming) { t.outputLines = 10 },
+	func(t *trimming) { t.failureDetails = true },
+}
+
+// fitReport renders data as Markdown within the limit of cfg.target. It
+// renders again with less detail until the report fits, cuts it off as a
+// last resort, and says what was left out at the top.
+func fitReport(data *ReportData, cfg *config) string {
+	content := renderMarkdownReport(data, cfg)
+	if len(content) <= cfg.target.limit {
+		return content
+	}
+
+	budget := max(cfg.target.limit-trimReserve, 0)
+	trimmedCfg := *cfg
+	trimmedData := *data
+	for _, stage := range trimStages {
+		stage(&trimmedCfg.trim)
+		trimmedData.Integrity = data.Integrity
+		for _, note := range trimmedCfg.trim.notes() {
+			trimmedData.Integrity = trimmedData.Integrity.WithTruncation(note)
+		}
+		content = renderMarkdownReport(&trimmedData, &trimmedCfg)
+		if len(content) <= budget {
+			break
+		}
+	}
+
+	if len(content) > budget {
+		cut := strings.LastIndex(content[:budget], "\n") + 1
+		if i := strings.Index(content, "<!-- gotest-report:integrity"); i >= 0 && i < cut {
+			cut = i
+		}
+		integrity := trimmedData.Integrity.WithTruncation(fmt.Sprintf("report cut off after %d of %d bytes", cut, len(content)))
+		content = closeOpenBlocks(content[:cut]) + "\n" + integrityComment(integrity)
+	}
+
+	full := fmt.Sprintf("is attached to the %s as an artifact", workflowRunLink())
+	if data.ReportURL != "" {
+		full = fmt.Sprintf("is [uploaded](%s)", data.ReportURL)
+	}
+	note := fmt.Sprintf("> ✂️ **This report was trimmed to fit the %s** (%s). The full report %s.\n\n",
+		cfg.target.description, strings.Join(trimmedCfg.trim.notes(), ", "), full)
+	// Right under the title, where it's seen and kept by tools that drop the footer
+	if i := strings.Index(content, "\n\n"); i >= 0 {
+		return content[:i+2] + note + content[i+2:]
+	}
+	return note + content
+}
+
+// closeOpenBlocks closes the code fence and <details> elements left open by
+// cutting Markdown short
+func closeOpenBlocks(content string) string {
+	fences := 0
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fences++
+		}
+	}
+	if fences%2 == 1 {
+		content += "```\n"
+	}
+	open := strings.Count(content, "<details>") - strings.Count(content, "</details>")
+	for ; open > 0; open-- {
+		content += "\n</details>\n"
+	}
+	return content
+}
+
+// workflowRunLink links to the current GitHub Actions run or GitLab CI job
+// when there is one
+func workflowRunLink() string {
+	text, link := workflowRun()
+	if link == "" {
+		return text
+	}
+	return fmt.Sprintf("[%s](%s)", text, link)
+}
+
+// workflowRun names the current GitHub Actions run or GitLab CI job and
+// returns its URL, which is empty outside of them
+func workflowRun() (text, link string) {
+	if job := os.Getenv("CI_JOB_URL"); job != "" {
+		return "pipeline job", job
+	}
+	server, repo, runID := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if server == "" || repo == "" || runID == "" {
+		return "workflow run", ""
+	}
+	return "workflow run", fmt.Sprintf("%s/%s/actions/runs/%s", strings.TrimSuffix(server, "/"), repo, runID)
+}
+
+// trimOutput keeps the first and last lines of output, limit in total, so
+// both the first assertion and the final panic or FAIL line survive
+func trimOutput(output []string, limit int) []string {
+	if limit <= 0 || len(output) <= limit {
+		return output
+	}
+	head := (limit + 1) / 2
+	trimmed := make([]string, 0, limit)
+	trimmed = append(trimmed, output[:head]...)
+	return append(trimmed, output[len(output)-(limit-head):]...)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// largeReport builds a report with passing tests and failing tests with long
+// output, bigger than the limits used below
+func largeReport(passing, failing, outputLines int) *ReportData {
+	data := &ReportData{Results: map[string]*TestResult{}}
+	for i := 0; i < passing; i++ {
+		name := fmt.Sprintf("TestPassing%03d", i)
+		data.Results[name] = &TestResult{Name: name, Package: "example.com/app", Status: "PASS", Duration: float64(i) / 100}
+	}
+	for i := 0; i < failing; i++ {
+		name := fmt.Sprintf("TestFailing%03d", i)
+		var output []string
+		for l := 0; l < outputLines; l++ {
+			output = append(output, fmt.Sprintf("    app_test.go:%d: step %d of %s: expected %d, got %d", l+10, l, name, l, l+i))
+		}
+		data.Results[name] = &TestResult{Name: name, Package: "example.com/app", Status: "FAIL", Output: output}
+	}
+	for name, result := range data.Results {
+		data.SortedTestNames = append(data.SortedTestNames, name)
+		data.TotalTests++
+		if result.Status == "FAIL" {
+			data.FailedTests++
+		} else {
+			data.PassedTests++
+		}
+	}
+	return data
+}
+
+func TestFitReport(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "")
+	data := largeReport(200, 10, 200)
+	cfg := defaultConfig()
+	cfg.target = reportTarget{limit: 20000, description: "test limit"}
+
+	full := renderMarkdownReport(data, defaultConfig())
+	if len(full) <= cfg.target.limit {
+		t.Fatalf("test report too small to need trimming: %d bytes", len(full))
+	}
+
+	content := fitReport(data, cfg)
+	if len(content) > cfg.target.limit-trimReserve/2 {
+		t.Errorf("trimmed report is %d bytes, limit %d", len(content), cfg.target.limit)
+	}
+	for _, want := range []string{
+		"# 🧪 Test Summary Report\n\n> ✂️ **This report was trimmed to fit the test limit** (durations tables left out, passing tests left out of the results table, failure output cut to 10 lines per test). The full report is attached to the workflow run as an artifact.",
+		"_✂️ 200 passing tests not listed to keep the report within the size limit._",
+		"_✂️ Showing the first and last 10 of 200 output lines._",
+		`"truncations":["durations tables left out","passing tests left out of the results table","failure output cut to 10 lines per test"]`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("trimmed report missing %q", want)
+		}
+	}
+	if strings.Contains(content, "TestPassing000") || strings.Contains(content, "Test Durations") {
+		t.Error("trimmed report still lists passing tests or durations")
+	}
+
+	// A report within the limit is left alone
+	cfg.target.limit = len(full)
+	if content := fitReport(data, cfg); strings.Contains(content, "✂️") {
+		t.Error("report within the limit was trimmed")
+	}
+}
+
+func TestFitReportCutsOff(t *testing.T) {
+	data := largeReport(0, 300, 1)
+	cfg := defaultConfig()
+	cfg.target = reportTarget{limit: 6000, description: "test limit"}
+
+	content := fitReport(data, cfg)
+	if len(content) > cfg.target.limit {
+		t.Errorf("cut off report is %d bytes, limit %d", len(content), cfg.target.limit)
+	}
+	if strings.Count(content, "<!-- gotest-report:integrity") != 1 || !strings.Contains(content, "report cut off after ") {
+		t.Errorf("cut off report needs exactly one integrity trailer noting the cut:\n%s", content)
+	}
+	if strings.Count(content, "<details>") != strings.Count(content, "</details>") {
+		t.Error("cut off report leaves <details> open")
+	}
+}
+
+func TestTrimOutput(t *testing.T) {
+	output := []string{"1", "2", "3", "4", "5", "6", "7"}
+	tests := []struct {
+		limit int
+		want  string
+	}{
+		{0, "1|2|3|4|5|6|7"},
+		{7, "1|2|3|4|5|6|7"},
+		{4, "1|2|6|7"},
+		{3, "1|2|7"},
+	}
+	for _, test := range tests {
+		if got := strings.Join(trimOutput(output, test.limit), "|"); got != test.want {
+			t.Errorf("trimOutput(%d): got %q, want %q", test.limit, got, test.want)
+		}
+	}
+}
+
+func TestCheckTarget(t *testing.T) {
+	if target, err := checkTarget("comment", "markdown"); err != nil || target.limit != 65536 {
+		t.Errorf("comment target: got %+v, %v", target, err)
+	}
+	if target, err := checkTarget("gitlab-note", "markdown"); err != nil || target.limit != 1000000 {
+		t.Errorf("gitlab-note target: got %+v, %v", target, err)
+	}
+	if target, err := checkTarget("file", "json"); err != nil || target.limit != 0 {
+		t.Errorf("file target: got %+v, %v", target, err)
+	}
+	if _, err := checkTarget("step-summary", "html"); err == nil {
+		t.Error("expected an error for a trimmed target with HTML output")
+	}
+	if _, err := checkTarget("slack", "markdown"); err == nil {
+		t.Error("expected an error for an unknown target")
+	}
+}
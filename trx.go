@@ -0,0 +1,247 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Fixed identifiers of the TRX schema: the unit test type, and the test
+// lists Visual Studio files results under
+const (
+	trxUnitTestType   = "13cdc9d9-ddb5-4fa4-a97d-d965ccfc6d4b"
+	trxResultsList    = "8c84fa94-04c1-424b-9868-57a2d4851a1d"
+	trxAllResultsList = "19431567-8539-422a-85d7-44ee4e166bda"
+)
+
+// Visual Studio test results (.trx), as published by Azure DevOps'
+// PublishTestResults task with testResultsFormat VSTest. Every test and
+// subtest is a unit test; its class is the package.
+type trxTestRun struct {
+	XMLName         xml.Name         `xml:"TestRun"`
+	Xmlns           string           `xml:"xmlns,attr"`
+	ID              string           `xml:"id,attr"`
+	Name            string           `xml:"name,attr"`
+	Times           trxTimes         `xml:"Times"`
+	Results         []trxResult      `xml:"Results>UnitTestResult"`
+	TestDefinitions []trxUnitTest    `xml:"TestDefinitions>UnitTest"`
+	TestEntries     []trxTestEntry   `xml:"TestEntries>TestEntry"`
+	TestLists       []trxTestList    `xml:"TestLists>TestList"`
+	ResultSummary   trxResultSummary `xml:"ResultSummary"`
+}
+
+type trxTimes struct {
+	Creation string `xml:"creation,attr"`
+	Queuing  string `xml:"queuing,attr"`
+	Start    string `xml:"start,attr"`
+	Finish   string `xml:"finish,attr"`
+}
+
+type trxResult struct {
+	ExecutionID  string     `xml:"executionId,attr"`
+	TestID       string     `xml:"testId,attr"`
+	TestName     string     `xml:"testName,attr"`
+	ComputerName string     `xml:"computerName,attr"`
+	Duration     string     `xml:"duration,attr"`
+	StartTime    string     `xml:"startTime,attr"`
+	EndTime      string     `xml:"endTime,attr"`
+	TestType     string     `xml:"testType,attr"`
+	Outcome      string     `xml:"outcome,attr"` // Passed, Failed or NotExecuted
+	TestListID   string     `xml:"testListId,attr"`
+	Output       *trxOutput `xml:"Output,omitempty"`
+}
+
+type trxOutput struct {
+	StdOut    string        `xml:"StdOut,omitempty"`
+	ErrorInfo *trxErrorInfo `xml:"ErrorInfo,omitempty"`
+}
+
+type trxErrorInfo struct {
+	Message    string `xml:"Message"`
+	StackTrace string `xml:"StackTrace,omitempty"`
+}
+
+type trxUnitTest struct {
+	Name       string        `xml:"name,attr"`
+	ID         string        `xml:"id,attr"`
+	Storage    string        `xml:"storage,attr"`
+	Execution  trxExecution  `xml:"Execution"`
+	TestMethod trxTestMethod `xml:"TestMethod"`
+}
+
+type trxExecution struct {
+	ID string `xml:"id,attr"`
+}
+
+type trxTestMethod struct {
+	CodeBase        string `xml:"codeBase,attr"`
+	AdapterTypeName string `xml:"adapterTypeName,attr"`
+	ClassName       string `xml:"className,attr"`
+	Name            string `xml:"name,attr"`
+}
+
+type trxTestEntry struct {
+	TestID      string `xml:"testId,attr"`
+	ExecutionID string `xml:"executionId,attr"`
+	TestListID  string `xml:"testListId,attr"`
+}
+
+type trxTestList struct {
+	Name string `xml:"name,attr"`
+	ID   string `xml:"id,attr"`
+}
+
+type trxResultSummary struct {
+	Outcome  string      `xml:"outcome,attr"` // Completed or Failed
+	Counters trxCounters `xml:"Counters"`
+}
+
+type trxCounters struct {
+	Total       int `xml:"total,attr"`
+	Executed    int `xml:"executed,attr"`
+	Passed      int `xml:"passed,attr"`
+	Failed      int `xml:"failed,attr"`
+	Error       int `xml:"error,attr"`
+	Timeout     int `xml:"timeout,attr"`
+	Aborted     int `xml:"aborted,attr"`
+	NotExecuted int `xml:"notExecuted,attr"`
+}
+
+// renderTRX renders data as a Visual Studio test results file. The run is
+// taken to have ended at now; go test doesn't record when each test
+// started, so tests are placed one after the other from the start of the
+// run. As in JUnit output, flaky tests pass with their attempts noted in
+// the output, and tests that never reported a result fail.
+func renderTRX(data *ReportData, now time.Time) (string, error) {
+	start := now.Add(-trxSeconds(data.TotalDuration))
+	run := trxTestRun{
+		Xmlns: "http://microsoft.com/schemas/VisualStudio/TeamTest/2010",
+		ID:    trxGUID("run", now.Format(time.RFC3339Nano)),
+		Name:  "gotest-report " + now.UTC().Format("2006-01-02 15:04:05"),
+		Times: trxTimes{Creation: trxTime(now), Queuing: trxTime(start), Start: trxTime(start), Finish: trxTime(now)},
+		TestLists: []trxTestList{
+			{Name: "Results Not in a List", ID: trxResultsList},
+			{Name: "All Loaded Results", ID: trxAllResultsList},
+		},
+	}
+
+	computer, err := os.Hostname()
+	if err != nil {
+		computer = "localhost"
+	}
+	counters := &run.ResultSummary.Counters
+	offset := start
+	packages, byPackage := resultsByPackage(data)
+	for _, pkg := range packages {
+		// Subtests are placed at the start of their top-level test
+		starts := make(map[string]time.Time)
+		for _, result := range byPackage[pkg] {
+			testID := trxGUID("test", pkg, result.Name)
+			executionID := trxGUID("execution", pkg, result.Name)
+			duration := trxSeconds(result.Duration)
+			top, _, _ := strings.Cut(result.Name, "/")
+			testStart, ok := starts[top]
+			if !ok {
+				testStart = offset
+				starts[top] = offset
+				if parent := data.Results[top]; parent != nil {
+					offset = offset.Add(trxSeconds(parent.Duration))
+				}
+			}
+			testResult := trxResult{
+				ExecutionID:  executionID,
+				TestID:       testID,
+				TestName:     result.Name,
+				ComputerName: computer,
+				Duration:     trxDuration(duration),
+				StartTime:    trxTime(testStart),
+				EndTime:      trxTime(testStart.Add(duration)),
+				TestType:     trxUnitTestType,
+				TestListID:   trxResultsList,
+			}
+
+			output := strings.Join(result.Output, "\n")
+			var errorInfo *trxErrorInfo
+			counters.Total++
+			switch result.Status {
+			case "PASS", "FLAKY":
+				testResult.Outcome = "Passed"
+				counters.Executed++
+				counters.Passed++
+				if result.Status == "FLAKY" {
+					output = fmt.Sprintf("Passed on attempt %d after failing %d times\n", len(result.Attempts)+1, len(result.Attempts)) + output
+				}
+			case "SKIP":
+				testResult.Outcome = "NotExecuted"
+				counters.NotExecuted++
+				if result.SkipReason != "" {
+					errorInfo = &trxErrorInfo{Message: result.SkipReason}
+				}
+			default:
+				testResult.Outcome = "Failed"
+				counters.Executed++
+				counters.Failed++
+				message := "test failed"
+				if result.Status != "FAIL" {
+					message = "test did not report a result"
+				}
+				failure := xunitFailureOf(result, "", message)
+				errorInfo = &trxErrorInfo{Message: failure.Message.Text, StackTrace: failure.StackTrace.Text}
+			}
+			if output != "" || errorInfo != nil {
+				testResult.Output = &trxOutput{StdOut: output, ErrorInfo: errorInfo}
+			}
+
+			run.Results = append(run.Results, testResult)
+			run.TestDefinitions = append(run.TestDefinitions, trxUnitTest{
+				Name:      result.Name,
+				ID:        testID,
+				Storage:   pkg,
+				Execution: trxExecution{ID: executionID},
+				TestMethod: trxTestMethod{
+					CodeBase:        pkg,
+					AdapterTypeName: "executor://gotest-report",
+					ClassName:       pkg,
+					Name:            result.Name,
+				},
+			})
+			run.TestEntries = append(run.TestEntries, trxTestEntry{TestID: testID, ExecutionID: executionID, TestListID: trxResultsList})
+		}
+	}
+	run.ResultSummary.Outcome = "Completed"
+	if counters.Failed > 0 {
+		run.ResultSummary.Outcome = "Failed"
+	}
+
+	encoded, err := xml.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(encoded) + "\n" + integrityComment(data.Integrity), nil
+}
+
+// trxGUID derives a stable GUID from parts, so the same test keeps its id
+// across runs and trends can follow it
+func trxGUID(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	sum[6] = sum[6]&0x0f | 0x50 // version 5, name-based
+	sum[8] = sum[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func trxSeconds(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// trxTime formats a time as TRX files do, with 100ns precision
+func trxTime(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05.0000000Z07:00")
+}
+
+// trxDuration formats a duration as a .NET TimeSpan, e.g. 00:01:02.5000000
+func trxDuration(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d:%02d.%07d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60, d.Nanoseconds()%int64(time.Second)/100)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderTRX(t *testing.T) {
+	data, err := processTestEvents(strings.NewReader(formatTestEvents), parseOptions(defaultConfig(), "trx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	content, err := renderTRX(data, now)
+	if err != nil {
+		t.Fatalf("renderTRX: %v", err)
+	}
+
+	var run trxTestRun
+	if err := xml.Unmarshal([]byte(content), &run); err != nil {
+		t.Fatalf("decoding TRX: %v\n%s", err, content)
+	}
+	if run.Times.Start != "2024-05-06T07:08:08.2500000Z" || run.Times.Finish != "2024-05-06T07:08:09.0000000Z" {
+		t.Errorf("times: got %+v", run.Times)
+	}
+	want := trxCounters{Total: 6, Executed: 5, Passed: 2, Failed: 3, NotExecuted: 1}
+	if run.ResultSummary.Counters != want || run.ResultSummary.Outcome != "Failed" {
+		t.Errorf("summary: got %+v, want %+v", run.ResultSummary, want)
+	}
+	if len(run.Results) != 6 || len(run.TestDefinitions) != 6 || len(run.TestEntries) != 6 {
+		t.Fatalf("got %d results, %d definitions and %d entries", len(run.Results), len(run.TestDefinitions), len(run.TestEntries))
+	}
+
+	// example.com/a: TestPass, TestTable, TestTable/bad, TestTable/ok
+	bad := run.Results[2]
+	if bad.TestName != "TestTable/bad" || bad.Outcome != "Failed" || bad.Duration != "00:00:00.2000000" ||
+		bad.StartTime != "2024-05-06T07:08:08.5000000Z" || bad.Output == nil || bad.Output.ErrorInfo == nil ||
+		bad.Output.ErrorInfo.Message != "got 1, want 2 <�>" || bad.Output.ErrorInfo.StackTrace != "a_test.go:12" {
+		t.Errorf("failing subtest: got %+v", bad)
+	}
+	if definition := run.TestDefinitions[2]; definition.ID != bad.TestID || definition.Execution.ID != bad.ExecutionID ||
+		definition.TestMethod.ClassName != "example.com/a" || definition.TestMethod.Name != "TestTable/bad" {
+		t.Errorf("definition: got %+v", definition)
+	}
+	if skip := run.Results[5]; skip.TestName != "TestSkip" || skip.Outcome != "NotExecuted" || skip.Output.ErrorInfo.Message != "needs docker" {
+		t.Errorf("skipped test: got %+v", skip)
+	}
+
+	// Test ids don't change between runs
+	again, err := renderTRX(data, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(again, `testId="`+bad.TestID+`"`) {
+		t.Errorf("test id %s changed between runs", bad.TestID)
+	}
+	if guid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`); !guid.MatchString(bad.TestID) {
+		t.Errorf("test id %s is not a GUID", bad.TestID)
+	}
+}
+
+func TestTRXDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                       "00:00:00.0000000",
+		1500 * time.Millisecond: "00:00:01.5000000",
+		time.Hour + 2*time.Minute + 3*time.Second + 123: "01:02:03.0000001",
+	} {
+		if got := trxDuration(d); got != want {
+			t.Errorf("trxDuration(%v): got %s, want %s", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The status filters of the results browser, cycled with f
+var tuiFilters = []string{"ALL", "FAIL", "SKIP", "PASS", "FLAKY"}
+
+// browser is the state of the interactive results browser: a filtered list
+// of tests, and the output of the selected test when it is opened. It only
+// reacts to keys and renders to a writer, so it works without a terminal.
+type browser struct {
+	results []*TestResult // every test, by package and name
+	visible []*TestResult // the tests passing the filter and search
+
+	filter    int    // index into tuiFilters
+	search    string // case-insensitive substring of the test or package
+	searching bool   // typing into search; the previous query is kept in query
+	query     string
+
+	cursor int // index into visible
+	offset int // first visible row shown
+
+	// The output of the selected test when opened, and its scroll position
+	detail       []string
+	detailOffset int
+
+	width, height int
+}
+
+func newBrowser(data *ReportData, width, height int) *browser {
+	b := &browser{width: width, height: height}
+	for _, result := range data.Results {
+		b.results = append(b.results, result)
+	}
+	sort.Slice(b.results, func(i, j int) bool {
+		if b.results[i].Package != b.results[j].Package {
+			return b.results[i].Package < b.results[j].Package
+		}
+		return b.results[i].Name < b.results[j].Name
+	})
+	b.apply()
+	return b
+}
+
+// apply recomputes the visible tests, keeping the cursor in range
+func (b *browser) apply() {
+	filter := tuiFilters[b.filter]
+	search := strings.ToLower(b.search)
+	b.visible = b.visible[:0]
+	for _, result := range b.results {
+		if filter != "ALL" && result.Status != filter {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(result.Name), search) &&
+			!strings.Contains(strings.ToLower(result.Package), search) {
+			continue
+		}
+		b.visible = append(b.visible, result)
+	}
+	b.cursor = max(0, min(b.cursor, len(b.visible)-1))
+	b.scroll()
+}
+
+// rows is the number of list or output rows that fit between the header
+// and the footer
+func (b *browser) rows() int {
+	return max(1, b.height-3)
+}
+
+// scroll keeps the cursor on screen
+func (b *browser) scroll() {
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+b.rows() {
+		b.offset = b.cursor - b.rows() + 1
+	}
+}
+
+// handleKey applies one key and reports whether the browser should close
+func (b *browser) handleKey(key string) bool {
+	if b.searching {
+		switch key {
+		case "enter":
+			b.searching = false
+		case "esc":
+			b.search, b.searching = b.query, false
+		case "backspace":
+			if _, size := utf8.DecodeLastRuneInString(b.search); size > 0 {
+				b.search = b.search[:len(b.search)-size]
+			}
+		default:
+			if utf8.RuneCountInString(key) == 1 {
+				b.search += key
+			}
+		}
+		b.apply()
+		return false
+	}
+
+	if b.detail != nil {
+		last := max(0, len(b.detail)-b.rows())
+		switch key {
+		case "q", "esc", "enter", "left", "h":
+			b.detail = nil
+		case "down", "j":
+			b.detailOffset = min(last, b.detailOffset+1)
+		case "up", "k":
+			b.detailOffset = max(0, b.detailOffset-1)
+		case "pgdown", " ":
+			b.detailOffset = min(last, b.detailOffset+b.rows())
+		case "pgup":
+			b.detailOffset = max(0, b.detailOffset-b.rows())
+		case "g", "home":
+			b.detailOffset = 0
+		case "G", "end":
+			b.detailOffset = last
+		}
+		return false
+	}
+
+	switch key {
+	case "q", "ctrl+c":
+		return true
+	case "down", "j":
+		b.cursor++
+	case "up", "k":
+		b.cursor--
+	case "pgdown", " ":
+		b.cursor += b.rows()
+	case "pgup":
+		b.cursor -= b.rows()
+	case "g", "home":
+		b.cursor = 0
+	case "G", "end":
+		b.cursor = len(b.visible) - 1
+	case "f":
+		b.filter = (b.filter + 1) % len(tuiFilters)
+	case "/":
+		b.query, b.searching = b.search, true
+	case "esc":
+		b.search = ""
+	case "enter", "right", "l":
+		if len(b.visible) > 0 {
+			b.detail = testDetail(b.visible[b.cursor])
+			b.detailOffset = 0
+		}
+		return false
+	}
+	b.apply()
+	return false
+}
+
+// testDetail returns the lines shown for an opened test
+func testDetail(result *TestResult) []string {
+	lines := []string{fmt.Sprintf("%s %s (%s) %.2fs", statusIcon(result.Status), result.Name, result.Package, result.Duration)}
+	if result.SkipReason != "" {
+		lines = append(lines, "Skipped: "+result.SkipReason)
+	}
+	for i, attempt := range result.Attempts {
+		lines = append(lines, "", fmt.Sprintf("Attempt %d: %s (%.2fs)", i+1, attempt.Status, attempt.Duration))
+		lines = append(lines, attempt.Output...)
+	}
+	lines = append(lines, "")
+	if len(result.Output) == 0 {
+		return append(lines, "(no output)")
+	}
+	for _, line := range result.Output {
+		lines = append(lines, strings.Split(strings.TrimRight(line, "\n"), "\n")...)
+	}
+	return lines
+}
+
+// render draws the whole screen
+func (b *browser) render(w io.Writer) {
+	var sb strings.Builder
+	sb.WriteString("\x1b[H\x1b[2J")
+
+	if b.detail != nil {
+		sb.WriteString("\x1b[1m" + b.fit(b.detail[0]) + "\x1b[0m\r\n")
+		body := b.detail[1:]
+		end := min(len(body), b.detailOffset+b.rows())
+		for _, line := range body[min(b.detailOffset, end):end] {
+			sb.WriteString(b.fit(line) + "\r\n")
+		}
+		for i := end - b.detailOffset; i < b.rows(); i++ {
+			sb.WriteString("\r\n")
+		}
+		sb.WriteString("\x1b[7m" + b.fit(" ↑/↓ scroll  PgUp/PgDn page  Enter/Esc back ") + "\x1b[0m")
+		io.WriteString(w, sb.String())
+		return
+	}
+
+	header := fmt.Sprintf("gotest-report · %d of %d tests · filter: %s", len(b.visible), len(b.results), tuiFilters[b.filter])
+	if b.search != "" || b.searching {
+		header += " · search: " + b.search
+	}
+	sb.WriteString("\x1b[1m" + b.fit(header) + "\x1b[0m\r\n")
+
+	end := min(len(b.visible), b.offset+b.rows())
+	for i := b.offset; i < end; i++ {
+		result := b.visible[i]
+		row := fmt.Sprintf("%s %-*s %7.2fs  %s", statusIcon(result.Status), 5, result.Status, result.Duration, result.Name)
+		if result.Package != "" {
+			row += "  (" + result.Package + ")"
+		}
+		row = b.fit(row)
+		if i == b.cursor {
+			row = "\x1b[7m" + row + "\x1b[0m"
+		}
+		sb.WriteString(row + "\r\n")
+	}
+	for i := end - b.offset; i < b.rows(); i++ {
+		sb.WriteString("\r\n")
+	}
+
+	footer := " ↑/↓ move  Enter output  f filter  / search  q quit "
+	if b.searching {
+		footer = " search: " + b.search + "█  (Enter apply, Esc cancel) "
+	}
+	sb.WriteString("\x1b[7m" + b.fit(footer) + "\x1b[0m")
+	io.WriteString(w, sb.String())
+}
+
+// fit cuts a line to the screen width
+func (b *browser) fit(line string) string {
+	if b.width <= 0 || utf8.RuneCountInString(line) <= b.width {
+		return line
+	}
+	return string([]rune(line)[:b.width-1]) + "…"
+}
+
+func statusIcon(status string) string {
+	switch status {
+	case "PASS":
+		return "✅"
+	case "FAIL":
+		return "❌"
+	case "SKIP":
+		return "⏭️"
+	case "FLAKY":
+		return "🔁"
+	}
+	return "❔"
+}
+
+// readKey reads one key press from a terminal in raw mode, naming the
+// special keys the browser uses ("up", "enter", "esc", ...)
+func readKey(r *bufio.Reader) (string, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case '\r', '\n':
+		return "enter", nil
+	case 3:
+		return "ctrl+c", nil
+	case 127, 8:
+		return "backspace", nil
+	case 27:
+	default:
+		return string(c), nil
+	}
+
+	// An escape sequence follows immediately; a lone escape doesn't
+	if r.Buffered() == 0 {
+		return "esc", nil
+	}
+	next, _ := r.ReadByte()
+	if next != '[' && next != 'O' {
+		return "esc", nil
+	}
+	var seq strings.Builder
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		seq.WriteByte(b)
+		if b >= 0x40 && b <= 0x7e {
+			break
+		}
+	}
+	switch seq.String() {
+	case "A":
+		return "up", nil
+	case "B":
+		return "down", nil
+	case "C":
+		return "right", nil
+	case "D":
+		return "left", nil
+	case "H", "1~":
+		return "home", nil
+	case "F", "4~":
+		return "end", nil
+	case "5~":
+		return "pgup", nil
+	case "6~":
+		return "pgdown", nil
+	}
+	return "", nil
+}
+
+// stty runs the stty tool on the terminal. The standard library can't switch
+// a terminal to raw mode, so this relies on stty as compressed input relies
+// on zstd.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// terminalSize returns the width and height of the terminal
+func terminalSize() (int, int, error) {
+	size, err := stty("size")
+	if err != nil {
+		return 0, 0, err
+	}
+	rows, cols, ok := strings.Cut(size, " ")
+	if !ok {
+		return 0, 0, fmt.Errorf("unexpected stty size output %q", size)
+	}
+	height, err := strconv.Atoi(rows)
+	if err != nil {
+		return 0, 0, err
+	}
+	width, err := strconv.Atoi(cols)
+	if err != nil {
+		return 0, 0, err
+	}
+	return width, height, nil
+}
+
+// tuiCommand implements `gotest-report tui [flags]`, a keyboard-driven
+// browser for the tests of a go test -json file
+func tuiCommand(args []string) int {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	inputs := addInputFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	if len(inputs.inputs.specs) == 0 {
+		logger.Error("tui needs an -input file, since it reads keys from stdin")
+		return 1
+	}
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		logger.Error("tui needs an interactive terminal")
+		return 1
+	}
+
+	cfg := defaultConfig()
+	cfg.includePassOutput = true
+	inputs.configure(cfg)
+	runs, err := inputs.load(cfg, "markdown")
+	if err != nil {
+		logger.Error("processing test events", "error", err)
+		return 1
+	}
+	data := combineRuns(runs)
+	warnSkippedLines(data)
+
+	width, height, err := terminalSize()
+	if err != nil {
+		logger.Error("reading the terminal size", "error", err)
+		return 1
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		logger.Error("reading the terminal settings", "error", err)
+		return 1
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		logger.Error("switching the terminal to raw mode", "error", err)
+		return 1
+	}
+	// Draw on the alternate screen with the cursor hidden, restoring both
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		stty(saved)
+	}()
+
+	b := newBrowser(data, width, height)
+	keys := bufio.NewReader(os.Stdin)
+	for {
+		b.render(os.Stdout)
+		key, err := readKey(keys)
+		if errors.Is(err, io.EOF) {
+			return 0
+		}
+		if err != nil {
+			logger.Error("reading keys", "error", err)
+			return 1
+		}
+		if b.handleKey(key) {
+			return 0
+		}
+	}
+}
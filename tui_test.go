@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func testBrowser() *browser {
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestA":     {Name: "TestA", Package: "example.com/a", Status: "PASS", Duration: 0.1},
+		"TestB":     {Name: "TestB", Package: "example.com/a", Status: "FAIL", Duration: 0.2, Output: []string{"=== RUN   TestB\n", "    b_test.go:3: boom\n"}},
+		"TestCache": {Name: "TestCache", Package: "example.com/cache", Status: "SKIP", SkipReason: "needs redis"},
+		"TestD":     {Name: "TestD", Package: "example.com/d", Status: "FAIL"},
+	}}
+	return newBrowser(data, 60, 6)
+}
+
+func visibleNames(b *browser) string {
+	names := make([]string, len(b.visible))
+	for i, result := range b.visible {
+		names[i] = result.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestBrowserFilterAndSearch(t *testing.T) {
+	b := testBrowser()
+	if got := visibleNames(b); got != "TestA,TestB,TestCache,TestD" {
+		t.Fatalf("visible tests: got %s", got)
+	}
+
+	b.handleKey("f")
+	if got := visibleNames(b); got != "TestB,TestD" {
+		t.Errorf("FAIL filter: got %s", got)
+	}
+	b.handleKey("f")
+	if got := visibleNames(b); got != "TestCache" {
+		t.Errorf("SKIP filter: got %s", got)
+	}
+	for range tuiFilters[2:] {
+		b.handleKey("f")
+	}
+
+	for _, key := range []string{"/", "C", "a", "x", "backspace", "enter"} {
+		b.handleKey(key)
+	}
+	if got := visibleNames(b); got != "TestCache" || b.search != "Ca" {
+		t.Errorf("search %q: got %s", b.search, got)
+	}
+	for _, key := range []string{"/", "z", "esc"} {
+		b.handleKey(key)
+	}
+	if b.search != "Ca" {
+		t.Errorf("cancelled search: got %q, want the previous query", b.search)
+	}
+	b.handleKey("esc")
+	if got := visibleNames(b); got != "TestA,TestB,TestCache,TestD" {
+		t.Errorf("cleared search: got %s", got)
+	}
+}
+
+func TestBrowserNavigation(t *testing.T) {
+	b := testBrowser()
+	b.handleKey("up")
+	if b.cursor != 0 {
+		t.Errorf("cursor above the first test: got %d", b.cursor)
+	}
+	b.handleKey("G")
+	if b.cursor != 3 || b.offset != 1 {
+		t.Errorf("last test: got cursor %d offset %d, want 3 and 1", b.cursor, b.offset)
+	}
+	b.handleKey("g")
+	b.handleKey("j")
+	b.handleKey("enter")
+	if b.detail == nil || b.detail[0] != "❌ TestB (example.com/a) 0.20s" || b.detail[len(b.detail)-1] != "    b_test.go:3: boom" {
+		t.Fatalf("opened test: got %q", b.detail)
+	}
+
+	var screen strings.Builder
+	b.render(&screen)
+	if !strings.Contains(screen.String(), "=== RUN   TestB\r\n") {
+		t.Errorf("output view:\n%q", screen.String())
+	}
+	b.handleKey("esc")
+	if b.detail != nil {
+		t.Error("esc should close the output view")
+	}
+	if b.handleKey("q") != true {
+		t.Error("q should quit")
+	}
+}
+
+func TestBrowserRender(t *testing.T) {
+	b := testBrowser()
+	b.handleKey("j")
+	var screen strings.Builder
+	b.render(&screen)
+
+	for _, want := range []string{
+		"gotest-report · 4 of 4 tests · filter: ALL",
+		"✅ PASS     0.10s  TestA  (example.com/a)\r\n",
+		"\x1b[7m❌ FAIL     0.20s  TestB  (example.com/a)\x1b[0m\r\n",
+		"⏭️ SKIP     0.00s  TestCache  (example.com/cache)\r\n",
+	} {
+		if !strings.Contains(screen.String(), want) {
+			t.Errorf("screen missing %q:\n%q", want, screen.String())
+		}
+	}
+	if strings.Contains(screen.String(), "TestD") {
+		t.Errorf("screen shows more rows than fit:\n%q", screen.String())
+	}
+	if got := b.fit(strings.Repeat("x", 80)); got != strings.Repeat("x", 59)+"…" {
+		t.Errorf("fit: got %q", got)
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("j\x1b[A\x1b[6~\r\x7fé\x03"))
+	var keys []string
+	for {
+		key, err := readKey(r)
+		if err != nil {
+			break
+		}
+		keys = append(keys, key)
+	}
+	if got := strings.Join(keys, " "); got != "j up pgdown enter backspace é ctrl+c" {
+		t.Errorf("keys: got %q", got)
+	}
+
+	if key, _ := readKey(bufio.NewReader(strings.NewReader("\x1b"))); key != "esc" {
+		t.Errorf("lone escape: got %q", key)
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// uploadTarget is the bucket or container location given to -upload:
+// s3://bucket/prefix, gs://bucket/prefix or az://account/container/prefix
+type uploadTarget struct {
+	scheme  string // s3, gs or az
+	account string // Azure storage account
+	bucket  string // bucket, or Azure container
+	prefix  string // key prefix, without leading or trailing slashes
+}
+
+// uploadTools are the command-line tools uploads go through, by scheme.
+// They pick up the credentials the CI already configured for them.
+var uploadTools = map[string]string{"s3": "aws", "gs": "gcloud", "az": "az"}
+
+func parseUploadTarget(raw string) (*uploadTarget, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -upload location %q: %v", raw, err)
+	}
+	if _, ok := uploadTools[u.Scheme]; !ok {
+		return nil, fmt.Errorf("unsupported -upload location %q (want s3://bucket/prefix, gs://bucket/prefix or az://account/container/prefix)", raw)
+	}
+	target := &uploadTarget{scheme: u.Scheme, bucket: u.Host, prefix: strings.Trim(u.Path, "/")}
+	if u.Scheme == "az" {
+		container, prefix, _ := strings.Cut(target.prefix, "/")
+		target.account, target.bucket, target.prefix = u.Host, container, prefix
+	}
+	if target.bucket == "" {
+		return nil, fmt.Errorf("-upload location %q has no bucket or container", raw)
+	}
+	return target, nil
+}
+
+// key returns the object name of a file named name
+func (t *uploadTarget) key(name string) string {
+	return path.Join(t.prefix, name)
+}
+
+// upload copies file to the object named name and returns a link to it:
+// a presigned (S3), signed (GCS) or SAS (Azure) URL valid for expires when
+// the tool can create one, and otherwise the object's public URL
+func (t *uploadTarget) upload(file, name, contentType string, expires time.Duration) (string, error) {
+	tool := uploadTools[t.scheme]
+	if _, err := exec.LookPath(tool); err != nil {
+		return "", fmt.Errorf("-upload to %s:// needs the %s tool on PATH", t.scheme, tool)
+	}
+	key := t.key(name)
+
+	var upload, sign []string
+	var public string
+	switch t.scheme {
+	case "s3":
+		object := fmt.Sprintf("s3://%s/%s", t.bucket, key)
+		upload = []string{"s3", "cp", file, object, "--content-type", contentType, "--only-show-errors"}
+		sign = []string{"s3", "presign", object, "--expires-in", fmt.Sprint(int(expires.Seconds()))}
+		public = fmt.Sprintf("https://%s.s3.amazonaws.com/%s", t.bucket, escapeKey(key))
+	case "gs":
+		object := fmt.Sprintf("gs://%s/%s", t.bucket, key)
+		upload = []string{"storage", "cp", file, object, "--content-type=" + contentType}
+		sign = []string{"storage", "sign-url", object, "--duration=" + fmt.Sprintf("%ds", int(expires.Seconds())), "--format=value(signed_url)"}
+		public = fmt.Sprintf("https://storage.googleapis.com/%s/%s", t.bucket, escapeKey(key))
+	case "az":
+		blob := []string{"--account-name", t.account, "--container-name", t.bucket, "--name", key}
+		upload = append(append([]string{"storage", "blob", "upload"}, blob...), "--file", file, "--content-type", contentType, "--overwrite", "--only-show-errors")
+		sign = append(append([]string{"storage", "blob", "generate-sas"}, blob...), "--permissions", "r", "--expiry",
+			time.Now().Add(expires).UTC().Format("2006-01-02T15:04Z"), "--full-uri", "--output", "tsv", "--only-show-errors")
+		public = fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s", t.account, t.bucket, escapeKey(key))
+	}
+
+	if out, err := exec.Command(tool, upload...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("error uploading %s with %s: %v\n%s", file, tool, err, out)
+	}
+	signed, err := exec.Command(tool, sign...).Output()
+	if link := strings.TrimSpace(string(signed)); err == nil && strings.HasPrefix(link, "https://") {
+		return link, nil
+	}
+	logger.Debug("could not sign the upload URL; using the public URL", "tool", tool, "error", err)
+	return public, nil
+}
+
+// escapeKey escapes each segment of an object name for use in a URL
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// formatContentTypes are the content types of the report formats
+var formatContentTypes = map[string]string{
+	"markdown": "text/markdown; charset=utf-8",
+	"json":     "application/json",
+	"ndjson":   "application/x-ndjson",
+	"html":     "text/html; charset=utf-8",
+	"junit":    "application/xml",
+	"xunit":    "application/xml",
+	"nunit3":   "application/xml",
+	"trx":      "application/xml",
+	"pdf":      "application/pdf",
+	"jira":     "text/plain; charset=utf-8",
+	"xlsx":     "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"influx":   "text/plain; charset=utf-8",
+}
+
+// inputContentType returns the content type of a go test output file by
+// its extension; uncompressed go test -json output is NDJSON
+func inputContentType(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json", ".jsonl", ".ndjson":
+		return "application/x-ndjson"
+	case ".gz":
+		return "application/gzip"
+	case ".zst":
+		return "application/zstd"
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(file)); contentType != "" {
+		return contentType
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// uploadFile is a local file and the object name it is uploaded as
+type uploadFile struct {
+	path, name, contentType string
+	input                   bool // go test output, which needs redacting
+}
+
+// reportUploads lists the files -upload copies: the report, with the package
+// reports next to it when it was split, and the go test output it was
+// rendered from. Object names keep the layout the split index links to.
+func reportUploads(output, format string, split bool, inputs []inputSpec) ([]uploadFile, error) {
+	files := []uploadFile{{path: output, name: filepath.Base(output), contentType: formatContentTypes[format]}}
+	if split {
+		dir := strings.TrimSuffix(output, filepath.Ext(output))
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				files = append(files, uploadFile{
+					path:        filepath.Join(dir, entry.Name()),
+					name:        path.Join(filepath.Base(dir), entry.Name()),
+					contentType: formatContentTypes["markdown"],
+				})
+			}
+		}
+	}
+	for _, input := range inputs {
+		if input.path == "" {
+			logger.Warn("not uploading go test output read from stdin")
+			continue
+		}
+		files = append(files, uploadFile{path: input.path, name: filepath.Base(input.path), contentType: inputContentType(input.path), input: true})
+	}
+	return files, nil
+}
+
+// redactedInput writes a copy of the go test output in file with the secrets
+// redactor finds masked, as in the report, and returns it as an upload
+// replacing file. The caller removes the copy. Compressed output is copied
+// gzip-compressed, uncompressed output as is.
+func redactedInput(file uploadFile, redactor *report.Redactor) (uploadFile, error) {
+	in, err := os.Open(file.path)
+	if err != nil {
+		return uploadFile{}, err
+	}
+	defer in.Close()
+	reader, err := report.Decompress(in)
+	if err != nil {
+		return uploadFile{}, err
+	}
+
+	name := file.name
+	compressed := false
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gz", ".zst":
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + ".gz"
+		compressed = true
+	}
+	out, err := os.CreateTemp("", "gotest-report-upload-*-"+name)
+	if err != nil {
+		return uploadFile{}, err
+	}
+	copied := uploadFile{path: out.Name(), name: name, contentType: inputContentType(name)}
+
+	var w io.Writer = out
+	var gz *gzip.Writer
+	if compressed {
+		gz = gzip.NewWriter(out)
+		w = gz
+	}
+	err = redactLines(w, reader, redactor)
+	if gz != nil {
+		if closeErr := gz.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(copied.path)
+		return uploadFile{}, err
+	}
+	return copied, nil
+}
+
+// redactLines copies r to w line by line, masking secrets. In go test -json
+// events only the output is redacted, so the events stay valid JSON; other
+// lines, such as plain go test -v output, are redacted whole.
+func redactLines(w io.Writer, r io.Reader, redactor *report.Redactor) error {
+	out := bufio.NewWriter(w)
+	in := bufio.NewReader(r)
+	for {
+		line, readErr := in.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, err := out.Write(redactLine(line, redactor)); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		} else if readErr != nil {
+			return readErr
+		}
+	}
+	return out.Flush()
+}
+
+// redactLine masks the secrets in one line, keeping its line ending
+func redactLine(line []byte, redactor *report.Redactor) []byte {
+	text := bytes.TrimRight(line, "\r\n")
+	ending := line[len(text):]
+	if !bytes.HasPrefix(bytes.TrimSpace(text), []byte("{")) {
+		return append([]byte(redactor.Redact(string(text))), ending...)
+	}
+
+	var event map[string]json.RawMessage
+	if json.Unmarshal(text, &event) != nil {
+		return append([]byte(redactor.Redact(string(text))), ending...)
+	}
+	var output string
+	if json.Unmarshal(event["Output"], &output) != nil {
+		return line
+	}
+	redacted := redactor.Redact(output)
+	if redacted == output {
+		return line
+	}
+	// Marshalling a string and a map of raw values can't fail
+	event["Output"], _ = json.Marshal(redacted)
+	encoded, _ := json.Marshal(event)
+	return append(encoded, ending...)
+}
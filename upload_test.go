@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestParseUploadTarget(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want uploadTarget
+	}{
+		{"s3://reports/ci/123/", uploadTarget{scheme: "s3", bucket: "reports", prefix: "ci/123"}},
+		{"gs://reports", uploadTarget{scheme: "gs", bucket: "reports"}},
+		{"az://acct/reports/ci", uploadTarget{scheme: "az", account: "acct", bucket: "reports", prefix: "ci"}},
+	}
+	for _, tt := range tests {
+		got, err := parseUploadTarget(tt.raw)
+		if err != nil || *got != tt.want {
+			t.Errorf("parseUploadTarget(%q): got %+v, %v, want %+v", tt.raw, got, err, tt.want)
+		}
+	}
+	for _, raw := range []string{"ftp://host/x", "s3:///prefix", "az://acct", "reports/ci"} {
+		if _, err := parseUploadTarget(raw); err == nil {
+			t.Errorf("parseUploadTarget(%q): expected an error", raw)
+		}
+	}
+}
+
+func TestUpload(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	// A stand-in for the aws tool that records its arguments and presigns
+	// by echoing the object
+	script := "#!/bin/sh\necho \"$@\" >> " + log + "\n[ \"$2\" = presign ] && echo \"https://signed.example/$3\"\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(dir, "aws"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	target, err := parseUploadTarget("s3://reports/ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, err := target.upload("out/test-report.md", "test-report.md", formatContentTypes["markdown"], time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "https://signed.example/s3://reports/ci/test-report.md" {
+		t.Errorf("link: got %s", link)
+	}
+	calls, _ := os.ReadFile(log)
+	want := "s3 cp out/test-report.md s3://reports/ci/test-report.md --content-type text/markdown; charset=utf-8 --only-show-errors\n" +
+		"s3 presign s3://reports/ci/test-report.md --expires-in 3600\n"
+	if string(calls) != want {
+		t.Errorf("aws calls: got\n%s\nwant\n%s", calls, want)
+	}
+
+	// Without a signed URL, the public one
+	gcloud := "#!/bin/sh\n[ \"$2\" = sign-url ] && exit 1\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(dir, "gcloud"), []byte(gcloud), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	target, _ = parseUploadTarget("gs://reports/ci")
+	if link, err := target.upload("x", "pkg/a b.md", "text/plain", time.Hour); err != nil || link != "https://storage.googleapis.com/reports/ci/pkg/a%20b.md" {
+		t.Errorf("public link: got %s, %v", link, err)
+	}
+
+	target, _ = parseUploadTarget("az://acct/reports")
+	if _, err := target.upload("x", "y", "text/plain", time.Hour); err == nil || !strings.Contains(err.Error(), "az tool") {
+		t.Errorf("without the az tool: got %v", err)
+	}
+}
+
+func TestReportUploads(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "test-report.md")
+	os.MkdirAll(filepath.Join(dir, "test-report"), 0o755)
+	os.WriteFile(filepath.Join(dir, "test-report", "pkg-a.md"), nil, 0o644)
+
+	files, err := reportUploads(output, "markdown", true, []inputSpec{{path: "results/run.json.gz"}, {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uploadFile{
+		{path: output, name: "test-report.md", contentType: "text/markdown; charset=utf-8"},
+		{path: filepath.Join(dir, "test-report", "pkg-a.md"), name: "test-report/pkg-a.md", contentType: "text/markdown; charset=utf-8"},
+		{path: "results/run.json.gz", name: "run.json.gz", contentType: "application/gzip", input: true},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("got %+v, want %+v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("file %d: got %+v, want %+v", i, files[i], want[i])
+		}
+	}
+}
+
+func TestRedactedInput(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	redactor, err := report.NewRedactor(false, []string{"hunter2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{
+		`{"Action":"output","Test":"TestA","Package":"pkg/a","Output":"password hunter2\n"}`,
+		`{"Action":"pass","Test":"TestA","Package":"pkg/a","Elapsed":0.1}`,
+		`hunter2 in plain output`,
+	}
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	gz.Close()
+	input := filepath.Join(t.TempDir(), "run.json.gz")
+	if err := os.WriteFile(input, compressed.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := redactedInput(uploadFile{path: input, name: "run.json.gz", input: true}, redactor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.path)
+	if file.name != "run.json.gz" || file.contentType != "application/gzip" {
+		t.Errorf("copy: got %+v", file)
+	}
+	f, err := os.Open(file.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	reader, err := report.Decompress(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(reader)
+	want := []string{
+		`{"Action":"output","Output":"password [REDACTED]\n","Package":"pkg/a","Test":"TestA"}`,
+		lines[1],
+		`[REDACTED] in plain output`,
+	}
+	if string(got) != strings.Join(want, "\n")+"\n" {
+		t.Errorf("redacted copy:\ngot:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestFormatContentTypes(t *testing.T) {
+	for _, format := range reportFormats {
+		if formatContentTypes[format] == "" {
+			t.Errorf("no content type for -format %s", format)
+		}
+	}
+}
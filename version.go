@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Set at build time with -ldflags "-X main.version=... -X main.commit=... -X main.date=..."
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+// commandNames lists the names of the subcommands
+func commandNames() []string {
+	names := make([]string, len(commands))
+	for i, cmd := range commands {
+		names[i] = cmd.name
+	}
+	return names
+}
+
+// reportFormats lists the values accepted by -format
+var reportFormats = []string{"markdown", "json", "ndjson", "html", "junit", "xunit", "nunit3", "trx", "pdf", "jira", "xlsx", "influx"}
+
+// features lists optional capabilities wrapper scripts can check for before
+// relying on them
+var features = []string{
+	"added-removed-tests",
+	"aggregate-parent-durations",
+	"allure-results",
+	"badge",
+	"baseline-comparison",
+	"benchmarks",
+	"bitbucket-code-insights",
+	"blame",
+	"bounded-memory",
+	"build-failure-exit-code",
+	"categories",
+	"commit-status",
+	"compressed-input:gzip",
+	"compressed-input:zstd",
+	"count-stats",
+	"ctrf-input",
+	"datadog",
+	"duplicate-subtests",
+	"duration-budgets",
+	"duration-histogram",
+	"duration-regressions",
+	"embed-source",
+	"environment",
+	"examples",
+	"excel-output",
+	"failing-streaks",
+	"failure-groups",
+	"file-issues",
+	"first-bad-commit",
+	"github-source-links",
+	"gitlab",
+	"goleak",
+	"history",
+	"history-sparkline",
+	"html-filter-sort",
+	"html-output-search",
+	"include-pass-output",
+	"influx-output",
+	"integrity-trailer",
+	"invocations",
+	"jira-output",
+	"jira-tickets",
+	"junit-input",
+	"junit-output",
+	"lenient",
+	"long-lines",
+	"matrix-labels",
+	"mermaid-pie",
+	"module-rollup",
+	"ndjson-output",
+	"nunit3-output",
+	"output-filter",
+	"pdf-output",
+	"progress",
+	"quality-gate",
+	"quarantine",
+	"redact-secrets",
+	"reportportal",
+	"rerun-commands",
+	"rerun-fails",
+	"results-db",
+	"retry-attempts",
+	"review-comments",
+	"sanitizers",
+	"self-metrics",
+	"shard-merge",
+	"shuffle-seed",
+	"size-limit-trim",
+	"slow-annotations",
+	"slow-threshold",
+	"slowest-packages",
+	"source-index",
+	"split-by-package",
+	"status-icons",
+	"structured-logging",
+	"subtest-names",
+	"suites",
+	"terminal-summary",
+	"test-binary-input",
+	"test-descriptions",
+	"test-labels",
+	"testrail",
+	"text-input",
+	"timeline",
+	"trend-chart",
+	"triage-checklist",
+	"trx-output",
+	"tui",
+	"upload",
+	"wall-clock",
+	"webhook",
+	"xunit-output",
+}
+
+// versionInfo is what -version-json prints
+type versionInfo struct {
+	Version    string   `json:"version"`
+	Commit     string   `json:"commit"`
+	Modified   bool     `json:"modified"`   // built from a checkout with uncommitted changes
+	BuildDate  string   `json:"buildDate"`  // only set with -ldflags
+	CommitTime string   `json:"commitTime"` // when the commit was made, from the VCS stamp
+	GoVersion  string   `json:"goVersion"`
+	Platform   string   `json:"platform"`
+	Commands   []string `json:"commands"`
+	Formats    []string `json:"formats"`
+	Features   []string `json:"features"`
+}
+
+// buildVersionInfo fills in what wasn't set with -ldflags from the build
+// information Go embeds: the module version for go install builds, and the
+// VCS stamp for builds from a checkout. The VCS stamp only has the time of
+// the commit, so the build date stays unknown unless set with -ldflags.
+func buildVersionInfo() versionInfo {
+	info := versionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Commands:  commandNames(),
+		Formats:   reportFormats,
+		Features:  features,
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		info.GoVersion = build.GoVersion
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time":
+				info.CommitTime = setting.Value
+			case setting.Key == "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// versionText renders the version and build information for people
+func versionText() string {
+	info := buildVersionInfo()
+	orUnknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	commit := orUnknown(info.Commit)
+	if info.Modified {
+		commit += " (modified)"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("gotest-report version %s\n", info.Version))
+	sb.WriteString(fmt.Sprintf("  commit:     %s\n", commit))
+	sb.WriteString(fmt.Sprintf("  built:      %s\n", orUnknown(info.BuildDate)))
+	if info.CommitTime != "" {
+		sb.WriteString(fmt.Sprintf("  committed:  %s\n", info.CommitTime))
+	}
+	sb.WriteString(fmt.Sprintf("  go version: %s %s\n", info.GoVersion, info.Platform))
+	return sb.String()
+}
+
+// versionJSON renders the version and capabilities as indented JSON
+func versionJSON() (string, error) {
+	encoded, err := json.MarshalIndent(buildVersionInfo(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(encoded) + "\n", nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestVersionJSON(t *testing.T) {
+	encoded, err := versionJSON()
+	if err != nil {
+		t.Fatalf("versionJSON: %v", err)
+	}
+
+	var info versionInfo
+	if err := json.Unmarshal([]byte(encoded), &info); err != nil {
+		t.Fatalf("decoding version JSON: %v\n%s", err, encoded)
+	}
+	if info.Version != version || info.GoVersion == "" {
+		t.Errorf("unexpected version info: %+v", info)
+	}
+	if info.BuildDate != date {
+		t.Errorf("build date: got %q, want only the -ldflags date %q", info.BuildDate, date)
+	}
+	for _, format := range info.Formats {
+		if err := checkFormat(format); err != nil {
+			t.Errorf("advertised format %q is rejected: %v", format, err)
+		}
+	}
+	if len(info.Features) == 0 || len(info.Commands) == 0 {
+		t.Errorf("expected features and commands, got %+v", info)
+	}
+}
+
+func TestVersionText(t *testing.T) {
+	text := versionText()
+	info := buildVersionInfo()
+	for _, want := range []string{"gotest-report version " + info.Version + "\n", "  commit:     ", "  built:      ", "  go version: " + info.GoVersion + " " + runtime.GOOS + "/" + runtime.GOARCH} {
+		if !strings.Contains(text, want) {
+			t.Errorf("version text missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestFeaturesSorted(t *testing.T) {
+	if !sort.StringsAreSorted(features) {
+		t.Errorf("features should be in alphabetical order: %v", features)
+	}
+}
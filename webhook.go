@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// headerList collects repeated -webhook-header flags
+type headerList []string
+
+func (h *headerList) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerList) Set(value string) error {
+	name, _, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("invalid header %q (want Name: value)", value)
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+// webhook POSTs the results to an HTTP endpoint, by default as the JSON
+// report. A template, executed with text/template, can shape the body for
+// endpoints expecting their own format.
+type webhook struct {
+	url      string
+	template *template.Template // nil sends the JSON report
+	headers  headerList
+	http     *http.Client
+}
+
+// webhookData is what a webhook template is executed with: the report data
+// plus its failures as in the JSON report
+type webhookData struct {
+	*ReportData
+	Failures []report.Failure
+}
+
+// webhookFuncs are the functions available in webhook templates; json
+// encodes a value, e.g. a string with quotes escaped
+var webhookFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	},
+}
+
+// newWebhook sets up a webhook, reading the body template from
+// templateFile unless it is empty
+func newWebhook(url, templateFile string, headers headerList) (*webhook, error) {
+	w := &webhook{url: url, headers: headers, http: &http.Client{Timeout: 30 * time.Second}}
+	if templateFile != "" {
+		text, err := os.ReadFile(templateFile)
+		if err != nil {
+			return nil, err
+		}
+		w.template, err = template.New(templateFile).Funcs(webhookFuncs).Option("missingkey=error").Parse(string(text))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// send posts data to the webhook
+func (w *webhook) send(data *ReportData) error {
+	var body []byte
+	if w.template == nil {
+		encoded, err := data.JSON()
+		if err != nil {
+			return err
+		}
+		body = encoded
+	} else {
+		var buf bytes.Buffer
+		if err := w.template.Execute(&buf, webhookData{ReportData: data, Failures: report.Failures(data)}); err != nil {
+			return fmt.Errorf("executing the webhook template: %w", err)
+		}
+		body = buf.Bytes()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gotest-report/"+version)
+	for _, header := range w.headers {
+		name, value, _ := strings.Cut(header, ":")
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("POST %s: %s: %s", w.url, resp.Status, strings.TrimSpace(string(message)))
+	}
+	logger.Info("sent webhook", "status", resp.StatusCode)
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHeaderListSet(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"Authorization: Bearer abc", true},
+		{"X-Empty:", true},
+		{"Authorization", false},
+		{": value", false},
+	}
+	for _, tt := range tests {
+		var headers headerList
+		if err := headers.Set(tt.value); (err == nil) != tt.ok {
+			t.Errorf("Set(%q): got error %v, want ok %v", tt.value, err, tt.ok)
+		}
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	data := &ReportData{
+		TotalTests:  2,
+		PassedTests: 1,
+		FailedTests: 1,
+		Results: map[string]*TestResult{
+			"TestOK":  {Name: "TestOK", Package: "example.com/pkg", Status: "PASS"},
+			"TestBad": {Name: "TestBad", Package: "example.com/pkg", Status: "FAIL", Output: []string{`    pkg_test.go:9: want "a"`}},
+		},
+		SortedTestNames: []string{"TestBad", "TestOK"},
+		ReportURL:       "https://reports.example.com/run.html",
+	}
+
+	var gotBody []byte
+	var gotHeader http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotHeader = r.Header
+	}))
+	defer server.Close()
+
+	hook, err := newWebhook(server.URL, "", headerList{"Authorization: Bearer secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hook.send(data); err != nil {
+		t.Fatal(err)
+	}
+	var summary map[string]any
+	if err := json.Unmarshal(gotBody, &summary); err != nil {
+		t.Fatalf("default body is not JSON: %v\n%s", err, gotBody)
+	}
+	if summary["failed"] != 1.0 || summary["reportUrl"] != data.ReportURL {
+		t.Errorf("failed and report: got %v and %v", summary["failed"], summary["reportUrl"])
+	}
+	if got := gotHeader.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization: got %q, want %q", got, "Bearer secret")
+	}
+	if got := gotHeader.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type: got %q, want application/json", got)
+	}
+
+	tmpl := filepath.Join(t.TempDir(), "webhook.tmpl")
+	text := `{"text": {{ printf "%d passed, %d failed" .PassedTests .FailedTests | json }}, "failed": [{{ range $i, $f := .Failures }}{{ if $i }}, {{ end }}{{ json $f.Name }}{{ end }}], "report": {{ json .ReportURL }}}`
+	if err := os.WriteFile(tmpl, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hook, err = newWebhook(server.URL, tmpl, headerList{"Content-Type: text/plain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hook.send(data); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"text": "1 passed, 1 failed", "failed": ["TestBad"], "report": "https://reports.example.com/run.html"}`; string(gotBody) != want {
+		t.Errorf("templated body: got %s, want %s", gotBody, want)
+	}
+	if got := gotHeader.Get("Content-Type"); got != "text/plain" {
+		t.Errorf("Content-Type: got %q, want text/plain", got)
+	}
+}
+
+func TestWebhookSendError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such hook", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	hook, err := newWebhook(server.URL, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = hook.send(&ReportData{})
+	if err == nil || !strings.Contains(err.Error(), "no such hook") {
+		t.Errorf("got error %v, want the response body in it", err)
+	}
+}
+
+func TestNewWebhookTemplateError(t *testing.T) {
+	tmpl := filepath.Join(t.TempDir(), "webhook.tmpl")
+	if err := os.WriteFile(tmpl, []byte("{{ .PassedTests"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newWebhook("http://example.com", tmpl, nil); err == nil {
+		t.Error("got no error for an unterminated action")
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// workspaceModules returns the module paths of a multi-module repository:
+// those used by workFile, or without one by the go.work enclosing dir, or
+// else those of every go.mod in the repository around dir. It returns nil
+// outside of a Go module.
+func workspaceModules(workFile, dir string) ([]string, error) {
+	if workFile == "" {
+		workFile = findGoWork(dir)
+	}
+	if workFile != "" {
+		return goWorkModules(workFile)
+	}
+
+	tree := findSourceTree(dir)
+	if tree == nil {
+		return nil, nil
+	}
+	var modules []string
+	err := filepath.WalkDir(tree.repoRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Leave out what can't be read rather than failing the report
+			return nil
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			// The go command ignores most of these too
+			if path != tree.repoRoot && (name == "vendor" || name == "testdata" || name == "node_modules" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name == "go.mod" {
+			if module, err := modulePath(path); err == nil && module != "" {
+				modules = append(modules, module)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error finding modules: %v", err)
+	}
+	sort.Strings(modules)
+	return modules, nil
+}
+
+// rollUpModules adds the per-module counts to data when its tests span more
+// than one module of the workspace
+func rollUpModules(data *ReportData, workFile string) error {
+	modules, err := workspaceModules(workFile, ".")
+	if err != nil {
+		return err
+	}
+	if summaries := report.ModuleSummaries(data, modules); len(summaries) > 1 {
+		data.Modules = summaries
+	}
+	return nil
+}
+
+// findGoWork returns the go.work file enclosing dir, or ""
+func findGoWork(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, "go.work")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// goWorkModules reads the module paths of the directories a go.work file
+// uses, both as single "use ./dir" lines and in "use ( ... )" blocks
+func goWorkModules(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening workspace file: %v", err)
+	}
+	defer file.Close()
+
+	var dirs []string
+	inUse := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		line = strings.TrimSpace(line)
+		switch {
+		case inUse && line == ")":
+			inUse = false
+		case inUse && line != "":
+			dirs = append(dirs, line)
+		case line == "use (":
+			inUse = true
+		case strings.HasPrefix(line, "use "):
+			dirs = append(dirs, strings.TrimSpace(strings.TrimPrefix(line, "use ")))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading workspace file %s: %v", path, err)
+	}
+
+	var modules []string
+	for _, dir := range dirs {
+		dir = strings.Trim(dir, `"`)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(path), dir)
+		}
+		module, err := modulePath(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			return nil, fmt.Errorf("error reading module of workspace %s: %v", path, err)
+		}
+		if module != "" {
+			modules = append(modules, module)
+		}
+	}
+	sort.Strings(modules)
+	return modules, nil
+}
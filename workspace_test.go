@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+func TestWorkspaceModules(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                    "module example.com/mono\n",
+		"services/api/go.mod":       "module example.com/mono/services/api\n",
+		"services/billing/go.mod":   "module \"example.com/mono/services/billing\"\n",
+		"vendor/example.com/go.mod": "module example.com/vendored\n",
+		"testdata/fixture/go.mod":   "module example.com/fixture\n",
+		".git/HEAD":                 "ref: refs/heads/main\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	modules, err := workspaceModules("", filepath.Join(root, "services", "api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(modules, ","); got != "example.com/mono,example.com/mono/services/api,example.com/mono/services/billing" {
+		t.Errorf("modules found in the repository: got %s", got)
+	}
+
+	work := "go 1.22\n\nuse (\n\t./services/api // the API\n\t\"./services/billing\"\n)\n"
+	if err := os.WriteFile(filepath.Join(root, "go.work"), []byte(work), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modules, err = workspaceModules("", filepath.Join(root, "services"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(modules, ","); got != "example.com/mono/services/api,example.com/mono/services/billing" {
+		t.Errorf("modules of go.work: got %s", got)
+	}
+
+	if _, err := workspaceModules(filepath.Join(root, "missing.work"), root); err == nil {
+		t.Error("expected an error for a missing workspace file")
+	}
+}
+
+func TestModulesSection(t *testing.T) {
+	data := &ReportData{Modules: []report.ModuleSummary{
+		{Module: "example.com/mono/services/api", Packages: 2, Total: 5, Passed: 4, Failed: 1, Duration: 3},
+		{Module: "example.com/mono/services/billing", Packages: 1, Total: 2, Passed: 2, Duration: 1},
+	}}
+	markdown := generateMarkdownReport(data)
+	for _, want := range []string{
+		"## 🧱 Modules\n\n",
+		"| ❌ `example.com/mono/services/api` | 2 | 5 | 4 | 1 | 0 | 0 | 3.00s |\n",
+		"| ✅ `example.com/mono/services/billing` | 1 | 2 | 2 | 0 | 0 | 0 | 1.00s |\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q:\n%s", want, markdown)
+		}
+	}
+}
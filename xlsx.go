@@ -0,0 +1,230 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// xlsxCellLimit is the most characters Excel keeps in a cell; longer output
+// is cut from the start, keeping where Go prints the failure
+const xlsxCellLimit = 32767
+
+// xlsxSheet is one worksheet: a bold header row, then rows of string,
+// int or float64 cells
+type xlsxSheet struct {
+	name   string
+	header []string
+	widths []int // column widths in characters
+	rows   [][]any
+}
+
+// renderXLSX renders an Excel workbook with Summary, Results, Failures and
+// Durations sheets, written as the zip of XML parts an .xlsx file is
+func renderXLSX(data *ReportData, cfg *config) (string, error) {
+	results := make([]*TestResult, 0, len(data.Results))
+	for _, result := range data.Results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Package != results[j].Package {
+			return results[i].Package < results[j].Package
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	passRate := 0.0
+	if data.TotalTests > 0 {
+		passRate = float64(data.PassedTests) / float64(data.TotalTests) * 100
+	}
+	summary := xlsxSheet{name: "Summary", header: []string{"Metric", "Value"}, widths: []int{20, 14}, rows: [][]any{
+		{"Total", data.TotalTests},
+		{"Passed", data.PassedTests},
+		{"Failed", data.FailedTests},
+		{"Skipped", data.SkippedTests},
+		{"Flaky", data.FlakyTests},
+		{"Pass Rate (%)", passRate},
+		{"Duration (s)", data.TotalDuration},
+	}}
+	if gate := data.QualityGate; gate != nil {
+		status := "passed"
+		if !gate.Passed {
+			status = "failed: " + strings.Join(gate.Violations, "; ")
+		}
+		summary.rows = append(summary.rows, []any{"Quality Gate", status})
+	}
+	if encoded, err := json.Marshal(data.Integrity.Summary()); err == nil {
+		summary.rows = append(summary.rows, []any{"Integrity", string(encoded)})
+	}
+
+	resultSheet := xlsxSheet{name: "Results", header: []string{"Package", "Test", "Status", "Duration (s)", "Subtest", "Skip Reason"}, widths: []int{40, 50, 8, 12, 8, 40}}
+	failures := xlsxSheet{name: "Failures", header: []string{"Package", "Test", "Assertion", "Location", "Output"}, widths: []int{40, 50, 60, 24, 100}}
+	for _, result := range results {
+		pkg := cfg.packageName(result.Package)
+		subtest := "no"
+		if result.IsSubTest {
+			subtest = "yes"
+		}
+		resultSheet.rows = append(resultSheet.rows, []any{pkg, result.Name, result.Status, result.Duration, subtest, result.SkipReason})
+
+		if result.Status != "FAIL" {
+			continue
+		}
+		excerpt := report.ExtractExcerpt(result.Output)
+		location := ""
+		if excerpt.File != "" {
+			location = fmt.Sprintf("%s:%d", excerpt.File, excerpt.Line)
+		}
+		failures.rows = append(failures.rows, []any{pkg, result.Name, excerpt.Assertion, location, xlsxOutput(result.Output)})
+	}
+
+	durations := xlsxSheet{name: "Durations", header: []string{"Package", "Test", "Duration (s)", "Status"}, widths: []int{40, 50, 12, 8}}
+	byDuration := append([]*TestResult(nil), results...)
+	sort.SliceStable(byDuration, func(i, j int) bool { return byDuration[i].Duration > byDuration[j].Duration })
+	for _, result := range byDuration {
+		if result.IsSubTest {
+			continue // already part of the parent's time
+		}
+		durations.rows = append(durations.rows, []any{cfg.packageName(result.Package), result.Name, result.Duration, result.Status})
+	}
+
+	return writeXLSX([]xlsxSheet{summary, resultSheet, failures, durations})
+}
+
+// xlsxOutput joins a test's output into one cell
+func xlsxOutput(output []string) string {
+	var sb strings.Builder
+	for _, line := range output {
+		sb.WriteString(strings.TrimRight(line, "\n") + "\n")
+	}
+	text := strings.TrimRight(sb.String(), "\n")
+	if runes := []rune(text); len(runes) > xlsxCellLimit {
+		text = "…" + string(runes[len(runes)-xlsxCellLimit+1:])
+	}
+	return text
+}
+
+// writeXLSX packages sheets as an Office Open XML workbook
+func writeXLSX(sheets []xlsxSheet) (string, error) {
+	var workbook, rels, types strings.Builder
+	for i, sheet := range sheets {
+		workbook.WriteString(fmt.Sprintf(`<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheet.name), i+1, i+1))
+		rels.WriteString(fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1))
+		types.WriteString(fmt.Sprintf(`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1))
+	}
+	rels.WriteString(fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(sheets)+1))
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			types.String() + `</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + workbook.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+		// Style 1 is the bold header, style 2 wraps long text such as output
+		{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+			`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+			`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+			`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0" applyAlignment="1"><alignment vertical="top" wrapText="1"/></xf></cellXfs>` +
+			`</styleSheet>`},
+	}
+	for i, sheet := range sheets {
+		parts = append(parts, struct{ name, content string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet.xml()})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, part := range parts {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: part.name, Method: zip.Deflate})
+		if err != nil {
+			return "", err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// xml renders the worksheet, with the header row frozen and filterable
+func (s xlsxSheet) xml() string {
+	var sb strings.Builder
+	sb.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	sb.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	sb.WriteString("<cols>")
+	for i, width := range s.widths {
+		sb.WriteString(fmt.Sprintf(`<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width))
+	}
+	sb.WriteString("</cols><sheetData>")
+
+	header := make([]any, len(s.header))
+	for i, name := range s.header {
+		header[i] = name
+	}
+	for r, row := range append([][]any{header}, s.rows...) {
+		sb.WriteString(fmt.Sprintf(`<row r="%d">`, r+1))
+		for c, value := range row {
+			ref := xlsxColumn(c) + fmt.Sprint(r+1)
+			style := ""
+			if r == 0 {
+				style = ` s="1"`
+			}
+			switch v := value.(type) {
+			case int:
+				sb.WriteString(fmt.Sprintf(`<c r="%s"%s><v>%d</v></c>`, ref, style, v))
+			case float64:
+				sb.WriteString(fmt.Sprintf(`<c r="%s"%s><v>%s</v></c>`, ref, style, fmt.Sprint(v)))
+			case string:
+				if v == "" {
+					continue
+				}
+				if r > 0 && strings.Contains(v, "\n") {
+					style = ` s="2"`
+				}
+				sb.WriteString(fmt.Sprintf(`<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(v)))
+			}
+		}
+		sb.WriteString("</row>")
+	}
+	sb.WriteString("</sheetData>")
+	sb.WriteString(fmt.Sprintf(`<autoFilter ref="A1:%s%d"/>`, xlsxColumn(len(s.header)-1), len(s.rows)+1))
+	sb.WriteString("</worksheet>")
+	return sb.String()
+}
+
+// xlsxColumn returns the letters of a zero-based column index: A, ..., Z, AA, ...
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xmlEscape escapes text for an XML element or attribute. Characters XML
+// can't hold, such as the escape codes of colored output, become U+FFFD.
+func xmlEscape(text string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(text))
+	return sb.String()
+}
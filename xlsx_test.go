@@ -0,0 +1,71 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRenderXLSX(t *testing.T) {
+	data := &ReportData{
+		TotalTests: 2, PassedTests: 1, FailedTests: 1, TotalDuration: 0.5,
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Package: "example.com/a", Status: "PASS", Duration: 0.1},
+			"TestB": {Name: "TestB", Package: "example.com/a", Status: "FAIL", Duration: 0.4, Output: []string{
+				"=== RUN   TestB", "    b_test.go:9: got <nil> & \x1b[31mred\x1b[0m", "--- FAIL: TestB (0.40s)",
+			}},
+		},
+	}
+	xlsx, err := renderReport(data, defaultConfig(), "xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(strings.NewReader(xlsx), int64(len(xlsx)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(content)
+	}
+
+	tests := []struct {
+		part string
+		want string
+	}{
+		{"[Content_Types].xml", `PartName="/xl/worksheets/sheet4.xml"`},
+		{"_rels/.rels", `Target="xl/workbook.xml"`},
+		{"xl/workbook.xml", `<sheet name="Summary" sheetId="1" r:id="rId1"/><sheet name="Results" sheetId="2" r:id="rId2"/><sheet name="Failures" sheetId="3" r:id="rId3"/><sheet name="Durations" sheetId="4" r:id="rId4"/>`},
+		{"xl/styles.xml", `<cellXfs count="3">`},
+		{"xl/worksheets/sheet1.xml", `<row r="4"><c r="A4" t="inlineStr"><is><t xml:space="preserve">Failed</t></is></c><c r="B4"><v>1</v></c></row>`},
+		{"xl/worksheets/sheet1.xml", `<t xml:space="preserve">Integrity</t></is></c><c r="B9" t="inlineStr"><is><t xml:space="preserve">{&#34;eventsParsed&#34;:0,`},
+		{"xl/worksheets/sheet2.xml", `<c r="C3" t="inlineStr"><is><t xml:space="preserve">FAIL</t></is></c><c r="D3"><v>0.4</v></c>`},
+		{"xl/worksheets/sheet2.xml", `<autoFilter ref="A1:F3"/>`},
+		{"xl/worksheets/sheet3.xml", `<t xml:space="preserve">got &lt;nil&gt; &amp; ` + "\uFFFD" + `[31mred`},
+		{"xl/worksheets/sheet3.xml", `<t xml:space="preserve">b_test.go:9</t>`},
+		{"xl/worksheets/sheet4.xml", `<row r="2"><c r="A2" t="inlineStr"><is><t xml:space="preserve">example.com/a</t></is></c><c r="B2" t="inlineStr"><is><t xml:space="preserve">TestB</t></is></c>`},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(parts[tt.part], tt.want) {
+			t.Errorf("%s missing %q:\n%s", tt.part, tt.want, parts[tt.part])
+		}
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(i); got != want {
+			t.Errorf("xlsxColumn(%d): got %s, want %s", i, got, want)
+		}
+	}
+}
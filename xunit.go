@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dipjyotimetia/gotest-report/report"
+)
+
+// xUnit.net v2 XML, as read by Azure DevOps' xUnit result format: one
+// assembly per package and one collection per top-level test, holding the
+// test and its subtests as flat test elements
+type xunitAssemblies struct {
+	XMLName    xml.Name        `xml:"assemblies"`
+	Assemblies []xunitAssembly `xml:"assembly"`
+}
+
+type xunitAssembly struct {
+	Name          string            `xml:"name,attr"`
+	TestFramework string            `xml:"test-framework,attr"`
+	RunDate       string            `xml:"run-date,attr"`
+	RunTime       string            `xml:"run-time,attr"`
+	Total         int               `xml:"total,attr"`
+	Passed        int               `xml:"passed,attr"`
+	Failed        int               `xml:"failed,attr"`
+	Skipped       int               `xml:"skipped,attr"`
+	Errors        int               `xml:"errors,attr"`
+	Time          string            `xml:"time,attr"`
+	Collections   []xunitCollection `xml:"collection"`
+}
+
+type xunitCollection struct {
+	Name    string      `xml:"name,attr"`
+	Total   int         `xml:"total,attr"`
+	Passed  int         `xml:"passed,attr"`
+	Failed  int         `xml:"failed,attr"`
+	Skipped int         `xml:"skipped,attr"`
+	Time    string      `xml:"time,attr"`
+	Tests   []xunitTest `xml:"test"`
+}
+
+type xunitTest struct {
+	Name    string        `xml:"name,attr"`
+	Type    string        `xml:"type,attr"`
+	Method  string        `xml:"method,attr"`
+	Time    string        `xml:"time,attr"`
+	Result  string        `xml:"result,attr"` // Pass, Fail or Skip
+	Failure *xunitFailure `xml:"failure,omitempty"`
+	Reason  *xunitText    `xml:"reason,omitempty"`
+	Output  *xunitText    `xml:"output,omitempty"`
+}
+
+type xunitFailure struct {
+	ExceptionType string    `xml:"exception-type,attr"`
+	Message       xunitText `xml:"message"`
+	StackTrace    xunitText `xml:"stack-trace"`
+}
+
+type xunitText struct {
+	Text string `xml:",chardata"`
+}
+
+// renderXUnit renders data as xUnit.net v2 XML. As in JUnit output, flaky
+// tests pass with their attempts noted in the output, and tests that never
+// reported a result fail.
+func renderXUnit(data *ReportData, now time.Time) (string, error) {
+	doc := xunitAssemblies{}
+	packages, byPackage := resultsByPackage(data)
+	for _, pkg := range packages {
+		assembly := xunitAssembly{
+			Name:          pkg,
+			TestFramework: "go test",
+			RunDate:       now.Format("2006-01-02"),
+			RunTime:       now.Format("15:04:05"),
+		}
+		var duration float64
+		collections := make(map[string]*xunitCollection)
+		var order []string
+		for _, result := range byPackage[pkg] {
+			top, _, _ := strings.Cut(result.Name, "/")
+			collection, ok := collections[top]
+			if !ok {
+				collection = &xunitCollection{Name: top}
+				collections[top] = collection
+				order = append(order, top)
+			}
+			test := xunitCase(result)
+			collection.Tests = append(collection.Tests, test)
+			collection.Total++
+			switch test.Result {
+			case "Pass":
+				collection.Passed++
+			case "Fail":
+				collection.Failed++
+			case "Skip":
+				collection.Skipped++
+			}
+			if !result.IsSubTest {
+				collection.Time = junitTime(result.Duration)
+				duration += result.Duration
+			}
+		}
+		for _, name := range order {
+			collection := collections[name]
+			if collection.Time == "" {
+				collection.Time = junitTime(0)
+			}
+			assembly.Total += collection.Total
+			assembly.Passed += collection.Passed
+			assembly.Failed += collection.Failed
+			assembly.Skipped += collection.Skipped
+			assembly.Collections = append(assembly.Collections, *collection)
+		}
+		assembly.Time = junitTime(duration)
+		doc.Assemblies = append(doc.Assemblies, assembly)
+	}
+
+	encoded, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(encoded) + "\n" + integrityComment(data.Integrity), nil
+}
+
+func xunitCase(result *TestResult) xunitTest {
+	test := xunitTest{
+		Name:   result.Name,
+		Type:   result.Package,
+		Method: result.Name,
+		Time:   junitTime(result.Duration),
+	}
+	output := strings.Join(result.Output, "\n")
+
+	switch result.Status {
+	case "PASS", "FLAKY":
+		test.Result = "Pass"
+		if result.Status == "FLAKY" {
+			output = fmt.Sprintf("Passed on attempt %d after failing %d times\n", len(result.Attempts)+1, len(result.Attempts)) + output
+		}
+	case "SKIP":
+		test.Result = "Skip"
+		test.Reason = &xunitText{result.SkipReason}
+	case "FAIL":
+		test.Result = "Fail"
+		test.Failure = xunitFailureOf(result, "failure", "test failed")
+	default:
+		test.Result = "Fail"
+		test.Failure = xunitFailureOf(result, "incomplete", "test did not report a result")
+	}
+	if output != "" {
+		test.Output = &xunitText{output}
+	}
+	return test
+}
+
+// xunitFailureOf describes a failure by its first assertion, falling back
+// to message, with the file:line it came from in place of a stack trace
+func xunitFailureOf(result *TestResult, kind, message string) *xunitFailure {
+	excerpt := report.ExtractExcerpt(result.Output)
+	if excerpt.Assertion != "" {
+		message = excerpt.Assertion
+	}
+	failure := &xunitFailure{ExceptionType: kind, Message: xunitText{message}}
+	if excerpt.File != "" {
+		failure.StackTrace.Text = fmt.Sprintf("%s:%d", excerpt.File, excerpt.Line)
+	}
+	return failure
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+// formatTestEvents covers a passing, failing, skipped and unfinished test,
+// and a failing subtest, across two packages
+const formatTestEvents = `
+{"Action":"run","Package":"example.com/a","Test":"TestPass"}
+{"Action":"pass","Package":"example.com/a","Test":"TestPass","Elapsed":0.25}
+{"Action":"run","Package":"example.com/a","Test":"TestTable"}
+{"Action":"run","Package":"example.com/a","Test":"TestTable/ok"}
+{"Action":"pass","Package":"example.com/a","Test":"TestTable/ok","Elapsed":0.1}
+{"Action":"run","Package":"example.com/a","Test":"TestTable/bad"}
+{"Action":"output","Package":"example.com/a","Test":"TestTable/bad","Output":"    a_test.go:12: got 1, want 2 <\u001b>\n"}
+{"Action":"fail","Package":"example.com/a","Test":"TestTable/bad","Elapsed":0.2}
+{"Action":"fail","Package":"example.com/a","Test":"TestTable","Elapsed":0.5}
+{"Action":"run","Package":"example.com/b","Test":"TestSkip"}
+{"Action":"output","Package":"example.com/b","Test":"TestSkip","Output":"    b_test.go:3: needs docker\n"}
+{"Action":"skip","Package":"example.com/b","Test":"TestSkip"}
+{"Action":"run","Package":"example.com/b","Test":"TestHang"}
+`
+
+func TestRenderXUnit(t *testing.T) {
+	data, err := processTestEvents(strings.NewReader(formatTestEvents), parseOptions(defaultConfig(), "xunit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := renderXUnit(data, time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("renderXUnit: %v", err)
+	}
+
+	var doc xunitAssemblies
+	if err := xml.Unmarshal([]byte(content), &doc); err != nil {
+		t.Fatalf("decoding xUnit XML: %v\n%s", err, content)
+	}
+	if len(doc.Assemblies) != 2 {
+		t.Fatalf("assemblies: got %+v", doc.Assemblies)
+	}
+	a, b := doc.Assemblies[0], doc.Assemblies[1]
+	if a.Name != "example.com/a" || a.Total != 4 || a.Passed != 2 || a.Failed != 2 || a.Time != "0.750" || a.RunDate != "2024-05-06" || a.RunTime != "07:08:09" {
+		t.Errorf("assembly a: got %+v", a)
+	}
+	if len(a.Collections) != 2 || a.Collections[1].Name != "TestTable" || len(a.Collections[1].Tests) != 3 || a.Collections[1].Time != "0.500" {
+		t.Fatalf("collections: got %+v", a.Collections)
+	}
+	bad := a.Collections[1].Tests[1]
+	if bad.Name != "TestTable/bad" || bad.Result != "Fail" || bad.Failure == nil ||
+		bad.Failure.Message.Text != "got 1, want 2 <�>" || bad.Failure.StackTrace.Text != "a_test.go:12" {
+		t.Errorf("failing subtest: got %+v", bad)
+	}
+	if b.Total != 2 || b.Failed != 1 || b.Skipped != 1 {
+		t.Errorf("assembly b: got %+v", b)
+	}
+	if hang := b.Collections[0].Tests[0]; hang.Result != "Fail" || hang.Failure == nil || hang.Failure.ExceptionType != "incomplete" {
+		t.Errorf("unfinished test: got %+v", hang)
+	}
+	if skip := b.Collections[1].Tests[0]; skip.Result != "Skip" || skip.Reason == nil || skip.Reason.Text != "needs docker" {
+		t.Errorf("skipped test: got %+v", skip)
+	}
+}